@@ -114,10 +114,14 @@ func run() error {
 	trustDefaultStore := store.NewTrustDefaultStore(pool)
 	modelConfigStore := store.NewModelConfigStore(pool)
 	webhookStore := store.NewWebhookStore(pool)
+	egressRuleStore := store.NewEgressRuleStore(pool)
+	toolSchemaStore := store.NewMCPToolSchemaStore(pool)
 	modelEndpointStore := store.NewModelEndpointStore(pool, []byte(cfg.CredentialEncryptionKey))
 
-	// Create webhook dispatcher
-	dispatcher := notify.NewDispatcher(&subscriptionLoaderAdapter{store: webhookStore}, notify.Config{
+	// Create webhook dispatcher; deliveries are queued in the outbox in the
+	// transaction of the mutation that produced them.
+	webhookOutboxStore := store.NewWebhookOutboxStore(pool)
+	dispatcher := notify.NewDispatcher(&subscriptionLoaderAdapter{store: webhookStore}, &webhookOutboxAdapter{store: webhookOutboxStore}, notify.Config{
 		Workers:    cfg.WebhookWorkers,
 		MaxRetries: cfg.WebhookRetries,
 		Timeout:    time.Duration(cfg.WebhookTimeoutS) * time.Second,
@@ -125,6 +129,25 @@ func run() error {
 	dispatcher.Start()
 	defer dispatcher.Stop()
 
+	// Webhook outbox retention cleanup
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cutoff := time.Now().AddDate(0, 0, -cfg.WebhookRetentionD)
+				if deleted, err := webhookOutboxStore.DeleteFinishedBefore(ctx, cutoff); err != nil {
+					log.Printf("webhook outbox cleanup error: %v", err)
+				} else if deleted > 0 {
+					log.Printf("cleaned up %d finished webhook deliveries", deleted)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	// Seed default admin
 	if err := seedDefaultAdmin(ctx, userStore); err != nil {
 		log.Printf("warning: failed to seed default admin: %v", err)
@@ -200,10 +223,22 @@ func run() error {
 	// Encryption key for MCP server credentials
 	encKey := []byte(cfg.CredentialEncryptionKey)
 	mcpServersHandler := api.NewMCPServersHandler(mcpServerStore, auditStore, encKey, dispatcher)
+	egressGuard := gateway.NewEgressGuard(&egressRuleProviderAdapter{store: egressRuleStore}, 30*time.Second)
+	mcpServersHandler.SetEgressGuard(egressGuard)
+	egressRulesHandler := api.NewEgressRulesHandler(egressRuleStore, auditStore, egressGuard)
 	trustRulesHandler := api.NewTrustRulesHandler(trustRuleStore, auditStore, dispatcher)
 	trustDefaultsHandler := api.NewTrustDefaultsHandler(trustDefaultStore, auditStore, dispatcher)
+	workspaceBudgetStore := store.NewWorkspaceBudgetStore(pool)
+	workspaceBudgetsHandler := api.NewWorkspaceBudgetsHandler(workspaceBudgetStore, auditStore, dispatcher)
+	workspaceSettingsStore := store.NewWorkspaceSettingsStore(pool)
+	workspaceSettingsHandler := api.NewWorkspaceSettingsHandler(workspaceSettingsStore, auditStore, dispatcher)
+	recordingStore := store.NewMCPRecordingStore(pool)
+	workspaceMemberStore := store.NewWorkspaceMemberStore(pool)
+	workspaceMembersHandler := api.NewWorkspaceMembersHandler(workspaceMemberStore, auditStore, dispatcher)
 	modelConfigHandler := api.NewModelConfigHandler(modelConfigStore, auditStore, dispatcher)
 	webhooksHandler := api.NewWebhooksHandler(webhookStore, auditStore)
+	webhooksHandler.SetPinger(dispatcher)
+	webhookDeliveriesHandler := api.NewWebhookDeliveriesHandler(webhookStore, webhookOutboxStore, auditStore)
 	modelEndpointsHandler := api.NewModelEndpointsHandler(modelEndpointStore, auditStore, encKey, dispatcher)
 	auditLogHandler := api.NewAuditHandler(auditStore)
 	discoveryHandler := api.NewDiscoveryHandler(agentStore, mcpServerStore, trustDefaultStore, modelConfigStore, modelEndpointStore)
@@ -235,23 +270,112 @@ func run() error {
 
 	// MCP Gateway handler (opt-in via GATEWAY_MODE)
 	var mcpGatewayHandler *api.MCPGatewayHandler
+	var toolDiscoverer api.ToolDiscoverer
+	var circuitBreaker *gateway.CircuitBreaker
+	var gatewayUsageHandler *api.GatewayUsageHandler
+	gatewayUsageStore := store.NewGatewayUsageStore(pool)
 	if cfg.GatewayMode {
 		cb := gateway.NewCircuitBreaker()
+		cb.SetObserver(api.CircuitEventObserver(dispatcher))
+		if cfg.GatewayCircuitShared {
+			// Circuit state is shared with other replicas through Postgres.
+			cb.SetStore(&circuitStateStoreAdapter{store: store.NewCircuitStateStore(pool)})
+			go gateway.RunCircuitSync(ctx, cb, time.Duration(cfg.GatewayCircuitSyncS)*time.Second)
+			log.Println("Shared circuit breaker state enabled")
+		}
+		circuitBreaker = cb
+		var stdioSupervisor *gateway.StdioSupervisor
+		if cfg.GatewayStdioEnabled {
+			// Local stdio servers run as children of this process.
+			stdioSupervisor = gateway.NewStdioSupervisor(gateway.StdioSupervisorConfig{})
+			defer stdioSupervisor.Close()
+			mcpServersHandler.SetStdioSupervisor(stdioSupervisor)
+			log.Println("Stdio MCP servers enabled")
+		}
 		pc := gateway.NewProxyClient(gateway.ProxyClientConfig{
 			Timeout:             time.Duration(cfg.GatewayTimeoutS) * time.Second,
 			MaxIdleConnsPerHost: 10,
+			Egress:              egressGuard,
+			Stdio:               stdioSupervisor,
 		})
 		tc := gateway.NewTrustClassifier(
 			&trustRuleProviderAdapter{store: trustRuleStore},
 			&trustDefaultProviderAdapter{store: trustDefaultStore},
 			&agentTrustProviderAdapter{store: agentStore},
 		)
+		if cfg.GatewayTrustCacheTTLS > 0 {
+			// Cached policy is invalidated by trust policy notifications
+			// from every replica and only served while subscribed.
+			trustCache := gateway.NewTrustPolicyCache(time.Duration(cfg.GatewayTrustCacheTTLS) * time.Second)
+			tc.SetCache(trustCache)
+			go gateway.RunTrustPolicyInvalidation(ctx, trustCache,
+				&trustChangeSourceAdapter{listener: store.NewTrustPolicyListener(pool)}, 5*time.Second)
+			log.Println("Trust policy cache enabled")
+		}
 		mcpGatewayHandler = api.NewMCPGatewayHandler(
 			mcpServerStore, auditStore, tc, cb, pc, rateLimiter, encKey,
 		)
+		lb := gateway.NewLoadBalancer()
+		mcpGatewayHandler.SetLoadBalancer(lb)
+		go gateway.RunHealthChecks(ctx, lb, pc, mcpGatewayHandler.HealthTargets,
+			time.Duration(cfg.GatewayHealthIntervalS)*time.Second, 5*time.Second)
+		mcpGatewayHandler.SetToolSchemas(toolSchemaStore, pc)
+		usageRecorder := gateway.NewUsageRecorder()
+		mcpGatewayHandler.SetUsageRecorder(usageRecorder)
+		mcpGatewayHandler.SetBudgets(workspaceBudgetStore, dispatcher)
+		mcpGatewayHandler.SetAgentToolEnforcement(agentStore, workspaceSettingsStore)
+		mcpGatewayHandler.SetPromptModes(promptStore)
+		mcpGatewayHandler.SetWorkspaceMembers(workspaceMemberStore)
+		mcpGatewayHandler.SetSandbox(recordingStore, workspaceSettingsStore)
+		go gateway.RunUsageFlush(ctx, usageRecorder, &usageSinkAdapter{store: gatewayUsageStore},
+			time.Duration(cfg.GatewayUsageFlushS)*time.Second)
+		gatewayUsageHandler = api.NewGatewayUsageHandler(gatewayUsageStore)
+
+		// Usage retention cleanup
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					cutoff := time.Now().AddDate(0, 0, -cfg.GatewayUsageRetentionD)
+					if deleted, err := gatewayUsageStore.DeleteBefore(ctx, cutoff); err != nil {
+						log.Printf("gateway usage cleanup error: %v", err)
+					} else if deleted > 0 {
+						log.Printf("cleaned up %d gateway usage buckets", deleted)
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		responseCache := gateway.NewResponseCache(cfg.GatewayCacheEntries)
+		mcpGatewayHandler.SetResponseCache(responseCache)
+		mcpServersHandler.SetResponseCache(responseCache)
+		toolDiscoverer = mcpGatewayHandler
+
+		// Tool schema discovery; each server is refreshed at its own
+		// discovery_interval.
+		go func() {
+			mcpGatewayHandler.RefreshToolSchemas(ctx)
+			ticker := time.NewTicker(30 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					mcpGatewayHandler.RefreshToolSchemas(ctx)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
 		log.Println("MCP gateway mode enabled")
 	}
 
+	toolSchemasHandler := api.NewToolSchemasHandler(mcpServerStore, toolSchemaStore, toolDiscoverer, auditStore)
+	circuitsHandler := api.NewCircuitsHandler(mcpServerStore, circuitBreaker, auditStore)
+	recordingsHandler := api.NewRecordingsHandler(mcpServerStore, recordingStore, auditStore)
+
 	// Set up router
 	router := api.NewRouter(api.RouterConfig{
 		Health:        health,
@@ -261,14 +385,23 @@ func run() error {
 		Agents:        agentsHandler,
 		Prompts:       promptsHandler,
 		MCPServers:    mcpServersHandler,
+		ToolSchemas:   toolSchemasHandler,
+		Circuits:      circuitsHandler,
+		Recordings:    recordingsHandler,
 		TrustRules:    trustRulesHandler,
 		TrustDefaults: trustDefaultsHandler,
+		Budgets:       workspaceBudgetsHandler,
+		WorkspaceSettings: workspaceSettingsHandler,
+		WorkspaceMembers:  workspaceMembersHandler,
+		EgressRules:   egressRulesHandler,
 		ModelConfig:    modelConfigHandler,
 		ModelEndpoints: modelEndpointsHandler,
 		Webhooks:      webhooksHandler,
+		WebhookDeliveries: webhookDeliveriesHandler,
 		Discovery:     discoveryHandler,
 		A2A:           a2aHandler,
 		MCP:           mcpHandler,
+		GatewayUsage:  gatewayUsageHandler,
 		MCPGateway:    mcpGatewayHandler,
 		AuditLog:      auditLogHandler,
 		AuthMW:        authMW,
@@ -452,30 +585,30 @@ type apiKeyLookupAdapter struct {
 	users   *store.UserStore
 }
 
-func (a *apiKeyLookupAdapter) ValidateAPIKey(ctx context.Context, key string) (uuid.UUID, string, error) {
+func (a *apiKeyLookupAdapter) ValidateAPIKey(ctx context.Context, key string) (uuid.UUID, string, uuid.UUID, error) {
 	hash := internalAuth.HashAPIKey(key)
 	apiKey, err := a.apiKeys.GetByHash(ctx, hash)
 	if err != nil {
-		return uuid.Nil, "", err
+		return uuid.Nil, "", uuid.Nil, err
 	}
 
 	if apiKey.UserID == nil {
-		return uuid.Nil, "", fmt.Errorf("api key has no associated user")
+		return uuid.Nil, "", uuid.Nil, fmt.Errorf("api key has no associated user")
 	}
 
 	user, err := a.users.GetByID(ctx, *apiKey.UserID)
 	if err != nil {
-		return uuid.Nil, "", err
+		return uuid.Nil, "", uuid.Nil, err
 	}
 
 	if !user.IsActive {
-		return uuid.Nil, "", fmt.Errorf("user is inactive")
+		return uuid.Nil, "", uuid.Nil, fmt.Errorf("user is inactive")
 	}
 
 	// Update last used timestamp (fire and forget)
 	go a.apiKeys.UpdateLastUsed(context.Background(), apiKey.ID)
 
-	return user.ID, user.Role, nil
+	return user.ID, user.Role, apiKey.ID, nil
 }
 
 // authUserStoreAdapter bridges store.UserStore to auth.UserForAuth.
@@ -707,21 +840,128 @@ func (a *subscriptionLoaderAdapter) ListActive(ctx context.Context) ([]notify.Su
 		return nil, err
 	}
 	result := make([]notify.Subscription, len(subs))
-	for i, s := range subs {
-		var events []string
-		if len(s.Events) > 0 {
-			json.Unmarshal(s.Events, &events)
+	for i := range subs {
+		result[i] = toNotifySubscription(&subs[i])
+	}
+	return result, nil
+}
+
+func (a *subscriptionLoaderAdapter) Get(ctx context.Context, id uuid.UUID) (*notify.Subscription, error) {
+	sub, err := a.store.GetWithSecrets(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result := toNotifySubscription(sub)
+	return &result, nil
+}
+
+func toNotifySubscription(s *store.WebhookSubscription) notify.Subscription {
+	var events, resourceTypes, resourceIDs []string
+	if len(s.Events) > 0 {
+		json.Unmarshal(s.Events, &events)
+	}
+	if len(s.ResourceTypes) > 0 {
+		json.Unmarshal(s.ResourceTypes, &resourceTypes)
+	}
+	if len(s.ResourceIDs) > 0 {
+		json.Unmarshal(s.ResourceIDs, &resourceIDs)
+	}
+	var workspaceID string
+	if s.WorkspaceID != nil {
+		workspaceID = *s.WorkspaceID
+	}
+	return notify.Subscription{
+		ID:              s.ID,
+		URL:             s.URL,
+		Secret:          s.Secret,
+		PreviousSecret:  s.PreviousSecret,
+		SignatureScheme: s.SignatureScheme,
+		Payload: notify.PayloadOptions{
+			Format:          s.PayloadFormat,
+			IncludeVersion:  s.IncludeVersion,
+			IncludeSnapshot: s.IncludeSnapshot,
+			IncludeDiff:     s.IncludeDiff,
+		},
+		Events:        events,
+		ResourceTypes: resourceTypes,
+		ResourceIDs:   resourceIDs,
+		WorkspaceID:   workspaceID,
+	}
+}
+
+// webhookOutboxAdapter bridges store.WebhookOutboxStore to notify.Outbox.
+type webhookOutboxAdapter struct {
+	store *store.WebhookOutboxStore
+}
+
+func (a *webhookOutboxAdapter) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return a.store.InTx(ctx, fn)
+}
+
+func (a *webhookOutboxAdapter) Enqueue(ctx context.Context, entries []notify.OutboxEntry) error {
+	rows := make([]store.WebhookOutboxEntry, len(entries))
+	for i, e := range entries {
+		rows[i] = store.WebhookOutboxEntry{
+			SubscriptionID: e.SubscriptionID,
+			EventType:      e.EventType,
+			Payload:        e.Payload,
+			ContentType:    e.ContentType,
 		}
-		result[i] = notify.Subscription{
-			ID:     s.ID,
-			URL:    s.URL,
-			Secret: s.Secret,
-			Events: events,
+	}
+	return a.store.Enqueue(ctx, rows)
+}
+
+func (a *webhookOutboxAdapter) Claim(ctx context.Context, limit int, lease time.Duration) ([]notify.Delivery, error) {
+	claimed, err := a.store.Claim(ctx, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]notify.Delivery, len(claimed))
+	for i, c := range claimed {
+		result[i] = notify.Delivery{
+			ID:              c.ID,
+			MessageID:       c.MessageID,
+			SubscriptionID:  c.SubscriptionID,
+			URL:             c.URL,
+			Secret:          c.Secret,
+			PreviousSecret:  c.PreviousSecret,
+			SignatureScheme: c.SignatureScheme,
+			EventType:       c.EventType,
+			Payload:         c.Payload,
+			ContentType:     c.ContentType,
+			Attempts:        c.Attempts,
 		}
 	}
 	return result, nil
 }
 
+func (a *webhookOutboxAdapter) MarkDelivered(ctx context.Context, id uuid.UUID) error {
+	return a.store.MarkDelivered(ctx, id)
+}
+
+func (a *webhookOutboxAdapter) MarkRetry(ctx context.Context, id uuid.UUID, next time.Time, lastErr string) error {
+	return a.store.MarkRetry(ctx, id, next, lastErr)
+}
+
+func (a *webhookOutboxAdapter) MarkFailed(ctx context.Context, id uuid.UUID, lastErr string) error {
+	return a.store.MarkFailed(ctx, id, lastErr)
+}
+
+func (a *webhookOutboxAdapter) RecordAttempt(ctx context.Context, attempt notify.Attempt) error {
+	var status *int
+	if attempt.StatusCode != 0 {
+		status = &attempt.StatusCode
+	}
+	return a.store.RecordAttempt(ctx, &store.WebhookDeliveryAttempt{
+		DeliveryID:   attempt.DeliveryID,
+		Attempt:      attempt.Attempt,
+		StatusCode:   status,
+		LatencyMS:    int(attempt.Latency.Milliseconds()),
+		ResponseBody: attempt.ResponseBody,
+		Error:        attempt.Error,
+	})
+}
+
 // --- Gateway provider adapters ---
 
 // trustRuleProviderAdapter bridges store.TrustRuleStore to gateway.TrustRuleProvider.
@@ -741,6 +981,57 @@ func (a *trustRuleProviderAdapter) List(ctx context.Context, workspaceID uuid.UU
 	return records, nil
 }
 
+// egressRuleProviderAdapter bridges store.EgressRuleStore to gateway.EgressRuleProvider.
+type egressRuleProviderAdapter struct {
+	store *store.EgressRuleStore
+}
+
+func (a *egressRuleProviderAdapter) List(ctx context.Context) ([]gateway.EgressRuleRecord, error) {
+	rules, err := a.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]gateway.EgressRuleRecord, len(rules))
+	for i, r := range rules {
+		records[i] = gateway.EgressRuleRecord{Action: r.Action, CIDR: r.CIDR, Hostname: r.Hostname}
+	}
+	return records, nil
+}
+
+// circuitStateStoreAdapter bridges store.CircuitStateStore to gateway.CircuitStateStore.
+type circuitStateStoreAdapter struct {
+	store *store.CircuitStateStore
+}
+
+func (a *circuitStateStoreAdapter) ListCircuitStates(ctx context.Context) ([]gateway.CircuitRecord, error) {
+	states, err := a.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]gateway.CircuitRecord, len(states))
+	for i, c := range states {
+		records[i] = gateway.CircuitRecord{
+			Key: c.Key, State: gateway.ParseCircuitState(c.State), Failures: c.Failures,
+			Forced: c.Forced, UpdatedAt: c.UpdatedAt,
+		}
+		if c.OpenedAt != nil {
+			records[i].OpenedAt = *c.OpenedAt
+		}
+	}
+	return records, nil
+}
+
+func (a *circuitStateStoreAdapter) SaveCircuitState(ctx context.Context, rec gateway.CircuitRecord) error {
+	c := &store.CircuitState{
+		Key: rec.Key, State: rec.State.String(), Failures: rec.Failures,
+		Forced: rec.Forced, UpdatedAt: rec.UpdatedAt,
+	}
+	if !rec.OpenedAt.IsZero() {
+		c.OpenedAt = &rec.OpenedAt
+	}
+	return a.store.Upsert(ctx, c)
+}
+
 // trustDefaultProviderAdapter bridges store.TrustDefaultStore to gateway.TrustDefaultProvider.
 type trustDefaultProviderAdapter struct {
 	store *store.TrustDefaultStore
@@ -785,3 +1076,32 @@ func (a *agentTrustProviderAdapter) GetTrustOverrides(ctx context.Context, agent
 	}
 	return overrides, nil
 }
+
+// trustChangeSourceAdapter bridges store.TrustPolicyListener to gateway.TrustChangeSource.
+type trustChangeSourceAdapter struct {
+	listener *store.TrustPolicyListener
+}
+
+func (a *trustChangeSourceAdapter) Listen(ctx context.Context, ready func(), onChange func(gateway.TrustChange)) error {
+	return a.listener.Listen(ctx, ready, func(c store.TrustPolicyChange) {
+		onChange(gateway.TrustChange{Scope: c.Scope, Key: c.Key})
+	})
+}
+
+// usageSinkAdapter bridges store.GatewayUsageStore to gateway.UsageSink.
+type usageSinkAdapter struct {
+	store *store.GatewayUsageStore
+}
+
+func (a *usageSinkAdapter) SaveUsage(ctx context.Context, buckets []gateway.UsageBucket) error {
+	rows := make([]store.GatewayUsageBucket, len(buckets))
+	for i, b := range buckets {
+		rows[i] = store.GatewayUsageBucket{
+			BucketStart: b.Start, ServerLabel: b.Server, ToolName: b.Tool, AgentID: b.AgentID,
+			WorkspaceID: b.WorkspaceID, CallerID: b.CallerID, Outcome: b.Outcome, Calls: b.Calls,
+			LatencySumMS: b.LatencySumMS, LatencyHist: b.Latency, BytesIn: b.BytesIn, BytesOut: b.BytesOut,
+			CostMicros: b.CostMicros,
+		}
+	}
+	return a.store.Add(ctx, rows)
+}
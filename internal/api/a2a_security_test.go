@@ -962,8 +962,8 @@ func (m *secMockSessionLookup) TouchSession(_ context.Context, _ string) error {
 // secMockAPIKeyLookup always returns an error (no valid API keys).
 type secMockAPIKeyLookup struct{}
 
-func (m *secMockAPIKeyLookup) ValidateAPIKey(_ context.Context, _ string) (uuid.UUID, string, error) {
-	return uuid.Nil, "", fmt.Errorf("invalid API key")
+func (m *secMockAPIKeyLookup) ValidateAPIKey(_ context.Context, _ string) (uuid.UUID, string, uuid.UUID, error) {
+	return uuid.Nil, "", uuid.Nil, fmt.Errorf("invalid API key")
 }
 
 // errorAgentStore is a mock that always returns errors from List.
@@ -227,7 +227,13 @@ func (h *AgentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		CreatedBy:      userID.String(),
 	}
 
-	if err := h.agents.Create(r.Context(), agent); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.agents.Create(ctx, agent); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "agent.created", "agent", agent.ID, agentChange(agent, nil))
+	})
+	if err != nil {
 		if isConflictError(err) {
 			RespondError(w, r, apierrors.Conflict("agent '"+req.ID+"' already exists"))
 			return
@@ -237,7 +243,6 @@ func (h *AgentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "agent_create", "agent", agent.ID)
-	h.dispatchEvent(r, "agent.created", "agent", agent.ID)
 	h.publishA2A(agent.ID, "upsert")
 
 	RespondJSON(w, r, http.StatusCreated, toAgentAPIResponse(agent, true))
@@ -344,6 +349,8 @@ func (h *AgentsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	previous := *existing
+
 	// Apply all fields (PUT = full update)
 	existing.Name = req.Name
 	existing.Description = req.Description
@@ -361,7 +368,13 @@ func (h *AgentsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		existing.IsActive = *req.IsActive
 	}
 
-	if err := h.agents.Update(r.Context(), existing, etag); err != nil {
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.agents.Update(ctx, existing, etag); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "agent.updated", "agent", agentID, agentChange(existing, &previous))
+	})
+	if err != nil {
 		if isConflictError(err) {
 			RespondError(w, r, apierrors.Conflict("resource was modified by another client"))
 			return
@@ -371,7 +384,6 @@ func (h *AgentsHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "agent_update", "agent", agentID)
-	h.dispatchEvent(r, "agent.updated", "agent", agentID)
 	h.publishA2A(agentID, "upsert")
 
 	RespondJSON(w, r, http.StatusOK, toAgentAPIResponse(existing, true))
@@ -401,7 +413,17 @@ func (h *AgentsHandler) PatchAgent(w http.ResponseWriter, r *http.Request) {
 
 	userID, _ := auth.UserIDFromContext(r.Context())
 
-	agent, err := h.agents.Patch(r.Context(), agentID, rawFields, etag, userID.String())
+	var agent *store.Agent
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		previous, err := h.previousAgent(ctx, agentID)
+		if err != nil {
+			return err
+		}
+		if agent, err = h.agents.Patch(ctx, agentID, rawFields, etag, userID.String()); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "agent.updated", "agent", agentID, agentChange(agent, previous))
+	})
 	if err != nil {
 		if isNotFoundError(err) {
 			RespondError(w, r, apierrors.NotFound("agent", agentID))
@@ -416,7 +438,6 @@ func (h *AgentsHandler) PatchAgent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "agent_update", "agent", agentID)
-	h.dispatchEvent(r, "agent.updated", "agent", agentID)
 	h.publishA2A(agentID, "upsert")
 
 	RespondJSON(w, r, http.StatusOK, toAgentAPIResponse(agent, true))
@@ -426,7 +447,13 @@ func (h *AgentsHandler) PatchAgent(w http.ResponseWriter, r *http.Request) {
 func (h *AgentsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	agentID := chi.URLParam(r, "agentId")
 
-	if err := h.agents.Delete(r.Context(), agentID); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.agents.Delete(ctx, agentID); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "agent.deleted", "agent", agentID, nil)
+	})
+	if err != nil {
 		if isNotFoundError(err) {
 			RespondError(w, r, apierrors.NotFound("agent", agentID))
 			return
@@ -436,7 +463,6 @@ func (h *AgentsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "agent_delete", "agent", agentID)
-	h.dispatchEvent(r, "agent.deleted", "agent", agentID)
 	h.publishA2A(agentID, "delete")
 
 	RespondNoContent(w)
@@ -510,7 +536,17 @@ func (h *AgentsHandler) Rollback(w http.ResponseWriter, r *http.Request) {
 
 	userID, _ := auth.UserIDFromContext(r.Context())
 
-	agent, err := h.agents.Rollback(r.Context(), agentID, *req.TargetVersion, userID.String())
+	var agent *store.Agent
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		previous, err := h.previousAgent(ctx, agentID)
+		if err != nil {
+			return err
+		}
+		if agent, err = h.agents.Rollback(ctx, agentID, *req.TargetVersion, userID.String()); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "agent.rolled_back", "agent", agentID, agentChange(agent, previous))
+	})
 	if err != nil {
 		if isNotFoundError(err) {
 			RespondError(w, r, apierrors.NotFound("agent_version", agentID))
@@ -521,7 +557,6 @@ func (h *AgentsHandler) Rollback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "agent_rollback", "agent", agentID)
-	h.dispatchEvent(r, "agent.rolled_back", "agent", agentID)
 	h.publishA2A(agentID, "upsert")
 
 	RespondJSON(w, r, http.StatusOK, toAgentAPIResponse(agent, true))
@@ -544,20 +579,39 @@ func (h *AgentsHandler) auditLog(r *http.Request, action, resourceType, resource
 	}
 }
 
-func (h *AgentsHandler) dispatchEvent(r *http.Request, eventType, resourceType, resourceID string) {
+func (h *AgentsHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string, change *notify.Change) error {
 	if h.dispatcher == nil {
-		return
+		return nil
 	}
 	callerID, _ := auth.UserIDFromContext(r.Context())
-	h.dispatcher.Dispatch(notify.Event{
+	return h.dispatcher.Dispatch(ctx, notify.Event{
 		Type:         eventType,
 		ResourceType: resourceType,
 		ResourceID:   resourceID,
 		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
 		Actor:        callerID.String(),
+		Change:       change,
 	})
 }
 
+// previousAgent loads an agent before a mutation so its event can carry a
+// diff. It skips the lookup when no events are dispatched.
+func (h *AgentsHandler) previousAgent(ctx context.Context, agentID string) (*store.Agent, error) {
+	if h.dispatcher == nil {
+		return nil, nil
+	}
+	return h.agents.GetByID(ctx, agentID)
+}
+
+// agentChange describes the agent version an event produced.
+func agentChange(agent, previous *store.Agent) *notify.Change {
+	change := &notify.Change{Version: agent.Version, Snapshot: toAgentAPIResponse(agent, true)}
+	if previous != nil {
+		change.Previous = toAgentAPIResponse(previous, true)
+	}
+	return change
+}
+
 func (h *AgentsHandler) publishA2A(agentID, action string) {
 	if h.a2aPublisher == nil {
 		return
@@ -1204,6 +1204,52 @@ func TestAgentsHandler_Update_PreservesCreatedBy(t *testing.T) {
 	}
 }
 
+func TestAgentsHandler_UpdateDispatchesChange(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	agentStore := newMockAgentStore()
+	agentStore.agents["test_agent"] = &store.Agent{
+		ID:             "test_agent",
+		Name:           "Test Agent",
+		Tools:          json.RawMessage(`[]`),
+		TrustOverrides: json.RawMessage(`{}`),
+		ExamplePrompts: json.RawMessage(`[]`),
+		IsActive:       true,
+		Version:        1,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	d := &txDispatcher{}
+	h := NewAgentsHandler(agentStore, &mockAuditStoreForAPI{}, d)
+
+	req := agentRequest(http.MethodPut, "/api/v1/agents/test_agent", map[string]interface{}{
+		"name": "Renamed Agent",
+	}, "editor")
+	req.Header.Set("If-Match", now.Format(time.RFC3339Nano))
+	req = withChiParam(req, "agentId", "test_agent")
+	w := httptest.NewRecorder()
+	h.Update(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if len(d.committed) != 1 || d.committed[0].Change == nil {
+		t.Fatalf("expected one committed event with a change, got %+v", d.committed)
+	}
+	change := d.committed[0].Change
+	if change.Version != 2 {
+		t.Errorf("change version = %d, want 2", change.Version)
+	}
+	snapshot, ok := change.Snapshot.(agentAPIResponse)
+	if !ok || snapshot.Name != "Renamed Agent" {
+		t.Errorf("unexpected snapshot: %+v", change.Snapshot)
+	}
+	previous, ok := change.Previous.(agentAPIResponse)
+	if !ok || previous.Name != "Test Agent" || previous.Version != 1 {
+		t.Errorf("unexpected previous version: %+v", change.Previous)
+	}
+}
+
 // --- A2A Publisher trigger tests ---
 
 type mockA2ACardProvider struct {
@@ -1376,3 +1422,52 @@ func TestAgentsHandler_NilPublisher_NoError(t *testing.T) {
 		t.Fatalf("expected 201, got %d; body: %s", w.Code, w.Body.String())
 	}
 }
+
+func TestAgentsHandler_CreateDispatchesInTransaction(t *testing.T) {
+	body := map[string]interface{}{"id": "tx_agent", "name": "Tx Agent"}
+
+	t.Run("committed with the mutation", func(t *testing.T) {
+		d := &txDispatcher{}
+		h := NewAgentsHandler(newMockAgentStore(), &mockAuditStoreForAPI{}, d)
+		w := httptest.NewRecorder()
+		h.Create(w, agentRequest(http.MethodPost, "/api/v1/agents", body, "editor"))
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d; body: %s", w.Code, w.Body.String())
+		}
+		if len(d.committed) != 1 || d.committed[0].Type != "agent.created" || d.committed[0].ResourceID != "tx_agent" {
+			t.Fatalf("expected one committed agent.created event, got %+v", d.committed)
+		}
+	})
+
+	t.Run("failed mutation dispatches nothing", func(t *testing.T) {
+		d := &txDispatcher{}
+		agentStore := newMockAgentStore()
+		agentStore.createErr = fmt.Errorf("db down")
+		h := NewAgentsHandler(agentStore, &mockAuditStoreForAPI{}, d)
+		w := httptest.NewRecorder()
+		h.Create(w, agentRequest(http.MethodPost, "/api/v1/agents", body, "editor"))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", w.Code)
+		}
+		if len(d.committed) != 0 {
+			t.Fatalf("expected no committed events, got %+v", d.committed)
+		}
+	})
+
+	t.Run("failed dispatch fails the mutation", func(t *testing.T) {
+		d := &txDispatcher{dispatchErr: fmt.Errorf("outbox unavailable")}
+		audit := &mockAuditStoreForAPI{}
+		h := NewAgentsHandler(newMockAgentStore(), audit, d)
+		w := httptest.NewRecorder()
+		h.Create(w, agentRequest(http.MethodPost, "/api/v1/agents", body, "editor"))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", w.Code)
+		}
+		if len(audit.entries) != 0 {
+			t.Fatalf("expected no audit entry for a rolled back mutation, got %d", len(audit.entries))
+		}
+	})
+}
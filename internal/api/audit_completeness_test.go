@@ -43,6 +43,9 @@ func (m *mockPromptStoreForAudit) Activate(_ context.Context, _ uuid.UUID) (*sto
 func (m *mockPromptStoreForAudit) Rollback(_ context.Context, _ string, _ int, _ string) (*store.Prompt, error) {
 	return &store.Prompt{ID: uuid.New()}, nil
 }
+func (m *mockPromptStoreForAudit) GetByVersion(_ context.Context, _ string, _ int) (*store.Prompt, error) {
+	return nil, nil
+}
 
 // mockMCPServerStoreForAudit implements MCPServerStoreForAPI.
 type mockMCPServerStoreForAudit struct{}
@@ -126,6 +129,18 @@ func (m *mockWebhookStoreForAudit) Create(_ context.Context, sub *store.WebhookS
 func (m *mockWebhookStoreForAudit) List(_ context.Context) ([]store.WebhookSubscription, error) {
 	return nil, nil
 }
+func (m *mockWebhookStoreForAudit) GetByID(_ context.Context, id uuid.UUID) (*store.WebhookSubscription, error) {
+	return &store.WebhookSubscription{ID: id}, nil
+}
+func (m *mockWebhookStoreForAudit) Update(_ context.Context, _ *store.WebhookSubscription) error {
+	return nil
+}
+func (m *mockWebhookStoreForAudit) SetActive(_ context.Context, id uuid.UUID, active bool) (*store.WebhookSubscription, error) {
+	return &store.WebhookSubscription{ID: id, IsActive: active}, nil
+}
+func (m *mockWebhookStoreForAudit) RotateSecret(_ context.Context, id uuid.UUID, secret string, _ time.Duration) (*store.WebhookSubscription, error) {
+	return &store.WebhookSubscription{ID: id, Secret: secret}, nil
+}
 func (m *mockWebhookStoreForAudit) Delete(_ context.Context, _ uuid.UUID) error {
 	return nil
 }
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// EgressRuleStoreForAPI is the interface the egress rules handler needs from the store.
+type EgressRuleStoreForAPI interface {
+	List(ctx context.Context) ([]store.EgressRule, error)
+	Create(ctx context.Context, rule *store.EgressRule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// EgressRulesHandler provides HTTP handlers for the global egress allow/deny list.
+type EgressRulesHandler struct {
+	rules EgressRuleStoreForAPI
+	audit AuditStoreForAPI
+	guard *gateway.EgressGuard
+}
+
+// NewEgressRulesHandler creates a new EgressRulesHandler. guard, if non-nil, is
+// invalidated after every change so new rules apply to the next connection.
+func NewEgressRulesHandler(rules EgressRuleStoreForAPI, audit AuditStoreForAPI, guard *gateway.EgressGuard) *EgressRulesHandler {
+	return &EgressRulesHandler{
+		rules: rules,
+		audit: audit,
+		guard: guard,
+	}
+}
+
+// List handles GET /api/v1/egress-rules.
+func (h *EgressRulesHandler) List(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.rules.List(r.Context())
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to list egress rules"))
+		return
+	}
+
+	if rules == nil {
+		rules = []store.EgressRule{}
+	}
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"rules": rules,
+		"total": len(rules),
+	})
+}
+
+type createEgressRuleRequest struct {
+	Action      string `json:"action"`
+	CIDR        string `json:"cidr"`
+	Hostname    string `json:"hostname"`
+	Description string `json:"description"`
+}
+
+// Create handles POST /api/v1/egress-rules.
+func (h *EgressRulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createEgressRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+
+	if req.Action != gateway.EgressAllow && req.Action != gateway.EgressDeny {
+		RespondError(w, r, apierrors.Validation("action must be one of: allow, deny"))
+		return
+	}
+	if (req.CIDR == "") == (req.Hostname == "") {
+		RespondError(w, r, apierrors.Validation("exactly one of cidr or hostname is required"))
+		return
+	}
+	if req.CIDR != "" {
+		ipNet, err := gateway.ParseEgressCIDR(req.CIDR)
+		if err != nil {
+			RespondError(w, r, apierrors.Validation(err.Error()))
+			return
+		}
+		req.CIDR = ipNet.String()
+	}
+	if req.Hostname != "" {
+		if err := gateway.ValidateHostPattern(req.Hostname); err != nil {
+			RespondError(w, r, apierrors.Validation(err.Error()))
+			return
+		}
+		req.Hostname = strings.ToLower(req.Hostname)
+	}
+	if len(req.Description) > 500 {
+		RespondError(w, r, apierrors.Validation("description must be at most 500 characters"))
+		return
+	}
+
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	rule := &store.EgressRule{
+		Action:      req.Action,
+		CIDR:        req.CIDR,
+		Hostname:    req.Hostname,
+		Description: req.Description,
+		CreatedBy:   callerID.String(),
+	}
+
+	if err := h.rules.Create(r.Context(), rule); err != nil {
+		RespondError(w, r, apierrors.Internal("failed to create egress rule"))
+		return
+	}
+	if h.guard != nil {
+		h.guard.Invalidate()
+	}
+
+	h.auditLog(r, "egress_rule_create", "egress_rule", rule.ID.String())
+
+	RespondJSON(w, r, http.StatusCreated, rule)
+}
+
+// Delete handles DELETE /api/v1/egress-rules/{ruleId}.
+func (h *EgressRulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid rule ID"))
+		return
+	}
+
+	if err := h.rules.Delete(r.Context(), ruleID); err != nil {
+		RespondError(w, r, apierrors.NotFound("egress_rule", ruleID.String()))
+		return
+	}
+	if h.guard != nil {
+		h.guard.Invalidate()
+	}
+
+	h.auditLog(r, "egress_rule_delete", "egress_rule", ruleID.String())
+
+	RespondNoContent(w)
+}
+
+func (h *EgressRulesHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
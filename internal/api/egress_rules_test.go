@@ -0,0 +1,266 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// --- Mock egress rule store ---
+
+type mockEgressRuleStore struct {
+	rules []store.EgressRule
+}
+
+func (m *mockEgressRuleStore) List(_ context.Context) ([]store.EgressRule, error) {
+	return m.rules, nil
+}
+
+func (m *mockEgressRuleStore) Create(_ context.Context, rule *store.EgressRule) error {
+	rule.ID = uuid.New()
+	rule.CreatedAt = time.Now()
+	m.rules = append(m.rules, *rule)
+	return nil
+}
+
+func (m *mockEgressRuleStore) Delete(_ context.Context, id uuid.UUID) error {
+	for i, r := range m.rules {
+		if r.ID == id {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("not found")
+}
+
+// egressProvider exposes the mock store to an EgressGuard.
+type egressProvider struct {
+	store *mockEgressRuleStore
+}
+
+func (p *egressProvider) List(ctx context.Context) ([]gateway.EgressRuleRecord, error) {
+	rules, _ := p.store.List(ctx)
+	records := make([]gateway.EgressRuleRecord, len(rules))
+	for i, r := range rules {
+		records[i] = gateway.EgressRuleRecord{Action: r.Action, CIDR: r.CIDR, Hostname: r.Hostname}
+	}
+	return records, nil
+}
+
+// --- Egress rules handler tests ---
+
+func TestEgressRulesHandler_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{"allow CIDR", map[string]interface{}{"action": "allow", "cidr": "10.20.0.0/16"}, http.StatusCreated},
+		{"deny hostname", map[string]interface{}{"action": "deny", "hostname": "*.Internal.example.com"}, http.StatusCreated},
+		{"single IP", map[string]interface{}{"action": "deny", "cidr": "203.0.113.9"}, http.StatusCreated},
+		{"invalid action", map[string]interface{}{"action": "maybe", "cidr": "10.0.0.0/8"}, http.StatusBadRequest},
+		{"both cidr and hostname", map[string]interface{}{"action": "allow", "cidr": "10.0.0.0/8", "hostname": "a.example.com"}, http.StatusBadRequest},
+		{"neither cidr nor hostname", map[string]interface{}{"action": "allow"}, http.StatusBadRequest},
+		{"invalid CIDR", map[string]interface{}{"action": "allow", "cidr": "10.0.0.0/40"}, http.StatusBadRequest},
+		{"invalid hostname", map[string]interface{}{"action": "allow", "hostname": "http://a.example.com"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewEgressRulesHandler(&mockEgressRuleStore{}, &mockAuditStoreForAPI{}, nil)
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/egress-rules", tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestEgressRulesHandler_CreateInvalidatesGuard(t *testing.T) {
+	rules := &mockEgressRuleStore{}
+	guard := gateway.NewEgressGuard(&egressProvider{store: rules}, time.Hour)
+	h := NewEgressRulesHandler(rules, &mockAuditStoreForAPI{}, guard)
+
+	ips := []net.IP{net.ParseIP("10.20.1.1")}
+	if err := guard.Check(context.Background(), "svc.internal", ips, nil); err == nil {
+		t.Fatal("expected private address to be denied before allow rule exists")
+	}
+
+	w := httptest.NewRecorder()
+	h.Create(w, adminRequest(http.MethodPost, "/api/v1/egress-rules", map[string]interface{}{"action": "allow", "cidr": "10.20.0.0/16"}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+
+	if err := guard.Check(context.Background(), "svc.internal", ips, nil); err != nil {
+		t.Errorf("expected new allow rule to apply immediately, got %v", err)
+	}
+}
+
+func TestEgressRulesHandler_ListAndDelete(t *testing.T) {
+	rules := &mockEgressRuleStore{}
+	h := NewEgressRulesHandler(rules, &mockAuditStoreForAPI{}, nil)
+	rules.Create(context.Background(), &store.EgressRule{Action: "deny", CIDR: "203.0.113.0/24"})
+	id := rules.rules[0].ID
+
+	w := httptest.NewRecorder()
+	h.List(w, adminRequest(http.MethodGet, "/api/v1/egress-rules", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", w.Code)
+	}
+	env := parseEnvelope(t, w)
+	data := env.Data.(map[string]interface{})
+	if data["total"].(float64) != 1 {
+		t.Errorf("total = %v, want 1", data["total"])
+	}
+
+	req := adminRequest(http.MethodDelete, "/api/v1/egress-rules/"+id.String(), nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("ruleId", id.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w = httptest.NewRecorder()
+	h.Delete(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	if len(rules.rules) != 0 {
+		t.Errorf("expected rule deleted, %d remain", len(rules.rules))
+	}
+}
+
+func TestMCPServersHandler_Create_EgressPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		global     []store.EgressRule
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{
+			name:       "private endpoint rejected without allowlist",
+			body:       map[string]interface{}{"label": "svc", "endpoint": "http://10.1.2.3:8080/mcp"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "private endpoint allowed by server allowlist",
+			body: map[string]interface{}{
+				"label": "svc", "endpoint": "http://10.1.2.3:8080/mcp",
+				"egress_policy": map[string]interface{}{"allow_cidrs": []string{"10.1.0.0/16"}},
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "private endpoint allowed by global allow rule",
+			global:     []store.EgressRule{{Action: "allow", CIDR: "10.1.0.0/16"}},
+			body:       map[string]interface{}{"label": "svc", "endpoint": "http://10.1.2.3:8080/mcp"},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:   "global deny beats server allowlist",
+			global: []store.EgressRule{{Action: "deny", CIDR: "10.1.2.0/24"}},
+			body: map[string]interface{}{
+				"label": "svc", "endpoint": "http://10.1.2.3:8080/mcp",
+				"egress_policy": map[string]interface{}{"allow_cidrs": []string{"10.1.0.0/16"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid allowlist CIDR",
+			body: map[string]interface{}{
+				"label": "svc", "endpoint": "https://93.184.216.34/mcp",
+				"egress_policy": map[string]interface{}{"allow_cidrs": []string{"not-a-cidr"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "non-canonical IP still rejected",
+			body:       map[string]interface{}{"label": "svc", "endpoint": "http://0177.0.0.1/mcp"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, nil, nil)
+			h.SetEgressGuard(gateway.NewEgressGuard(&egressProvider{store: &mockEgressRuleStore{rules: tt.global}}, time.Minute))
+
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Create_OAuth2TokenURLEgressPolicy(t *testing.T) {
+	encKey := make([]byte, 32)
+	oauth2 := map[string]interface{}{"client_id": "id", "client_secret": "s", "token_url": "http://10.1.9.9:8080/token"}
+	tests := []struct {
+		name       string
+		policy     map[string]interface{}
+		wantStatus int
+	}{
+		{"private token_url rejected without allowlist", nil, http.StatusBadRequest},
+		{"private token_url allowed by server allowlist", map[string]interface{}{"allow_cidrs": []string{"10.1.0.0/16"}}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMCPServersHandler(newMockMCPServerStore(), &mockAuditStoreForAPI{}, encKey, nil)
+			h.SetEgressGuard(gateway.NewEgressGuard(nil, 0))
+			body := map[string]interface{}{
+				"label": "svc", "endpoint": "https://93.184.216.34/mcp",
+				"auth_type": "oauth2_client_credentials", "oauth2": oauth2,
+			}
+			if tt.policy != nil {
+				body["egress_policy"] = tt.policy
+			}
+
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Update_EgressPolicyRevalidatesEndpoint(t *testing.T) {
+	mcpStore := newMockMCPServerStore()
+	h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, nil, nil)
+	h.SetEgressGuard(gateway.NewEgressGuard(nil, 0))
+
+	serverID := uuid.New()
+	updatedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	mcpStore.servers[serverID] = &store.MCPServer{
+		ID: serverID, Label: "svc", Endpoint: "http://10.1.2.3:8080/mcp", AuthType: "none",
+		EgressPolicy:   []byte(`{"allow_cidrs":["10.1.0.0/16"]}`),
+		CircuitBreaker: []byte(`{}`), DiscoveryInterval: "5m", IsEnabled: true,
+		CreatedAt: time.Now(), UpdatedAt: updatedAt,
+	}
+	mcpStore.labels["svc"] = serverID
+
+	req := adminRequest(http.MethodPut, "/api/v1/mcp-servers/"+serverID.String(), map[string]interface{}{"egress_policy": map[string]interface{}{}})
+	req.Header.Set("If-Match", updatedAt.UTC().Format(time.RFC3339Nano))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("serverId", serverID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	h.Update(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("removing the allowlist for a private endpoint: expected 400, got %d; body: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// maxUsageWindow bounds the window of a usage query.
+const maxUsageWindow = 31 * 24 * time.Hour
+
+// GatewayUsageStoreForAPI is the interface the usage handler needs from the store.
+type GatewayUsageStoreForAPI interface {
+	Summarize(ctx context.Context, q store.GatewayUsageQuery) ([]store.GatewayUsageGroup, error)
+}
+
+// GatewayUsageHandler provides the HTTP handler for gateway usage analytics.
+type GatewayUsageHandler struct {
+	store GatewayUsageStoreForAPI
+}
+
+// NewGatewayUsageHandler creates a new GatewayUsageHandler.
+func NewGatewayUsageHandler(s GatewayUsageStoreForAPI) *GatewayUsageHandler {
+	return &GatewayUsageHandler{store: s}
+}
+
+type usageLatency struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Avg float64 `json:"avg"`
+}
+
+type usageStats struct {
+	Key       string           `json:"key,omitempty"`
+	Calls     int64            `json:"calls"`
+	Errors    int64            `json:"errors"`
+	ErrorRate float64          `json:"error_rate"`
+	Outcomes  map[string]int64 `json:"outcomes"`
+	LatencyMS usageLatency     `json:"latency_ms"`
+	BytesIn   int64            `json:"bytes_in"`
+	BytesOut  int64            `json:"bytes_out"`
+	Cost      float64          `json:"cost"`
+}
+
+// usageSorts orders usage groups, largest first.
+var usageSorts = map[string]func(a, b usageStats) bool{
+	"calls":      func(a, b usageStats) bool { return a.Calls > b.Calls },
+	"errors":     func(a, b usageStats) bool { return a.Errors > b.Errors },
+	"error_rate": func(a, b usageStats) bool { return a.ErrorRate > b.ErrorRate },
+	"p95":        func(a, b usageStats) bool { return a.LatencyMS.P95 > b.LatencyMS.P95 },
+	"p99":        func(a, b usageStats) bool { return a.LatencyMS.P99 > b.LatencyMS.P99 },
+	"bytes_out":  func(a, b usageStats) bool { return a.BytesOut > b.BytesOut },
+	"cost":       func(a, b usageStats) bool { return a.Cost > b.Cost },
+}
+
+// usageAccumulator sums usage groups and derives their statistics.
+type usageAccumulator struct {
+	outcomes   map[string]int64
+	latencySum int64
+	hist       []int64
+	bytesIn    int64
+	bytesOut   int64
+	costMicros int64
+}
+
+func (a *usageAccumulator) add(g store.GatewayUsageGroup) {
+	if a.outcomes == nil {
+		a.outcomes = make(map[string]int64)
+	}
+	for outcome, n := range g.Outcomes {
+		a.outcomes[outcome] += n
+	}
+	a.latencySum += g.LatencySumMS
+	for len(a.hist) < len(g.LatencyHist) {
+		a.hist = append(a.hist, 0)
+	}
+	for i, n := range g.LatencyHist {
+		a.hist[i] += n
+	}
+	a.bytesIn += g.BytesIn
+	a.bytesOut += g.BytesOut
+	a.costMicros += g.CostMicros
+}
+
+func (a *usageAccumulator) stats(key string) usageStats {
+	s := usageStats{
+		Key: key, Outcomes: a.outcomes, BytesIn: a.bytesIn, BytesOut: a.bytesOut,
+		Cost: microsToUnits(a.costMicros),
+	}
+	if s.Outcomes == nil {
+		s.Outcomes = map[string]int64{}
+	}
+	for outcome, n := range a.outcomes {
+		s.Calls += n
+		if gateway.IsErrorOutcome(outcome) {
+			s.Errors += n
+		}
+	}
+	if s.Calls > 0 {
+		s.ErrorRate = math.Round(float64(s.Errors)/float64(s.Calls)*10000) / 10000
+	}
+	var forwarded int64
+	for _, n := range a.hist {
+		forwarded += n
+	}
+	if forwarded > 0 {
+		s.LatencyMS = usageLatency{
+			P50: round2(gateway.LatencyQuantile(a.hist, 0.50)),
+			P95: round2(gateway.LatencyQuantile(a.hist, 0.95)),
+			P99: round2(gateway.LatencyQuantile(a.hist, 0.99)),
+			Avg: round2(float64(a.latencySum) / float64(forwarded)),
+		}
+	}
+	return s
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// microsToUnits converts metered micro-units to currency units.
+func microsToUnits(micros int64) float64 {
+	return float64(micros) / gateway.MicrosPerUnit
+}
+
+// Query handles GET /api/v1/gateway/usage.
+func (h *GatewayUsageHandler) Query(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	to := time.Now().UTC()
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			RespondError(w, r, apierrors.Validation("to must be an RFC 3339 timestamp"))
+			return
+		}
+		to = t.UTC()
+	}
+	window := 24 * time.Hour
+	if v := q.Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < gateway.UsageBucketWidth || d > maxUsageWindow {
+			RespondError(w, r, apierrors.Validation("window must be a duration between 1m and 744h"))
+			return
+		}
+		window = d
+	}
+
+	groupBy := q.Get("group_by")
+	if groupBy == "" {
+		groupBy = "tool"
+	}
+	if !store.ValidUsageGroupBy(groupBy) {
+		RespondError(w, r, apierrors.Validation("group_by must be one of server, tool, agent, workspace, caller, outcome"))
+		return
+	}
+	sortBy := q.Get("sort")
+	if sortBy == "" {
+		sortBy = "calls"
+	}
+	less, ok := usageSorts[sortBy]
+	if !ok {
+		RespondError(w, r, apierrors.Validation("sort must be one of calls, errors, error_rate, p95, p99, bytes_out, cost"))
+		return
+	}
+	limit := 20
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			RespondError(w, r, apierrors.Validation("limit must be between 1 and 100"))
+			return
+		}
+		limit = n
+	}
+	var minCalls int64
+	if v := q.Get("min_calls"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			RespondError(w, r, apierrors.Validation("min_calls must be a non-negative integer"))
+			return
+		}
+		minCalls = n
+	}
+
+	query := store.GatewayUsageQuery{
+		From:        to.Add(-window),
+		To:          to,
+		GroupBy:     groupBy,
+		ServerLabel: q.Get("server"),
+		ToolName:    q.Get("tool"),
+		AgentID:     q.Get("agent_id"),
+		WorkspaceID: q.Get("workspace_id"),
+		CallerID:    q.Get("caller_id"),
+	}
+	groups, err := h.store.Summarize(r.Context(), query)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to load gateway usage"))
+		return
+	}
+
+	var total usageAccumulator
+	items := make([]usageStats, 0, len(groups))
+	for _, g := range groups {
+		total.add(g)
+		var acc usageAccumulator
+		acc.add(g)
+		if s := acc.stats(g.Key); s.Calls >= minCalls {
+			items = append(items, s)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"from":     query.From,
+		"to":       query.To,
+		"group_by": groupBy,
+		"sort":     sortBy,
+		"items":    items,
+		"totals":   total.stats(""),
+	})
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+type mockGatewayUsageStore struct {
+	groups    []store.GatewayUsageGroup
+	err       error
+	lastQuery store.GatewayUsageQuery
+}
+
+func (m *mockGatewayUsageStore) Summarize(_ context.Context, q store.GatewayUsageQuery) ([]store.GatewayUsageGroup, error) {
+	m.lastQuery = q
+	return m.groups, m.err
+}
+
+func usageHist(counts map[int64]int64) []int64 {
+	hist := make([]int64, len(gateway.LatencyBoundsMS)+1)
+	for ms, n := range counts {
+		for i, bound := range gateway.LatencyBoundsMS {
+			if ms <= bound {
+				hist[i] += n
+				break
+			}
+		}
+	}
+	return hist
+}
+
+func newUsageStoreFixture() *mockGatewayUsageStore {
+	return &mockGatewayUsageStore{groups: []store.GatewayUsageGroup{
+		{
+			Key:         "github/search",
+			Outcomes:    map[string]int64{"success": 90, "upstream_5xx": 10},
+			LatencyHist: usageHist(map[int64]int64{80: 100}),
+			BytesIn:     1000, BytesOut: 50000,
+			CostMicros: 1500000,
+		},
+		{
+			Key:         "jira/create_issue",
+			Outcomes:    map[string]int64{"success": 5, "upstream_error": 5, "trust_denied": 10},
+			LatencyHist: usageHist(map[int64]int64{2000: 10}),
+		},
+		{
+			Key:      "slack/post",
+			Outcomes: map[string]int64{"rate_limited": 1},
+		},
+	}}
+}
+
+func TestGatewayUsageHandler_Query(t *testing.T) {
+	s := newUsageStoreFixture()
+	h := NewGatewayUsageHandler(s)
+
+	w := httptest.NewRecorder()
+	h.Query(w, adminRequest(http.MethodGet, "/api/v1/gateway/usage?window=6h&server=github", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if s.lastQuery.GroupBy != "tool" || s.lastQuery.ServerLabel != "github" || s.lastQuery.To.Sub(s.lastQuery.From) != 6*time.Hour {
+		t.Errorf("unexpected query: %+v", s.lastQuery)
+	}
+
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	items := data["items"].([]interface{})
+	if len(items) != 3 || items[0].(map[string]interface{})["key"] != "github/search" {
+		t.Fatalf("expected items sorted by calls, got %v", items)
+	}
+	top := items[0].(map[string]interface{})
+	if top["errors"].(float64) != 10 || top["error_rate"].(float64) != 0.1 {
+		t.Errorf("errors = %v, error_rate = %v", top["errors"], top["error_rate"])
+	}
+	latency := top["latency_ms"].(map[string]interface{})
+	if latency["p50"].(float64) != 75 || latency["p99"].(float64) != 99.5 {
+		t.Errorf("unexpected latency: %v", latency)
+	}
+
+	totals := data["totals"].(map[string]interface{})
+	if totals["calls"].(float64) != 121 || totals["errors"].(float64) != 15 || totals["cost"].(float64) != 1.5 {
+		t.Errorf("unexpected totals: %v", totals)
+	}
+}
+
+func TestGatewayUsageHandler_SortByErrorRate(t *testing.T) {
+	h := NewGatewayUsageHandler(newUsageStoreFixture())
+
+	w := httptest.NewRecorder()
+	h.Query(w, adminRequest(http.MethodGet, "/api/v1/gateway/usage?sort=error_rate&min_calls=2&limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	items := parseEnvelope(t, w).Data.(map[string]interface{})["items"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if key := items[0].(map[string]interface{})["key"]; key != "jira/create_issue" {
+		t.Errorf("top error rate = %v, want jira/create_issue", key)
+	}
+}
+
+func TestGatewayUsageHandler_InvalidParams(t *testing.T) {
+	tests := []string{
+		"window=forever",
+		"window=30s",
+		"window=1000h",
+		"group_by=ip",
+		"sort=name",
+		"limit=0",
+		"min_calls=-1",
+		"to=yesterday",
+	}
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			h := NewGatewayUsageHandler(newUsageStoreFixture())
+			w := httptest.NewRecorder()
+			h.Query(w, adminRequest(http.MethodGet, "/api/v1/gateway/usage?"+query, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestGatewayUsageHandler_StoreError(t *testing.T) {
+	h := NewGatewayUsageHandler(&mockGatewayUsageStore{err: errors.New("db down")})
+
+	w := httptest.NewRecorder()
+	h.Query(w, adminRequest(http.MethodGet, "/api/v1/gateway/usage", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+}
@@ -89,14 +89,15 @@ type mockAPIKeyLookupForInteg struct {
 type mockAPIKeyData struct {
 	userID uuid.UUID
 	role   string
+	keyID  uuid.UUID
 }
 
-func (m *mockAPIKeyLookupForInteg) ValidateAPIKey(_ context.Context, key string) (uuid.UUID, string, error) {
+func (m *mockAPIKeyLookupForInteg) ValidateAPIKey(_ context.Context, key string) (uuid.UUID, string, uuid.UUID, error) {
 	k, ok := m.keys[key]
 	if !ok {
-		return uuid.Nil, "", fmt.Errorf("invalid API key")
+		return uuid.Nil, "", uuid.Nil, fmt.Errorf("invalid API key")
 	}
-	return k.userID, k.role, nil
+	return k.userID, k.role, k.keyID, nil
 }
 
 // --- Helper to build a full integration test router ---
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/notify"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// CircuitsHandler provides HTTP handlers to inspect and operate the gateway
+// circuit breakers of an MCP server.
+type CircuitsHandler struct {
+	servers MCPServerLookup
+	breaker *gateway.CircuitBreaker
+	audit   AuditStoreForAPI
+}
+
+// NewCircuitsHandler creates a new CircuitsHandler. breaker may be nil when
+// gateway mode is off, in which case every endpoint returns 503.
+func NewCircuitsHandler(servers MCPServerLookup, breaker *gateway.CircuitBreaker, audit AuditStoreForAPI) *CircuitsHandler {
+	return &CircuitsHandler{
+		servers: servers,
+		breaker: breaker,
+		audit:   audit,
+	}
+}
+
+type circuitResponse struct {
+	Endpoint string     `json:"endpoint"`
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	Forced   bool       `json:"forced"`
+}
+
+// serverPool resolves the server in the URL and its endpoint pool.
+func (h *CircuitsHandler) serverPool(w http.ResponseWriter, r *http.Request) (*store.MCPServer, []gateway.Endpoint, bool) {
+	if h.breaker == nil {
+		RespondError(w, r, apierrors.ServiceUnavailable("gateway mode is not enabled"))
+		return nil, nil, false
+	}
+	serverID, err := uuid.Parse(chi.URLParam(r, "serverId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid server ID"))
+		return nil, nil, false
+	}
+	server, err := h.servers.GetByID(r.Context(), serverID)
+	if err != nil {
+		RespondError(w, r, apierrors.NotFound("mcp_server", serverID.String()))
+		return nil, nil, false
+	}
+	pool, err := serverEndpointPool(server)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("invalid endpoint pool"))
+		return nil, nil, false
+	}
+	return server, pool, true
+}
+
+func (h *CircuitsHandler) respondState(w http.ResponseWriter, r *http.Request, server *store.MCPServer, pool []gateway.Endpoint) {
+	circuits := make([]circuitResponse, 0, len(pool))
+	for _, e := range pool {
+		status := h.breaker.Status(gateway.EndpointKey(server.Label, pool, e.URL))
+		c := circuitResponse{
+			Endpoint: e.URL,
+			State:    status.State.String(),
+			Failures: status.Failures,
+			Forced:   status.Forced,
+		}
+		if !status.OpenedAt.IsZero() && status.State != gateway.CircuitClosed {
+			openedAt := status.OpenedAt.UTC()
+			c.OpenedAt = &openedAt
+		}
+		circuits = append(circuits, c)
+	}
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"server_id": server.ID,
+		"label":     server.Label,
+		"circuits":  circuits,
+	})
+}
+
+// Get handles GET /api/v1/mcp-servers/{serverId}/circuit.
+func (h *CircuitsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	server, pool, ok := h.serverPool(w, r)
+	if !ok {
+		return
+	}
+	h.respondState(w, r, server, pool)
+}
+
+// Open handles POST /api/v1/mcp-servers/{serverId}/circuit/open. The circuit
+// stays open until it is closed manually.
+func (h *CircuitsHandler) Open(w http.ResponseWriter, r *http.Request) {
+	h.force(w, r, true)
+}
+
+// Close handles POST /api/v1/mcp-servers/{serverId}/circuit/close.
+func (h *CircuitsHandler) Close(w http.ResponseWriter, r *http.Request) {
+	h.force(w, r, false)
+}
+
+type forceCircuitRequest struct {
+	Endpoint string `json:"endpoint"`
+}
+
+func (h *CircuitsHandler) force(w http.ResponseWriter, r *http.Request, open bool) {
+	server, pool, ok := h.serverPool(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; without an endpoint every circuit of the server
+	// is changed.
+	var req forceCircuitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+	targets := pool
+	if req.Endpoint != "" {
+		if !poolContains(pool, req.Endpoint) {
+			RespondError(w, r, apierrors.Validation("endpoint is not part of the server's endpoint pool"))
+			return
+		}
+		targets = []gateway.Endpoint{{URL: req.Endpoint}}
+	}
+
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	for _, e := range targets {
+		key := gateway.EndpointKey(server.Label, pool, e.URL)
+		if open {
+			h.breaker.ForceOpen(key, callerID.String())
+		} else {
+			h.breaker.ForceClose(key, callerID.String())
+		}
+	}
+
+	action := "mcp_circuit_close"
+	if open {
+		action = "mcp_circuit_open"
+	}
+	h.auditLog(r, action, "mcp_server", server.ID.String())
+
+	h.respondState(w, r, server, pool)
+}
+
+func (h *CircuitsHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
+
+// circuitEventTypes maps a circuit's new state to its webhook event.
+var circuitEventTypes = map[gateway.CircuitState]string{
+	gateway.CircuitOpen:     "mcp_server.circuit_opened",
+	gateway.CircuitHalfOpen: "mcp_server.circuit_half_opened",
+	gateway.CircuitClosed:   "mcp_server.circuit_closed",
+}
+
+// CircuitEventObserver returns a circuit breaker observer that dispatches a
+// webhook event for every state transition. The resource ID is the circuit
+// key: the server label, or "label|url" for a pooled endpoint.
+func CircuitEventObserver(dispatcher notify.EventDispatcher) func(gateway.CircuitTransition) {
+	return func(t gateway.CircuitTransition) {
+		if dispatcher == nil {
+			return
+		}
+		actor := t.Actor
+		if actor == "" {
+			actor = "system"
+		}
+		err := dispatcher.Dispatch(context.Background(), notify.Event{
+			Type:         circuitEventTypes[t.To],
+			ResourceType: "mcp_circuit",
+			ResourceID:   t.Key,
+			Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+			Actor:        actor,
+		})
+		if err != nil {
+			log.Printf("dispatching %s for circuit %s failed: %v", circuitEventTypes[t.To], t.Key, err)
+		}
+	}
+}
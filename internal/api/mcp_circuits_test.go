@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/notify"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+type recordingDispatcher struct {
+	events []notify.Event
+}
+
+func (d *recordingDispatcher) Dispatch(_ context.Context, event notify.Event) error {
+	d.events = append(d.events, event)
+	return nil
+}
+
+func newTestCircuitsHandler(t *testing.T, cb *gateway.CircuitBreaker) (*CircuitsHandler, *store.MCPServer, *mockAuditStoreForAPI) {
+	t.Helper()
+	servers := newMockMCPServerStore()
+	server := enabledMCPServer()
+	server.Endpoints = json.RawMessage(`[{"url":"http://a.example/mcp","weight":1},{"url":"http://b.example/mcp","weight":1}]`)
+	if err := servers.Create(context.Background(), server); err != nil {
+		t.Fatalf("create server: %v", err)
+	}
+	audit := &mockAuditStoreForAPI{}
+	return NewCircuitsHandler(servers, cb, audit), server, audit
+}
+
+func circuitStates(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	states := make(map[string]string)
+	for _, c := range data["circuits"].([]interface{}) {
+		m := c.(map[string]interface{})
+		states[m["endpoint"].(string)] = m["state"].(string)
+	}
+	return states
+}
+
+func TestCircuitsHandler_Get(t *testing.T) {
+	cb := gateway.NewCircuitBreaker()
+	h, server, _ := newTestCircuitsHandler(t, cb)
+	cb.RecordFailure(server.Label+"|http://b.example/mcp", gateway.CircuitBreakerConfig{FailThreshold: 1})
+
+	w := httptest.NewRecorder()
+	h.Get(w, toolSchemaRequest(http.MethodGet, "/api/v1/mcp-servers/x/circuit", nil, server.ID, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	states := circuitStates(t, w)
+	if states["http://a.example/mcp"] != "closed" || states["http://b.example/mcp"] != "open" {
+		t.Errorf("unexpected states: %v", states)
+	}
+
+	w = httptest.NewRecorder()
+	h.Get(w, toolSchemaRequest(http.MethodGet, "/api/v1/mcp-servers/x/circuit", nil, uuid.New(), ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown server: expected 404, got %d", w.Code)
+	}
+}
+
+func TestCircuitsHandler_OpenClose(t *testing.T) {
+	cb := gateway.NewCircuitBreaker()
+	h, server, audit := newTestCircuitsHandler(t, cb)
+
+	w := httptest.NewRecorder()
+	h.Open(w, toolSchemaRequest(http.MethodPost, "/api/v1/mcp-servers/x/circuit/open", []byte(`{"endpoint":"http://a.example/mcp"}`), server.ID, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	states := circuitStates(t, w)
+	if states["http://a.example/mcp"] != "open" || states["http://b.example/mcp"] != "closed" {
+		t.Errorf("unexpected states after open: %v", states)
+	}
+	if !cb.Status(server.Label + "|http://a.example/mcp").Forced {
+		t.Error("expected circuit to be forced open")
+	}
+
+	// Without an endpoint every circuit of the server is closed.
+	w = httptest.NewRecorder()
+	h.Close(w, toolSchemaRequest(http.MethodPost, "/api/v1/mcp-servers/x/circuit/close", nil, server.ID, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for endpoint, state := range circuitStates(t, w) {
+		if state != "closed" {
+			t.Errorf("%s: state = %s, want closed", endpoint, state)
+		}
+	}
+
+	if len(audit.entries) != 2 || audit.entries[0].Action != "mcp_circuit_open" || audit.entries[1].Action != "mcp_circuit_close" {
+		t.Errorf("expected open and close audit entries, got %+v", audit.entries)
+	}
+}
+
+func TestCircuitsHandler_UnknownEndpoint(t *testing.T) {
+	h, server, _ := newTestCircuitsHandler(t, gateway.NewCircuitBreaker())
+
+	w := httptest.NewRecorder()
+	h.Open(w, toolSchemaRequest(http.MethodPost, "/api/v1/mcp-servers/x/circuit/open", []byte(`{"endpoint":"http://c.example/mcp"}`), server.ID, ""))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCircuitsHandler_GatewayDisabled(t *testing.T) {
+	h, server, _ := newTestCircuitsHandler(t, nil)
+
+	w := httptest.NewRecorder()
+	h.Get(w, toolSchemaRequest(http.MethodGet, "/api/v1/mcp-servers/x/circuit", nil, server.ID, ""))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestCircuitEventObserver(t *testing.T) {
+	d := &recordingDispatcher{}
+	cb := gateway.NewCircuitBreaker()
+	cb.SetObserver(CircuitEventObserver(d))
+
+	cb.RecordFailure("srv", gateway.CircuitBreakerConfig{FailThreshold: 1})
+	cb.ForceClose("srv", "admin-id")
+
+	if len(d.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", d.events)
+	}
+	if e := d.events[0]; e.Type != "mcp_server.circuit_opened" || e.ResourceID != "srv" || e.ResourceType != "mcp_circuit" || e.Actor != "system" {
+		t.Errorf("unexpected open event: %+v", e)
+	}
+	if e := d.events[1]; e.Type != "mcp_server.circuit_closed" || e.Actor != "admin-id" {
+		t.Errorf("unexpected close event: %+v", e)
+	}
+}
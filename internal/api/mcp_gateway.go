@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -14,6 +17,7 @@ import (
 	"github.com/agent-smit/agentic-registry/internal/auth"
 	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
 	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/notify"
 	"github.com/agent-smit/agentic-registry/internal/ratelimit"
 	"github.com/agent-smit/agentic-registry/internal/store"
 )
@@ -27,6 +31,54 @@ type Forwarder interface {
 	Forward(ctx context.Context, req gateway.ProxyRequest) (*gateway.ProxyResponse, error)
 }
 
+// MCPGatewayToolSchemaStore stores tool schemas discovered from upstream
+// servers along with admin overlays.
+type MCPGatewayToolSchemaStore interface {
+	Get(ctx context.Context, serverID uuid.UUID, toolName string) (*store.MCPToolSchema, error)
+	ReplaceDiscovered(ctx context.Context, serverID uuid.UUID, tools []store.MCPToolSchema) error
+}
+
+// GatewayUsageRecorder aggregates gateway calls for usage analytics.
+type GatewayUsageRecorder interface {
+	Record(s gateway.UsageSample)
+}
+
+// GatewayBudgetStore meters workspace spend against monthly budgets.
+type GatewayBudgetStore interface {
+	Get(ctx context.Context, workspaceID uuid.UUID) (*store.WorkspaceBudget, error)
+	GetSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time) (int64, int64, error)
+	AddSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time, micros int64) (int64, error)
+	ReserveSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time, micros, limit int64) (int64, bool, error)
+	ReleaseSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time, micros int64) error
+}
+
+// GatewayAgentStore looks up the agents tool calls are made on behalf of.
+type GatewayAgentStore interface {
+	GetByID(ctx context.Context, id string) (*store.Agent, error)
+}
+
+// GatewayMemberStore checks the workspace membership of gateway callers.
+type GatewayMemberStore interface {
+	IsMember(ctx context.Context, workspaceID uuid.UUID, principalType string, principalID uuid.UUID) (bool, error)
+}
+
+// GatewayPromptStore looks up the active prompt of the agent a tool call is
+// made on behalf of.
+type GatewayPromptStore interface {
+	GetActive(ctx context.Context, agentID string) (*store.Prompt, error)
+}
+
+// GatewayRecordingStore stores upstream responses recorded in sandbox mode.
+type GatewayRecordingStore interface {
+	Get(ctx context.Context, serverID uuid.UUID, toolName, argsHash string) (*store.MCPRecording, error)
+	Upsert(ctx context.Context, rec *store.MCPRecording) error
+}
+
+// ToolLister fetches an upstream server's tool definitions.
+type ToolLister interface {
+	ListTools(ctx context.Context, req gateway.ProxyRequest) ([]gateway.UpstreamTool, error)
+}
+
 type MCPGatewayHandler struct {
 	servers         MCPGatewayServerStore
 	audit           AuditStoreForAPI
@@ -35,6 +87,22 @@ type MCPGatewayHandler struct {
 	forwarder       Forwarder
 	rateLimiter     *ratelimit.RateLimiter
 	encKey          []byte
+	balancer        *gateway.LoadBalancer
+	concurrency     *gateway.ConcurrencyLimiter
+	cache           *gateway.ResponseCache
+	usage           GatewayUsageRecorder
+	budgets         GatewayBudgetStore
+	dispatcher      notify.EventDispatcher
+	agents          GatewayAgentStore
+	settings        WorkspaceSettingsStoreForAPI
+	prompts         GatewayPromptStore
+	members         GatewayMemberStore
+	recordings      GatewayRecordingStore
+
+	toolSchemas    MCPGatewayToolSchemaStore
+	toolLister     ToolLister
+	discoveryMu    sync.Mutex
+	lastDiscovered map[uuid.UUID]time.Time
 }
 
 func NewMCPGatewayHandler(
@@ -50,10 +118,73 @@ func NewMCPGatewayHandler(
 		servers: servers, audit: audit, trustClassifier: trustClassifier,
 		circuitBreaker: circuitBreaker, forwarder: forwarder,
 		rateLimiter: rateLimiter, encKey: encKey,
+		balancer:    gateway.NewLoadBalancer(),
+		concurrency: gateway.NewConcurrencyLimiter(),
 	}
 }
 
+// SetLoadBalancer replaces the handler's load balancer, so endpoint health
+// recorded by background checks applies to tool calls.
+func (h *MCPGatewayHandler) SetLoadBalancer(lb *gateway.LoadBalancer) {
+	h.balancer = lb
+}
+
+// SetResponseCache enables response caching for servers that opt in through
+// their response_cache settings.
+func (h *MCPGatewayHandler) SetResponseCache(c *gateway.ResponseCache) {
+	h.cache = c
+}
+
+// SetUsageRecorder enables usage analytics for tool calls.
+func (h *MCPGatewayHandler) SetUsageRecorder(u GatewayUsageRecorder) {
+	h.usage = u
+}
+
+// SetBudgets enables workspace budget enforcement and spend metering for
+// priced tools. Threshold events are sent through dispatcher.
+func (h *MCPGatewayHandler) SetBudgets(budgets GatewayBudgetStore, dispatcher notify.EventDispatcher) {
+	h.budgets = budgets
+	h.dispatcher = dispatcher
+}
+
+// SetAgentToolEnforcement enables checking calls made on behalf of an agent
+// against the agent's declared tools, in each workspace's configured mode.
+func (h *MCPGatewayHandler) SetAgentToolEnforcement(agents GatewayAgentStore, settings WorkspaceSettingsStoreForAPI) {
+	h.agents = agents
+	h.settings = settings
+}
+
+// SetPromptModes enables applying the prompt mode of the calling agent's
+// active prompt to each call's trust tier.
+func (h *MCPGatewayHandler) SetPromptModes(prompts GatewayPromptStore) {
+	h.prompts = prompts
+}
+
+// SetWorkspaceMembers enables rejecting calls for workspaces the caller is
+// not a member of.
+func (h *MCPGatewayHandler) SetWorkspaceMembers(members GatewayMemberStore) {
+	h.members = members
+}
+
+// SetSandbox enables per-workspace sandbox modes: recording upstream
+// responses and replaying them instead of calling the upstream.
+func (h *MCPGatewayHandler) SetSandbox(recordings GatewayRecordingStore, settings WorkspaceSettingsStoreForAPI) {
+	h.recordings = recordings
+	h.settings = settings
+}
+
+// SetToolSchemas enables argument validation against discovered tool
+// schemas and periodic schema discovery through lister.
+func (h *MCPGatewayHandler) SetToolSchemas(schemas MCPGatewayToolSchemaStore, lister ToolLister) {
+	h.toolSchemas = schemas
+	h.toolLister = lister
+}
+
 func (h *MCPGatewayHandler) ProxyToolCall(w http.ResponseWriter, r *http.Request) {
+	// The caller's trace is continued upstream and recorded in every audit
+	// entry of the call.
+	trace := gateway.NewTraceContext(r.Header.Get("traceparent"), r.Header.Get("tracestate"), requestID(r))
+	r = r.WithContext(gateway.ContextWithTrace(r.Context(), trace))
 	ctx := r.Context()
 	serverLabel := chi.URLParam(r, "serverLabel")
 	toolName := chi.URLParam(r, "toolName")
@@ -75,8 +206,18 @@ func (h *MCPGatewayHandler) ProxyToolCall(w http.ResponseWriter, r *http.Request
 		RespondError(w, r, apierrors.Internal("invalid circuit breaker config"))
 		return
 	}
-	if !h.circuitBreaker.Allow(serverLabel, cbConfig) {
+	pool, err := serverEndpointPool(server)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("invalid endpoint pool"))
+		return
+	}
+	ready := func(e gateway.Endpoint) bool {
+		return h.circuitBreaker.Ready(gateway.EndpointKey(serverLabel, pool, e.URL), cbConfig)
+	}
+	usage := gateway.UsageSample{Server: serverLabel, Tool: toolName}
+	if !anyEndpoint(pool, ready) {
 		h.auditGatewayCall(r, serverLabel, toolName, 0, "circuit_open", 0)
+		h.recordUsage(r, usage, "circuit_open")
 		RespondError(w, r, apierrors.ServiceUnavailable("circuit breaker open for "+serverLabel))
 		return
 	}
@@ -92,8 +233,47 @@ func (h *MCPGatewayHandler) ProxyToolCall(w http.ResponseWriter, r *http.Request
 	classifyInput := gateway.ClassifyInput{ToolName: toolName, AgentID: reqBody.AgentID}
 	if reqBody.WorkspaceID != nil {
 		wid, err := uuid.Parse(*reqBody.WorkspaceID)
-		if err == nil {
-			classifyInput.WorkspaceID = &wid
+		if err != nil {
+			RespondError(w, r, apierrors.Validation("invalid workspace_id"))
+			return
+		}
+		classifyInput.WorkspaceID = &wid
+		usage.WorkspaceID = wid.String()
+	}
+	usage.AgentID = reqBody.AgentID
+	usage.BytesIn = len(reqBody.Arguments)
+	if classifyInput.WorkspaceID == nil && server.WorkspaceRequired {
+		h.auditGatewayCall(r, serverLabel, toolName, 0, "workspace_denied", 0)
+		h.recordUsage(r, usage, "workspace_denied")
+		RespondError(w, r, apierrors.Forbidden("workspace_id is required for "+serverLabel))
+		return
+	}
+	if classifyInput.WorkspaceID != nil {
+		member, err := h.isWorkspaceMember(ctx, *classifyInput.WorkspaceID)
+		if err != nil {
+			RespondError(w, r, apierrors.Internal("workspace membership check failed"))
+			return
+		}
+		if !member {
+			h.auditGatewayCallDetails(r, serverLabel, toolName, 0, "workspace_denied", 0,
+				map[string]interface{}{"workspace_id": classifyInput.WorkspaceID.String()})
+			h.recordUsage(r, usage, "workspace_denied")
+			RespondError(w, r, apierrors.Forbidden("caller is not a member of workspace "+classifyInput.WorkspaceID.String()))
+			return
+		}
+	}
+	mode, violation, err := h.agentToolViolation(ctx, serverLabel, toolName, reqBody.AgentID, classifyInput.WorkspaceID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("agent tool check failed"))
+		return
+	}
+	if violation != "" {
+		h.auditAgentToolViolation(r, serverLabel, toolName, reqBody.AgentID, classifyInput.WorkspaceID, mode, violation)
+		if mode == AgentToolsEnforce {
+			h.recordUsage(r, usage, "agent_tool_denied")
+			RespondError(w, r, apierrors.Forbidden("tool is not declared by agent "+reqBody.AgentID).
+				WithDetails(map[string]string{"reason": violation}))
+			return
 		}
 	}
 	tier, err := h.trustClassifier.Classify(ctx, classifyInput)
@@ -101,15 +281,50 @@ func (h *MCPGatewayHandler) ProxyToolCall(w http.ResponseWriter, r *http.Request
 		RespondError(w, r, apierrors.Internal("trust classification failed"))
 		return
 	}
-	if tier == gateway.TrustBlock || tier == gateway.TrustReview {
-		h.auditGatewayCall(r, serverLabel, toolName, 0, "trust_denied", 0)
-		RespondError(w, r, apierrors.Forbidden("tool blocked by trust policy"))
+	promptMode, err := h.promptMode(ctx, reqBody.AgentID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("prompt mode lookup failed"))
+		return
+	}
+	// Every audit entry for the call records the prompt mode applied to it.
+	auditDetails := func(extra map[string]interface{}) map[string]interface{} {
+		if promptMode == "" {
+			return extra
+		}
+		details := map[string]interface{}{"prompt_mode": promptMode}
+		for k, v := range extra {
+			details[k] = v
+		}
+		return details
+	}
+	switch gateway.ApplyPromptMode(promptMode, tier) {
+	case gateway.PromptModeDeny:
+		h.auditGatewayCallDetails(r, serverLabel, toolName, 0, "trust_denied", 0,
+			auditDetails(map[string]interface{}{"trust_tier": string(tier)}))
+		h.recordUsage(r, usage, "trust_denied")
+		msg := "tool blocked by trust policy"
+		if promptMode == gateway.PromptModeRAGReadonly && tier == gateway.TrustReview {
+			msg = "tool not allowed in rag_readonly prompt mode"
+		}
+		RespondError(w, r, apierrors.Forbidden(msg))
+		return
+	case gateway.PromptModeApprove:
+		h.auditGatewayCallDetails(r, serverLabel, toolName, 0, "approval_required", 0,
+			auditDetails(map[string]interface{}{"trust_tier": string(tier)}))
+		h.recordUsage(r, usage, "approval_required")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(Envelope{
+			Success: false,
+			Error:   map[string]string{"code": "APPROVAL_REQUIRED", "message": "tool call requires approval"},
+			Meta:    newMeta(r),
+		})
 		return
 	}
 	userID, _ := auth.UserIDFromContext(ctx)
 	rateLimitKey := "gateway:" + serverLabel + ":" + toolName + ":" + userID.String()
 	if allowed, _, _ := h.rateLimiter.Allow(rateLimitKey, 60, time.Minute); !allowed {
-		h.auditGatewayCall(r, serverLabel, toolName, 0, "rate_limited", 0)
+		h.auditGatewayCallDetails(r, serverLabel, toolName, 0, "rate_limited", 0, auditDetails(nil))
+		h.recordUsage(r, usage, "rate_limited")
 		w.WriteHeader(http.StatusTooManyRequests)
 		json.NewEncoder(w).Encode(Envelope{
 			Success: false,
@@ -118,48 +333,819 @@ func (h *MCPGatewayHandler) ProxyToolCall(w http.ResponseWriter, r *http.Request
 		})
 		return
 	}
-	var plainCredential string
-	if server.AuthType != "none" && server.AuthCredential != "" {
-		ciphertext, err := base64.StdEncoding.DecodeString(server.AuthCredential)
-		if err != nil {
-			RespondError(w, r, apierrors.Internal("credential decode failed"))
+	if violations, apiErr := h.validateArguments(ctx, server, toolName, reqBody.Arguments); apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	} else if len(violations) > 0 {
+		h.auditGatewayCallDetails(r, serverLabel, toolName, 0, "invalid_arguments", 0,
+			auditDetails(map[string]interface{}{"violations": violations}))
+		h.recordUsage(r, usage, "invalid_arguments")
+		RespondError(w, r, apierrors.Validation("arguments do not match the tool's input schema").WithDetails(violations))
+		return
+	}
+	sandbox, err := h.sandboxFor(ctx, serverLabel, classifyInput.WorkspaceID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("sandbox lookup failed"))
+		return
+	}
+	if sandbox.Mode == gateway.SandboxReplay {
+		h.replayToolCall(w, r, server, toolName, reqBody.Arguments, sandbox, usage, auditDetails)
+		return
+	}
+	cachePolicy, err := parseResponseCache(server.ResponseCache)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("invalid response cache config"))
+		return
+	}
+	// Only auto-tier tools are cached: their calls are read-only by policy.
+	// Recorded calls always reach the upstream.
+	useCache := h.cache != nil && tier == gateway.TrustAuto && cachePolicy.Caches(toolName) &&
+		sandbox.Mode != gateway.SandboxRecord
+	var cacheKey gateway.CacheKey
+	if useCache {
+		scope := userID.String()
+		if classifyInput.WorkspaceID != nil {
+			scope += "|" + classifyInput.WorkspaceID.String()
+		}
+		cacheKey = gateway.CacheKey{Server: serverLabel, Tool: toolName, Args: reqBody.Arguments, Scope: scope}
+		if cached, ok := h.cache.Get(cacheKey); ok {
+			h.auditGatewayCallDetails(r, serverLabel, toolName, cached.StatusCode, "success", 0,
+				auditDetails(map[string]interface{}{"cached": true}))
+			usage.BytesOut = len(cached.Body)
+			h.recordUsage(r, usage, "cache_hit")
+			w.Header().Set("X-Gateway-Cache", "HIT")
+			RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+				"status_code":  cached.StatusCode,
+				"body":         cached.Body,
+				"latency_ms":   0,
+				"attempts":     0,
+				"cached":       true,
+				"cache_age_ms": time.Since(cached.StoredAt).Milliseconds(),
+			})
 			return
 		}
-		plaintext, err := auth.Decrypt(ciphertext, h.encKey)
+	}
+	pricing, err := parsePricing(server.Pricing)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("invalid pricing config"))
+		return
+	}
+	cost := pricing.CostMicros(toolName)
+	charge, err := h.checkBudget(ctx, classifyInput.WorkspaceID, cost)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("budget check failed"))
+		return
+	}
+	exhausted := charge.exhausted
+	if exhausted && charge.budget.OnExhausted == "reject" {
+		h.auditGatewayCallDetails(r, serverLabel, toolName, 0, "budget_exhausted", 0, auditDetails(nil))
+		h.recordUsage(r, usage, "budget_exhausted")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(Envelope{
+			Success: false,
+			Error:   map[string]string{"code": "BUDGET_EXHAUSTED", "message": "workspace budget exhausted"},
+			Meta:    newMeta(r),
+		})
+		return
+	}
+	if exhausted {
+		w.Header().Set("X-Gateway-Budget", "exceeded")
+	}
+	if charge.reserved {
+		// A call that is not answered gives its reservation back.
+		defer func() {
+			if !charge.settled {
+				h.releaseBudget(r, *classifyInput.WorkspaceID, charge, cost)
+			}
+		}()
+	}
+	proxyReq, apiErr := h.upstreamRequest(server)
+	if apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+	proxyReq.ToolName = toolName
+	proxyReq.Arguments = reqBody.Arguments
+	proxyReq.Trace = &trace
+	retryPolicy, err := parseRetryPolicy(server.RetryPolicy)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("invalid retry policy"))
+		return
+	}
+	limits, err := parseCallLimits(server.CallLimits)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("invalid call limits"))
+		return
+	}
+	proxyReq.Timeout = limits.TimeoutFor(toolName)
+	// Only calls that are safe to repeat are retried or hedged.
+	idempotent := tier == gateway.TrustAuto || retryPolicy.IsIdempotent(toolName)
+	hooks := gateway.RetryHooks{
+		BeforeAttempt: func() bool { return anyEndpoint(pool, ready) },
+		OnAttempt: func(a gateway.Attempt) {
+			status, outcome := attemptOutcome(a)
+			// Attempts that never reached an endpoint say nothing about its
+			// health. Stdio servers have no endpoint URL and are keyed by label.
+			if outcome != "circuit_open" && outcome != "hedge_canceled" && outcome != "canceled" {
+				key := gateway.EndpointKey(serverLabel, pool, a.Endpoint)
+				h.circuitBreaker.Observe(key, cbConfig, outcome == "success", a.Latency)
+			}
+			details := map[string]interface{}{"attempt": a.Number, "hedged": a.Hedged, "endpoint": a.Endpoint}
+			if exhausted {
+				details["budget_exceeded"] = true
+			}
+			h.auditGatewayCallDetails(r, serverLabel, toolName, status, outcome, a.Latency, auditDetails(details))
+		},
+	}
+	// Each attempt picks its own endpoint, avoiding the previous pick so
+	// retries and hedges go to a different upstream when one is available.
+	var pickMu sync.Mutex
+	var lastPicked string
+	forward := func(ctx context.Context, a *gateway.Attempt) (*gateway.ProxyResponse, error) {
+		pickMu.Lock()
+		endpoint, err := h.balancer.Pick(serverLabel, server.LoadBalancing, pool, lastPicked, ready)
+		if err == nil && !h.circuitBreaker.Allow(gateway.EndpointKey(serverLabel, pool, endpoint.URL), cbConfig) {
+			err = gateway.ErrNoHealthyEndpoint
+		}
+		if err == nil {
+			lastPicked = endpoint.URL
+		}
+		pickMu.Unlock()
 		if err != nil {
-			RespondError(w, r, apierrors.Internal("credential decrypt failed"))
-			return
+			return nil, err
+		}
+		a.Endpoint = endpoint.URL
+		req := proxyReq
+		req.ServerEndpoint = endpoint.URL
+		resp, err := h.forwarder.Forward(ctx, req)
+		if err == nil {
+			h.balancer.ObserveLatency(serverLabel, endpoint.URL, resp.Latency)
 		}
-		plainCredential = string(plaintext)
+		return resp, err
 	}
-	proxyReq := gateway.ProxyRequest{
-		ServerEndpoint: server.Endpoint, ToolName: toolName,
-		Arguments: reqBody.Arguments, AuthType: server.AuthType,
-		AuthCredential: plainCredential,
+	// The slot is held across retries and hedges of the call.
+	release, err := h.concurrency.Acquire(ctx, serverLabel, limits)
+	if err != nil {
+		outcome := "canceled"
+		if errors.Is(err, gateway.ErrConcurrencyLimit) {
+			outcome = "concurrency_limited"
+		}
+		h.auditGatewayCallDetails(r, serverLabel, toolName, 0, outcome, 0, auditDetails(nil))
+		h.recordUsage(r, usage, outcome)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(Envelope{
+			Success: false,
+			Error:   map[string]string{"code": "CONCURRENCY_LIMITED", "message": "too many concurrent calls to " + serverLabel},
+			Meta:    newMeta(r),
+		})
+		return
 	}
+	defer release()
 	start := time.Now()
-	proxyResp, err := h.forwarder.Forward(ctx, proxyReq)
-	latency := time.Since(start)
+	proxyResp, attempts, err := gateway.ForwardWithRetry(ctx, forward, retryPolicy,
+		idempotent, idempotent && retryPolicy.ShouldHedge(toolName), hooks)
+	usage.Forwarded = true
+	usage.Latency = time.Since(start)
 	if err != nil {
-		h.circuitBreaker.RecordFailure(serverLabel, cbConfig)
-		h.auditGatewayCall(r, serverLabel, toolName, 0, "upstream_error", latency)
+		_, outcome := attemptOutcome(gateway.Attempt{Err: err})
+		h.recordUsage(r, usage, outcome)
+		if errors.Is(err, gateway.ErrNoHealthyEndpoint) {
+			RespondError(w, r, apierrors.ServiceUnavailable("circuit breaker open for "+serverLabel))
+			return
+		}
+		if errors.Is(err, gateway.ErrEgressDenied) {
+			RespondError(w, r, apierrors.BadGateway("upstream blocked by egress policy"))
+			return
+		}
+		if errors.Is(err, gateway.ErrTokenFetch) {
+			RespondError(w, r, apierrors.BadGateway("upstream token request failed"))
+			return
+		}
+		if errors.Is(err, gateway.ErrUpstreamTimeout) {
+			RespondError(w, r, apierrors.GatewayTimeout("upstream "+err.Error()))
+			return
+		}
+		if errors.Is(err, gateway.ErrStdioUnavailable) {
+			RespondError(w, r, apierrors.ServiceUnavailable("stdio server "+serverLabel+" is not running"))
+			return
+		}
 		RespondError(w, r, apierrors.BadGateway("upstream request failed"))
 		return
 	}
-	if proxyResp.StatusCode >= 500 {
-		h.circuitBreaker.RecordFailure(serverLabel, cbConfig)
-		h.auditGatewayCall(r, serverLabel, toolName, proxyResp.StatusCode, "upstream_5xx", latency)
-	} else {
-		h.circuitBreaker.RecordSuccess(serverLabel)
-		h.auditGatewayCall(r, serverLabel, toolName, proxyResp.StatusCode, "success", latency)
+	usage.BytesOut = len(proxyResp.Body)
+	// Calls are charged once an upstream has answered them.
+	usage.CostMicros = cost
+	if classifyInput.WorkspaceID != nil && cost > 0 {
+		h.chargeWorkspace(r, *classifyInput.WorkspaceID, charge, cost)
+	}
+	_, outcome := attemptOutcome(gateway.Attempt{Response: proxyResp})
+	h.recordUsage(r, usage, outcome)
+	if useCache {
+		h.cache.Set(cacheKey, proxyResp, cachePolicy.TTL)
+		w.Header().Set("X-Gateway-Cache", "MISS")
+	}
+	if sandbox.Mode == gateway.SandboxRecord {
+		h.recordToolCall(r, server, toolName, reqBody.Arguments, classifyInput.WorkspaceID, proxyResp)
+		w.Header().Set("X-Gateway-Sandbox", gateway.SandboxRecord)
 	}
 	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
 		"status_code": proxyResp.StatusCode,
 		"body":        proxyResp.Body,
 		"latency_ms":  proxyResp.Latency.Milliseconds(),
+		"attempts":    attempts,
+		"cached":      false,
+	})
+}
+
+// sandboxFor returns the workspace's sandbox config for the server. Calls
+// without a workspace are always live.
+func (h *MCPGatewayHandler) sandboxFor(ctx context.Context, serverLabel string, workspaceID *uuid.UUID) (gateway.SandboxConfig, error) {
+	off := gateway.SandboxConfig{Mode: gateway.SandboxOff}
+	if h.recordings == nil || h.settings == nil || workspaceID == nil {
+		return off, nil
+	}
+	settings, err := loadWorkspaceSettings(ctx, h.settings, *workspaceID)
+	if err != nil {
+		return off, err
+	}
+	configs, err := workspaceSandbox(settings)
+	if err != nil {
+		return off, err
+	}
+	return gateway.SandboxFor(configs, serverLabel), nil
+}
+
+// replayToolCall answers a call from the server's recordings without
+// contacting the upstream. Replayed calls are not charged.
+func (h *MCPGatewayHandler) replayToolCall(w http.ResponseWriter, r *http.Request, server *store.MCPServer, toolName string,
+	args json.RawMessage, sandbox gateway.SandboxConfig, usage gateway.UsageSample,
+	auditDetails func(map[string]interface{}) map[string]interface{}) {
+	hash, ok := gateway.RecordingHash(toolName, args)
+	if !ok {
+		RespondError(w, r, apierrors.Validation("invalid arguments"))
+		return
+	}
+	rec, err := h.recordings.Get(r.Context(), server.ID, toolName, hash)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+			RespondError(w, r, apierrors.Internal("recording lookup failed"))
+			return
+		}
+		rec = nil
+	}
+	w.Header().Set("X-Gateway-Sandbox", gateway.SandboxReplay)
+	if rec == nil {
+		h.auditGatewayCallDetails(r, server.Label, toolName, 0, "replay_miss", 0,
+			auditDetails(map[string]interface{}{"sandbox": gateway.SandboxReplay, "args_hash": hash}))
+		h.recordUsage(r, usage, "replay_miss")
+		if sandbox.MissPolicy == gateway.ReplayMissStub {
+			body := gateway.ReplayMissStubBody(toolName)
+			RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+				"status_code": http.StatusOK,
+				"body":        body,
+				"latency_ms":  0,
+				"attempts":    0,
+				"cached":      false,
+				"replayed":    false,
+				"replay_miss": true,
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(Envelope{
+			Success: false,
+			Error:   map[string]string{"code": "RECORDING_NOT_FOUND", "message": "no recording of " + toolName + " with these arguments"},
+			Meta:    newMeta(r),
+		})
+		return
+	}
+	h.auditGatewayCallDetails(r, server.Label, toolName, rec.StatusCode, "success", 0,
+		auditDetails(map[string]interface{}{"sandbox": gateway.SandboxReplay, "recording_id": rec.ID.String()}))
+	usage.BytesOut = len(rec.Response)
+	h.recordUsage(r, usage, "replayed")
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"status_code": rec.StatusCode,
+		"body":        rec.Response,
+		"latency_ms":  0,
+		"attempts":    0,
+		"cached":      false,
+		"replayed":    true,
 	})
 }
 
+// recordToolCall stores an upstream response for later replay. Responses
+// that are not JSON are not recorded; a failed write does not fail the call.
+func (h *MCPGatewayHandler) recordToolCall(r *http.Request, server *store.MCPServer, toolName string,
+	args json.RawMessage, workspaceID *uuid.UUID, resp *gateway.ProxyResponse) {
+	hash, ok := gateway.RecordingHash(toolName, args)
+	if !ok || !json.Valid(resp.Body) {
+		return
+	}
+	userID, _ := auth.UserIDFromContext(r.Context())
+	rec := &store.MCPRecording{
+		ServerID:    server.ID,
+		ToolName:    toolName,
+		ArgsHash:    hash,
+		Arguments:   args,
+		StatusCode:  resp.StatusCode,
+		Response:    resp.Body,
+		WorkspaceID: workspaceID,
+		RecordedBy:  userID.String(),
+	}
+	// The recording is kept even if the caller goes away once answered.
+	if err := h.recordings.Upsert(context.WithoutCancel(r.Context()), rec); err != nil {
+		log.Printf("recording %s/%s failed: %v", server.Label, toolName, err)
+	}
+}
+
+// agentToolViolation checks a call made on behalf of an agent against the
+// tools declared by the agent's active version. It returns the workspace's
+// enforcement mode and the reason the call violates it, or an empty reason
+// when the call is allowed or enforcement is off.
+func (h *MCPGatewayHandler) agentToolViolation(ctx context.Context, serverLabel, toolName, agentID string, workspaceID *uuid.UUID) (string, string, error) {
+	if h.agents == nil || h.settings == nil || agentID == "" || workspaceID == nil {
+		return AgentToolsOff, "", nil
+	}
+	settings, err := loadWorkspaceSettings(ctx, h.settings, *workspaceID)
+	if err != nil {
+		return "", "", err
+	}
+	mode := settings.AgentToolEnforcement
+	if mode == AgentToolsOff {
+		return mode, "", nil
+	}
+
+	agent, err := h.agents.GetByID(ctx, agentID)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return mode, "agent_not_found", nil
+		}
+		return "", "", err
+	}
+	if !agent.IsActive {
+		return mode, "agent_inactive", nil
+	}
+	var tools []agentTool
+	if len(agent.Tools) > 0 {
+		if err := json.Unmarshal(agent.Tools, &tools); err != nil {
+			return mode, "tool_not_declared", nil
+		}
+	}
+	for _, t := range tools {
+		if t.Source == "mcp" && t.ServerLabel == serverLabel && t.Name == toolName {
+			return mode, "", nil
+		}
+	}
+	return mode, "tool_not_declared", nil
+}
+
+// isWorkspaceMember reports whether the caller belongs to a workspace. Calls
+// authenticated with an API key need the key itself to be a member; other
+// calls need the user to be. Every caller is a member when membership is
+// not checked.
+func (h *MCPGatewayHandler) isWorkspaceMember(ctx context.Context, workspaceID uuid.UUID) (bool, error) {
+	if h.members == nil {
+		return true, nil
+	}
+	if authType, _ := auth.AuthTypeFromContext(ctx); authType == "apikey" {
+		keyID, ok := auth.APIKeyIDFromContext(ctx)
+		if !ok {
+			return false, nil
+		}
+		return h.members.IsMember(ctx, workspaceID, store.PrincipalAPIKey, keyID)
+	}
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+	return h.members.IsMember(ctx, workspaceID, store.PrincipalUser, userID)
+}
+
+// promptMode returns the mode of the agent's active prompt, or an empty
+// mode when prompt modes are not applied, the call is not made on behalf of
+// an agent or the agent has no active prompt.
+func (h *MCPGatewayHandler) promptMode(ctx context.Context, agentID string) (string, error) {
+	if h.prompts == nil || agentID == "" {
+		return "", nil
+	}
+	prompt, err := h.prompts.GetActive(ctx, agentID)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return prompt.Mode, nil
+}
+
+// auditAgentToolViolation records a call that used a tool its agent does
+// not declare.
+func (h *MCPGatewayHandler) auditAgentToolViolation(r *http.Request, serverLabel, toolName, agentID string, workspaceID *uuid.UUID, mode, reason string) {
+	details := map[string]interface{}{
+		"server_label": serverLabel, "tool_name": toolName, "agent_id": agentID,
+		"mode": mode, "reason": reason, "enforced": mode == AgentToolsEnforce,
+	}
+	if workspaceID != nil {
+		details["workspace_id"] = workspaceID.String()
+	}
+	h.insertGatewayAudit(r, "gateway_agent_tool_violation", serverLabel+"/"+toolName, details)
+}
+
+// budgetCharge is a priced call's standing against its workspace's budget.
+type budgetCharge struct {
+	budget    *store.WorkspaceBudget
+	period    time.Time
+	exhausted bool  // The call would take the spend over the limit
+	reserved  bool  // The cost was added to the spend before forwarding
+	after     int64 // The period's spend including a reserved cost
+	settled   bool  // A reservation was kept by chargeWorkspace
+}
+
+// checkBudget checks a priced call against its workspace's budget. Budgets
+// that reject exhausted calls reserve the cost up front with a conditional
+// increment, so concurrent calls cannot overspend; chargeWorkspace keeps the
+// reservation and releaseBudget gives it back. Free calls, calls without a
+// workspace and workspaces without a budget have a nil budget.
+func (h *MCPGatewayHandler) checkBudget(ctx context.Context, workspaceID *uuid.UUID, cost int64) (*budgetCharge, error) {
+	charge := &budgetCharge{period: gateway.BudgetPeriod(time.Now())}
+	if h.budgets == nil || workspaceID == nil || cost == 0 {
+		return charge, nil
+	}
+	budget, err := h.budgets.Get(ctx, *workspaceID)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return charge, nil
+		}
+		return nil, err
+	}
+	charge.budget = budget
+	if budget.OnExhausted == "reject" {
+		after, ok, err := h.budgets.ReserveSpend(ctx, *workspaceID, charge.period, cost, budget.MonthlyLimitMicros)
+		if err != nil {
+			return nil, err
+		}
+		charge.exhausted, charge.reserved, charge.after = !ok, ok, after
+		return charge, nil
+	}
+	spent, _, err := h.budgets.GetSpend(ctx, *workspaceID, charge.period)
+	if err != nil {
+		return nil, err
+	}
+	charge.exhausted = spent+cost > budget.MonthlyLimitMicros
+	return charge, nil
+}
+
+// releaseBudget gives back the spend reserved for a call that was not
+// answered.
+func (h *MCPGatewayHandler) releaseBudget(r *http.Request, workspaceID uuid.UUID, charge *budgetCharge, cost int64) {
+	ctx := context.WithoutCancel(r.Context())
+	if err := h.budgets.ReleaseSpend(ctx, workspaceID, charge.period, cost); err != nil {
+		log.Printf("gateway: releasing spend for workspace %s failed: %v", workspaceID, err)
+	}
+}
+
+// chargeWorkspace adds a call's cost to its workspace's spend, or keeps the
+// spend reserved for it, and sends an event for each budget threshold the
+// charge crosses.
+func (h *MCPGatewayHandler) chargeWorkspace(r *http.Request, workspaceID uuid.UUID, charge *budgetCharge, cost int64) {
+	if h.budgets == nil {
+		return
+	}
+	// The upstream has served the call, so the charge outlives the request.
+	ctx := context.WithoutCancel(r.Context())
+	after := charge.after
+	if charge.reserved {
+		charge.settled = true
+	} else {
+		var err error
+		after, err = h.budgets.AddSpend(ctx, workspaceID, charge.period, cost)
+		if err != nil {
+			log.Printf("gateway: metering spend for workspace %s failed: %v", workspaceID, err)
+			return
+		}
+	}
+	budget := charge.budget
+	if budget == nil || h.dispatcher == nil {
+		return
+	}
+	thresholds := append(append([]int{}, budget.SoftThresholds...), 100)
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	for _, pct := range gateway.CrossedThresholds(budget.MonthlyLimitMicros, after-cost, after, thresholds) {
+		eventType := "workspace.budget_threshold_reached"
+		if pct >= 100 {
+			eventType = "workspace.budget_exhausted"
+		}
+		err := h.dispatcher.Dispatch(ctx, notify.Event{
+			Type:         eventType,
+			ResourceType: "workspace_budget",
+			ResourceID:   workspaceID.String(),
+			WorkspaceID:  workspaceID.String(),
+			Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+			Actor:        callerID.String(),
+			Details: map[string]any{
+				"threshold_percent":    pct,
+				"monthly_limit_micros": budget.MonthlyLimitMicros,
+				"spent_micros":         after,
+			},
+		})
+		if err != nil {
+			log.Printf("gateway: dispatching %s for workspace %s failed: %v", eventType, workspaceID, err)
+		}
+	}
+}
+
+// validateArguments checks tool arguments against the tool's discovered
+// input schema and admin overlay. Tools without a stored schema are not
+// validated.
+func (h *MCPGatewayHandler) validateArguments(ctx context.Context, server *store.MCPServer, toolName string, args json.RawMessage) ([]gateway.SchemaViolation, *apierrors.APIError) {
+	if h.toolSchemas == nil {
+		return nil, nil
+	}
+	schema, err := h.toolSchemas.Get(ctx, server.ID, toolName)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, apierrors.Internal("tool schema lookup failed")
+	}
+	violations, err := gateway.ValidateArguments(args, schema.InputSchema, schema.SchemaOverlay)
+	if err != nil {
+		return nil, apierrors.Validation("arguments must be valid JSON")
+	}
+	return violations, nil
+}
+
+// upstreamRequest builds the connection settings for calls to a server:
+// decrypted credentials, TLS material, custom headers and egress policy.
+func (h *MCPGatewayHandler) upstreamRequest(server *store.MCPServer) (gateway.ProxyRequest, *apierrors.APIError) {
+	req := gateway.ProxyRequest{
+		ServerEndpoint: server.Endpoint, AuthType: server.AuthType, ServerLabel: server.Label,
+	}
+	if server.Transport == transportStdio {
+		stdio, apiErr := h.decryptStdioConfig(server)
+		if apiErr != nil {
+			return req, apiErr
+		}
+		req.Stdio = stdio
+		return req, nil
+	}
+	if server.AuthType != "none" && server.AuthCredential != "" {
+		ciphertext, err := base64.StdEncoding.DecodeString(server.AuthCredential)
+		if err != nil {
+			return req, apierrors.Internal("credential decode failed")
+		}
+		plaintext, err := auth.Decrypt(ciphertext, h.encKey)
+		if err != nil {
+			return req, apierrors.Internal("credential decrypt failed")
+		}
+		if server.AuthType == authTypeOAuth2ClientCredentials {
+			req.OAuth2 = &gateway.OAuth2ClientCredentials{}
+			if err := json.Unmarshal(plaintext, req.OAuth2); err != nil {
+				return req, apierrors.Internal("oauth2 credential decode failed")
+			}
+		} else {
+			req.AuthCredential = string(plaintext)
+		}
+	}
+	tlsCfg, headers, err := h.decryptUpstreamSettings(server)
+	if err != nil {
+		return req, err.(*apierrors.APIError)
+	}
+	req.TLS, req.Headers = tlsCfg, headers
+	if req.Egress, err = parseEgressPolicy(server.EgressPolicy); err != nil {
+		return req, apierrors.Internal("invalid egress policy")
+	}
+	return req, nil
+}
+
+// DiscoverTools fetches a server's tools/list and stores the advertised
+// input schemas.
+func (h *MCPGatewayHandler) DiscoverTools(ctx context.Context, server *store.MCPServer) ([]store.MCPToolSchema, error) {
+	if h.toolSchemas == nil || h.toolLister == nil {
+		return nil, errors.New("tool schema discovery is not configured")
+	}
+	req, apiErr := h.upstreamRequest(server)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	upstream, err := h.toolLister.ListTools(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	tools := make([]store.MCPToolSchema, 0, len(upstream))
+	for _, t := range upstream {
+		if t.Name == "" {
+			continue
+		}
+		tools = append(tools, store.MCPToolSchema{
+			ServerID: server.ID, ToolName: t.Name, Description: t.Description, InputSchema: t.InputSchema,
+		})
+	}
+	if err := h.toolSchemas.ReplaceDiscovered(ctx, server.ID, tools); err != nil {
+		return nil, err
+	}
+	h.discoveryMu.Lock()
+	if h.lastDiscovered == nil {
+		h.lastDiscovered = make(map[uuid.UUID]time.Time)
+	}
+	h.lastDiscovered[server.ID] = time.Now()
+	h.discoveryMu.Unlock()
+	return tools, nil
+}
+
+// RefreshToolSchemas rediscovers tools for every enabled server whose
+// discovery interval has elapsed since its last run.
+func (h *MCPGatewayHandler) RefreshToolSchemas(ctx context.Context) {
+	if h.toolSchemas == nil || h.toolLister == nil {
+		return
+	}
+	servers, err := h.servers.List(ctx)
+	if err != nil {
+		log.Printf("tool schema discovery: listing servers: %v", err)
+		return
+	}
+	for i := range servers {
+		server := &servers[i]
+		if !server.IsEnabled {
+			continue
+		}
+		interval, err := time.ParseDuration(server.DiscoveryInterval)
+		if err != nil || interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		h.discoveryMu.Lock()
+		last, seen := h.lastDiscovered[server.ID]
+		h.discoveryMu.Unlock()
+		if seen && time.Since(last) < interval {
+			continue
+		}
+		if _, err := h.DiscoverTools(ctx, server); err != nil {
+			log.Printf("tool schema discovery: %s: %v", server.Label, err)
+		}
+	}
+}
+
+// attemptOutcome maps an upstream attempt to its audited status and outcome.
+func attemptOutcome(a gateway.Attempt) (int, string) {
+	switch {
+	case a.Canceled:
+		return 0, "hedge_canceled"
+	case errors.Is(a.Err, gateway.ErrNoHealthyEndpoint):
+		return 0, "circuit_open"
+	case errors.Is(a.Err, gateway.ErrEgressDenied):
+		return 0, "egress_denied"
+	case errors.Is(a.Err, gateway.ErrTokenFetch):
+		return 0, "token_error"
+	case errors.Is(a.Err, gateway.ErrStdioUnavailable):
+		return 0, "stdio_unavailable"
+	case errors.Is(a.Err, gateway.ErrUpstreamTimeout):
+		return 0, "upstream_timeout"
+	case errors.Is(a.Err, context.Canceled):
+		return 0, "canceled"
+	case a.Err != nil:
+		return 0, "upstream_error"
+	case a.Response.StatusCode >= 500:
+		return a.Response.StatusCode, "upstream_5xx"
+	default:
+		return a.Response.StatusCode, "success"
+	}
+}
+
+// serverEndpointPool returns the endpoints a server balances across. Servers
+// without a pool use their single endpoint and health endpoint.
+func serverEndpointPool(server *store.MCPServer) ([]gateway.Endpoint, error) {
+	pool, err := parseEndpointPool(server.Endpoints)
+	if err != nil {
+		return nil, err
+	}
+	if len(pool) == 0 {
+		pool = []gateway.Endpoint{{URL: server.Endpoint, Weight: 1, HealthEndpoint: server.HealthEndpoint}}
+	}
+	return pool, nil
+}
+
+func anyEndpoint(pool []gateway.Endpoint, ready func(gateway.Endpoint) bool) bool {
+	for _, e := range pool {
+		if ready(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthTargets lists the endpoints of enabled servers that have a health
+// endpoint configured, for background health checks.
+func (h *MCPGatewayHandler) HealthTargets(ctx context.Context) ([]gateway.HealthTarget, error) {
+	servers, err := h.servers.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var targets []gateway.HealthTarget
+	for i := range servers {
+		server := &servers[i]
+		if !server.IsEnabled {
+			continue
+		}
+		pool, err := serverEndpointPool(server)
+		if err != nil {
+			log.Printf("gateway health checks: %s: invalid endpoint pool: %v", server.Label, err)
+			continue
+		}
+		tlsCfg, headers, err := h.decryptUpstreamSettings(server)
+		if err != nil {
+			log.Printf("gateway health checks: %s: %v", server.Label, err)
+			continue
+		}
+		egressPolicy, err := parseEgressPolicy(server.EgressPolicy)
+		if err != nil {
+			log.Printf("gateway health checks: %s: invalid egress policy: %v", server.Label, err)
+			continue
+		}
+		req := gateway.ProxyRequest{ServerLabel: server.Label, TLS: tlsCfg, Headers: headers, Egress: egressPolicy}
+		for _, e := range pool {
+			if e.HealthEndpoint == "" {
+				continue
+			}
+			targets = append(targets, gateway.HealthTarget{
+				Label: server.Label, Endpoint: e.URL, HealthURL: e.HealthEndpoint, Request: req,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// decryptSecret reverses the base64 + AES-256-GCM encoding used for stored secrets.
+func (h *MCPGatewayHandler) decryptSecret(encoded string) (string, error) {
+	if encoded == "" {
+		return "", nil
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := auth.Decrypt(ciphertext, h.encKey)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// decryptUpstreamSettings returns the server's TLS material (nil when none is
+// configured) and custom headers.
+func (h *MCPGatewayHandler) decryptUpstreamSettings(server *store.MCPServer) (*gateway.TLSConfig, map[string]string, error) {
+	var tlsCfg gateway.TLSConfig
+	var err error
+	if tlsCfg.ClientCert, err = h.decryptSecret(server.TLSClientCert); err != nil {
+		return nil, nil, apierrors.Internal("tls credential decrypt failed")
+	}
+	if tlsCfg.ClientKey, err = h.decryptSecret(server.TLSClientKey); err != nil {
+		return nil, nil, apierrors.Internal("tls credential decrypt failed")
+	}
+	if tlsCfg.CABundle, err = h.decryptSecret(server.TLSCABundle); err != nil {
+		return nil, nil, apierrors.Internal("tls credential decrypt failed")
+	}
+	var headers map[string]string
+	plainHeaders, err := h.decryptSecret(server.CustomHeaders)
+	if err != nil {
+		return nil, nil, apierrors.Internal("custom headers decrypt failed")
+	}
+	if plainHeaders != "" {
+		if err := json.Unmarshal([]byte(plainHeaders), &headers); err != nil {
+			return nil, nil, apierrors.Internal("custom headers decode failed")
+		}
+	}
+	if tlsCfg.IsZero() {
+		return nil, headers, nil
+	}
+	return &tlsCfg, headers, nil
+}
+
+// decryptStdioConfig returns the command of a stdio server with its
+// environment decrypted.
+func (h *MCPGatewayHandler) decryptStdioConfig(server *store.MCPServer) (*gateway.StdioConfig, *apierrors.APIError) {
+	cfg := &gateway.StdioConfig{Command: server.StdioCommand}
+	if len(server.StdioArgs) > 0 {
+		if err := json.Unmarshal(server.StdioArgs, &cfg.Args); err != nil {
+			return nil, apierrors.Internal("stdio args decode failed")
+		}
+	}
+	plainEnv, err := h.decryptSecret(server.StdioEnv)
+	if err != nil {
+		return nil, apierrors.Internal("stdio env decrypt failed")
+	}
+	if plainEnv != "" {
+		if err := json.Unmarshal([]byte(plainEnv), &cfg.Env); err != nil {
+			return nil, apierrors.Internal("stdio env decode failed")
+		}
+	}
+	return cfg, nil
+}
+
 func (h *MCPGatewayHandler) ListTools(w http.ResponseWriter, r *http.Request) {
 	servers, err := h.servers.List(r.Context())
 	if err != nil {
@@ -181,8 +1167,15 @@ func parseCircuitBreakerCfg(cbJSON json.RawMessage) (gateway.CircuitBreakerConfi
 		return gateway.CircuitBreakerConfig{FailThreshold: 5, OpenDuration: 30 * time.Second}, nil
 	}
 	var cb struct {
-		FailThreshold int `json:"fail_threshold"`
-		OpenDurationS int `json:"open_duration_s"`
+		FailThreshold     int     `json:"fail_threshold"`
+		OpenDurationS     int     `json:"open_duration_s"`
+		Mode              string  `json:"mode"`
+		WindowS           int     `json:"window_s"`
+		MinRequests       int     `json:"min_requests"`
+		FailureRatePct    float64 `json:"failure_rate_pct"`
+		LatencyP95MS      int     `json:"latency_p95_ms"`
+		HalfOpenProbes    int     `json:"half_open_probes"`
+		ProbeSuccessRatio float64 `json:"probe_success_ratio"`
 	}
 	if err := json.Unmarshal(cbJSON, &cb); err != nil {
 		return gateway.CircuitBreakerConfig{}, err
@@ -193,17 +1186,151 @@ func parseCircuitBreakerCfg(cbJSON json.RawMessage) (gateway.CircuitBreakerConfi
 	if cb.OpenDurationS <= 0 {
 		cb.OpenDurationS = 30
 	}
-	return gateway.CircuitBreakerConfig{
-		FailThreshold: cb.FailThreshold,
-		OpenDuration:  time.Duration(cb.OpenDurationS) * time.Second,
+	cfg := gateway.CircuitBreakerConfig{
+		FailThreshold:     cb.FailThreshold,
+		OpenDuration:      time.Duration(cb.OpenDurationS) * time.Second,
+		HalfOpenProbes:    cb.HalfOpenProbes,
+		ProbeSuccessRatio: cb.ProbeSuccessRatio,
+	}
+	if cb.Mode == "window" {
+		if cb.WindowS <= 0 {
+			cb.WindowS = 60
+		}
+		if cb.MinRequests <= 0 {
+			cb.MinRequests = 20
+		}
+		cfg.Window = time.Duration(cb.WindowS) * time.Second
+		cfg.MinRequests = cb.MinRequests
+		cfg.FailureRatePct = cb.FailureRatePct
+		cfg.LatencyP95 = time.Duration(cb.LatencyP95MS) * time.Millisecond
+	}
+	return cfg, nil
+}
+
+func parseResponseCache(raw json.RawMessage) (gateway.CachePolicy, error) {
+	if len(raw) == 0 {
+		return gateway.CachePolicy{}, nil
+	}
+	var rc struct {
+		TTLS  int      `json:"ttl_s"`
+		Tools []string `json:"tools"`
+	}
+	if err := json.Unmarshal(raw, &rc); err != nil {
+		return gateway.CachePolicy{}, err
+	}
+	return gateway.CachePolicy{TTL: time.Duration(rc.TTLS) * time.Second, Tools: rc.Tools}, nil
+}
+
+func parsePricing(raw json.RawMessage) (gateway.Pricing, error) {
+	if len(raw) == 0 {
+		return gateway.Pricing{}, nil
+	}
+	var p struct {
+		UnitCost float64 `json:"unit_cost"`
+		Tools    []struct {
+			Pattern  string  `json:"pattern"`
+			UnitCost float64 `json:"unit_cost"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return gateway.Pricing{}, err
+	}
+	pricing := gateway.Pricing{UnitCostMicros: int64(math.Round(p.UnitCost * gateway.MicrosPerUnit))}
+	for _, t := range p.Tools {
+		pricing.Tools = append(pricing.Tools, gateway.ToolPrice{
+			Pattern:        t.Pattern,
+			UnitCostMicros: int64(math.Round(t.UnitCost * gateway.MicrosPerUnit)),
+		})
+	}
+	return pricing, nil
+}
+
+func parseCallLimits(raw json.RawMessage) (gateway.CallLimits, error) {
+	if len(raw) == 0 {
+		return gateway.CallLimits{}, nil
+	}
+	var cl struct {
+		TimeoutMS int `json:"timeout_ms"`
+		Tools     []struct {
+			Pattern   string `json:"pattern"`
+			TimeoutMS int    `json:"timeout_ms"`
+		} `json:"tools"`
+		MaxConcurrency int `json:"max_concurrency"`
+		MaxQueue       int `json:"max_queue"`
+		QueueTimeoutMS int `json:"queue_timeout_ms"`
+	}
+	if err := json.Unmarshal(raw, &cl); err != nil {
+		return gateway.CallLimits{}, err
+	}
+	limits := gateway.CallLimits{
+		Timeout:        time.Duration(cl.TimeoutMS) * time.Millisecond,
+		MaxConcurrency: cl.MaxConcurrency,
+		MaxQueue:       cl.MaxQueue,
+		QueueTimeout:   time.Duration(cl.QueueTimeoutMS) * time.Millisecond,
+	}
+	for _, t := range cl.Tools {
+		limits.ToolTimeouts = append(limits.ToolTimeouts, gateway.ToolTimeout{
+			Pattern: t.Pattern,
+			Timeout: time.Duration(t.TimeoutMS) * time.Millisecond,
+		})
+	}
+	return limits, nil
+}
+
+func parseRetryPolicy(raw json.RawMessage) (gateway.RetryPolicy, error) {
+	if len(raw) == 0 {
+		return gateway.RetryPolicy{}, nil
+	}
+	var rp struct {
+		MaxAttempts       int      `json:"max_attempts"`
+		InitialBackoffMS  int      `json:"initial_backoff_ms"`
+		MaxBackoffMS      int      `json:"max_backoff_ms"`
+		RetryableStatuses []int    `json:"retryable_statuses"`
+		IdempotentTools   []string `json:"idempotent_tools"`
+		HedgeTools        []string `json:"hedge_tools"`
+		HedgeDelayMS      int      `json:"hedge_delay_ms"`
+	}
+	if err := json.Unmarshal(raw, &rp); err != nil {
+		return gateway.RetryPolicy{}, err
+	}
+	if rp.RetryableStatuses == nil {
+		rp.RetryableStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	if rp.InitialBackoffMS == 0 {
+		rp.InitialBackoffMS = 100
+	}
+	if rp.MaxBackoffMS == 0 {
+		rp.MaxBackoffMS = 2000
+	}
+	return gateway.RetryPolicy{
+		MaxAttempts:       rp.MaxAttempts,
+		InitialBackoff:    time.Duration(rp.InitialBackoffMS) * time.Millisecond,
+		MaxBackoff:        time.Duration(rp.MaxBackoffMS) * time.Millisecond,
+		RetryableStatuses: rp.RetryableStatuses,
+		IdempotentTools:   rp.IdempotentTools,
+		HedgeTools:        rp.HedgeTools,
+		HedgeDelay:        time.Duration(rp.HedgeDelayMS) * time.Millisecond,
 	}, nil
 }
 
 func (h *MCPGatewayHandler) auditGatewayCall(r *http.Request, serverLabel, toolName string, upstreamStatus int, outcome string, latency time.Duration) {
-	if h.audit == nil {
+	h.auditGatewayCallDetails(r, serverLabel, toolName, upstreamStatus, outcome, latency, nil)
+}
+
+// recordUsage adds a completed tool call to the usage analytics.
+func (h *MCPGatewayHandler) recordUsage(r *http.Request, s gateway.UsageSample, outcome string) {
+	if h.usage == nil {
 		return
 	}
-	callerID, _ := auth.UserIDFromContext(r.Context())
+	if callerID, ok := auth.UserIDFromContext(r.Context()); ok {
+		s.CallerID = callerID.String()
+	}
+	s.Outcome = outcome
+	h.usage.Record(s)
+}
+
+// auditGatewayCallDetails records a gateway call with extra detail fields.
+func (h *MCPGatewayHandler) auditGatewayCallDetails(r *http.Request, serverLabel, toolName string, upstreamStatus int, outcome string, latency time.Duration, extra map[string]interface{}) {
 	details := map[string]interface{}{
 		"server_label": serverLabel, "tool_name": toolName,
 		"outcome": outcome, "latency_ms": latency.Milliseconds(),
@@ -211,12 +1338,28 @@ func (h *MCPGatewayHandler) auditGatewayCall(r *http.Request, serverLabel, toolN
 	if upstreamStatus > 0 {
 		details["upstream_status"] = upstreamStatus
 	}
+	for k, v := range extra {
+		details[k] = v
+	}
+	h.insertGatewayAudit(r, "gateway_tool_call", serverLabel+"/"+toolName, details)
+}
+
+// insertGatewayAudit records a gateway audit entry for a tool without
+// delaying the call.
+func (h *MCPGatewayHandler) insertGatewayAudit(r *http.Request, action, resourceID string, details map[string]interface{}) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if trace, ok := gateway.TraceFromContext(r.Context()); ok {
+		details["trace_id"] = trace.TraceID()
+	}
 	detailsJSON, _ := json.Marshal(details)
 	entry := &store.AuditEntry{
 		Actor: callerID.String(), ActorID: &callerID,
-		Action: "gateway_tool_call", ResourceType: "mcp_tool",
-		ResourceID: serverLabel + "/" + toolName,
-		Details: detailsJSON, IPAddress: clientIPFromRequest(r),
+		Action: action, ResourceType: "mcp_tool",
+		ResourceID: resourceID,
+		Details:    detailsJSON, IPAddress: clientIPFromRequest(r),
 	}
 	go func() {
 		if err := h.audit.Insert(context.Background(), entry); err != nil {
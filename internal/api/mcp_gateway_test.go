@@ -5,9 +5,12 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -16,6 +19,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
 	"github.com/agent-smit/agentic-registry/internal/gateway"
 	"github.com/agent-smit/agentic-registry/internal/ratelimit"
 	"github.com/agent-smit/agentic-registry/internal/store"
@@ -61,6 +65,45 @@ func (m *mockProxyForwarder) Forward(_ context.Context, req gateway.ProxyRequest
 	return m.resp, m.err
 }
 
+// sequenceForwarder returns the scripted statuses in order; safe for concurrent use.
+type sequenceForwarder struct {
+	mu       sync.Mutex
+	statuses []int
+	calls    int
+}
+
+func (m *sequenceForwarder) Forward(_ context.Context, _ gateway.ProxyRequest) (*gateway.ProxyResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := m.statuses[len(m.statuses)-1]
+	if m.calls < len(m.statuses) {
+		status = m.statuses[m.calls]
+	}
+	m.calls++
+	return &gateway.ProxyResponse{StatusCode: status, Body: json.RawMessage(`{}`), Latency: time.Millisecond}, nil
+}
+
+func (m *sequenceForwarder) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// endpointForwarder answers with a fixed status per endpoint URL and records
+// the endpoints it was called with; safe for concurrent use.
+type endpointForwarder struct {
+	mu       sync.Mutex
+	statuses map[string]int
+	calls    []string
+}
+
+func (m *endpointForwarder) Forward(_ context.Context, req gateway.ProxyRequest) (*gateway.ProxyResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, req.ServerEndpoint)
+	return &gateway.ProxyResponse{StatusCode: m.statuses[req.ServerEndpoint], Body: json.RawMessage(`{}`), Latency: time.Millisecond}, nil
+}
+
 type mockTrustDefaults struct {
 	records []gateway.TrustDefaultRecord
 }
@@ -487,210 +530,1605 @@ func TestGateway_UpstreamSuccess_RecordsCircuitSuccess(t *testing.T) {
 	}
 }
 
-// --- 8. Audit verification ---
-
-func TestGateway_Audit_SuccessfulCall(t *testing.T) {
+func TestGateway_UpstreamSuccess_OAuth2ClientCredentials(t *testing.T) {
 	srv := enabledMCPServer()
-	audit := &safeAuditMock{}
+	srv.AuthType = "oauth2_client_credentials"
+	srv.AuthCredential = encryptCredential(t, `{"client_id":"id","client_secret":"secret","token_url":"https://auth.example.com/token","scopes":["tools"]}`)
 	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
-	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
-	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "my_tool", nil)
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", rr.Code)
 	}
-	time.Sleep(100 * time.Millisecond)
-	entries := audit.getEntries()
-	if len(entries) == 0 {
-		t.Fatal("expected audit entry")
+	creds := forwarder.lastReq.OAuth2
+	if creds == nil {
+		t.Fatal("expected decrypted oauth2 credentials on proxy request")
 	}
-	if entries[0].Action != "gateway_tool_call" {
-		t.Errorf("action = %q, want gateway_tool_call", entries[0].Action)
+	if creds.ClientID != "id" || creds.ClientSecret != "secret" || creds.TokenURL != "https://auth.example.com/token" {
+		t.Errorf("oauth2 credentials = %+v", creds)
 	}
-	if entries[0].ResourceID != "test-server/my_tool" {
-		t.Errorf("resource = %q, want test-server/my_tool", entries[0].ResourceID)
+	if forwarder.lastReq.AuthCredential != "" {
+		t.Errorf("AuthCredential = %q, want empty for oauth2", forwarder.lastReq.AuthCredential)
 	}
 }
 
-func TestGateway_Audit_TrustDenied(t *testing.T) {
+func TestGateway_OAuth2TokenFailure_RecordsCircuitFailure(t *testing.T) {
 	srv := enabledMCPServer()
+	srv.AuthType = "oauth2_client_credentials"
+	srv.AuthCredential = encryptCredential(t, `{"client_id":"id","client_secret":"secret","token_url":"https://auth.example.com/token"}`)
+	srv.CircuitBreaker = json.RawMessage(`{"fail_threshold":1,"open_duration_s":60}`)
 	audit := &safeAuditMock{}
-	defaults := &mockTrustDefaults{records: []gateway.TrustDefaultRecord{{ToolPattern: "*", Tier: "block", Priority: 1}}}
-	tc := gateway.NewTrustClassifier(nil, defaults, nil)
-	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, tc, gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
-	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "blocked_tool", nil)
-	if rr.Code != http.StatusForbidden {
-		t.Fatalf("expected 403, got %d", rr.Code)
+	cb := gateway.NewCircuitBreaker()
+	forwarder := &mockProxyForwarder{err: fmt.Errorf("%w: invalid_client", gateway.ErrTokenFetch)}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), cb, forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rr.Code)
+	}
+	if cb.State("test-server") != gateway.CircuitOpen {
+		t.Error("expected circuit open after token fetch failure with threshold=1")
 	}
 	time.Sleep(100 * time.Millisecond)
 	entries := audit.getEntries()
 	if len(entries) == 0 {
-		t.Fatal("expected audit entry for trust denied")
+		t.Fatal("expected audit entry for token error")
 	}
 	var details map[string]interface{}
 	json.Unmarshal(entries[0].Details, &details)
-	if details["outcome"] != "trust_denied" {
-		t.Errorf("outcome = %q, want trust_denied", details["outcome"])
+	if details["outcome"] != "token_error" {
+		t.Errorf("outcome = %q, want token_error", details["outcome"])
 	}
 }
 
-func TestGateway_Audit_CircuitOpen(t *testing.T) {
+func TestGateway_PassesTLSAndCustomHeaders(t *testing.T) {
 	srv := enabledMCPServer()
-	audit := &safeAuditMock{}
-	cb := gateway.NewCircuitBreaker()
-	cbCfg := gateway.CircuitBreakerConfig{FailThreshold: 1, OpenDuration: 10 * time.Second}
-	cb.RecordFailure("test-server", cbCfg)
-	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), cb, &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	srv.TLSCABundle = encryptCredential(t, "ca-pem")
+	srv.TLSClientCert = encryptCredential(t, "cert-pem")
+	srv.TLSClientKey = encryptCredential(t, "key-pem")
+	srv.CustomHeaders = encryptCredential(t, `{"X-Tenant-ID":"acme"}`)
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	got := forwarder.lastReq
+	if got.TLS == nil || got.TLS.ClientCert != "cert-pem" || got.TLS.ClientKey != "key-pem" || got.TLS.CABundle != "ca-pem" {
+		t.Errorf("TLS = %+v", got.TLS)
+	}
+	if got.Headers["X-Tenant-ID"] != "acme" {
+		t.Errorf("Headers = %v", got.Headers)
+	}
+	if got.ServerLabel != "test-server" {
+		t.Errorf("ServerLabel = %q, want test-server", got.ServerLabel)
+	}
+}
+
+func TestGateway_StdioServer(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.Endpoint = ""
+	srv.Transport = "stdio"
+	srv.StdioCommand = "/usr/local/bin/mcp-files"
+	srv.StdioArgs = json.RawMessage(`["--root","/data"]`)
+	srv.StdioEnv = encryptCredential(t, `{"API_TOKEN":"t0k"}`)
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	got := forwarder.lastReq.Stdio
+	if got == nil || got.Command != "/usr/local/bin/mcp-files" || len(got.Args) != 2 || got.Args[1] != "/data" {
+		t.Fatalf("Stdio = %+v", got)
+	}
+	if got.Env["API_TOKEN"] != "t0k" {
+		t.Errorf("Env = %v", got.Env)
+	}
+}
+
+func TestGateway_StdioUnavailable(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.Transport = "stdio"
+	srv.StdioCommand = "/usr/local/bin/mcp-files"
+	forwarder := &mockProxyForwarder{err: fmt.Errorf("%w: restarting in 1s", gateway.ErrStdioUnavailable)}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
 	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
 	if rr.Code != http.StatusServiceUnavailable {
-		t.Fatalf("expected 503, got %d", rr.Code)
+		t.Errorf("expected 503, got %d", rr.Code)
+	}
+}
+
+func TestGateway_StdioFailuresTripCircuit(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.Endpoint = ""
+	srv.Transport = "stdio"
+	srv.StdioCommand = "/usr/local/bin/mcp-files"
+	srv.CircuitBreaker = json.RawMessage(`{"fail_threshold":2,"open_duration_s":30}`)
+	cb := gateway.NewCircuitBreaker()
+	forwarder := &mockProxyForwarder{err: fmt.Errorf("%w: process exited", gateway.ErrStdioUnavailable)}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), cb, forwarder, ratelimit.NewRateLimiter())
+
+	for i := 0; i < 2; i++ {
+		if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil); rr.Code != http.StatusServiceUnavailable {
+			t.Fatalf("call %d: expected 503, got %d", i+1, rr.Code)
+		}
+	}
+	if cb.State("test-server") != gateway.CircuitOpen {
+		t.Fatalf("expected circuit open after 2 stdio failures, got %+v", cb.Status("test-server"))
+	}
+	forwarder.lastReq = nil
+	if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 with the circuit open, got %d", rr.Code)
+	}
+	if forwarder.lastReq != nil {
+		t.Error("call should not reach the stdio server while its circuit is open")
+	}
+}
+
+func TestGateway_ToolTimeoutPassedToForwarder(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.CallLimits = json.RawMessage(`{"timeout_ms": 10000, "tools": [{"pattern": "slow_*", "timeout_ms": 60000}]}`)
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+
+	makeGatewayRequest(t, h.ProxyToolCall, "test-server", "slow_report", nil)
+	if forwarder.lastReq.Timeout != time.Minute {
+		t.Errorf("slow_report timeout = %s, want 1m", forwarder.lastReq.Timeout)
+	}
+	makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if forwarder.lastReq.Timeout != 10*time.Second {
+		t.Errorf("some_tool timeout = %s, want 10s", forwarder.lastReq.Timeout)
+	}
+}
+
+func TestGateway_UpstreamTimeout(t *testing.T) {
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{err: fmt.Errorf("%w after 5s", gateway.ErrUpstreamTimeout)}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: enabledMCPServer()}, audit, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", rr.Code)
 	}
 	time.Sleep(100 * time.Millisecond)
 	entries := audit.getEntries()
 	if len(entries) == 0 {
-		t.Fatal("expected audit entry for circuit open")
+		t.Fatal("expected audit entry for timeout")
 	}
 	var details map[string]interface{}
 	json.Unmarshal(entries[0].Details, &details)
-	if details["outcome"] != "circuit_open" {
-		t.Errorf("outcome = %q, want circuit_open", details["outcome"])
+	if details["outcome"] != "upstream_timeout" {
+		t.Errorf("outcome = %q, want upstream_timeout", details["outcome"])
 	}
 }
 
-// --- 9. ListTools ---
+// blockingForwarder holds every call until released.
+type blockingForwarder struct {
+	started chan struct{}
+	release chan struct{}
+}
 
-func TestGateway_ListTools_ReturnsEnabledServers(t *testing.T) {
-	servers := &mockGatewayServerStore{list: []store.MCPServer{
-		{Label: "enabled-server", Endpoint: "http://a.com", IsEnabled: true},
-		{Label: "disabled-server", Endpoint: "http://b.com", IsEnabled: false},
-		{Label: "another-enabled", Endpoint: "http://c.com", IsEnabled: true},
-	}}
-	h := newTestGatewayHandler(servers, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
-	req := httptest.NewRequest(http.MethodGet, "/mcp/v1/tools", nil)
-	rr := httptest.NewRecorder()
-	h.ListTools(rr, req)
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+func (b *blockingForwarder) Forward(ctx context.Context, _ gateway.ProxyRequest) (*gateway.ProxyResponse, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}, nil
+}
+
+func TestGateway_ConcurrencyLimit(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.CallLimits = json.RawMessage(`{"max_concurrency": 1}`)
+	forwarder := &blockingForwarder{started: make(chan struct{}, 1), release: make(chan struct{})}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+
+	first := make(chan int, 1)
+	go func() {
+		first <- makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil).Code
+	}()
+	<-forwarder.started
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
 	}
-	env := parseGatewayEnvelope(t, rr)
-	data := env.Data.(map[string]interface{})
-	serversList := data["servers"].([]interface{})
-	if len(serversList) != 2 {
-		t.Errorf("expected 2 enabled servers, got %d", len(serversList))
+	if !strings.Contains(rr.Body.String(), "CONCURRENCY_LIMITED") {
+		t.Errorf("body = %s, want CONCURRENCY_LIMITED", rr.Body.String())
 	}
-	// Verify endpoint is NOT exposed (security: information disclosure)
-	for i, srv := range serversList {
-		srvMap := srv.(map[string]interface{})
-		if _, hasEndpoint := srvMap["endpoint"]; hasEndpoint {
-			t.Errorf("server[%d] should not expose endpoint field (information disclosure)", i)
-		}
-		if _, hasLabel := srvMap["label"]; !hasLabel {
-			t.Errorf("server[%d] missing label field", i)
-		}
+
+	close(forwarder.release)
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first call status = %d, want 200", code)
+	}
+	if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil); rr.Code != http.StatusOK {
+		t.Errorf("call after release status = %d, want 200", rr.Code)
 	}
 }
 
-func TestGateway_ListTools_EmptyList(t *testing.T) {
-	servers := &mockGatewayServerStore{list: []store.MCPServer{}}
-	h := newTestGatewayHandler(servers, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
-	req := httptest.NewRequest(http.MethodGet, "/mcp/v1/tools", nil)
-	rr := httptest.NewRecorder()
-	h.ListTools(rr, req)
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+func TestGateway_NoTLSConfigured_LeavesTLSNil(t *testing.T) {
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: enabledMCPServer()}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if forwarder.lastReq.TLS != nil {
+		t.Errorf("TLS = %+v, want nil", forwarder.lastReq.TLS)
 	}
 }
 
-func TestGateway_ListTools_StoreError(t *testing.T) {
-	servers := &mockGatewayServerStore{err: fmt.Errorf("db connection lost")}
-	h := newTestGatewayHandler(servers, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
-	req := httptest.NewRequest(http.MethodGet, "/mcp/v1/tools", nil)
-	rr := httptest.NewRecorder()
-	h.ListTools(rr, req)
+func TestGateway_TLSDecryptFailure(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.TLSClientKey = "not-valid-base64!!!"
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
 	if rr.Code != http.StatusInternalServerError {
 		t.Errorf("expected 500, got %d", rr.Code)
 	}
 }
 
-// --- 10. Input validation ---
-
-func TestGateway_InvalidRequestBody(t *testing.T) {
+func TestGateway_EgressDenied(t *testing.T) {
 	srv := enabledMCPServer()
-	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
-	req := httptest.NewRequest(http.MethodPost, "/mcp/v1/proxy/test-server/tools/some_tool", bytes.NewReader([]byte("not json")))
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("serverLabel", "test-server")
-	rctx.URLParams.Add("toolName", "some_tool")
-	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
-	ctx = auth.ContextWithUser(ctx, uuid.New(), "admin", "session")
-	req = req.WithContext(ctx)
-	rr := httptest.NewRecorder()
-	h.ProxyToolCall(rr, req)
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	srv.EgressPolicy = json.RawMessage(`{"allow_hosts":["mcp.internal"]}`)
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{err: fmt.Errorf("%w: resolved IP 10.0.0.1 is in a private range", gateway.ErrEgressDenied)}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rr.Code)
+	}
+	if forwarder.lastReq.Egress == nil || forwarder.lastReq.Egress.AllowHosts[0] != "mcp.internal" {
+		t.Errorf("Egress = %+v, want server allowlist", forwarder.lastReq.Egress)
+	}
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) == 0 {
+		t.Fatal("expected audit entry for egress denial")
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["outcome"] != "egress_denied" {
+		t.Errorf("outcome = %q, want egress_denied", details["outcome"])
 	}
 }
 
-func TestGateway_EmptyURLParams(t *testing.T) {
-	h := newTestGatewayHandler(&mockGatewayServerStore{}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
-	req := httptest.NewRequest(http.MethodPost, "/mcp/v1/proxy//tools/", bytes.NewReader([]byte(`{}`)))
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("serverLabel", "")
-	rctx.URLParams.Add("toolName", "")
-	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
-	ctx = auth.ContextWithUser(ctx, uuid.New(), "admin", "session")
-	req = req.WithContext(ctx)
-	rr := httptest.NewRecorder()
-	h.ProxyToolCall(rr, req)
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+func TestGateway_RetriesIdempotentCall(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.RetryPolicy = json.RawMessage(`{"max_attempts":3,"initial_backoff_ms":1,"max_backoff_ms":2}`)
+	audit := &safeAuditMock{}
+	forwarder := &sequenceForwarder{statuses: []int{502, 200}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	env := parseGatewayEnvelope(t, rr)
+	data := env.Data.(map[string]interface{})
+	if data["attempts"] != float64(2) || data["status_code"] != float64(200) {
+		t.Errorf("attempts = %v, status_code = %v; want 2, 200", data["attempts"], data["status_code"])
+	}
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) != 2 {
+		t.Fatalf("expected one audit entry per attempt, got %d", len(entries))
+	}
+	outcomes := map[string]float64{}
+	for _, e := range entries {
+		var details map[string]interface{}
+		json.Unmarshal(e.Details, &details)
+		outcomes[details["outcome"].(string)] = details["attempt"].(float64)
+	}
+	if outcomes["upstream_5xx"] != 1 || outcomes["success"] != 2 {
+		t.Errorf("unexpected per-attempt audit outcomes: %v", outcomes)
 	}
 }
 
-// --- 11. Default circuit breaker config ---
-
-func TestGateway_DefaultCircuitBreakerConfig(t *testing.T) {
+func TestGateway_NoRetryWithoutPolicy(t *testing.T) {
 	srv := enabledMCPServer()
-	srv.CircuitBreaker = nil
-	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
+	forwarder := &sequenceForwarder{statuses: []int{502, 200}}
 	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
 	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if forwarder.callCount() != 1 {
+		t.Errorf("expected a single upstream call, got %d", forwarder.callCount())
 	}
 }
 
-func TestParseCircuitBreakerCfg(t *testing.T) {
-	tests := []struct {
-		name      string
-		input     json.RawMessage
-		wantFail  int
-		wantDur   time.Duration
-		wantError bool
-	}{
-		{"nil uses defaults", nil, 5, 30 * time.Second, false},
-		{"empty uses defaults", json.RawMessage(``), 5, 30 * time.Second, false},
-		{"valid config", json.RawMessage(`{"fail_threshold":10,"open_duration_s":60}`), 10, 60 * time.Second, false},
-		{"zero values use defaults", json.RawMessage(`{"fail_threshold":0,"open_duration_s":0}`), 5, 30 * time.Second, false},
-		{"invalid JSON", json.RawMessage(`not json`), 0, 0, true},
+func TestGateway_RetryStopsWhenCircuitOpens(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.CircuitBreaker = json.RawMessage(`{"fail_threshold":1,"open_duration_s":30}`)
+	srv.RetryPolicy = json.RawMessage(`{"max_attempts":5,"initial_backoff_ms":1,"max_backoff_ms":2}`)
+	forwarder := &sequenceForwarder{statuses: []int{503}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if forwarder.callCount() != 1 {
+		t.Errorf("expected retries to stop once the circuit opened, got %d calls", forwarder.callCount())
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			cfg, err := parseCircuitBreakerCfg(tt.input)
-			if tt.wantError {
-				if err == nil {
-					t.Fatal("expected error")
-				}
-				return
-			}
-			if err != nil {
+}
+
+func TestGateway_EndpointPool_RetryFailsOverToHealthyEndpoint(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.Endpoints = json.RawMessage(`[{"url":"http://a.example.com/mcp"},{"url":"http://b.example.com/mcp"}]`)
+	srv.RetryPolicy = json.RawMessage(`{"max_attempts":2,"initial_backoff_ms":1,"max_backoff_ms":2}`)
+	forwarder := &endpointForwarder{statuses: map[string]int{"http://a.example.com/mcp": 502, "http://b.example.com/mcp": 200}}
+	cb := gateway.NewCircuitBreaker()
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), cb, forwarder, ratelimit.NewRateLimiter())
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	data := parseGatewayEnvelope(t, rr).Data.(map[string]interface{})
+	if data["status_code"] != float64(200) {
+		t.Errorf("status_code = %v, want 200 from the healthy endpoint", data["status_code"])
+	}
+	if len(forwarder.calls) != 2 || forwarder.calls[0] == forwarder.calls[1] {
+		t.Errorf("expected the retry to use the other endpoint, got %v", forwarder.calls)
+	}
+}
+
+func TestGateway_EndpointPool_PerEndpointCircuit(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.CircuitBreaker = json.RawMessage(`{"fail_threshold":1,"open_duration_s":30}`)
+	pool := []gateway.Endpoint{{URL: "http://a.example.com/mcp"}, {URL: "http://b.example.com/mcp"}}
+	srv.Endpoints, _ = json.Marshal(pool)
+	forwarder := &endpointForwarder{statuses: map[string]int{"http://a.example.com/mcp": 200, "http://b.example.com/mcp": 200}}
+	cb := gateway.NewCircuitBreaker()
+	cb.RecordFailure(gateway.EndpointKey("test-server", pool, "http://a.example.com/mcp"), gateway.CircuitBreakerConfig{FailThreshold: 1, OpenDuration: 30 * time.Second})
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), cb, forwarder, ratelimit.NewRateLimiter())
+
+	for i := 0; i < 3; i++ {
+		if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil); rr.Code != http.StatusOK {
+			t.Fatalf("expected 200 while one endpoint is healthy, got %d", rr.Code)
+		}
+	}
+	for _, c := range forwarder.calls {
+		if c != "http://b.example.com/mcp" {
+			t.Errorf("call sent to endpoint with open circuit: %s", c)
+		}
+	}
+
+	cb.RecordFailure(gateway.EndpointKey("test-server", pool, "http://b.example.com/mcp"), gateway.CircuitBreakerConfig{FailThreshold: 1, OpenDuration: 30 * time.Second})
+	if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 once every endpoint circuit is open, got %d", rr.Code)
+	}
+}
+
+func TestGateway_HealthTargets(t *testing.T) {
+	pooled := enabledMCPServer()
+	pooled.Endpoints = json.RawMessage(`[{"url":"http://a.example.com/mcp","health_endpoint":"http://a.example.com/health"},{"url":"http://b.example.com/mcp"}]`)
+	single := enabledMCPServer()
+	single.Label = "single"
+	single.HealthEndpoint = "http://single.example.com/health"
+	disabled := enabledMCPServer()
+	disabled.Label = "disabled"
+	disabled.HealthEndpoint = "http://disabled.example.com/health"
+	disabled.IsEnabled = false
+
+	servers := &mockGatewayServerStore{list: []store.MCPServer{*pooled, *single, *disabled}}
+	h := newTestGatewayHandler(servers, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	targets, err := h.HealthTargets(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 health targets, got %+v", targets)
+	}
+	if targets[0].Endpoint != "http://a.example.com/mcp" || targets[0].HealthURL != "http://a.example.com/health" {
+		t.Errorf("unexpected pooled target: %+v", targets[0])
+	}
+	if targets[1].Label != "single" || targets[1].Endpoint != single.Endpoint {
+		t.Errorf("unexpected single-endpoint target: %+v", targets[1])
+	}
+}
+
+// mockToolSchemaStore keys schemas by tool name; safe for concurrent use.
+type mockToolSchemaStore struct {
+	mu         sync.Mutex
+	tools      map[string]*store.MCPToolSchema
+	discovered []store.MCPToolSchema
+	err        error
+}
+
+func newMockToolSchemaStore(tools ...store.MCPToolSchema) *mockToolSchemaStore {
+	m := &mockToolSchemaStore{tools: make(map[string]*store.MCPToolSchema)}
+	for i := range tools {
+		m.tools[tools[i].ToolName] = &tools[i]
+	}
+	return m
+}
+
+func (m *mockToolSchemaStore) Get(_ context.Context, _ uuid.UUID, toolName string) (*store.MCPToolSchema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	t, ok := m.tools[toolName]
+	if !ok {
+		return nil, apierrors.NotFound("tool schema", toolName)
+	}
+	return t, nil
+}
+
+func (m *mockToolSchemaStore) ReplaceDiscovered(_ context.Context, _ uuid.UUID, tools []store.MCPToolSchema) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.discovered = tools
+	for i := range tools {
+		m.tools[tools[i].ToolName] = &tools[i]
+	}
+	return nil
+}
+
+func (m *mockToolSchemaStore) ListByServer(_ context.Context, _ uuid.UUID) ([]store.MCPToolSchema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []store.MCPToolSchema
+	for _, t := range m.tools {
+		out = append(out, *t)
+	}
+	return out, nil
+}
+
+func (m *mockToolSchemaStore) SetOverlay(_ context.Context, serverID uuid.UUID, toolName string, overlay json.RawMessage) (*store.MCPToolSchema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tools[toolName]
+	if !ok {
+		t = &store.MCPToolSchema{ServerID: serverID, ToolName: toolName, InputSchema: json.RawMessage(`{}`)}
+		m.tools[toolName] = t
+	}
+	t.SchemaOverlay = overlay
+	return t, nil
+}
+
+type mockToolLister struct {
+	tools   []gateway.UpstreamTool
+	err     error
+	lastReq *gateway.ProxyRequest
+}
+
+func (m *mockToolLister) ListTools(_ context.Context, req gateway.ProxyRequest) ([]gateway.UpstreamTool, error) {
+	m.lastReq = &req
+	return m.tools, m.err
+}
+
+var searchToolSchema = store.MCPToolSchema{
+	ToolName:      "search",
+	InputSchema:   json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"},"limit":{"type":"integer"}},"required":["query"]}`),
+	SchemaOverlay: json.RawMessage(`{"properties":{"limit":{"maximum":10}}}`),
+}
+
+func TestGateway_SchemaViolationRejected(t *testing.T) {
+	srv := enabledMCPServer()
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetToolSchemas(newMockToolSchemaStore(searchToolSchema), &mockToolLister{})
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", map[string]interface{}{"arguments": map[string]interface{}{"limit": 50}})
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d (body: %s)", rr.Code, rr.Body.String())
+	}
+	if forwarder.lastReq != nil {
+		t.Error("invalid arguments must not be forwarded")
+	}
+	env := parseGatewayEnvelope(t, rr)
+	errMap, _ := env.Error.(map[string]interface{})
+	if errMap["code"] != "VALIDATION_ERROR" {
+		t.Errorf("code = %v, want VALIDATION_ERROR", errMap["code"])
+	}
+	violations, _ := errMap["details"].([]interface{})
+	if len(violations) != 2 {
+		t.Fatalf("expected violations for missing query and overlay maximum, got %v", errMap["details"])
+	}
+	paths := map[string]bool{}
+	for _, v := range violations {
+		paths[v.(map[string]interface{})["path"].(string)] = true
+	}
+	if !paths["$.query"] || !paths["$.limit"] {
+		t.Errorf("violation paths = %v, want $.query and $.limit", paths)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["outcome"] != "invalid_arguments" || details["violations"] == nil {
+		t.Errorf("audit details = %v, want invalid_arguments with violations", details)
+	}
+}
+
+func TestGateway_SchemaValidArgumentsForwarded(t *testing.T) {
+	srv := enabledMCPServer()
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetToolSchemas(newMockToolSchemaStore(searchToolSchema), &mockToolLister{})
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", map[string]interface{}{"arguments": map[string]interface{}{"query": "go", "limit": 5}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (body: %s)", rr.Code, rr.Body.String())
+	}
+
+	// Tools without a stored schema are forwarded unvalidated.
+	rr = makeGatewayRequest(t, h.ProxyToolCall, "test-server", "unknown_tool", map[string]interface{}{"arguments": map[string]interface{}{"anything": true}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for tool without schema, got %d", rr.Code)
+	}
+}
+
+func TestGateway_SchemaLookupFailure(t *testing.T) {
+	srv := enabledMCPServer()
+	schemas := newMockToolSchemaStore()
+	schemas.err = errors.New("db down")
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	h.SetToolSchemas(schemas, &mockToolLister{})
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", nil)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+}
+
+func TestGateway_DiscoverTools(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.AuthType = "bearer"
+	srv.AuthCredential = encryptCredential(t, "secret-token")
+	schemas := newMockToolSchemaStore()
+	lister := &mockToolLister{tools: []gateway.UpstreamTool{
+		{Name: "search", Description: "Search", InputSchema: json.RawMessage(`{"type":"object"}`)},
+		{Name: ""},
+	}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv, list: []store.MCPServer{*srv}}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	h.SetToolSchemas(schemas, lister)
+
+	h.RefreshToolSchemas(context.Background())
+	if lister.lastReq == nil || lister.lastReq.AuthCredential != "secret-token" {
+		t.Fatalf("expected discovery to use decrypted credentials, got %+v", lister.lastReq)
+	}
+	if len(schemas.discovered) != 1 || schemas.discovered[0].ToolName != "search" || schemas.discovered[0].ServerID != srv.ID {
+		t.Fatalf("discovered = %+v, want the search tool", schemas.discovered)
+	}
+
+	// A second refresh within the discovery interval is skipped.
+	lister.lastReq = nil
+	h.RefreshToolSchemas(context.Background())
+	if lister.lastReq != nil {
+		t.Error("expected refresh to honor the discovery interval")
+	}
+}
+
+func TestGateway_ResponseCache(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.ResponseCache = json.RawMessage(`{"ttl_s":60,"tools":["search_*"]}`)
+	audit := &safeAuditMock{}
+	forwarder := &sequenceForwarder{statuses: []int{200, 200, 200}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	cache := gateway.NewResponseCache(100)
+	h.SetResponseCache(cache)
+
+	callerID := uuid.New()
+	call := func(tool string, args string, caller uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp/v1/proxy/test-server/tools/"+tool, bytes.NewReader([]byte(`{"arguments":`+args+`}`)))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("serverLabel", "test-server")
+		rctx.URLParams.Add("toolName", tool)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = auth.ContextWithUser(ctx, caller, "admin", "session")
+		rr := httptest.NewRecorder()
+		h.ProxyToolCall(rr, req.WithContext(ctx))
+		return rr
+	}
+
+	rr := call("search_docs", `{"q":"go","limit":5}`, callerID)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Gateway-Cache") != "MISS" {
+		t.Fatalf("first call: got %d, cache header %q", rr.Code, rr.Header().Get("X-Gateway-Cache"))
+	}
+	rr = call("search_docs", `{"limit":5,"q":"go"}`, callerID)
+	if rr.Header().Get("X-Gateway-Cache") != "HIT" {
+		t.Fatalf("expected cache hit for reordered arguments, got %q", rr.Header().Get("X-Gateway-Cache"))
+	}
+	data := parseGatewayEnvelope(t, rr).Data.(map[string]interface{})
+	if data["cached"] != true || data["status_code"].(float64) != 200 {
+		t.Errorf("expected cached marker in response, got %v", data)
+	}
+	if forwarder.callCount() != 1 {
+		t.Errorf("expected 1 upstream call, got %d", forwarder.callCount())
+	}
+
+	// Other callers and non-matching tools go upstream.
+	call("search_docs", `{"q":"go","limit":5}`, uuid.New())
+	if rr := call("write_doc", `{}`, callerID); rr.Header().Get("X-Gateway-Cache") != "" {
+		t.Errorf("tool outside the cache list should not be cached")
+	}
+	if forwarder.callCount() != 3 {
+		t.Errorf("expected 3 upstream calls, got %d", forwarder.callCount())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	hits := 0
+	for _, e := range audit.getEntries() {
+		var details map[string]interface{}
+		json.Unmarshal(e.Details, &details)
+		if details["cached"] == true {
+			hits++
+		}
+	}
+	if hits != 1 {
+		t.Errorf("expected 1 audited cache hit, got %d", hits)
+	}
+}
+
+func TestGateway_ResponseCacheDisabledByDefault(t *testing.T) {
+	srv := enabledMCPServer()
+	forwarder := &sequenceForwarder{statuses: []int{200, 200}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetResponseCache(gateway.NewResponseCache(100))
+
+	makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search_docs", nil)
+	makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search_docs", nil)
+	if forwarder.callCount() != 2 {
+		t.Errorf("expected caching to be opt-in, got %d upstream calls", forwarder.callCount())
+	}
+}
+
+// --- 8. Audit verification ---
+
+func TestGateway_RecordsUsage(t *testing.T) {
+	okResp := &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{"result":"ok"}`), Latency: 20 * time.Millisecond}
+	blockAll := &mockTrustDefaults{records: []gateway.TrustDefaultRecord{{ToolPattern: "*", Tier: "block", Priority: 1}}}
+	tests := []struct {
+		name          string
+		defaults      *mockTrustDefaults
+		forwarder     *mockProxyForwarder
+		wantOutcome   string
+		wantForwarded bool
+	}{
+		{"success", nil, &mockProxyForwarder{resp: okResp}, "success", true},
+		{"upstream 5xx", nil, &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 503, Body: json.RawMessage(`{}`)}}, "upstream_5xx", true},
+		{"upstream error", nil, &mockProxyForwarder{err: context.DeadlineExceeded}, "upstream_error", true},
+		{"trust denied", blockAll, &mockProxyForwarder{resp: okResp}, "trust_denied", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var defaults gateway.TrustDefaultProvider
+			if tt.defaults != nil {
+				defaults = tt.defaults
+			}
+			h := newTestGatewayHandler(&mockGatewayServerStore{server: enabledMCPServer()}, gateway.NewTrustClassifier(nil, defaults, nil),
+				gateway.NewCircuitBreaker(), tt.forwarder, ratelimit.NewRateLimiter())
+			usage := gateway.NewUsageRecorder()
+			h.SetUsageRecorder(usage)
+
+			wsID := uuid.New()
+			makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", map[string]interface{}{
+				"arguments": map[string]string{"q": "x"}, "agent_id": "researcher", "workspace_id": wsID.String(),
+			})
+
+			buckets := usage.Drain()
+			if len(buckets) != 1 {
+				t.Fatalf("expected 1 usage bucket, got %+v", buckets)
+			}
+			b := buckets[0]
+			if b.Outcome != tt.wantOutcome || b.Server != "test-server" || b.Tool != "search" ||
+				b.AgentID != "researcher" || b.WorkspaceID != wsID.String() || b.CallerID == "" || b.Calls != 1 {
+				t.Errorf("unexpected bucket: %+v", b)
+			}
+			if b.BytesIn != int64(len(`{"q":"x"}`)) {
+				t.Errorf("bytes_in = %d", b.BytesIn)
+			}
+			var forwarded int64
+			for _, n := range b.Latency {
+				forwarded += n
+			}
+			if (forwarded == 1) != tt.wantForwarded {
+				t.Errorf("latency histogram = %v, forwarded %v", b.Latency, tt.wantForwarded)
+			}
+		})
+	}
+}
+
+func TestGateway_Audit_SuccessfulCall(t *testing.T) {
+	srv := enabledMCPServer()
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "my_tool", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) == 0 {
+		t.Fatal("expected audit entry")
+	}
+	if entries[0].Action != "gateway_tool_call" {
+		t.Errorf("action = %q, want gateway_tool_call", entries[0].Action)
+	}
+	if entries[0].ResourceID != "test-server/my_tool" {
+		t.Errorf("resource = %q, want test-server/my_tool", entries[0].ResourceID)
+	}
+}
+
+func TestGateway_Audit_TrustDenied(t *testing.T) {
+	srv := enabledMCPServer()
+	audit := &safeAuditMock{}
+	defaults := &mockTrustDefaults{records: []gateway.TrustDefaultRecord{{ToolPattern: "*", Tier: "block", Priority: 1}}}
+	tc := gateway.NewTrustClassifier(nil, defaults, nil)
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, tc, gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "blocked_tool", nil)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) == 0 {
+		t.Fatal("expected audit entry for trust denied")
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["outcome"] != "trust_denied" {
+		t.Errorf("outcome = %q, want trust_denied", details["outcome"])
+	}
+}
+
+func TestGateway_Audit_CircuitOpen(t *testing.T) {
+	srv := enabledMCPServer()
+	audit := &safeAuditMock{}
+	cb := gateway.NewCircuitBreaker()
+	cbCfg := gateway.CircuitBreakerConfig{FailThreshold: 1, OpenDuration: 10 * time.Second}
+	cb.RecordFailure("test-server", cbCfg)
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil), cb, &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) == 0 {
+		t.Fatal("expected audit entry for circuit open")
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["outcome"] != "circuit_open" {
+		t.Errorf("outcome = %q, want circuit_open", details["outcome"])
+	}
+}
+
+// --- 9. ListTools ---
+
+func TestGateway_ListTools_ReturnsEnabledServers(t *testing.T) {
+	servers := &mockGatewayServerStore{list: []store.MCPServer{
+		{Label: "enabled-server", Endpoint: "http://a.com", IsEnabled: true},
+		{Label: "disabled-server", Endpoint: "http://b.com", IsEnabled: false},
+		{Label: "another-enabled", Endpoint: "http://c.com", IsEnabled: true},
+	}}
+	h := newTestGatewayHandler(servers, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	req := httptest.NewRequest(http.MethodGet, "/mcp/v1/tools", nil)
+	rr := httptest.NewRecorder()
+	h.ListTools(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	env := parseGatewayEnvelope(t, rr)
+	data := env.Data.(map[string]interface{})
+	serversList := data["servers"].([]interface{})
+	if len(serversList) != 2 {
+		t.Errorf("expected 2 enabled servers, got %d", len(serversList))
+	}
+	// Verify endpoint is NOT exposed (security: information disclosure)
+	for i, srv := range serversList {
+		srvMap := srv.(map[string]interface{})
+		if _, hasEndpoint := srvMap["endpoint"]; hasEndpoint {
+			t.Errorf("server[%d] should not expose endpoint field (information disclosure)", i)
+		}
+		if _, hasLabel := srvMap["label"]; !hasLabel {
+			t.Errorf("server[%d] missing label field", i)
+		}
+	}
+}
+
+func TestGateway_ListTools_EmptyList(t *testing.T) {
+	servers := &mockGatewayServerStore{list: []store.MCPServer{}}
+	h := newTestGatewayHandler(servers, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	req := httptest.NewRequest(http.MethodGet, "/mcp/v1/tools", nil)
+	rr := httptest.NewRecorder()
+	h.ListTools(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+}
+
+func TestGateway_ListTools_StoreError(t *testing.T) {
+	servers := &mockGatewayServerStore{err: fmt.Errorf("db connection lost")}
+	h := newTestGatewayHandler(servers, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	req := httptest.NewRequest(http.MethodGet, "/mcp/v1/tools", nil)
+	rr := httptest.NewRecorder()
+	h.ListTools(rr, req)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+}
+
+// --- 10. Input validation ---
+
+func TestGateway_InvalidRequestBody(t *testing.T) {
+	srv := enabledMCPServer()
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	req := httptest.NewRequest(http.MethodPost, "/mcp/v1/proxy/test-server/tools/some_tool", bytes.NewReader([]byte("not json")))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("serverLabel", "test-server")
+	rctx.URLParams.Add("toolName", "some_tool")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = auth.ContextWithUser(ctx, uuid.New(), "admin", "session")
+	req = req.WithContext(ctx)
+	rr := httptest.NewRecorder()
+	h.ProxyToolCall(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestGateway_EmptyURLParams(t *testing.T) {
+	h := newTestGatewayHandler(&mockGatewayServerStore{}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	req := httptest.NewRequest(http.MethodPost, "/mcp/v1/proxy//tools/", bytes.NewReader([]byte(`{}`)))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("serverLabel", "")
+	rctx.URLParams.Add("toolName", "")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = auth.ContextWithUser(ctx, uuid.New(), "admin", "session")
+	req = req.WithContext(ctx)
+	rr := httptest.NewRecorder()
+	h.ProxyToolCall(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+// --- 11. Default circuit breaker config ---
+
+func TestGateway_DefaultCircuitBreakerConfig(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.CircuitBreaker = nil
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`), Latency: 1 * time.Millisecond}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: srv}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "some_tool", nil)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+}
+
+func TestParseCircuitBreakerCfg(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     json.RawMessage
+		wantFail  int
+		wantDur   time.Duration
+		wantError bool
+	}{
+		{"nil uses defaults", nil, 5, 30 * time.Second, false},
+		{"empty uses defaults", json.RawMessage(``), 5, 30 * time.Second, false},
+		{"valid config", json.RawMessage(`{"fail_threshold":10,"open_duration_s":60}`), 10, 60 * time.Second, false},
+		{"zero values use defaults", json.RawMessage(`{"fail_threshold":0,"open_duration_s":0}`), 5, 30 * time.Second, false},
+		{"invalid JSON", json.RawMessage(`not json`), 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseCircuitBreakerCfg(tt.input)
+			if tt.wantError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if cfg.FailThreshold != tt.wantFail {
-				t.Errorf("fail_threshold = %d, want %d", cfg.FailThreshold, tt.wantFail)
+			if cfg.FailThreshold != tt.wantFail {
+				t.Errorf("fail_threshold = %d, want %d", cfg.FailThreshold, tt.wantFail)
+			}
+			if cfg.OpenDuration != tt.wantDur {
+				t.Errorf("open_duration = %v, want %v", cfg.OpenDuration, tt.wantDur)
+			}
+		})
+	}
+}
+
+func TestParseCircuitBreakerCfg_WindowMode(t *testing.T) {
+	cfg, err := parseCircuitBreakerCfg(json.RawMessage(`{"mode":"window","open_duration_s":10,"failure_rate_pct":25.5,"latency_p95_ms":1500,"half_open_probes":4,"probe_success_ratio":0.75}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Window != 60*time.Second || cfg.MinRequests != 20 {
+		t.Errorf("window defaults = %v/%d, want 1m0s/20", cfg.Window, cfg.MinRequests)
+	}
+	if cfg.FailureRatePct != 25.5 || cfg.LatencyP95 != 1500*time.Millisecond {
+		t.Errorf("thresholds = %v/%v", cfg.FailureRatePct, cfg.LatencyP95)
+	}
+	if cfg.HalfOpenProbes != 4 || cfg.ProbeSuccessRatio != 0.75 {
+		t.Errorf("probes = %d/%v", cfg.HalfOpenProbes, cfg.ProbeSuccessRatio)
+	}
+
+	// Window fields are ignored outside window mode.
+	cfg, _ = parseCircuitBreakerCfg(json.RawMessage(`{"fail_threshold":3,"open_duration_s":10,"window_s":60}`))
+	if cfg.Window != 0 {
+		t.Errorf("consecutive mode window = %v, want 0", cfg.Window)
+	}
+}
+
+func pricedMCPServer() *store.MCPServer {
+	srv := enabledMCPServer()
+	srv.Pricing = json.RawMessage(`{"unit_cost":0.01,"tools":[{"pattern":"ping","unit_cost":0}]}`)
+	return srv
+}
+
+func TestGateway_BudgetMetersSpend(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	budgets.budgets[wsID] = &store.WorkspaceBudget{
+		WorkspaceID: wsID, MonthlyLimitMicros: 30000, SoftThresholds: []int{50}, OnExhausted: "reject",
+	}
+	dispatcher := &recordingDispatcher{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: pricedMCPServer()}, gateway.NewTrustClassifier(nil, nil, nil),
+		gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetBudgets(budgets, dispatcher)
+	usage := gateway.NewUsageRecorder()
+	h.SetUsageRecorder(usage)
+
+	body := map[string]interface{}{"arguments": map[string]string{}, "workspace_id": wsID.String()}
+	var statuses []int
+	for i := 0; i < 4; i++ {
+		statuses = append(statuses, makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", body).Code)
+	}
+
+	want := []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("statuses = %v, want %v", statuses, want)
+		}
+	}
+	if budgets.spend[wsID] != 30000 || budgets.calls[wsID] != 3 {
+		t.Errorf("spend = %d over %d calls, want 30000 over 3", budgets.spend[wsID], budgets.calls[wsID])
+	}
+	var types []string
+	for _, e := range dispatcher.events {
+		types = append(types, e.Type)
+		if e.ResourceID != wsID.String() {
+			t.Errorf("event resource = %s, want workspace %s", e.ResourceID, wsID)
+		}
+	}
+	if len(types) != 2 || types[0] != "workspace.budget_threshold_reached" || types[1] != "workspace.budget_exhausted" {
+		t.Fatalf("events = %v", types)
+	}
+	// The second call crosses 50%, the third reaches the limit.
+	for i, want := range []map[string]any{
+		{"threshold_percent": 50, "monthly_limit_micros": int64(30000), "spent_micros": int64(20000)},
+		{"threshold_percent": 100, "monthly_limit_micros": int64(30000), "spent_micros": int64(30000)},
+	} {
+		if got := dispatcher.events[i].Details; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s details = %v, want %v", types[i], got, want)
+		}
+	}
+
+	var cost int64
+	outcomes := map[string]int64{}
+	for _, b := range usage.Drain() {
+		cost += b.CostMicros
+		outcomes[b.Outcome] += b.Calls
+	}
+	if cost != 30000 || outcomes["budget_exhausted"] != 1 {
+		t.Errorf("usage cost = %d, outcomes = %v", cost, outcomes)
+	}
+
+	// Free tools are never rejected.
+	if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "ping", body); rr.Code != http.StatusOK {
+		t.Errorf("free tool status = %d, want 200", rr.Code)
+	}
+}
+
+func TestGateway_BudgetExhaustedResponse(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	budgets.budgets[wsID] = &store.WorkspaceBudget{WorkspaceID: wsID, MonthlyLimitMicros: 10000, OnExhausted: "reject"}
+	budgets.spend[wsID] = 10000
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: pricedMCPServer()}, gateway.NewTrustClassifier(nil, nil, nil),
+		gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetBudgets(budgets, nil)
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search",
+		map[string]interface{}{"arguments": map[string]string{}, "workspace_id": wsID.String()})
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	var env struct {
+		Error map[string]string `json:"error"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.Error["code"] != "BUDGET_EXHAUSTED" {
+		t.Errorf("error code = %q, want BUDGET_EXHAUSTED", env.Error["code"])
+	}
+	if forwarder.lastReq != nil {
+		t.Errorf("rejected call should not be forwarded")
+	}
+}
+
+func TestGateway_BudgetRejectsCallOverLimit(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	budgets.budgets[wsID] = &store.WorkspaceBudget{WorkspaceID: wsID, MonthlyLimitMicros: 25000, OnExhausted: "reject"}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: pricedMCPServer()}, gateway.NewTrustClassifier(nil, nil, nil),
+		gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetBudgets(budgets, nil)
+
+	body := map[string]interface{}{"arguments": map[string]string{}, "workspace_id": wsID.String()}
+	var statuses []int
+	for i := 0; i < 3; i++ {
+		statuses = append(statuses, makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", body).Code)
+	}
+	// The third call's price would take the spend from 20000 to 30000.
+	if statuses[0] != http.StatusOK || statuses[1] != http.StatusOK || statuses[2] != http.StatusTooManyRequests {
+		t.Fatalf("statuses = %v, want 200, 200, 429", statuses)
+	}
+	if budgets.spend[wsID] != 20000 || budgets.calls[wsID] != 2 {
+		t.Errorf("spend = %d over %d calls, want 20000 over 2", budgets.spend[wsID], budgets.calls[wsID])
+	}
+}
+
+func TestGateway_BudgetReleasedWhenNotAnswered(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	budgets.budgets[wsID] = &store.WorkspaceBudget{WorkspaceID: wsID, MonthlyLimitMicros: 10000, OnExhausted: "reject"}
+	forwarder := &mockProxyForwarder{err: errors.New("connection refused")}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: pricedMCPServer()}, gateway.NewTrustClassifier(nil, nil, nil),
+		gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetBudgets(budgets, nil)
+
+	body := map[string]interface{}{"arguments": map[string]string{}, "workspace_id": wsID.String()}
+	if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", body); rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if budgets.spend[wsID] != 0 || budgets.calls[wsID] != 0 {
+		t.Errorf("spend = %d over %d calls, want the reservation released", budgets.spend[wsID], budgets.calls[wsID])
+	}
+
+	forwarder.err = nil
+	forwarder.resp = &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}
+	if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", body); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 after the release, got %d", rr.Code)
+	}
+	if budgets.spend[wsID] != 10000 || budgets.calls[wsID] != 1 {
+		t.Errorf("spend = %d over %d calls, want 10000 over 1", budgets.spend[wsID], budgets.calls[wsID])
+	}
+}
+
+func TestGateway_BudgetExhaustedFlag(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	budgets.budgets[wsID] = &store.WorkspaceBudget{WorkspaceID: wsID, MonthlyLimitMicros: 10000, OnExhausted: "flag"}
+	budgets.spend[wsID] = 10000
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: pricedMCPServer()}, audit,
+		gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetBudgets(budgets, nil)
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search",
+		map[string]interface{}{"arguments": map[string]string{}, "workspace_id": wsID.String()})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Gateway-Budget") != "exceeded" {
+		t.Errorf("expected X-Gateway-Budget: exceeded header")
+	}
+	if budgets.spend[wsID] != 20000 {
+		t.Errorf("flagged calls are still metered, spend = %d", budgets.spend[wsID])
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["budget_exceeded"] != true {
+		t.Errorf("expected budget_exceeded in audit details, got %v", details)
+	}
+}
+
+func agentToolTestHandler(t *testing.T, mode string, audit *safeAuditMock) (*MCPGatewayHandler, *mockProxyForwarder, uuid.UUID) {
+	t.Helper()
+	wsID := uuid.New()
+	settings := newMockWorkspaceSettingsStore()
+	settings.settings[wsID] = &store.WorkspaceSettings{WorkspaceID: wsID, AgentToolEnforcement: mode}
+	agents := newMockAgentStore()
+	agents.agents["researcher"] = &store.Agent{
+		ID: "researcher", IsActive: true,
+		Tools: json.RawMessage(`[{"name":"search","source":"mcp","server_label":"test-server"},{"name":"ping","source":"internal","server_label":"test-server"}]`),
+	}
+	agents.agents["retired"] = &store.Agent{
+		ID: "retired", IsActive: false,
+		Tools: json.RawMessage(`[{"name":"search","source":"mcp","server_label":"test-server"}]`),
+	}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: enabledMCPServer()}, audit,
+		gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetAgentToolEnforcement(agents, settings)
+	return h, forwarder, wsID
+}
+
+func TestGateway_AgentToolEnforce(t *testing.T) {
+	tests := []struct {
+		name       string
+		agentID    string
+		tool       string
+		wantStatus int
+		wantReason string
+	}{
+		{"declared tool", "researcher", "search", http.StatusOK, ""},
+		{"undeclared tool", "researcher", "delete", http.StatusForbidden, "tool_not_declared"},
+		{"non-mcp declaration", "researcher", "ping", http.StatusForbidden, "tool_not_declared"},
+		{"inactive agent", "retired", "search", http.StatusForbidden, "agent_inactive"},
+		{"unknown agent", "ghost", "search", http.StatusForbidden, "agent_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &safeAuditMock{}
+			h, forwarder, wsID := agentToolTestHandler(t, AgentToolsEnforce, audit)
+
+			rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", tt.tool, map[string]interface{}{
+				"arguments": map[string]string{}, "agent_id": tt.agentID, "workspace_id": wsID.String(),
+			})
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantReason == "" {
+				return
 			}
-			if cfg.OpenDuration != tt.wantDur {
-				t.Errorf("open_duration = %v, want %v", cfg.OpenDuration, tt.wantDur)
+			if forwarder.lastReq != nil {
+				t.Error("rejected call should not be forwarded")
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			entries := audit.getEntries()
+			if len(entries) != 1 || entries[0].Action != "gateway_agent_tool_violation" {
+				t.Fatalf("expected one gateway_agent_tool_violation entry, got %+v", entries)
+			}
+			var details map[string]interface{}
+			json.Unmarshal(entries[0].Details, &details)
+			if details["reason"] != tt.wantReason || details["enforced"] != true {
+				t.Errorf("unexpected violation details: %v", details)
+			}
+		})
+	}
+}
+
+func TestGateway_AgentToolAuditOnly(t *testing.T) {
+	audit := &safeAuditMock{}
+	h, forwarder, wsID := agentToolTestHandler(t, AgentToolsAudit, audit)
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "delete", map[string]interface{}{
+		"arguments": map[string]string{}, "agent_id": "researcher", "workspace_id": wsID.String(),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if forwarder.lastReq == nil {
+		t.Error("audit-only violations should be forwarded")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	actions := map[string]int{}
+	for _, e := range audit.getEntries() {
+		actions[e.Action]++
+	}
+	if actions["gateway_agent_tool_violation"] != 1 || actions["gateway_tool_call"] != 1 {
+		t.Errorf("expected a violation and a call audit entry, got %v", actions)
+	}
+}
+
+func TestGateway_AgentToolOff(t *testing.T) {
+	audit := &safeAuditMock{}
+	h, _, wsID := agentToolTestHandler(t, AgentToolsOff, audit)
+
+	for _, body := range []map[string]interface{}{
+		{"arguments": map[string]string{}, "agent_id": "ghost", "workspace_id": wsID.String()},
+		{"arguments": map[string]string{}, "agent_id": "ghost", "workspace_id": uuid.New().String()},
+	} {
+		if rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "delete", body); rr.Code != http.StatusOK {
+			t.Errorf("expected 200 with enforcement off, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	for _, e := range audit.getEntries() {
+		if e.Action == "gateway_agent_tool_violation" {
+			t.Errorf("unexpected violation entry with enforcement off")
+		}
+	}
+}
+
+func TestGateway_PromptMode(t *testing.T) {
+	defaults := &mockTrustDefaults{records: []gateway.TrustDefaultRecord{
+		{ToolPattern: "read_*", Tier: "auto", Priority: 1},
+		{ToolPattern: "write_*", Tier: "review", Priority: 2},
+		{ToolPattern: "*", Tier: "block", Priority: 3},
+	}}
+	tests := []struct {
+		name        string
+		mode        string
+		tool        string
+		wantStatus  int
+		wantCode    string
+		wantOutcome string
+	}{
+		{"readonly auto", "rag_readonly", "read_doc", http.StatusOK, "", "success"},
+		{"readonly review", "rag_readonly", "write_doc", http.StatusForbidden, "FORBIDDEN", "trust_denied"},
+		{"safe review", "toolcalling_safe", "write_doc", http.StatusForbidden, "APPROVAL_REQUIRED", "approval_required"},
+		{"safe block", "toolcalling_safe", "drop_db", http.StatusForbidden, "FORBIDDEN", "trust_denied"},
+		{"auto review", "toolcalling_auto", "write_doc", http.StatusForbidden, "FORBIDDEN", "trust_denied"},
+		{"auto auto", "toolcalling_auto", "read_doc", http.StatusOK, "", "success"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompts := newMockPromptStore()
+			promptID := uuid.New()
+			prompts.prompts[promptID] = &store.Prompt{ID: promptID, AgentID: "researcher", Mode: tt.mode, IsActive: true}
+			prompts.byAgent["researcher"] = []uuid.UUID{promptID}
+			audit := &safeAuditMock{}
+			forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+			h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: enabledMCPServer()}, audit,
+				gateway.NewTrustClassifier(nil, defaults, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+			h.SetPromptModes(prompts)
+
+			rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", tt.tool,
+				map[string]interface{}{"arguments": map[string]string{}, "agent_id": "researcher"})
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				var env struct {
+					Error map[string]string `json:"error"`
+				}
+				json.Unmarshal(rr.Body.Bytes(), &env)
+				if env.Error["code"] != tt.wantCode {
+					t.Errorf("error code = %q, want %q", env.Error["code"], tt.wantCode)
+				}
+				if forwarder.lastReq != nil {
+					t.Error("rejected call should not be forwarded")
+				}
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			entries := audit.getEntries()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 audit entry, got %d", len(entries))
+			}
+			var details map[string]interface{}
+			json.Unmarshal(entries[0].Details, &details)
+			if details["prompt_mode"] != tt.mode || details["outcome"] != tt.wantOutcome {
+				t.Errorf("unexpected audit details: %v", details)
+			}
+		})
+	}
+}
+
+func TestGateway_PromptModeWithoutActivePrompt(t *testing.T) {
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: enabledMCPServer()}, audit,
+		gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetPromptModes(newMockPromptStore())
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search",
+		map[string]interface{}{"arguments": map[string]string{}, "agent_id": "researcher"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	for _, e := range audit.getEntries() {
+		var details map[string]interface{}
+		json.Unmarshal(e.Details, &details)
+		if _, ok := details["prompt_mode"]; ok {
+			t.Errorf("agent without an active prompt should have no prompt_mode, got %v", details)
+		}
+	}
+}
+
+func TestGateway_WorkspaceMembership(t *testing.T) {
+	wsID := uuid.New()
+	memberUser := uuid.New()
+	memberKey := uuid.New()
+	members := newMockWorkspaceMemberStore()
+	members.Add(context.Background(), &store.WorkspaceMember{WorkspaceID: wsID, PrincipalType: store.PrincipalUser, PrincipalID: memberUser})
+	members.Add(context.Background(), &store.WorkspaceMember{WorkspaceID: wsID, PrincipalType: store.PrincipalAPIKey, PrincipalID: memberKey})
+
+	tests := []struct {
+		name       string
+		withCaller func(ctx context.Context) context.Context
+		wantStatus int
+	}{
+		{"member user", func(ctx context.Context) context.Context {
+			return auth.ContextWithUser(ctx, memberUser, "viewer", "session")
+		}, http.StatusOK},
+		{"other user", func(ctx context.Context) context.Context {
+			return auth.ContextWithUser(ctx, uuid.New(), "admin", "session")
+		}, http.StatusForbidden},
+		{"member key", func(ctx context.Context) context.Context {
+			return auth.ContextWithAPIKeyID(auth.ContextWithUser(ctx, uuid.New(), "viewer", "apikey"), memberKey)
+		}, http.StatusOK},
+		{"member user with other key", func(ctx context.Context) context.Context {
+			return auth.ContextWithAPIKeyID(auth.ContextWithUser(ctx, memberUser, "viewer", "apikey"), uuid.New())
+		}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+			h := newTestGatewayHandler(&mockGatewayServerStore{server: enabledMCPServer()}, gateway.NewTrustClassifier(nil, nil, nil),
+				gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+			h.SetWorkspaceMembers(members)
+
+			body := []byte(`{"arguments":{},"workspace_id":"` + wsID.String() + `"}`)
+			req := httptest.NewRequest(http.MethodPost, "/mcp/v1/proxy/test-server/tools/search", bytes.NewReader(body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("serverLabel", "test-server")
+			rctx.URLParams.Add("toolName", "search")
+			req = req.WithContext(tt.withCaller(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+			rr := httptest.NewRecorder()
+			h.ProxyToolCall(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK && forwarder.lastReq != nil {
+				t.Error("rejected call should not be forwarded")
+			}
+		})
+	}
+}
+
+func TestGateway_InvalidWorkspaceID(t *testing.T) {
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandler(&mockGatewayServerStore{server: enabledMCPServer()}, gateway.NewTrustClassifier(nil, nil, nil),
+		gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search",
+		map[string]interface{}{"arguments": map[string]string{}, "workspace_id": "not-a-uuid"})
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if forwarder.lastReq != nil {
+		t.Error("call with an invalid workspace should not be forwarded")
+	}
+}
+
+func TestGateway_WorkspaceRequired(t *testing.T) {
+	srv := enabledMCPServer()
+	srv.WorkspaceRequired = true
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit, gateway.NewTrustClassifier(nil, nil, nil),
+		gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", nil)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["outcome"] != "workspace_denied" {
+		t.Errorf("outcome = %v, want workspace_denied", details["outcome"])
+	}
+
+	rr = makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search",
+		map[string]interface{}{"arguments": map[string]string{}, "workspace_id": uuid.New().String()})
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 with a workspace, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func sandboxTestHandler(t *testing.T, sandbox string, audit *safeAuditMock) (*MCPGatewayHandler, *mockProxyForwarder, *mockRecordingStore, *store.MCPServer, uuid.UUID) {
+	t.Helper()
+	wsID := uuid.New()
+	settings := newMockWorkspaceSettingsStore()
+	settings.settings[wsID] = &store.WorkspaceSettings{
+		WorkspaceID: wsID, AgentToolEnforcement: AgentToolsOff, Sandbox: json.RawMessage(sandbox),
+	}
+	srv := enabledMCPServer()
+	recordings := &mockRecordingStore{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{"result":{"n":1}}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: srv}, audit,
+		gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+	h.SetSandbox(recordings, settings)
+	return h, forwarder, recordings, srv, wsID
+}
+
+func TestGateway_SandboxRecordThenReplay(t *testing.T) {
+	audit := &safeAuditMock{}
+	h, forwarder, recordings, srv, wsID := sandboxTestHandler(t, `{"test-server":{"mode":"record"}}`, audit)
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", map[string]interface{}{
+		"arguments": map[string]interface{}{"q": "go", "limit": 5}, "workspace_id": wsID.String(),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("record: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Gateway-Sandbox") != "record" {
+		t.Errorf("X-Gateway-Sandbox = %q, want record", rr.Header().Get("X-Gateway-Sandbox"))
+	}
+	recs := recordings.all()
+	if len(recs) != 1 || recs[0].ServerID != srv.ID || recs[0].ToolName != "search" || recs[0].StatusCode != 200 {
+		t.Fatalf("unexpected recordings: %+v", recs)
+	}
+
+	// Replay from another workspace; argument order and whitespace differ.
+	replayWS := uuid.New()
+	h.settings.(*mockWorkspaceSettingsStore).settings[replayWS] = &store.WorkspaceSettings{
+		WorkspaceID: replayWS, Sandbox: json.RawMessage(`{"*":{"mode":"replay"}}`),
+	}
+	forwarder.lastReq = nil
+	rr = makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", map[string]interface{}{
+		"arguments": json.RawMessage(`{ "limit": 5, "q": "go" }`), "workspace_id": replayWS.String(),
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("replay: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if forwarder.lastReq != nil {
+		t.Error("replayed call should not reach the upstream")
+	}
+	data := parseEnvelope(t, rr).Data.(map[string]interface{})
+	if data["replayed"] != true {
+		t.Errorf("replayed = %v, want true", data["replayed"])
+	}
+	if body, _ := json.Marshal(data["body"]); string(body) != `{"result":{"n":1}}` {
+		t.Errorf("body = %s, want the recorded response", body)
+	}
+}
+
+func TestGateway_SandboxReplayMiss(t *testing.T) {
+	tests := []struct {
+		name       string
+		sandbox    string
+		wantStatus int
+	}{
+		{"error policy", `{"test-server":{"mode":"replay"}}`, http.StatusNotFound},
+		{"stub policy", `{"test-server":{"mode":"replay","miss_policy":"stub"}}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &safeAuditMock{}
+			h, forwarder, _, _, wsID := sandboxTestHandler(t, tt.sandbox, audit)
+
+			rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", map[string]interface{}{
+				"arguments": map[string]string{"q": "unseen"}, "workspace_id": wsID.String(),
+			})
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if forwarder.lastReq != nil {
+				t.Error("replay miss should not reach the upstream")
+			}
+			if tt.wantStatus == http.StatusNotFound && !strings.Contains(rr.Body.String(), "RECORDING_NOT_FOUND") {
+				t.Errorf("expected RECORDING_NOT_FOUND, got %s", rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && !strings.Contains(rr.Body.String(), `"isError":true`) {
+				t.Errorf("expected a stub error result, got %s", rr.Body.String())
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			entries := audit.getEntries()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 audit entry, got %d", len(entries))
 			}
+			var details map[string]interface{}
+			json.Unmarshal(entries[0].Details, &details)
+			if details["outcome"] != "replay_miss" {
+				t.Errorf("outcome = %v, want replay_miss", details["outcome"])
+			}
+		})
+	}
+}
+
+func TestGateway_SandboxPerWorkspace(t *testing.T) {
+	h, forwarder, recordings, _, sandboxedWS := sandboxTestHandler(t, `{"other-server":{"mode":"replay"}}`, &safeAuditMock{})
+
+	// Neither a workspace without settings nor a workspace sandboxing other
+	// servers is affected.
+	for _, wsID := range []uuid.UUID{uuid.New(), sandboxedWS} {
+		forwarder.lastReq = nil
+		rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", map[string]interface{}{
+			"arguments": map[string]string{}, "workspace_id": wsID.String(),
 		})
+		if rr.Code != http.StatusOK || forwarder.lastReq == nil {
+			t.Fatalf("live call: got %d, forwarded %v", rr.Code, forwarder.lastReq != nil)
+		}
+		if rr.Header().Get("X-Gateway-Sandbox") != "" {
+			t.Errorf("live call should not set X-Gateway-Sandbox")
+		}
+	}
+	if len(recordings.all()) != 0 {
+		t.Error("live calls should not be recorded")
+	}
+}
+
+func TestGateway_PropagatesTraceContext(t *testing.T) {
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: enabledMCPServer()}, audit,
+		gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp/v1/proxy/test-server/tools/search", strings.NewReader(`{"arguments":{}}`))
+	req.Header.Set("traceparent", parent)
+	req.Header.Set("tracestate", "vendor=abc")
+	req.Header.Set("X-Request-Id", "req-123")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("serverLabel", "test-server")
+	rctx.URLParams.Add("toolName", "search")
+	ctx := auth.ContextWithUser(context.WithValue(req.Context(), chi.RouteCtxKey, rctx), uuid.New(), "admin", "session")
+	rr := httptest.NewRecorder()
+	h.ProxyToolCall(rr, req.WithContext(ctx))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	trace := forwarder.lastReq.Trace
+	if trace == nil || trace.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" || trace.TraceParent == parent ||
+		trace.TraceState != "vendor=abc" || trace.RequestID != "req-123" {
+		t.Fatalf("forwarded trace = %+v, want the caller's trace with a new parent ID", trace)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace_id = %v, want the caller's trace ID", details["trace_id"])
+	}
+}
+
+func TestGateway_StartsTraceWithoutTraceparent(t *testing.T) {
+	audit := &safeAuditMock{}
+	forwarder := &mockProxyForwarder{resp: &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}}
+	h := newTestGatewayHandlerWithAudit(&mockGatewayServerStore{server: enabledMCPServer()}, audit,
+		gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), forwarder, ratelimit.NewRateLimiter())
+
+	rr := makeGatewayRequest(t, h.ProxyToolCall, "test-server", "search", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	traceID := forwarder.lastReq.Trace.TraceID()
+	if traceID == "" {
+		t.Fatal("expected a new trace to be started")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	entries := audit.getEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	var details map[string]interface{}
+	json.Unmarshal(entries[0].Details, &details)
+	if details["trace_id"] != traceID {
+		t.Errorf("audited trace_id = %v, want forwarded trace %s", details["trace_id"], traceID)
 	}
 }
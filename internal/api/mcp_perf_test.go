@@ -112,6 +112,9 @@ func (s *perfMockPromptStore) Activate(_ context.Context, _ uuid.UUID) (*store.P
 func (s *perfMockPromptStore) Rollback(_ context.Context, _ string, _ int, _ string) (*store.Prompt, error) {
 	panic("unused")
 }
+func (s *perfMockPromptStore) GetByVersion(_ context.Context, _ string, _ int) (*store.Prompt, error) {
+	panic("unused")
+}
 
 type perfMockMCPServerStore struct{}
 
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// MCPRecordingStoreForAPI is the interface the recordings handler needs from
+// the store.
+type MCPRecordingStoreForAPI interface {
+	List(ctx context.Context, serverID uuid.UUID, toolName string, offset, limit int) ([]store.MCPRecording, int, error)
+	Delete(ctx context.Context, serverID uuid.UUID, toolName string) (int64, error)
+}
+
+// RecordingsHandler provides HTTP handlers to inspect and clear the sandbox
+// recordings of an MCP server.
+type RecordingsHandler struct {
+	servers    MCPServerLookup
+	recordings MCPRecordingStoreForAPI
+	audit      AuditStoreForAPI
+}
+
+// NewRecordingsHandler creates a new RecordingsHandler.
+func NewRecordingsHandler(servers MCPServerLookup, recordings MCPRecordingStoreForAPI, audit AuditStoreForAPI) *RecordingsHandler {
+	return &RecordingsHandler{
+		servers:    servers,
+		recordings: recordings,
+		audit:      audit,
+	}
+}
+
+func (h *RecordingsHandler) server(w http.ResponseWriter, r *http.Request) (*store.MCPServer, bool) {
+	serverID, err := uuid.Parse(chi.URLParam(r, "serverId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid server ID"))
+		return nil, false
+	}
+	server, err := h.servers.GetByID(r.Context(), serverID)
+	if err != nil {
+		RespondError(w, r, apierrors.NotFound("mcp_server", serverID.String()))
+		return nil, false
+	}
+	return server, true
+}
+
+// List handles GET /api/v1/mcp-servers/{serverId}/recordings. The optional
+// tool query parameter limits the list to one tool.
+func (h *RecordingsHandler) List(w http.ResponseWriter, r *http.Request) {
+	server, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	recs, total, err := h.recordings.List(r.Context(), server.ID, r.URL.Query().Get("tool"), offset, limit)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to list recordings"))
+		return
+	}
+	if recs == nil {
+		recs = []store.MCPRecording{}
+	}
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"recordings": recs,
+		"total":      total,
+	})
+}
+
+// Delete handles DELETE /api/v1/mcp-servers/{serverId}/recordings. The
+// optional tool query parameter limits the deletion to one tool.
+func (h *RecordingsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	server, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+
+	tool := r.URL.Query().Get("tool")
+	deleted, err := h.recordings.Delete(r.Context(), server.ID, tool)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to delete recordings"))
+		return
+	}
+
+	resourceID := server.ID.String()
+	if tool != "" {
+		resourceID += "/" + tool
+	}
+	h.auditLog(r, "mcp_recordings_delete", "mcp_server", resourceID)
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"deleted": deleted,
+	})
+}
+
+func (h *RecordingsHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
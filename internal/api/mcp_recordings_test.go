@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// mockRecordingStore is safe for concurrent use.
+type mockRecordingStore struct {
+	mu   sync.Mutex
+	recs []store.MCPRecording
+}
+
+func (m *mockRecordingStore) Get(_ context.Context, serverID uuid.UUID, toolName, argsHash string) (*store.MCPRecording, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rec := range m.recs {
+		if rec.ServerID == serverID && rec.ToolName == toolName && rec.ArgsHash == argsHash {
+			copied := rec
+			return &copied, nil
+		}
+	}
+	return nil, apierrors.NotFound("recording", toolName)
+}
+
+func (m *mockRecordingStore) Upsert(_ context.Context, rec *store.MCPRecording) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec.RecordedAt = time.Now()
+	for i, existing := range m.recs {
+		if existing.ServerID == rec.ServerID && existing.ToolName == rec.ToolName && existing.ArgsHash == rec.ArgsHash {
+			rec.ID = existing.ID
+			m.recs[i] = *rec
+			return nil
+		}
+	}
+	rec.ID = uuid.New()
+	m.recs = append(m.recs, *rec)
+	return nil
+}
+
+func (m *mockRecordingStore) List(_ context.Context, serverID uuid.UUID, toolName string, offset, limit int) ([]store.MCPRecording, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var matched []store.MCPRecording
+	for _, rec := range m.recs {
+		if rec.ServerID == serverID && (toolName == "" || rec.ToolName == toolName) {
+			matched = append(matched, rec)
+		}
+	}
+	total := len(matched)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+func (m *mockRecordingStore) Delete(_ context.Context, serverID uuid.UUID, toolName string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []store.MCPRecording
+	for _, rec := range m.recs {
+		if rec.ServerID != serverID || (toolName != "" && rec.ToolName != toolName) {
+			kept = append(kept, rec)
+		}
+	}
+	deleted := int64(len(m.recs) - len(kept))
+	m.recs = kept
+	return deleted, nil
+}
+
+func (m *mockRecordingStore) all() []store.MCPRecording {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]store.MCPRecording(nil), m.recs...)
+}
+
+func newTestRecordingsHandler(t *testing.T) (*RecordingsHandler, *mockRecordingStore, *store.MCPServer, *mockAuditStoreForAPI) {
+	t.Helper()
+	servers := newMockMCPServerStore()
+	server := enabledMCPServer()
+	if err := servers.Create(context.Background(), server); err != nil {
+		t.Fatalf("create server: %v", err)
+	}
+	recordings := &mockRecordingStore{}
+	for _, tool := range []string{"search", "search", "fetch"} {
+		recordings.Upsert(context.Background(), &store.MCPRecording{
+			ServerID: server.ID, ToolName: tool, ArgsHash: uuid.NewString(),
+			StatusCode: 200, Response: json.RawMessage(`{}`),
+		})
+	}
+	audit := &mockAuditStoreForAPI{}
+	return NewRecordingsHandler(servers, recordings, audit), recordings, server, audit
+}
+
+func TestRecordingsHandler_List(t *testing.T) {
+	h, _, server, _ := newTestRecordingsHandler(t)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCount int
+		wantTotal int
+	}{
+		{"all tools", "", 3, 3},
+		{"one tool", "?tool=search", 2, 2},
+		{"paginated", "?limit=1&offset=1", 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.List(w, toolSchemaRequest(http.MethodGet, "/api/v1/mcp-servers/x/recordings"+tt.query, nil, server.ID, ""))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			data := parseEnvelope(t, w).Data.(map[string]interface{})
+			if got := len(data["recordings"].([]interface{})); got != tt.wantCount {
+				t.Errorf("recordings = %d, want %d", got, tt.wantCount)
+			}
+			if data["total"] != float64(tt.wantTotal) {
+				t.Errorf("total = %v, want %d", data["total"], tt.wantTotal)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	h.List(w, toolSchemaRequest(http.MethodGet, "/api/v1/mcp-servers/x/recordings", nil, uuid.New(), ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown server: expected 404, got %d", w.Code)
+	}
+}
+
+func TestRecordingsHandler_Delete(t *testing.T) {
+	h, recordings, server, audit := newTestRecordingsHandler(t)
+
+	w := httptest.NewRecorder()
+	h.Delete(w, toolSchemaRequest(http.MethodDelete, "/api/v1/mcp-servers/x/recordings?tool=search", nil, server.ID, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	if data["deleted"] != float64(2) {
+		t.Errorf("deleted = %v, want 2", data["deleted"])
+	}
+	if remaining := recordings.all(); len(remaining) != 1 || remaining[0].ToolName != "fetch" {
+		t.Errorf("remaining recordings = %+v, want only fetch", remaining)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != "mcp_recordings_delete" ||
+		audit.entries[0].ResourceID != server.ID.String()+"/search" {
+		t.Errorf("unexpected audit entries: %+v", audit.entries)
+	}
+}
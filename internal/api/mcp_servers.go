@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/textproto"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -14,6 +18,7 @@ import (
 
 	"github.com/agent-smit/agentic-registry/internal/auth"
 	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
 	"github.com/agent-smit/agentic-registry/internal/notify"
 	"github.com/agent-smit/agentic-registry/internal/store"
 )
@@ -34,6 +39,9 @@ type MCPServersHandler struct {
 	audit      AuditStoreForAPI
 	encKey     []byte
 	dispatcher notify.EventDispatcher
+	egress     *gateway.EgressGuard
+	cache      *gateway.ResponseCache
+	stdio      *gateway.StdioSupervisor
 }
 
 // NewMCPServersHandler creates a new MCPServersHandler.
@@ -46,19 +54,408 @@ func NewMCPServersHandler(servers MCPServerStoreForAPI, audit AuditStoreForAPI,
 	}
 }
 
+// SetEgressGuard enables resolving endpoints at create/update time and checking
+// them against the global egress rules and the server's allowlist. Without a
+// guard, endpoints must be public hosts.
+func (h *MCPServersHandler) SetEgressGuard(g *gateway.EgressGuard) {
+	h.egress = g
+}
+
+// SetResponseCache enables the cache purge endpoint and drops a server's
+// cached responses whenever it is updated or deleted.
+func (h *MCPServersHandler) SetResponseCache(c *gateway.ResponseCache) {
+	h.cache = c
+}
+
+// SetStdioSupervisor allows servers with transport stdio and stops a
+// server's process when it is renamed, disabled or deleted.
+func (h *MCPServersHandler) SetStdioSupervisor(s *gateway.StdioSupervisor) {
+	h.stdio = s
+}
+
+const (
+	transportHTTP  = "http"
+	transportStdio = "stdio"
+)
+
+// validateStdioConfig checks the command launched for a stdio server. PATH is
+// provided by the gateway and cannot be overridden.
+func validateStdioConfig(c *gateway.StdioConfig) error {
+	if c.Command == "" {
+		return apierrors.Validation("stdio.command is required")
+	}
+	if len(c.Command) > 1024 || strings.ContainsRune(c.Command, 0) || !filepath.IsAbs(c.Command) {
+		return apierrors.Validation("stdio.command must be an absolute path of at most 1024 characters")
+	}
+	if len(c.Args) > 50 {
+		return apierrors.Validation("stdio.args must contain at most 50 entries")
+	}
+	for _, arg := range c.Args {
+		if len(arg) > 4096 || strings.ContainsRune(arg, 0) {
+			return apierrors.Validation("stdio.args entries must be at most 4096 characters and contain no NUL bytes")
+		}
+	}
+	if len(c.Env) > 50 {
+		return apierrors.Validation("stdio.env must contain at most 50 entries")
+	}
+	for name, value := range c.Env {
+		if !isEnvName(name) {
+			return apierrors.Validation("stdio.env contains invalid variable name: " + name)
+		}
+		if name == "PATH" {
+			return apierrors.Validation("stdio.env cannot set PATH")
+		}
+		if len(value) > 4096 || strings.ContainsRune(value, 0) {
+			return apierrors.Validation("stdio.env value for " + name + " must be at most 4096 characters and contain no NUL bytes")
+		}
+	}
+	return nil
+}
+
+// isEnvName reports whether name is a portable environment variable name.
+func isEnvName(name string) bool {
+	if name == "" || len(name) > 128 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// stdioOnlyFieldsError rejects HTTP transport settings on a stdio server.
+func stdioOnlyFieldsError(fields map[string]bool) error {
+	for _, name := range []string{"endpoint", "endpoints", "health_endpoint", "tls", "custom_headers", "egress_policy", "auth_credential", "oauth2"} {
+		if fields[name] {
+			return apierrors.Validation(name + " is not used with transport stdio")
+		}
+	}
+	return nil
+}
+
+const authTypeOAuth2ClientCredentials = "oauth2_client_credentials"
+
 var validAuthTypes = map[string]bool{
-	"none":   true,
-	"bearer": true,
-	"basic":  true,
+	"none":                          true,
+	"bearer":                        true,
+	"basic":                         true,
+	authTypeOAuth2ClientCredentials: true,
+}
+
+const authTypeValidationMessage = "auth_type must be one of: none, bearer, basic, oauth2_client_credentials"
+
+// validateOAuth2Credentials checks the client-credentials settings for an MCP
+// server. The token endpoint is checked like the server's endpoints, under
+// its egress allowlist.
+func (h *MCPServersHandler) validateOAuth2Credentials(ctx context.Context, c *gateway.OAuth2ClientCredentials, policy *gateway.EgressPolicy) error {
+	if c.ClientID == "" {
+		return apierrors.Validation("oauth2.client_id is required")
+	}
+	if c.ClientSecret == "" {
+		return apierrors.Validation("oauth2.client_secret is required")
+	}
+	if c.TokenURL == "" {
+		return apierrors.Validation("oauth2.token_url is required")
+	}
+	if err := h.validateServerEndpoint(ctx, "oauth2.token_url", c.TokenURL, policy); err != nil {
+		return err
+	}
+	if len(c.Scopes) > 50 {
+		return apierrors.Validation("oauth2.scopes must contain at most 50 entries")
+	}
+	for _, scope := range c.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\r\n") {
+			return apierrors.Validation("oauth2.scopes entries must be non-empty and contain no whitespace")
+		}
+	}
+	return nil
+}
+
+// validateEgressPolicy checks the per-server egress allowlist.
+func validateEgressPolicy(p *gateway.EgressPolicy) error {
+	if len(p.AllowCIDRs) > 50 || len(p.AllowHosts) > 50 {
+		return apierrors.Validation("egress_policy allows at most 50 CIDRs and 50 hosts")
+	}
+	if err := p.Validate(); err != nil {
+		return apierrors.Validation("egress_policy: " + err.Error())
+	}
+	return nil
+}
+
+// parseEgressPolicy decodes a stored egress policy. It returns nil when the
+// server has no allowlist.
+func parseEgressPolicy(raw json.RawMessage) (*gateway.EgressPolicy, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var p gateway.EgressPolicy
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	if p.IsZero() {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+var validLoadBalancing = map[string]bool{
+	gateway.BalanceRoundRobin:   true,
+	gateway.BalanceLeastLatency: true,
+}
+
+// parseEndpointPool decodes a stored endpoint pool. It returns nil when the
+// server uses its single endpoint.
+func parseEndpointPool(raw json.RawMessage) ([]gateway.Endpoint, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var pool []gateway.Endpoint
+	if err := json.Unmarshal(raw, &pool); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// validateEndpointPool checks every URL in an endpoint pool against the same
+// rules as the primary endpoint. Omitted weights default to 1.
+func (h *MCPServersHandler) validateEndpointPool(ctx context.Context, pool []gateway.Endpoint, policy *gateway.EgressPolicy) error {
+	if len(pool) > 20 {
+		return apierrors.Validation("endpoints must contain at most 20 entries")
+	}
+	seen := make(map[string]bool, len(pool))
+	for i := range pool {
+		e := &pool[i]
+		field := fmt.Sprintf("endpoints[%d]", i)
+		if e.URL == "" {
+			return apierrors.Validation(field + ".url is required")
+		}
+		if seen[e.URL] {
+			return apierrors.Validation("endpoints must not contain duplicate urls")
+		}
+		seen[e.URL] = true
+		if e.Weight == 0 {
+			e.Weight = 1
+		}
+		if e.Weight < 1 || e.Weight > 100 {
+			return apierrors.Validation(field + ".weight must be between 1 and 100")
+		}
+		if err := h.validateServerEndpoint(ctx, field, e.URL, policy); err != nil {
+			return err
+		}
+		if e.HealthEndpoint != "" {
+			if err := h.validateServerEndpoint(ctx, field+".health_endpoint", e.HealthEndpoint, policy); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// poolContains reports whether url is one of the pool's endpoints.
+func poolContains(pool []gateway.Endpoint, url string) bool {
+	for _, e := range pool {
+		if e.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// validateServerEndpoint checks an endpoint of an MCP server. With an egress
+// guard configured the host is resolved and every address must be permitted
+// by the global rules or the server's allowlist; otherwise the endpoint must
+// be a public host.
+func (h *MCPServersHandler) validateServerEndpoint(ctx context.Context, field, endpoint string, policy *gateway.EgressPolicy) error {
+	prefix := ""
+	if field != "endpoint" {
+		prefix = field + ": "
+	}
+	if h.egress == nil {
+		if err := validateEndpointURL(endpoint); err != nil {
+			return apierrors.Validation(prefix + err.Error())
+		}
+		return nil
+	}
+	u, err := parseEndpointURL(endpoint)
+	if err != nil {
+		return apierrors.Validation(prefix + err.Error())
+	}
+	host := u.Hostname()
+	if looksLikeNonCanonicalIP(host) {
+		return apierrors.Validation(prefix + "endpoint must not point to a private or internal address")
+	}
+	if _, err := h.egress.Resolve(ctx, host, policy); err != nil {
+		if errors.Is(err, gateway.ErrEgressDenied) {
+			return apierrors.Validation(prefix + "endpoint is not permitted by egress policy (" + err.Error() + ")")
+		}
+		return apierrors.Validation(prefix + "endpoint host could not be resolved")
+	}
+	return nil
+}
+
+// validateUpstreamTLS checks that the PEM material parses and that the client
+// certificate and key belong together.
+func validateUpstreamTLS(c *gateway.TLSConfig) error {
+	for field, pem := range map[string]string{"client_cert": c.ClientCert, "client_key": c.ClientKey, "ca_bundle": c.CABundle} {
+		if len(pem) > 64*1024 {
+			return apierrors.Validation("tls." + field + " exceeds maximum size of 64KB")
+		}
+	}
+	if _, err := gateway.BuildTLSClientConfig(*c); err != nil {
+		return apierrors.Validation("tls: " + strings.TrimPrefix(err.Error(), gateway.ErrInvalidTLSConfig.Error()+": "))
+	}
+	return nil
+}
+
+// reservedUpstreamHeaders are set by the gateway itself or by the transport and
+// cannot be overridden through custom_headers. Authorization is configured via auth_type.
+var reservedUpstreamHeaders = map[string]bool{
+	"Authorization":       true,
+	"Connection":          true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Host":                true,
+	"Keep-Alive":          true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// validateCustomHeaders checks header names and values sent to an upstream server.
+func validateCustomHeaders(headers map[string]string) error {
+	if len(headers) > 20 {
+		return apierrors.Validation("custom_headers must contain at most 20 entries")
+	}
+	for name, value := range headers {
+		if !isHeaderToken(name) {
+			return apierrors.Validation("custom_headers contains invalid header name: " + name)
+		}
+		if reservedUpstreamHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return apierrors.Validation("custom_headers cannot set reserved header: " + name)
+		}
+		if len(value) > 4096 {
+			return apierrors.Validation("custom_headers value for " + name + " exceeds maximum length of 4096")
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return apierrors.Validation("custom_headers value for " + name + " contains invalid characters")
+		}
+	}
+	return nil
+}
+
+// isHeaderToken reports whether name is a valid RFC 7230 header field name.
+func isHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// encryptSecret encrypts plaintext with the credential key and base64-encodes it
+// for storage. Empty input stays empty.
+func (h *MCPServersHandler) encryptSecret(plaintext string) (string, error) {
+	if plaintext == "" || len(h.encKey) != 32 {
+		return "", nil
+	}
+	encrypted, err := auth.Encrypt([]byte(plaintext), h.encKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// setUpstreamTLS encrypts the TLS material onto server. A zero config clears it.
+func (h *MCPServersHandler) setUpstreamTLS(server *store.MCPServer, c *gateway.TLSConfig) error {
+	var err error
+	if server.TLSClientCert, err = h.encryptSecret(c.ClientCert); err != nil {
+		return err
+	}
+	if server.TLSClientKey, err = h.encryptSecret(c.ClientKey); err != nil {
+		return err
+	}
+	server.TLSCABundle, err = h.encryptSecret(c.CABundle)
+	return err
+}
+
+// setStdioConfig stores the command and arguments of a stdio server and
+// encrypts its environment onto server.
+func (h *MCPServersHandler) setStdioConfig(server *store.MCPServer, c *gateway.StdioConfig) error {
+	args := c.Args
+	if args == nil {
+		args = []string{}
+	}
+	server.StdioCommand = c.Command
+	server.StdioArgs, _ = json.Marshal(args)
+	if len(c.Env) == 0 {
+		server.StdioEnv = ""
+		return nil
+	}
+	plain, err := json.Marshal(c.Env)
+	if err != nil {
+		return err
+	}
+	server.StdioEnv, err = h.encryptSecret(string(plain))
+	return err
+}
+
+// setCustomHeaders encrypts the header map onto server. An empty map clears it.
+func (h *MCPServersHandler) setCustomHeaders(server *store.MCPServer, headers map[string]string) error {
+	if len(headers) == 0 {
+		server.CustomHeaders = ""
+		return nil
+	}
+	plain, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	server.CustomHeaders, err = h.encryptSecret(string(plain))
+	return err
 }
 
 // circuitBreakerSchema is used to validate the circuit_breaker JSON field.
 type circuitBreakerSchema struct {
-	FailThreshold float64 `json:"fail_threshold"`
-	OpenDurationS float64 `json:"open_duration_s"`
+	FailThreshold     float64  `json:"fail_threshold"`
+	OpenDurationS     float64  `json:"open_duration_s"`
+	Mode              string   `json:"mode"`
+	WindowS           *float64 `json:"window_s"`
+	MinRequests       *float64 `json:"min_requests"`
+	FailureRatePct    *float64 `json:"failure_rate_pct"`
+	LatencyP95MS      *float64 `json:"latency_p95_ms"`
+	HalfOpenProbes    *float64 `json:"half_open_probes"`
+	ProbeSuccessRatio *float64 `json:"probe_success_ratio"`
+}
+
+// circuitBreakerFields are the known circuit_breaker fields.
+var circuitBreakerFields = map[string]bool{
+	"fail_threshold": true, "open_duration_s": true, "mode": true,
+	"window_s": true, "min_requests": true, "failure_rate_pct": true, "latency_p95_ms": true,
+	"half_open_probes": true, "probe_success_ratio": true,
+}
+
+// isIntBetween reports whether v is an integer in [min, max].
+func isIntBetween(v, min, max float64) bool {
+	return v >= min && v <= max && v == float64(int(v))
 }
 
 // validateCircuitBreaker checks that circuit_breaker has valid schema and size.
+// The default "consecutive" mode requires fail_threshold; "window" mode
+// requires window_s, min_requests and at least one of failure_rate_pct and
+// latency_p95_ms.
 func validateCircuitBreaker(raw json.RawMessage) error {
 	if len(raw) > 1024 {
 		return apierrors.Validation("circuit_breaker exceeds maximum size of 1KB")
@@ -69,25 +466,251 @@ func validateCircuitBreaker(raw json.RawMessage) error {
 	}
 	// Only allow known fields
 	for key := range fields {
-		if key != "fail_threshold" && key != "open_duration_s" {
+		if !circuitBreakerFields[key] {
 			return apierrors.Validation("circuit_breaker contains unknown field: " + key)
 		}
 	}
-	if _, ok := fields["fail_threshold"]; !ok {
-		return apierrors.Validation("circuit_breaker must contain fail_threshold")
+	var cb circuitBreakerSchema
+	if err := json.Unmarshal(raw, &cb); err != nil {
+		return apierrors.Validation("circuit_breaker fields must be numeric")
 	}
 	if _, ok := fields["open_duration_s"]; !ok {
 		return apierrors.Validation("circuit_breaker must contain open_duration_s")
 	}
-	var cb circuitBreakerSchema
-	if err := json.Unmarshal(raw, &cb); err != nil {
-		return apierrors.Validation("circuit_breaker fields must be numeric")
+	if !isIntBetween(cb.OpenDurationS, 1, 3600) {
+		return apierrors.Validation("circuit_breaker open_duration_s must be an integer between 1 and 3600")
+	}
+
+	switch cb.Mode {
+	case "", "consecutive":
+		if _, ok := fields["fail_threshold"]; !ok {
+			return apierrors.Validation("circuit_breaker must contain fail_threshold")
+		}
+		for _, key := range []string{"window_s", "min_requests", "failure_rate_pct", "latency_p95_ms"} {
+			if _, ok := fields[key]; ok {
+				return apierrors.Validation("circuit_breaker " + key + " requires mode \"window\"")
+			}
+		}
+	case "window":
+		if cb.WindowS == nil || !isIntBetween(*cb.WindowS, 1, 3600) {
+			return apierrors.Validation("circuit_breaker window_s must be an integer between 1 and 3600")
+		}
+		if cb.MinRequests == nil || !isIntBetween(*cb.MinRequests, 1, 10000) {
+			return apierrors.Validation("circuit_breaker min_requests must be an integer between 1 and 10000")
+		}
+		if cb.FailureRatePct == nil && cb.LatencyP95MS == nil {
+			return apierrors.Validation("circuit_breaker window mode requires failure_rate_pct or latency_p95_ms")
+		}
+		if cb.FailureRatePct != nil && (*cb.FailureRatePct <= 0 || *cb.FailureRatePct > 100) {
+			return apierrors.Validation("circuit_breaker failure_rate_pct must be greater than 0 and at most 100")
+		}
+		if cb.LatencyP95MS != nil && !isIntBetween(*cb.LatencyP95MS, 1, 600000) {
+			return apierrors.Validation("circuit_breaker latency_p95_ms must be an integer between 1 and 600000")
+		}
+	default:
+		return apierrors.Validation("circuit_breaker mode must be \"consecutive\" or \"window\"")
 	}
-	if cb.FailThreshold < 1 || cb.FailThreshold > 100 || cb.FailThreshold != float64(int(cb.FailThreshold)) {
+	if _, ok := fields["fail_threshold"]; ok && !isIntBetween(cb.FailThreshold, 1, 100) {
 		return apierrors.Validation("circuit_breaker fail_threshold must be an integer between 1 and 100")
 	}
-	if cb.OpenDurationS < 1 || cb.OpenDurationS > 3600 || cb.OpenDurationS != float64(int(cb.OpenDurationS)) {
-		return apierrors.Validation("circuit_breaker open_duration_s must be an integer between 1 and 3600")
+
+	if cb.HalfOpenProbes != nil && !isIntBetween(*cb.HalfOpenProbes, 1, 100) {
+		return apierrors.Validation("circuit_breaker half_open_probes must be an integer between 1 and 100")
+	}
+	if cb.ProbeSuccessRatio != nil && (*cb.ProbeSuccessRatio <= 0 || *cb.ProbeSuccessRatio > 1) {
+		return apierrors.Validation("circuit_breaker probe_success_ratio must be greater than 0 and at most 1")
+	}
+	return nil
+}
+
+// retryPolicySchema is used to validate the retry_policy JSON field.
+type retryPolicySchema struct {
+	MaxAttempts       *int     `json:"max_attempts"`
+	InitialBackoffMS  *int     `json:"initial_backoff_ms"`
+	MaxBackoffMS      *int     `json:"max_backoff_ms"`
+	RetryableStatuses []int    `json:"retryable_statuses"`
+	IdempotentTools   []string `json:"idempotent_tools"`
+	HedgeTools        []string `json:"hedge_tools"`
+	HedgeDelayMS      *int     `json:"hedge_delay_ms"`
+}
+
+// validateRetryPolicy checks that retry_policy has valid schema and size.
+func validateRetryPolicy(raw json.RawMessage) error {
+	if len(raw) > 4096 {
+		return apierrors.Validation("retry_policy exceeds maximum size of 4KB")
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	var rp retryPolicySchema
+	if err := dec.Decode(&rp); err != nil {
+		return apierrors.Validation("retry_policy must be a JSON object with known fields: " + err.Error())
+	}
+	if rp.MaxAttempts != nil && (*rp.MaxAttempts < 1 || *rp.MaxAttempts > 5) {
+		return apierrors.Validation("retry_policy max_attempts must be between 1 and 5")
+	}
+	if rp.InitialBackoffMS != nil && (*rp.InitialBackoffMS < 1 || *rp.InitialBackoffMS > 10000) {
+		return apierrors.Validation("retry_policy initial_backoff_ms must be between 1 and 10000")
+	}
+	if rp.MaxBackoffMS != nil && (*rp.MaxBackoffMS < 1 || *rp.MaxBackoffMS > 60000) {
+		return apierrors.Validation("retry_policy max_backoff_ms must be between 1 and 60000")
+	}
+	if rp.InitialBackoffMS != nil && rp.MaxBackoffMS != nil && *rp.MaxBackoffMS < *rp.InitialBackoffMS {
+		return apierrors.Validation("retry_policy max_backoff_ms must not be less than initial_backoff_ms")
+	}
+	for _, status := range rp.RetryableStatuses {
+		if status != http.StatusRequestTimeout && status != http.StatusTooManyRequests && (status < 500 || status > 599) {
+			return apierrors.Validation("retry_policy retryable_statuses may only contain 408, 429 and 5xx codes")
+		}
+	}
+	for _, patterns := range [][]string{rp.IdempotentTools, rp.HedgeTools} {
+		if len(patterns) > 50 {
+			return apierrors.Validation("retry_policy tool lists must contain at most 50 patterns")
+		}
+		for _, p := range patterns {
+			if err := validateToolPattern(p); err != nil {
+				return apierrors.Validation("retry_policy: " + err.Error())
+			}
+		}
+	}
+	if rp.HedgeDelayMS != nil && (*rp.HedgeDelayMS < 10 || *rp.HedgeDelayMS > 10000) {
+		return apierrors.Validation("retry_policy hedge_delay_ms must be between 10 and 10000")
+	}
+	if len(rp.HedgeTools) > 0 && rp.HedgeDelayMS == nil {
+		return apierrors.Validation("retry_policy hedge_delay_ms is required when hedge_tools is set")
+	}
+	return nil
+}
+
+// responseCacheSchema is used to validate the response_cache JSON field.
+type responseCacheSchema struct {
+	TTLS  *int     `json:"ttl_s"`
+	Tools []string `json:"tools"`
+}
+
+// validateResponseCache checks that response_cache has valid schema and size.
+func validateResponseCache(raw json.RawMessage) error {
+	if len(raw) > 4096 {
+		return apierrors.Validation("response_cache exceeds maximum size of 4KB")
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	var rc responseCacheSchema
+	if err := dec.Decode(&rc); err != nil {
+		return apierrors.Validation("response_cache must be a JSON object with known fields: " + err.Error())
+	}
+	if rc.TTLS != nil && (*rc.TTLS < 0 || *rc.TTLS > 3600) {
+		return apierrors.Validation("response_cache ttl_s must be between 0 and 3600")
+	}
+	if len(rc.Tools) > 50 {
+		return apierrors.Validation("response_cache tools must contain at most 50 patterns")
+	}
+	for _, p := range rc.Tools {
+		if err := validateToolPattern(p); err != nil {
+			return apierrors.Validation("response_cache: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// pricingSchema is used to validate the pricing JSON field.
+type pricingSchema struct {
+	UnitCost *float64 `json:"unit_cost"`
+	Tools    []struct {
+		Pattern  string   `json:"pattern"`
+		UnitCost *float64 `json:"unit_cost"`
+	} `json:"tools"`
+}
+
+// validatePricing checks that pricing has valid schema and size.
+func validatePricing(raw json.RawMessage) error {
+	if len(raw) > 8192 {
+		return apierrors.Validation("pricing exceeds maximum size of 8KB")
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	var p pricingSchema
+	if err := dec.Decode(&p); err != nil {
+		return apierrors.Validation("pricing must be a JSON object with known fields: " + err.Error())
+	}
+	if p.UnitCost != nil {
+		if _, ok := unitsToMicros(*p.UnitCost); !ok {
+			return apierrors.Validation("pricing unit_cost must be a non-negative amount with at most 6 decimal places")
+		}
+	}
+	if len(p.Tools) > 50 {
+		return apierrors.Validation("pricing tools must contain at most 50 patterns")
+	}
+	for _, t := range p.Tools {
+		if t.Pattern == "" {
+			return apierrors.Validation("pricing tools entries require a pattern")
+		}
+		if err := validateToolPattern(t.Pattern); err != nil {
+			return apierrors.Validation("pricing: " + err.Error())
+		}
+		if t.UnitCost == nil {
+			return apierrors.Validation("pricing tools entries require a unit_cost")
+		}
+		if _, ok := unitsToMicros(*t.UnitCost); !ok {
+			return apierrors.Validation("pricing unit_cost must be a non-negative amount with at most 6 decimal places")
+		}
+	}
+	return nil
+}
+
+// callLimitsSchema is used to validate the call_limits JSON field.
+type callLimitsSchema struct {
+	TimeoutMS *int `json:"timeout_ms"`
+	Tools     []struct {
+		Pattern   string `json:"pattern"`
+		TimeoutMS *int   `json:"timeout_ms"`
+	} `json:"tools"`
+	MaxConcurrency *int `json:"max_concurrency"`
+	MaxQueue       *int `json:"max_queue"`
+	QueueTimeoutMS *int `json:"queue_timeout_ms"`
+}
+
+// validateCallLimits checks that call_limits has valid schema and size.
+func validateCallLimits(raw json.RawMessage) error {
+	if len(raw) > 8192 {
+		return apierrors.Validation("call_limits exceeds maximum size of 8KB")
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	var cl callLimitsSchema
+	if err := dec.Decode(&cl); err != nil {
+		return apierrors.Validation("call_limits must be a JSON object with known fields: " + err.Error())
+	}
+	if cl.TimeoutMS != nil && (*cl.TimeoutMS < 100 || *cl.TimeoutMS > 600000) {
+		return apierrors.Validation("call_limits timeout_ms must be between 100 and 600000")
+	}
+	if len(cl.Tools) > 50 {
+		return apierrors.Validation("call_limits tools must contain at most 50 patterns")
+	}
+	for _, t := range cl.Tools {
+		if t.Pattern == "" {
+			return apierrors.Validation("call_limits tools entries require a pattern")
+		}
+		if err := validateToolPattern(t.Pattern); err != nil {
+			return apierrors.Validation("call_limits: " + err.Error())
+		}
+		if t.TimeoutMS == nil || *t.TimeoutMS < 100 || *t.TimeoutMS > 600000 {
+			return apierrors.Validation("call_limits tools timeout_ms must be between 100 and 600000")
+		}
+	}
+	if cl.MaxConcurrency != nil && (*cl.MaxConcurrency < 1 || *cl.MaxConcurrency > 1000) {
+		return apierrors.Validation("call_limits max_concurrency must be between 1 and 1000")
+	}
+	if cl.MaxQueue != nil && (*cl.MaxQueue < 0 || *cl.MaxQueue > 10000) {
+		return apierrors.Validation("call_limits max_queue must be between 0 and 10000")
+	}
+	if cl.QueueTimeoutMS != nil && (*cl.QueueTimeoutMS < 1 || *cl.QueueTimeoutMS > 60000) {
+		return apierrors.Validation("call_limits queue_timeout_ms must be between 1 and 60000")
+	}
+	if (cl.MaxQueue != nil || cl.QueueTimeoutMS != nil) && cl.MaxConcurrency == nil {
+		return apierrors.Validation("call_limits max_queue and queue_timeout_ms require max_concurrency")
+	}
+	if cl.MaxQueue != nil && *cl.MaxQueue > 0 && cl.QueueTimeoutMS == nil {
+		return apierrors.Validation("call_limits queue_timeout_ms is required when max_queue is set")
 	}
 	return nil
 }
@@ -110,44 +733,99 @@ func validateDiscoveryInterval(interval string) error {
 	return nil
 }
 
-// mcpServerResponse is the JSON representation of an MCP server, never exposing auth_credential.
+// mcpServerResponse is the JSON representation of an MCP server, never exposing
+// auth_credential, TLS material, custom header values or stdio environment values.
 type mcpServerResponse struct {
-	ID                uuid.UUID       `json:"id"`
-	Label             string          `json:"label"`
-	Endpoint          string          `json:"endpoint"`
-	AuthType          string          `json:"auth_type"`
-	HealthEndpoint    string          `json:"health_endpoint"`
-	CircuitBreaker    json.RawMessage `json:"circuit_breaker"`
-	DiscoveryInterval string          `json:"discovery_interval"`
-	IsEnabled         bool            `json:"is_enabled"`
-	CreatedAt         time.Time       `json:"created_at"`
-	UpdatedAt         time.Time       `json:"updated_at"`
+	ID                      uuid.UUID       `json:"id"`
+	Label                   string          `json:"label"`
+	Endpoint                string          `json:"endpoint"`
+	AuthType                string          `json:"auth_type"`
+	Transport               string          `json:"transport"`
+	Stdio                   *stdioResponse  `json:"stdio,omitempty"`
+	TLSClientCertConfigured bool            `json:"tls_client_cert_configured"`
+	TLSCABundleConfigured   bool            `json:"tls_ca_bundle_configured"`
+	CustomHeadersConfigured bool            `json:"custom_headers_configured"`
+	EgressPolicy            json.RawMessage `json:"egress_policy"`
+	RetryPolicy             json.RawMessage `json:"retry_policy"`
+	Endpoints               json.RawMessage `json:"endpoints"`
+	LoadBalancing           string          `json:"load_balancing"`
+	ResponseCache           json.RawMessage `json:"response_cache"`
+	HealthEndpoint          string          `json:"health_endpoint"`
+	CircuitBreaker          json.RawMessage `json:"circuit_breaker"`
+	Pricing                 json.RawMessage `json:"pricing"`
+	CallLimits              json.RawMessage `json:"call_limits"`
+	WorkspaceRequired       bool            `json:"workspace_required"`
+	DiscoveryInterval       string          `json:"discovery_interval"`
+	IsEnabled               bool            `json:"is_enabled"`
+	CreatedAt               time.Time       `json:"created_at"`
+	UpdatedAt               time.Time       `json:"updated_at"`
+}
+
+// stdioResponse describes the command of a stdio server.
+type stdioResponse struct {
+	Command       string          `json:"command"`
+	Args          json.RawMessage `json:"args"`
+	EnvConfigured bool            `json:"env_configured"`
 }
 
 func toMCPServerResponse(s *store.MCPServer) mcpServerResponse {
+	transport := s.Transport
+	if transport == "" {
+		transport = transportHTTP
+	}
+	var stdio *stdioResponse
+	if transport == transportStdio {
+		stdio = &stdioResponse{Command: s.StdioCommand, Args: s.StdioArgs, EnvConfigured: s.StdioEnv != ""}
+	}
 	return mcpServerResponse{
-		ID:                s.ID,
-		Label:             s.Label,
-		Endpoint:          s.Endpoint,
-		AuthType:          s.AuthType,
-		HealthEndpoint:    s.HealthEndpoint,
-		CircuitBreaker:    s.CircuitBreaker,
-		DiscoveryInterval: s.DiscoveryInterval,
-		IsEnabled:         s.IsEnabled,
-		CreatedAt:         s.CreatedAt,
-		UpdatedAt:         s.UpdatedAt,
+		ID:                      s.ID,
+		Label:                   s.Label,
+		Endpoint:                s.Endpoint,
+		AuthType:                s.AuthType,
+		Transport:               transport,
+		Stdio:                   stdio,
+		TLSClientCertConfigured: s.TLSClientCert != "",
+		TLSCABundleConfigured:   s.TLSCABundle != "",
+		CustomHeadersConfigured: s.CustomHeaders != "",
+		EgressPolicy:            s.EgressPolicy,
+		RetryPolicy:             s.RetryPolicy,
+		Endpoints:               s.Endpoints,
+		LoadBalancing:           s.LoadBalancing,
+		ResponseCache:           s.ResponseCache,
+		HealthEndpoint:          s.HealthEndpoint,
+		CircuitBreaker:          s.CircuitBreaker,
+		Pricing:                 s.Pricing,
+		CallLimits:              s.CallLimits,
+		WorkspaceRequired:       s.WorkspaceRequired,
+		DiscoveryInterval:       s.DiscoveryInterval,
+		IsEnabled:               s.IsEnabled,
+		CreatedAt:               s.CreatedAt,
+		UpdatedAt:               s.UpdatedAt,
 	}
 }
 
 type createMCPServerRequest struct {
-	Label             string          `json:"label"`
-	Endpoint          string          `json:"endpoint"`
-	AuthType          string          `json:"auth_type"`
-	AuthCredential    string          `json:"auth_credential"`
-	HealthEndpoint    string          `json:"health_endpoint"`
-	CircuitBreaker    json.RawMessage `json:"circuit_breaker"`
-	DiscoveryInterval *string         `json:"discovery_interval"`
-	IsEnabled         *bool           `json:"is_enabled"`
+	Label             string                           `json:"label"`
+	Endpoint          string                           `json:"endpoint"`
+	Endpoints         []gateway.Endpoint               `json:"endpoints"`
+	LoadBalancing     string                           `json:"load_balancing"`
+	AuthType          string                           `json:"auth_type"`
+	AuthCredential    string                           `json:"auth_credential"`
+	Transport         string                           `json:"transport"`
+	Stdio             *gateway.StdioConfig             `json:"stdio"`
+	OAuth2            *gateway.OAuth2ClientCredentials `json:"oauth2"`
+	TLS               *gateway.TLSConfig               `json:"tls"`
+	CustomHeaders     map[string]string                `json:"custom_headers"`
+	EgressPolicy      *gateway.EgressPolicy            `json:"egress_policy"`
+	HealthEndpoint    string                           `json:"health_endpoint"`
+	CircuitBreaker    json.RawMessage                  `json:"circuit_breaker"`
+	RetryPolicy       json.RawMessage                  `json:"retry_policy"`
+	ResponseCache     json.RawMessage                  `json:"response_cache"`
+	Pricing           json.RawMessage                  `json:"pricing"`
+	CallLimits        json.RawMessage                  `json:"call_limits"`
+	WorkspaceRequired bool                             `json:"workspace_required"`
+	DiscoveryInterval *string                          `json:"discovery_interval"`
+	IsEnabled         *bool                            `json:"is_enabled"`
 }
 
 // Create handles POST /api/v1/mcp-servers.
@@ -162,25 +840,117 @@ func (h *MCPServersHandler) Create(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, r, apierrors.Validation("label is required"))
 		return
 	}
-	if req.Endpoint == "" {
-		RespondError(w, r, apierrors.Validation("endpoint is required"))
-		return
-	}
-	if err := validateEndpointURL(req.Endpoint); err != nil {
-		RespondError(w, r, err.(*apierrors.APIError))
-		return
+	if req.Transport == "" {
+		req.Transport = transportHTTP
 	}
-	if req.HealthEndpoint != "" {
-		if err := validateEndpointURL(req.HealthEndpoint); err != nil {
-			RespondError(w, r, apierrors.Validation("health_endpoint: "+err.Error()))
+	var egressPolicy *gateway.EgressPolicy
+	switch req.Transport {
+	case transportHTTP:
+		if req.Stdio != nil {
+			RespondError(w, r, apierrors.Validation("stdio is only valid with transport stdio"))
+			return
+		}
+		// With a pool, endpoint defaults to the first entry and must be a member.
+		if len(req.Endpoints) > 0 {
+			if req.Endpoint == "" {
+				req.Endpoint = req.Endpoints[0].URL
+			} else if !poolContains(req.Endpoints, req.Endpoint) {
+				RespondError(w, r, apierrors.Validation("endpoint must be one of endpoints"))
+				return
+			}
+		}
+		if req.Endpoint == "" {
+			RespondError(w, r, apierrors.Validation("endpoint is required"))
+			return
+		}
+		if req.EgressPolicy != nil {
+			if err := validateEgressPolicy(req.EgressPolicy); err != nil {
+				RespondError(w, r, err.(*apierrors.APIError))
+				return
+			}
+			if !req.EgressPolicy.IsZero() {
+				egressPolicy = req.EgressPolicy
+			}
+		}
+		if err := h.validateServerEndpoint(r.Context(), "endpoint", req.Endpoint, egressPolicy); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		if req.HealthEndpoint != "" {
+			if err := h.validateServerEndpoint(r.Context(), "health_endpoint", req.HealthEndpoint, egressPolicy); err != nil {
+				RespondError(w, r, err.(*apierrors.APIError))
+				return
+			}
+		}
+		if err := h.validateEndpointPool(r.Context(), req.Endpoints, egressPolicy); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+	case transportStdio:
+		if h.stdio == nil {
+			RespondError(w, r, apierrors.Validation("transport stdio is not enabled on this gateway"))
 			return
 		}
+		if req.Stdio == nil {
+			RespondError(w, r, apierrors.Validation("stdio is required when transport is stdio"))
+			return
+		}
+		if err := stdioOnlyFieldsError(map[string]bool{
+			"endpoint": req.Endpoint != "", "endpoints": len(req.Endpoints) > 0, "health_endpoint": req.HealthEndpoint != "",
+			"tls": req.TLS != nil, "custom_headers": len(req.CustomHeaders) > 0, "egress_policy": req.EgressPolicy != nil,
+			"auth_credential": req.AuthCredential != "", "oauth2": req.OAuth2 != nil,
+		}); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		if req.AuthType != "" && req.AuthType != "none" {
+			RespondError(w, r, apierrors.Validation("auth_type must be none with transport stdio"))
+			return
+		}
+		if err := validateStdioConfig(req.Stdio); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+	default:
+		RespondError(w, r, apierrors.Validation("transport must be one of: http, stdio"))
+		return
+	}
+	if req.LoadBalancing == "" {
+		req.LoadBalancing = gateway.BalanceRoundRobin
+	}
+	if !validLoadBalancing[req.LoadBalancing] {
+		RespondError(w, r, apierrors.Validation("load_balancing must be one of: round_robin, least_latency"))
+		return
 	}
 	if req.AuthType == "" {
 		req.AuthType = "none"
 	}
 	if !validAuthTypes[req.AuthType] {
-		RespondError(w, r, apierrors.Validation("auth_type must be one of: none, bearer, basic"))
+		RespondError(w, r, apierrors.Validation(authTypeValidationMessage))
+		return
+	}
+	credential := req.AuthCredential
+	if req.AuthType == authTypeOAuth2ClientCredentials {
+		if req.OAuth2 == nil {
+			RespondError(w, r, apierrors.Validation("oauth2 is required when auth_type is oauth2_client_credentials"))
+			return
+		}
+		if req.AuthCredential != "" {
+			RespondError(w, r, apierrors.Validation("auth_credential is not used with oauth2_client_credentials; set oauth2 instead"))
+			return
+		}
+		if err := h.validateOAuth2Credentials(r.Context(), req.OAuth2, egressPolicy); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		plain, err := json.Marshal(req.OAuth2)
+		if err != nil {
+			RespondError(w, r, apierrors.Internal("failed to encode oauth2 credentials"))
+			return
+		}
+		credential = string(plain)
+	} else if req.OAuth2 != nil {
+		RespondError(w, r, apierrors.Validation("oauth2 is only valid with auth_type oauth2_client_credentials"))
 		return
 	}
 	if req.CircuitBreaker != nil {
@@ -189,11 +959,45 @@ func (h *MCPServersHandler) Create(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if req.RetryPolicy != nil {
+		if err := validateRetryPolicy(req.RetryPolicy); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+	}
+	if req.ResponseCache != nil {
+		if err := validateResponseCache(req.ResponseCache); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+	}
+	if req.Pricing != nil {
+		if err := validatePricing(req.Pricing); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+	}
+	if req.CallLimits != nil {
+		if err := validateCallLimits(req.CallLimits); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+	}
+	if req.TLS != nil {
+		if err := validateUpstreamTLS(req.TLS); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+	}
+	if err := validateCustomHeaders(req.CustomHeaders); err != nil {
+		RespondError(w, r, err.(*apierrors.APIError))
+		return
+	}
 
 	// Encrypt credential if provided
 	encryptedCred := ""
-	if req.AuthCredential != "" && len(h.encKey) == 32 {
-		encrypted, err := auth.Encrypt([]byte(req.AuthCredential), h.encKey)
+	if credential != "" && len(h.encKey) == 32 {
+		encrypted, err := auth.Encrypt([]byte(credential), h.encKey)
 		if err != nil {
 			RespondError(w, r, apierrors.Internal("failed to encrypt credential"))
 			return
@@ -222,11 +1026,46 @@ func (h *MCPServersHandler) Create(w http.ResponseWriter, r *http.Request) {
 		AuthCredential:    encryptedCred,
 		HealthEndpoint:    req.HealthEndpoint,
 		CircuitBreaker:    req.CircuitBreaker,
+		RetryPolicy:       req.RetryPolicy,
+		ResponseCache:     req.ResponseCache,
+		Pricing:           req.Pricing,
+		CallLimits:        req.CallLimits,
+		WorkspaceRequired: req.WorkspaceRequired,
+		Transport:         req.Transport,
+		LoadBalancing:     req.LoadBalancing,
 		DiscoveryInterval: discoveryInterval,
 		IsEnabled:         isEnabled,
 	}
+	if req.TLS != nil {
+		if err := h.setUpstreamTLS(server, req.TLS); err != nil {
+			RespondError(w, r, apierrors.Internal("failed to encrypt tls material"))
+			return
+		}
+	}
+	if err := h.setCustomHeaders(server, req.CustomHeaders); err != nil {
+		RespondError(w, r, apierrors.Internal("failed to encrypt custom headers"))
+		return
+	}
+	if egressPolicy != nil {
+		server.EgressPolicy, _ = json.Marshal(egressPolicy)
+	}
+	if len(req.Endpoints) > 0 {
+		server.Endpoints, _ = json.Marshal(req.Endpoints)
+	}
+	if req.Stdio != nil {
+		if err := h.setStdioConfig(server, req.Stdio); err != nil {
+			RespondError(w, r, apierrors.Internal("failed to encrypt stdio env"))
+			return
+		}
+	}
 
-	if err := h.servers.Create(r.Context(), server); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.servers.Create(ctx, server); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "mcp_server.created", "mcp_server", server.ID.String())
+	})
+	if err != nil {
 		if strings.Contains(err.Error(), "duplicate") {
 			RespondError(w, r, apierrors.Conflict("mcp server with this label already exists"))
 			return
@@ -236,7 +1075,6 @@ func (h *MCPServersHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "mcp_server_create", "mcp_server", server.ID.String())
-	h.dispatchEvent(r, "mcp_server.created", "mcp_server", server.ID.String())
 
 	RespondJSON(w, r, http.StatusCreated, toMCPServerResponse(server))
 }
@@ -278,14 +1116,27 @@ func (h *MCPServersHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 type updateMCPServerRequest struct {
-	Label             *string          `json:"label"`
-	Endpoint          *string          `json:"endpoint"`
-	AuthType          *string          `json:"auth_type"`
-	AuthCredential    *string          `json:"auth_credential"`
-	HealthEndpoint    *string          `json:"health_endpoint"`
-	CircuitBreaker    *json.RawMessage `json:"circuit_breaker"`
-	DiscoveryInterval *string          `json:"discovery_interval"`
-	IsEnabled         *bool            `json:"is_enabled"`
+	Label             *string                          `json:"label"`
+	Endpoint          *string                          `json:"endpoint"`
+	Endpoints         *[]gateway.Endpoint              `json:"endpoints"`
+	LoadBalancing     *string                          `json:"load_balancing"`
+	AuthType          *string                          `json:"auth_type"`
+	AuthCredential    *string                          `json:"auth_credential"`
+	Transport         *string                          `json:"transport"`
+	Stdio             *gateway.StdioConfig             `json:"stdio"`
+	OAuth2            *gateway.OAuth2ClientCredentials `json:"oauth2"`
+	TLS               *gateway.TLSConfig               `json:"tls"`
+	CustomHeaders     *map[string]string               `json:"custom_headers"`
+	EgressPolicy      *gateway.EgressPolicy            `json:"egress_policy"`
+	HealthEndpoint    *string                          `json:"health_endpoint"`
+	CircuitBreaker    *json.RawMessage                 `json:"circuit_breaker"`
+	RetryPolicy       *json.RawMessage                 `json:"retry_policy"`
+	ResponseCache     *json.RawMessage                 `json:"response_cache"`
+	Pricing           *json.RawMessage                 `json:"pricing"`
+	CallLimits        *json.RawMessage                 `json:"call_limits"`
+	WorkspaceRequired *bool                            `json:"workspace_required"`
+	DiscoveryInterval *string                          `json:"discovery_interval"`
+	IsEnabled         *bool                            `json:"is_enabled"`
 }
 
 // Update handles PUT /api/v1/mcp-servers/{serverId}.
@@ -313,6 +1164,7 @@ func (h *MCPServersHandler) Update(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, r, apierrors.NotFound("mcp_server", serverID.String()))
 		return
 	}
+	previousLabel := server.Label
 
 	var req updateMCPServerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -320,35 +1172,193 @@ func (h *MCPServersHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if server.Transport == "" {
+		server.Transport = transportHTTP
+	}
+	if req.Transport != nil && *req.Transport != server.Transport {
+		RespondError(w, r, apierrors.Validation("transport cannot be changed; create a new server instead"))
+		return
+	}
+	if server.Transport == transportStdio {
+		authType := server.AuthType
+		if req.AuthType != nil {
+			authType = *req.AuthType
+		}
+		if err := stdioOnlyFieldsError(map[string]bool{
+			"endpoint": req.Endpoint != nil, "endpoints": req.Endpoints != nil, "health_endpoint": req.HealthEndpoint != nil,
+			"tls": req.TLS != nil, "custom_headers": req.CustomHeaders != nil, "egress_policy": req.EgressPolicy != nil,
+			"auth_credential": req.AuthCredential != nil, "oauth2": req.OAuth2 != nil,
+		}); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		if authType != "none" {
+			RespondError(w, r, apierrors.Validation("auth_type must be none with transport stdio"))
+			return
+		}
+		// A present stdio object replaces the command, arguments and environment.
+		if req.Stdio != nil {
+			if err := validateStdioConfig(req.Stdio); err != nil {
+				RespondError(w, r, err.(*apierrors.APIError))
+				return
+			}
+			if err := h.setStdioConfig(server, req.Stdio); err != nil {
+				RespondError(w, r, apierrors.Internal("failed to encrypt stdio env"))
+				return
+			}
+		}
+	} else if req.Stdio != nil {
+		RespondError(w, r, apierrors.Validation("stdio is only valid with transport stdio"))
+		return
+	}
+
 	if req.Label != nil {
 		server.Label = *req.Label
 	}
 	if req.Endpoint != nil {
-		if err := validateEndpointURL(*req.Endpoint); err != nil {
+		server.Endpoint = *req.Endpoint
+	}
+	// A present endpoints list replaces the pool; [] returns to the single endpoint.
+	if req.Endpoints != nil {
+		server.Endpoints, _ = json.Marshal(*req.Endpoints)
+		if len(*req.Endpoints) > 0 && req.Endpoint == nil {
+			server.Endpoint = (*req.Endpoints)[0].URL
+		}
+	}
+	if req.LoadBalancing != nil {
+		if !validLoadBalancing[*req.LoadBalancing] {
+			RespondError(w, r, apierrors.Validation("load_balancing must be one of: round_robin, least_latency"))
+			return
+		}
+		server.LoadBalancing = *req.LoadBalancing
+	}
+	if req.EgressPolicy != nil {
+		if err := validateEgressPolicy(req.EgressPolicy); err != nil {
 			RespondError(w, r, err.(*apierrors.APIError))
 			return
 		}
-		server.Endpoint = *req.Endpoint
+		server.EgressPolicy, _ = json.Marshal(req.EgressPolicy)
 	}
+	// Endpoints are re-checked whenever they or the allowlist change.
+	if req.Endpoint != nil || req.Endpoints != nil || req.EgressPolicy != nil {
+		egressPolicy, err := parseEgressPolicy(server.EgressPolicy)
+		if err != nil {
+			RespondError(w, r, apierrors.Internal("invalid stored egress policy"))
+			return
+		}
+		if err := h.validateServerEndpoint(r.Context(), "endpoint", server.Endpoint, egressPolicy); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		if server.HealthEndpoint != "" && req.HealthEndpoint == nil {
+			if err := h.validateServerEndpoint(r.Context(), "health_endpoint", server.HealthEndpoint, egressPolicy); err != nil {
+				RespondError(w, r, err.(*apierrors.APIError))
+				return
+			}
+		}
+		pool, err := parseEndpointPool(server.Endpoints)
+		if err != nil {
+			RespondError(w, r, apierrors.Internal("invalid stored endpoints"))
+			return
+		}
+		if err := h.validateEndpointPool(r.Context(), pool, egressPolicy); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		if len(pool) > 0 && !poolContains(pool, server.Endpoint) {
+			RespondError(w, r, apierrors.Validation("endpoint must be one of endpoints"))
+			return
+		}
+		server.Endpoints, _ = json.Marshal(pool)
+		if pool == nil {
+			server.Endpoints = json.RawMessage(`[]`)
+		}
+	}
+	previousAuthType := server.AuthType
 	if req.AuthType != nil {
 		if !validAuthTypes[*req.AuthType] {
-			RespondError(w, r, apierrors.Validation("auth_type must be one of: none, bearer, basic"))
+			RespondError(w, r, apierrors.Validation(authTypeValidationMessage))
 			return
 		}
 		server.AuthType = *req.AuthType
 	}
-	if req.AuthCredential != nil && len(h.encKey) == 32 {
-		encrypted, err := auth.Encrypt([]byte(*req.AuthCredential), h.encKey)
+	credential := req.AuthCredential
+	if server.AuthType == authTypeOAuth2ClientCredentials {
+		if req.AuthCredential != nil {
+			RespondError(w, r, apierrors.Validation("auth_credential is not used with oauth2_client_credentials; set oauth2 instead"))
+			return
+		}
+		if req.OAuth2 == nil && previousAuthType != authTypeOAuth2ClientCredentials {
+			RespondError(w, r, apierrors.Validation("oauth2 is required when auth_type is oauth2_client_credentials"))
+			return
+		}
+		if req.OAuth2 != nil {
+			egressPolicy, err := parseEgressPolicy(server.EgressPolicy)
+			if err != nil {
+				RespondError(w, r, apierrors.Internal("invalid stored egress policy"))
+				return
+			}
+			if err := h.validateOAuth2Credentials(r.Context(), req.OAuth2, egressPolicy); err != nil {
+				RespondError(w, r, err.(*apierrors.APIError))
+				return
+			}
+			plain, err := json.Marshal(req.OAuth2)
+			if err != nil {
+				RespondError(w, r, apierrors.Internal("failed to encode oauth2 credentials"))
+				return
+			}
+			encoded := string(plain)
+			credential = &encoded
+		}
+	} else {
+		if req.OAuth2 != nil {
+			RespondError(w, r, apierrors.Validation("oauth2 is only valid with auth_type oauth2_client_credentials"))
+			return
+		}
+		// Never reuse stored client credentials as a bearer/basic secret.
+		if previousAuthType == authTypeOAuth2ClientCredentials && credential == nil {
+			server.AuthCredential = ""
+		}
+	}
+	if credential != nil && len(h.encKey) == 32 {
+		encrypted, err := auth.Encrypt([]byte(*credential), h.encKey)
 		if err != nil {
 			RespondError(w, r, apierrors.Internal("failed to encrypt credential"))
 			return
 		}
 		server.AuthCredential = base64.StdEncoding.EncodeToString(encrypted)
 	}
+	// A present tls object replaces all stored TLS material; {} clears it.
+	if req.TLS != nil {
+		if err := validateUpstreamTLS(req.TLS); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		if err := h.setUpstreamTLS(server, req.TLS); err != nil {
+			RespondError(w, r, apierrors.Internal("failed to encrypt tls material"))
+			return
+		}
+	}
+	// A present custom_headers map replaces the stored headers; {} clears them.
+	if req.CustomHeaders != nil {
+		if err := validateCustomHeaders(*req.CustomHeaders); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		if err := h.setCustomHeaders(server, *req.CustomHeaders); err != nil {
+			RespondError(w, r, apierrors.Internal("failed to encrypt custom headers"))
+			return
+		}
+	}
 	if req.HealthEndpoint != nil {
 		if *req.HealthEndpoint != "" {
-			if err := validateEndpointURL(*req.HealthEndpoint); err != nil {
-				RespondError(w, r, apierrors.Validation("health_endpoint: "+err.Error()))
+			egressPolicy, err := parseEgressPolicy(server.EgressPolicy)
+			if err != nil {
+				RespondError(w, r, apierrors.Internal("invalid stored egress policy"))
+				return
+			}
+			if err := h.validateServerEndpoint(r.Context(), "health_endpoint", *req.HealthEndpoint, egressPolicy); err != nil {
+				RespondError(w, r, err.(*apierrors.APIError))
 				return
 			}
 		}
@@ -361,6 +1371,37 @@ func (h *MCPServersHandler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 		server.CircuitBreaker = *req.CircuitBreaker
 	}
+	if req.RetryPolicy != nil {
+		if err := validateRetryPolicy(*req.RetryPolicy); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		server.RetryPolicy = *req.RetryPolicy
+	}
+	if req.ResponseCache != nil {
+		if err := validateResponseCache(*req.ResponseCache); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		server.ResponseCache = *req.ResponseCache
+	}
+	if req.Pricing != nil {
+		if err := validatePricing(*req.Pricing); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		server.Pricing = *req.Pricing
+	}
+	if req.CallLimits != nil {
+		if err := validateCallLimits(*req.CallLimits); err != nil {
+			RespondError(w, r, err.(*apierrors.APIError))
+			return
+		}
+		server.CallLimits = *req.CallLimits
+	}
+	if req.WorkspaceRequired != nil {
+		server.WorkspaceRequired = *req.WorkspaceRequired
+	}
 	if req.DiscoveryInterval != nil {
 		if err := validateDiscoveryInterval(*req.DiscoveryInterval); err != nil {
 			RespondError(w, r, err.(*apierrors.APIError))
@@ -374,7 +1415,13 @@ func (h *MCPServersHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	server.UpdatedAt = etag
 
-	if err := h.servers.Update(r.Context(), server); err != nil {
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.servers.Update(ctx, server); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "mcp_server.updated", "mcp_server", server.ID.String())
+	})
+	if err != nil {
 		if strings.Contains(err.Error(), "conflict") || strings.Contains(err.Error(), "modified") {
 			RespondError(w, r, apierrors.Conflict("mcp server was modified by another request"))
 			return
@@ -383,8 +1430,16 @@ func (h *MCPServersHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.cache != nil {
+		h.cache.Purge(previousLabel, "")
+	}
+	// Command changes restart the process on its next call; a renamed or
+	// disabled server's process is no longer reachable and is stopped now.
+	if h.stdio != nil && (previousLabel != server.Label || !server.IsEnabled) {
+		h.stdio.Stop(previousLabel)
+	}
+
 	h.auditLog(r, "mcp_server_update", "mcp_server", server.ID.String())
-	h.dispatchEvent(r, "mcp_server.updated", "mcp_server", server.ID.String())
 
 	RespondJSON(w, r, http.StatusOK, toMCPServerResponse(server))
 }
@@ -397,17 +1452,68 @@ func (h *MCPServersHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.servers.Delete(r.Context(), serverID); err != nil {
+	var label string
+	if h.cache != nil || h.stdio != nil {
+		if server, err := h.servers.GetByID(r.Context(), serverID); err == nil {
+			label = server.Label
+		}
+	}
+
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.servers.Delete(ctx, serverID); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "mcp_server.deleted", "mcp_server", serverID.String())
+	})
+	if err != nil {
 		RespondError(w, r, apierrors.NotFound("mcp_server", serverID.String()))
 		return
 	}
+	if label != "" && h.cache != nil {
+		h.cache.Purge(label, "")
+	}
+	if label != "" && h.stdio != nil {
+		h.stdio.Stop(label)
+	}
 
 	h.auditLog(r, "mcp_server_delete", "mcp_server", serverID.String())
-	h.dispatchEvent(r, "mcp_server.deleted", "mcp_server", serverID.String())
 
 	RespondNoContent(w)
 }
 
+// PurgeCache handles DELETE /api/v1/mcp-servers/{serverId}/cache. The
+// optional tool query parameter limits the purge to one tool.
+func (h *MCPServersHandler) PurgeCache(w http.ResponseWriter, r *http.Request) {
+	if h.cache == nil {
+		RespondError(w, r, apierrors.ServiceUnavailable("response cache is not enabled"))
+		return
+	}
+	serverID, err := uuid.Parse(chi.URLParam(r, "serverId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid server ID"))
+		return
+	}
+
+	server, err := h.servers.GetByID(r.Context(), serverID)
+	if err != nil {
+		RespondError(w, r, apierrors.NotFound("mcp_server", serverID.String()))
+		return
+	}
+
+	tool := r.URL.Query().Get("tool")
+	purged := h.cache.Purge(server.Label, tool)
+
+	resourceID := server.ID.String()
+	if tool != "" {
+		resourceID += "/" + tool
+	}
+	h.auditLog(r, "mcp_cache_purge", "mcp_server", resourceID)
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"purged": purged,
+	})
+}
+
 func (h *MCPServersHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
 	if h.audit == nil {
 		return
@@ -425,12 +1531,12 @@ func (h *MCPServersHandler) auditLog(r *http.Request, action, resourceType, reso
 	}
 }
 
-func (h *MCPServersHandler) dispatchEvent(r *http.Request, eventType, resourceType, resourceID string) {
+func (h *MCPServersHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string) error {
 	if h.dispatcher == nil {
-		return
+		return nil
 	}
 	callerID, _ := auth.UserIDFromContext(r.Context())
-	h.dispatcher.Dispatch(notify.Event{
+	return h.dispatcher.Dispatch(ctx, notify.Event{
 		Type:         eventType,
 		ResourceType: resourceType,
 		ResourceID:   resourceID,
@@ -3,16 +3,25 @@ package api
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/agent-smit/agentic-registry/internal/gateway"
 	"github.com/agent-smit/agentic-registry/internal/store"
 )
 
@@ -568,6 +577,12 @@ func TestMCPServersHandler_Create_RejectsInvalidCircuitBreaker(t *testing.T) {
 		{"negative fail_threshold", map[string]interface{}{"fail_threshold": -1, "open_duration_s": 30}},
 		{"zero open_duration_s", map[string]interface{}{"fail_threshold": 5, "open_duration_s": 0}},
 		{"string values", map[string]interface{}{"fail_threshold": "five", "open_duration_s": "thirty"}},
+		{"unknown mode", map[string]interface{}{"mode": "ewma", "open_duration_s": 30}},
+		{"window field in consecutive mode", map[string]interface{}{"fail_threshold": 5, "open_duration_s": 30, "window_s": 60}},
+		{"window without trip condition", map[string]interface{}{"mode": "window", "open_duration_s": 30, "window_s": 60, "min_requests": 20}},
+		{"window without min_requests", map[string]interface{}{"mode": "window", "open_duration_s": 30, "window_s": 60, "failure_rate_pct": 50}},
+		{"failure rate above 100", map[string]interface{}{"mode": "window", "open_duration_s": 30, "window_s": 60, "min_requests": 20, "failure_rate_pct": 150}},
+		{"zero probe ratio", map[string]interface{}{"fail_threshold": 5, "open_duration_s": 30, "half_open_probes": 3, "probe_success_ratio": 0}},
 	}
 
 	for _, tt := range invalidCBs {
@@ -595,6 +610,25 @@ func TestMCPServersHandler_Create_RejectsInvalidCircuitBreaker(t *testing.T) {
 	}
 }
 
+func TestMCPServersHandler_Create_WindowCircuitBreaker(t *testing.T) {
+	h := NewMCPServersHandler(newMockMCPServerStore(), &mockAuditStoreForAPI{}, nil, nil)
+
+	body := map[string]interface{}{
+		"label":    "window-cb",
+		"endpoint": "https://valid.example.com",
+		"circuit_breaker": map[string]interface{}{
+			"mode": "window", "open_duration_s": 30, "window_s": 60, "min_requests": 20,
+			"failure_rate_pct": 50, "latency_p95_ms": 2000, "half_open_probes": 3, "probe_success_ratio": 0.66,
+		},
+	}
+	w := httptest.NewRecorder()
+	h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestMCPServersHandler_Create_RejectsInvalidDiscoveryInterval(t *testing.T) {
 	t.Parallel()
 
@@ -766,3 +800,746 @@ func TestMCPServersHandler_DuplicateLabel(t *testing.T) {
 		t.Fatalf("duplicate label: expected 409, got %d; body: %s", w.Code, w.Body.String())
 	}
 }
+
+func TestMCPServersHandler_Create_OAuth2ClientCredentials(t *testing.T) {
+	testEncKey := make([]byte, 32)
+	for i := range testEncKey {
+		testEncKey[i] = byte(i)
+	}
+
+	validOAuth2 := map[string]interface{}{
+		"client_id":     "registry-gateway",
+		"client_secret": "s3cret",
+		"token_url":     "https://auth.example.com/oauth/token",
+		"scopes":        []string{"tools.read", "tools.call"},
+	}
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{
+			name: "valid oauth2 client credentials",
+			body: map[string]interface{}{
+				"label": "oauth-server", "endpoint": "https://mcp.example.com",
+				"auth_type": "oauth2_client_credentials", "oauth2": validOAuth2,
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "missing oauth2 block",
+			body: map[string]interface{}{
+				"label": "oauth-server", "endpoint": "https://mcp.example.com",
+				"auth_type": "oauth2_client_credentials",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "auth_credential not allowed with oauth2",
+			body: map[string]interface{}{
+				"label": "oauth-server", "endpoint": "https://mcp.example.com",
+				"auth_type": "oauth2_client_credentials", "oauth2": validOAuth2,
+				"auth_credential": "token",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "oauth2 block with bearer auth_type",
+			body: map[string]interface{}{
+				"label": "oauth-server", "endpoint": "https://mcp.example.com",
+				"auth_type": "bearer", "oauth2": validOAuth2,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "missing client_secret",
+			body: map[string]interface{}{
+				"label": "oauth-server", "endpoint": "https://mcp.example.com",
+				"auth_type": "oauth2_client_credentials",
+				"oauth2":    map[string]interface{}{"client_id": "id", "token_url": "https://auth.example.com/token"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "private token_url rejected",
+			body: map[string]interface{}{
+				"label": "oauth-server", "endpoint": "https://mcp.example.com",
+				"auth_type": "oauth2_client_credentials",
+				"oauth2":    map[string]interface{}{"client_id": "id", "client_secret": "s", "token_url": "http://169.254.169.254/token"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "scope with whitespace rejected",
+			body: map[string]interface{}{
+				"label": "oauth-server", "endpoint": "https://mcp.example.com",
+				"auth_type": "oauth2_client_credentials",
+				"oauth2":    map[string]interface{}{"client_id": "id", "client_secret": "s", "token_url": "https://auth.example.com/token", "scopes": []string{"a b"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, testEncKey, nil)
+
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", tt.body))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			if bytes.Contains(w.Body.Bytes(), []byte("s3cret")) {
+				t.Fatal("response must NOT contain the client secret")
+			}
+			created, _ := mcpStore.GetByLabel(context.Background(), "oauth-server")
+			if created.AuthCredential == "" || bytes.Contains([]byte(created.AuthCredential), []byte("s3cret")) {
+				t.Fatal("client credentials must be stored encrypted")
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Update_OAuth2AuthTypeSwitch(t *testing.T) {
+	testEncKey := make([]byte, 32)
+	for i := range testEncKey {
+		testEncKey[i] = byte(i)
+	}
+
+	tests := []struct {
+		name           string
+		startAuthType  string
+		body           map[string]interface{}
+		wantStatus     int
+		wantCredential bool
+	}{
+		{
+			name:          "switch to oauth2 without oauth2 block",
+			startAuthType: "none",
+			body:          map[string]interface{}{"auth_type": "oauth2_client_credentials"},
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "switch to oauth2 with oauth2 block",
+			startAuthType: "none",
+			body: map[string]interface{}{
+				"auth_type": "oauth2_client_credentials",
+				"oauth2":    map[string]interface{}{"client_id": "id", "client_secret": "s", "token_url": "https://auth.example.com/token"},
+			},
+			wantStatus:     http.StatusOK,
+			wantCredential: true,
+		},
+		{
+			name:          "switch away from oauth2 clears stored client credentials",
+			startAuthType: "oauth2_client_credentials",
+			body:          map[string]interface{}{"auth_type": "bearer"},
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:           "keep oauth2 without resending credentials",
+			startAuthType:  "oauth2_client_credentials",
+			body:           map[string]interface{}{"label": "renamed"},
+			wantStatus:     http.StatusOK,
+			wantCredential: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, testEncKey, nil)
+
+			serverID := uuid.New()
+			updatedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+			startCredential := ""
+			if tt.startAuthType == "oauth2_client_credentials" {
+				startCredential = "encrypted-client-credentials"
+			}
+			mcpStore.servers[serverID] = &store.MCPServer{
+				ID: serverID, Label: "test-server", Endpoint: "https://mcp.example.com",
+				AuthType: tt.startAuthType, AuthCredential: startCredential, IsEnabled: true,
+				CircuitBreaker: json.RawMessage(`{}`), DiscoveryInterval: "5m",
+				CreatedAt: time.Now(), UpdatedAt: updatedAt,
+			}
+			mcpStore.labels["test-server"] = serverID
+
+			req := adminRequest(http.MethodPut, "/api/v1/mcp-servers/"+serverID.String(), tt.body)
+			req.Header.Set("If-Match", updatedAt.UTC().Format(time.RFC3339Nano))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("serverId", serverID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			h.Update(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := mcpStore.servers[serverID].AuthCredential != ""; got != tt.wantCredential {
+				t.Errorf("credential present = %v, want %v", got, tt.wantCredential)
+			}
+		})
+	}
+}
+
+// testClientCertPEM returns a self-signed certificate and matching key as PEM.
+func testClientCertPEM(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gateway"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestMCPServersHandler_Create_TLSAndCustomHeaders(t *testing.T) {
+	testEncKey := make([]byte, 32)
+	for i := range testEncKey {
+		testEncKey[i] = byte(i)
+	}
+	cert, key := testClientCertPEM(t)
+	_, otherKey := testClientCertPEM(t)
+
+	tests := []struct {
+		name       string
+		extra      map[string]interface{}
+		wantStatus int
+	}{
+		{
+			name: "client cert, key, CA bundle and headers",
+			extra: map[string]interface{}{
+				"tls":            map[string]string{"client_cert": cert, "client_key": key, "ca_bundle": cert},
+				"custom_headers": map[string]string{"X-Tenant-ID": "acme"},
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "CA bundle only",
+			extra:      map[string]interface{}{"tls": map[string]string{"ca_bundle": cert}},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "cert without key",
+			extra:      map[string]interface{}{"tls": map[string]string{"client_cert": cert}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "mismatched key",
+			extra:      map[string]interface{}{"tls": map[string]string{"client_cert": cert, "client_key": otherKey}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "CA bundle without certificates",
+			extra:      map[string]interface{}{"tls": map[string]string{"ca_bundle": "not a pem"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "reserved header",
+			extra:      map[string]interface{}{"custom_headers": map[string]string{"authorization": "Bearer x"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid header name",
+			extra:      map[string]interface{}{"custom_headers": map[string]string{"X Tenant": "acme"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "header value with newline",
+			extra:      map[string]interface{}{"custom_headers": map[string]string{"X-Tenant": "acme\r\nX-Injected: 1"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, testEncKey, nil)
+
+			body := map[string]interface{}{"label": "tls-server", "endpoint": "https://mcp.example.com"}
+			for k, v := range tt.extra {
+				body[k] = v
+			}
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			if bytes.Contains(w.Body.Bytes(), []byte("PRIVATE KEY")) || bytes.Contains(w.Body.Bytes(), []byte("acme")) {
+				t.Fatal("response must NOT contain TLS material or header values")
+			}
+			created, _ := mcpStore.GetByLabel(context.Background(), "tls-server")
+			if created.TLSCABundle == "" || bytes.Contains([]byte(created.TLSCABundle), []byte("CERTIFICATE")) {
+				t.Fatal("CA bundle must be stored encrypted")
+			}
+			if _, ok := tt.extra["custom_headers"]; ok && created.CustomHeaders == "" {
+				t.Fatal("custom headers must be stored")
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Update_ClearsTLSAndCustomHeaders(t *testing.T) {
+	testEncKey := make([]byte, 32)
+	for i := range testEncKey {
+		testEncKey[i] = byte(i)
+	}
+
+	mcpStore := newMockMCPServerStore()
+	h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, testEncKey, nil)
+
+	serverID := uuid.New()
+	updatedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	mcpStore.servers[serverID] = &store.MCPServer{
+		ID: serverID, Label: "test-server", Endpoint: "https://mcp.example.com",
+		AuthType: "none", IsEnabled: true,
+		TLSClientCert: "enc-cert", TLSClientKey: "enc-key", TLSCABundle: "enc-ca", CustomHeaders: "enc-headers",
+		CircuitBreaker: json.RawMessage(`{}`), DiscoveryInterval: "5m",
+		CreatedAt: time.Now(), UpdatedAt: updatedAt,
+	}
+	mcpStore.labels["test-server"] = serverID
+
+	body := map[string]interface{}{"tls": map[string]string{}, "custom_headers": map[string]string{}}
+	req := adminRequest(http.MethodPut, "/api/v1/mcp-servers/"+serverID.String(), body)
+	req.Header.Set("If-Match", updatedAt.UTC().Format(time.RFC3339Nano))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("serverId", serverID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	h.Update(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	got := mcpStore.servers[serverID]
+	if got.TLSClientCert != "" || got.TLSClientKey != "" || got.TLSCABundle != "" || got.CustomHeaders != "" {
+		t.Errorf("expected TLS material and custom headers cleared, got %+v", got)
+	}
+}
+
+func TestMCPServersHandler_Create_RetryPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		retryPolicy interface{}
+		wantStatus  int
+	}{
+		{"valid policy", map[string]interface{}{"max_attempts": 3, "initial_backoff_ms": 50, "max_backoff_ms": 500, "retryable_statuses": []int{429, 503}}, http.StatusCreated},
+		{"valid hedging", map[string]interface{}{"hedge_tools": []string{"search_*"}, "hedge_delay_ms": 200, "idempotent_tools": []string{"get_*"}}, http.StatusCreated},
+		{"unknown field", map[string]interface{}{"max_retries": 3}, http.StatusBadRequest},
+		{"too many attempts", map[string]interface{}{"max_attempts": 10}, http.StatusBadRequest},
+		{"max below initial backoff", map[string]interface{}{"initial_backoff_ms": 500, "max_backoff_ms": 100}, http.StatusBadRequest},
+		{"non-retryable status", map[string]interface{}{"retryable_statuses": []int{404}}, http.StatusBadRequest},
+		{"hedge without delay", map[string]interface{}{"hedge_tools": []string{"search"}}, http.StatusBadRequest},
+		{"invalid tool pattern", map[string]interface{}{"idempotent_tools": []string{""}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, nil, nil)
+
+			body := map[string]interface{}{
+				"label":        "retry-test",
+				"endpoint":     "https://valid.example.com",
+				"retry_policy": tt.retryPolicy,
+			}
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Create_EndpointPool(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         map[string]interface{}
+		wantStatus   int
+		wantEndpoint string
+	}{
+		{
+			name: "endpoint defaults to first pool entry",
+			body: map[string]interface{}{
+				"label": "pool", "load_balancing": "least_latency",
+				"endpoints": []map[string]interface{}{{"url": "https://a.example.com/mcp", "weight": 2}, {"url": "https://b.example.com/mcp"}},
+			},
+			wantStatus:   http.StatusCreated,
+			wantEndpoint: "https://a.example.com/mcp",
+		},
+		{
+			name: "endpoint outside pool",
+			body: map[string]interface{}{
+				"label": "pool", "endpoint": "https://c.example.com/mcp",
+				"endpoints": []map[string]interface{}{{"url": "https://a.example.com/mcp"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate urls",
+			body: map[string]interface{}{
+				"label":     "pool",
+				"endpoints": []map[string]interface{}{{"url": "https://a.example.com/mcp"}, {"url": "https://a.example.com/mcp"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "weight out of range",
+			body: map[string]interface{}{
+				"label":     "pool",
+				"endpoints": []map[string]interface{}{{"url": "https://a.example.com/mcp", "weight": 500}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "internal pool endpoint",
+			body: map[string]interface{}{
+				"label":     "pool",
+				"endpoints": []map[string]interface{}{{"url": "https://a.example.com/mcp"}, {"url": "http://169.254.169.254/mcp"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown strategy",
+			body:       map[string]interface{}{"label": "pool", "endpoint": "https://a.example.com/mcp", "load_balancing": "random"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, nil, nil)
+
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", tt.body))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantEndpoint == "" {
+				return
+			}
+			data := parseEnvelope(t, w).Data.(map[string]interface{})
+			if data["endpoint"] != tt.wantEndpoint {
+				t.Errorf("endpoint = %v, want %s", data["endpoint"], tt.wantEndpoint)
+			}
+			pool := data["endpoints"].([]interface{})
+			if len(pool) != 2 || pool[1].(map[string]interface{})["weight"] != float64(1) {
+				t.Errorf("expected pool stored with default weight, got %v", pool)
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Create_ResponseCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		responseCache interface{}
+		wantStatus    int
+	}{
+		{"valid cache", map[string]interface{}{"ttl_s": 60, "tools": []string{"search_*", "get_*"}}, http.StatusCreated},
+		{"disabled", map[string]interface{}{"ttl_s": 0}, http.StatusCreated},
+		{"unknown field", map[string]interface{}{"ttl": 60}, http.StatusBadRequest},
+		{"ttl too long", map[string]interface{}{"ttl_s": 7200}, http.StatusBadRequest},
+		{"invalid tool pattern", map[string]interface{}{"ttl_s": 60, "tools": []string{""}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMCPServersHandler(newMockMCPServerStore(), &mockAuditStoreForAPI{}, nil, nil)
+
+			body := map[string]interface{}{
+				"label":          "cache-test",
+				"endpoint":       "https://valid.example.com",
+				"response_cache": tt.responseCache,
+			}
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Create_Pricing(t *testing.T) {
+	tests := []struct {
+		name       string
+		pricing    interface{}
+		wantStatus int
+	}{
+		{"server cost", map[string]interface{}{"unit_cost": 0.01}, http.StatusCreated},
+		{"tool costs", map[string]interface{}{"unit_cost": 0.01, "tools": []map[string]interface{}{{"pattern": "search_*", "unit_cost": 0.002}}}, http.StatusCreated},
+		{"unknown field", map[string]interface{}{"cost": 0.01}, http.StatusBadRequest},
+		{"negative cost", map[string]interface{}{"unit_cost": -1}, http.StatusBadRequest},
+		{"too precise", map[string]interface{}{"unit_cost": 0.0000001}, http.StatusBadRequest},
+		{"missing tool cost", map[string]interface{}{"tools": []map[string]interface{}{{"pattern": "search_*"}}}, http.StatusBadRequest},
+		{"invalid tool pattern", map[string]interface{}{"tools": []map[string]interface{}{{"pattern": "a/b", "unit_cost": 1}}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMCPServersHandler(newMockMCPServerStore(), &mockAuditStoreForAPI{}, nil, nil)
+
+			body := map[string]interface{}{
+				"label":    "pricing-test",
+				"endpoint": "https://valid.example.com",
+				"pricing":  tt.pricing,
+			}
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Create_CallLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     interface{}
+		wantStatus int
+	}{
+		{"timeouts", map[string]interface{}{"timeout_ms": 10000, "tools": []map[string]interface{}{{"pattern": "search_*", "timeout_ms": 2000}}}, http.StatusCreated},
+		{"concurrency with queue", map[string]interface{}{"max_concurrency": 10, "max_queue": 20, "queue_timeout_ms": 500}, http.StatusCreated},
+		{"unknown field", map[string]interface{}{"timeout": 1000}, http.StatusBadRequest},
+		{"timeout too short", map[string]interface{}{"timeout_ms": 10}, http.StatusBadRequest},
+		{"tool without timeout", map[string]interface{}{"tools": []map[string]interface{}{{"pattern": "search_*"}}}, http.StatusBadRequest},
+		{"queue without concurrency", map[string]interface{}{"max_queue": 5, "queue_timeout_ms": 500}, http.StatusBadRequest},
+		{"queue without timeout", map[string]interface{}{"max_concurrency": 1, "max_queue": 5}, http.StatusBadRequest},
+		{"zero concurrency", map[string]interface{}{"max_concurrency": 0}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMCPServersHandler(newMockMCPServerStore(), &mockAuditStoreForAPI{}, nil, nil)
+
+			body := map[string]interface{}{
+				"label":       "limits-test",
+				"endpoint":    "https://valid.example.com",
+				"call_limits": tt.limits,
+			}
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Create_WorkspaceRequired(t *testing.T) {
+	h := NewMCPServersHandler(newMockMCPServerStore(), &mockAuditStoreForAPI{}, nil, nil)
+
+	body := map[string]interface{}{
+		"label":              "workspace-test",
+		"endpoint":           "https://valid.example.com",
+		"workspace_required": true,
+	}
+	w := httptest.NewRecorder()
+	h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	if data["workspace_required"] != true {
+		t.Errorf("workspace_required = %v, want true", data["workspace_required"])
+	}
+}
+
+func TestMCPServersHandler_Create_Stdio(t *testing.T) {
+	testEncKey := make([]byte, 32)
+	for i := range testEncKey {
+		testEncKey[i] = byte(i)
+	}
+	stdio := map[string]interface{}{
+		"command": "/usr/local/bin/mcp-files",
+		"args":    []string{"--root", "/data"},
+		"env":     map[string]string{"API_TOKEN": "t0k"},
+	}
+
+	tests := []struct {
+		name       string
+		disabled   bool
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{"valid", false, map[string]interface{}{"transport": "stdio", "stdio": stdio}, http.StatusCreated},
+		{"stdio disabled", true, map[string]interface{}{"transport": "stdio", "stdio": stdio}, http.StatusBadRequest},
+		{"missing stdio", false, map[string]interface{}{"transport": "stdio"}, http.StatusBadRequest},
+		{"unknown transport", false, map[string]interface{}{"transport": "grpc", "endpoint": "https://valid.example.com"}, http.StatusBadRequest},
+		{"stdio on http server", false, map[string]interface{}{"endpoint": "https://valid.example.com", "stdio": stdio}, http.StatusBadRequest},
+		{"endpoint on stdio server", false, map[string]interface{}{"transport": "stdio", "stdio": stdio, "endpoint": "https://valid.example.com"}, http.StatusBadRequest},
+		{"custom headers on stdio server", false, map[string]interface{}{"transport": "stdio", "stdio": stdio, "custom_headers": map[string]string{"X-A": "b"}}, http.StatusBadRequest},
+		{"bearer auth on stdio server", false, map[string]interface{}{"transport": "stdio", "stdio": stdio, "auth_type": "bearer"}, http.StatusBadRequest},
+		{"relative command", false, map[string]interface{}{"transport": "stdio", "stdio": map[string]interface{}{"command": "mcp-files"}}, http.StatusBadRequest},
+		{"invalid env name", false, map[string]interface{}{"transport": "stdio", "stdio": map[string]interface{}{"command": "/bin/mcp", "env": map[string]string{"1BAD": "x"}}}, http.StatusBadRequest},
+		{"env sets PATH", false, map[string]interface{}{"transport": "stdio", "stdio": map[string]interface{}{"command": "/bin/mcp", "env": map[string]string{"PATH": "/tmp"}}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, testEncKey, nil)
+			if !tt.disabled {
+				sup := gateway.NewStdioSupervisor(gateway.StdioSupervisorConfig{})
+				defer sup.Close()
+				h.SetStdioSupervisor(sup)
+			}
+			tt.body["label"] = "stdio-test"
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/mcp-servers", tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			data := parseEnvelope(t, w).Data.(map[string]interface{})
+			if data["transport"] != "stdio" {
+				t.Errorf("transport = %v, want stdio", data["transport"])
+			}
+			got, _ := data["stdio"].(map[string]interface{})
+			if got["command"] != "/usr/local/bin/mcp-files" || got["env_configured"] != true {
+				t.Errorf("stdio = %v", got)
+			}
+			if strings.Contains(w.Body.String(), "t0k") {
+				t.Error("response must not expose stdio env values")
+			}
+			for _, srv := range mcpStore.servers {
+				if srv.StdioEnv == "" || strings.Contains(srv.StdioEnv, "t0k") {
+					t.Errorf("stored env = %q, want encrypted", srv.StdioEnv)
+				}
+				if srv.Endpoint != "" {
+					t.Errorf("stored endpoint = %q, want empty", srv.Endpoint)
+				}
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_Update_Stdio(t *testing.T) {
+	testEncKey := make([]byte, 32)
+	for i := range testEncKey {
+		testEncKey[i] = byte(i)
+	}
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{"replace command", map[string]interface{}{"stdio": map[string]interface{}{"command": "/opt/mcp/v2"}}, http.StatusOK},
+		{"change transport", map[string]interface{}{"transport": "http"}, http.StatusBadRequest},
+		{"set endpoint", map[string]interface{}{"endpoint": "https://valid.example.com"}, http.StatusBadRequest},
+		{"invalid command", map[string]interface{}{"stdio": map[string]interface{}{"command": ""}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpStore := newMockMCPServerStore()
+			h := NewMCPServersHandler(mcpStore, &mockAuditStoreForAPI{}, testEncKey, nil)
+			serverID := uuid.New()
+			updatedAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+			mcpStore.servers[serverID] = &store.MCPServer{
+				ID: serverID, Label: "stdio-server", AuthType: "none", IsEnabled: true,
+				Transport: "stdio", StdioCommand: "/opt/mcp/v1", StdioArgs: json.RawMessage(`[]`),
+				CircuitBreaker: json.RawMessage(`{}`), DiscoveryInterval: "5m",
+				CreatedAt: time.Now(), UpdatedAt: updatedAt,
+			}
+			mcpStore.labels["stdio-server"] = serverID
+
+			req := adminRequest(http.MethodPut, "/api/v1/mcp-servers/"+serverID.String(), tt.body)
+			req.Header.Set("If-Match", updatedAt.UTC().Format(time.RFC3339Nano))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("serverId", serverID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+			h.Update(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && mcpStore.servers[serverID].StdioCommand != "/opt/mcp/v2" {
+				t.Errorf("command = %q, want /opt/mcp/v2", mcpStore.servers[serverID].StdioCommand)
+			}
+		})
+	}
+}
+
+func TestMCPServersHandler_PurgeCache(t *testing.T) {
+	mcpStore := newMockMCPServerStore()
+	audit := &mockAuditStoreForAPI{}
+	h := NewMCPServersHandler(mcpStore, audit, nil, nil)
+
+	serverID := uuid.New()
+	mcpStore.servers[serverID] = &store.MCPServer{ID: serverID, Label: "cached", Endpoint: "https://mcp.example.com"}
+	mcpStore.labels["cached"] = serverID
+
+	purge := func(query string) *httptest.ResponseRecorder {
+		req := adminRequest(http.MethodDelete, "/api/v1/mcp-servers/"+serverID.String()+"/cache"+query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("serverId", serverID.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		h.PurgeCache(w, req)
+		return w
+	}
+
+	if w := purge(""); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without a cache: expected 503, got %d", w.Code)
+	}
+
+	cache := gateway.NewResponseCache(10)
+	h.SetResponseCache(cache)
+	ok := &gateway.ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{"result":{}}`)}
+	cache.Set(gateway.CacheKey{Server: "cached", Tool: "search"}, ok, time.Minute)
+	cache.Set(gateway.CacheKey{Server: "cached", Tool: "get"}, ok, time.Minute)
+
+	w := purge("?tool=search")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := parseEnvelope(t, w).Data.(map[string]interface{})["purged"].(float64); n != 1 {
+		t.Errorf("purged = %v, want 1", n)
+	}
+	if n := parseEnvelope(t, purge("")).Data.(map[string]interface{})["purged"].(float64); n != 1 {
+		t.Errorf("purged = %v, want 1", n)
+	}
+	if len(audit.entries) != 2 || audit.entries[0].Action != "mcp_cache_purge" || audit.entries[0].ResourceID != serverID.String()+"/search" {
+		t.Errorf("unexpected audit entries: %+v", audit.entries)
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// maxSchemaOverlaySize bounds an admin schema overlay document.
+const maxSchemaOverlaySize = 16 << 10
+
+// ToolSchemaStoreForAPI is the interface the tool schemas handler needs from the store.
+type ToolSchemaStoreForAPI interface {
+	ListByServer(ctx context.Context, serverID uuid.UUID) ([]store.MCPToolSchema, error)
+	SetOverlay(ctx context.Context, serverID uuid.UUID, toolName string, overlay json.RawMessage) (*store.MCPToolSchema, error)
+}
+
+// MCPServerLookup resolves an MCP server by ID for nested server routes.
+type MCPServerLookup interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*store.MCPServer, error)
+}
+
+// ToolDiscoverer runs tools/list discovery against an upstream server.
+type ToolDiscoverer interface {
+	DiscoverTools(ctx context.Context, server *store.MCPServer) ([]store.MCPToolSchema, error)
+}
+
+// ToolSchemasHandler provides HTTP handlers for discovered tool schemas and
+// admin schema overlays.
+type ToolSchemasHandler struct {
+	servers    MCPServerLookup
+	schemas    ToolSchemaStoreForAPI
+	discoverer ToolDiscoverer
+	audit      AuditStoreForAPI
+}
+
+// NewToolSchemasHandler creates a new ToolSchemasHandler. discoverer may be
+// nil, in which case on-demand discovery is unavailable.
+func NewToolSchemasHandler(servers MCPServerLookup, schemas ToolSchemaStoreForAPI, discoverer ToolDiscoverer, audit AuditStoreForAPI) *ToolSchemasHandler {
+	return &ToolSchemasHandler{
+		servers:    servers,
+		schemas:    schemas,
+		discoverer: discoverer,
+		audit:      audit,
+	}
+}
+
+func (h *ToolSchemasHandler) server(w http.ResponseWriter, r *http.Request) (*store.MCPServer, bool) {
+	serverID, err := uuid.Parse(chi.URLParam(r, "serverId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid server ID"))
+		return nil, false
+	}
+	server, err := h.servers.GetByID(r.Context(), serverID)
+	if err != nil {
+		RespondError(w, r, apierrors.NotFound("mcp_server", serverID.String()))
+		return nil, false
+	}
+	return server, true
+}
+
+// List handles GET /api/v1/mcp-servers/{serverId}/tools.
+func (h *ToolSchemasHandler) List(w http.ResponseWriter, r *http.Request) {
+	server, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+
+	tools, err := h.schemas.ListByServer(r.Context(), server.ID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to list tool schemas"))
+		return
+	}
+	if tools == nil {
+		tools = []store.MCPToolSchema{}
+	}
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"tools": tools,
+		"total": len(tools),
+	})
+}
+
+// Discover handles POST /api/v1/mcp-servers/{serverId}/tools/discover.
+func (h *ToolSchemasHandler) Discover(w http.ResponseWriter, r *http.Request) {
+	if h.discoverer == nil {
+		RespondError(w, r, apierrors.ServiceUnavailable("tool discovery is not configured"))
+		return
+	}
+	server, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+
+	tools, err := h.discoverer.DiscoverTools(r.Context(), server)
+	if err != nil {
+		log.Printf("tool discovery for %s failed: %v", server.Label, err)
+		RespondError(w, r, apierrors.BadGateway("tool discovery failed"))
+		return
+	}
+	if tools == nil {
+		tools = []store.MCPToolSchema{}
+	}
+
+	h.auditLog(r, "mcp_tools_discover", "mcp_server", server.ID.String())
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"tools": tools,
+		"total": len(tools),
+	})
+}
+
+// SetOverlay handles PUT /api/v1/mcp-servers/{serverId}/tools/{toolName}/overlay.
+// The request body is the overlay schema itself; an empty object clears it.
+func (h *ToolSchemasHandler) SetOverlay(w http.ResponseWriter, r *http.Request) {
+	server, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	toolName := chi.URLParam(r, "toolName")
+	if strings.TrimSpace(toolName) == "" || len(toolName) > 200 {
+		RespondError(w, r, apierrors.Validation("invalid tool name"))
+		return
+	}
+
+	var overlay json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSchemaOverlaySize)).Decode(&overlay); err != nil {
+		RespondError(w, r, apierrors.Validation("request body must be a JSON schema of at most 16KB"))
+		return
+	}
+	if err := gateway.CheckSchema(overlay); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid schema overlay: "+err.Error()))
+		return
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, overlay); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid schema overlay"))
+		return
+	}
+
+	tool, err := h.schemas.SetOverlay(r.Context(), server.ID, toolName, compact.Bytes())
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to set schema overlay"))
+		return
+	}
+
+	h.auditLog(r, "mcp_tool_overlay_update", "mcp_tool", server.ID.String()+"/"+toolName)
+
+	RespondJSON(w, r, http.StatusOK, tool)
+}
+
+func (h *ToolSchemasHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/ratelimit"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+func toolSchemaRequest(method, url string, body []byte, serverID uuid.UUID, toolName string) *http.Request {
+	req := httptest.NewRequest(method, url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := auth.ContextWithUser(req.Context(), uuid.New(), "admin", "session")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("serverId", serverID.String())
+	if toolName != "" {
+		rctx.URLParams.Add("toolName", toolName)
+	}
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+func newTestToolSchemasHandler(t *testing.T, schemas *mockToolSchemaStore, discoverer ToolDiscoverer) (*ToolSchemasHandler, *store.MCPServer, *mockAuditStoreForAPI) {
+	t.Helper()
+	servers := newMockMCPServerStore()
+	server := enabledMCPServer()
+	if err := servers.Create(context.Background(), server); err != nil {
+		t.Fatalf("create server: %v", err)
+	}
+	audit := &mockAuditStoreForAPI{}
+	return NewToolSchemasHandler(servers, schemas, discoverer, audit), server, audit
+}
+
+func TestToolSchemasHandler_List(t *testing.T) {
+	h, server, _ := newTestToolSchemasHandler(t, newMockToolSchemaStore(searchToolSchema), nil)
+
+	w := httptest.NewRecorder()
+	h.List(w, toolSchemaRequest(http.MethodGet, "/api/v1/mcp-servers/x/tools", nil, server.ID, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	if data["total"].(float64) != 1 {
+		t.Errorf("total = %v, want 1", data["total"])
+	}
+
+	w = httptest.NewRecorder()
+	h.List(w, toolSchemaRequest(http.MethodGet, "/api/v1/mcp-servers/x/tools", nil, uuid.New(), ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown server: expected 404, got %d", w.Code)
+	}
+}
+
+func TestToolSchemasHandler_SetOverlay(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"tighten limit", `{"properties":{"limit":{"maximum":5}}}`, http.StatusOK},
+		{"clear overlay", `{}`, http.StatusOK},
+		{"unsupported keyword", `{"properties":{"url":{"format":"uri"}}}`, http.StatusBadRequest},
+		{"invalid pattern", `{"properties":{"q":{"pattern":"("}}}`, http.StatusBadRequest},
+		{"not an object", `["a"]`, http.StatusBadRequest},
+		{"malformed", `{"type":`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemas := newMockToolSchemaStore(searchToolSchema)
+			h, server, audit := newTestToolSchemasHandler(t, schemas, nil)
+
+			w := httptest.NewRecorder()
+			h.SetOverlay(w, toolSchemaRequest(http.MethodPut, "/api/v1/mcp-servers/x/tools/search/overlay", []byte(tt.body), server.ID, "search"))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var compact bytes.Buffer
+			json.Compact(&compact, []byte(tt.body))
+			if got := string(schemas.tools["search"].SchemaOverlay); got != compact.String() {
+				t.Errorf("overlay = %s, want %s", got, compact.String())
+			}
+			if len(audit.entries) != 1 || audit.entries[0].Action != "mcp_tool_overlay_update" {
+				t.Errorf("expected overlay update audit entry, got %+v", audit.entries)
+			}
+		})
+	}
+}
+
+func TestToolSchemasHandler_Discover(t *testing.T) {
+	schemas := newMockToolSchemaStore()
+	lister := &mockToolLister{tools: []gateway.UpstreamTool{{Name: "search", InputSchema: json.RawMessage(`{"type":"object"}`)}}}
+	gw := newTestGatewayHandler(&mockGatewayServerStore{}, gateway.NewTrustClassifier(nil, nil, nil), gateway.NewCircuitBreaker(), &mockProxyForwarder{}, ratelimit.NewRateLimiter())
+	gw.SetToolSchemas(schemas, lister)
+	h, server, audit := newTestToolSchemasHandler(t, schemas, gw)
+
+	w := httptest.NewRecorder()
+	h.Discover(w, toolSchemaRequest(http.MethodPost, "/api/v1/mcp-servers/x/tools/discover", nil, server.ID, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := schemas.tools["search"]; !ok {
+		t.Error("expected discovered tool to be stored")
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != "mcp_tools_discover" {
+		t.Errorf("expected discovery audit entry, got %+v", audit.entries)
+	}
+
+	lister.err = errors.New("connection refused")
+	w = httptest.NewRecorder()
+	h.Discover(w, toolSchemaRequest(http.MethodPost, "/api/v1/mcp-servers/x/tools/discover", nil, server.ID, ""))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("upstream failure: expected 502, got %d", w.Code)
+	}
+}
+
+func TestToolSchemasHandler_DiscoverUnavailable(t *testing.T) {
+	h, server, _ := newTestToolSchemasHandler(t, newMockToolSchemaStore(), nil)
+
+	w := httptest.NewRecorder()
+	h.Discover(w, toolSchemaRequest(http.MethodPost, "/api/v1/mcp-servers/x/tools/discover", nil, server.ID, ""))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
@@ -76,6 +76,9 @@ func (m *mockPromptStoreForMCPTools) Activate(ctx context.Context, id uuid.UUID)
 func (m *mockPromptStoreForMCPTools) Rollback(ctx context.Context, agentID string, targetVersion int, actor string) (*store.Prompt, error) {
 	return nil, nil
 }
+func (m *mockPromptStoreForMCPTools) GetByVersion(ctx context.Context, agentID string, version int) (*store.Prompt, error) {
+	return nil, nil
+}
 
 type mockMCPServerStoreForMCPTools struct {
 	listFn func(ctx context.Context) ([]store.MCPServer, error)
@@ -17,9 +17,9 @@ type SessionLookup interface {
 	TouchSession(ctx context.Context, sessionID string) error
 }
 
-// APIKeyLookup validates an API key and returns user info.
+// APIKeyLookup validates an API key and returns user info and the key's ID.
 type APIKeyLookup interface {
-	ValidateAPIKey(ctx context.Context, key string) (userID uuid.UUID, role string, err error)
+	ValidateAPIKey(ctx context.Context, key string) (userID uuid.UUID, role string, keyID uuid.UUID, err error)
 }
 
 // AuthMiddleware returns middleware that authenticates requests via API key or session.
@@ -31,12 +31,13 @@ func AuthMiddleware(sessions SessionLookup, apiKeys APIKeyLookup) func(http.Hand
 			if authHeader := r.Header.Get("Authorization"); authHeader != "" {
 				if strings.HasPrefix(authHeader, "Bearer areg_") {
 					key := strings.TrimPrefix(authHeader, "Bearer ")
-					userID, role, err := apiKeys.ValidateAPIKey(r.Context(), key)
+					userID, role, keyID, err := apiKeys.ValidateAPIKey(r.Context(), key)
 					if err != nil {
 						RespondError(w, r, apierrors.Unauthorized("invalid API key"))
 						return
 					}
 					ctx := auth.ContextWithUser(r.Context(), userID, role, "apikey")
+					ctx = auth.ContextWithAPIKeyID(ctx, keyID)
 					next.ServeHTTP(w, r.WithContext(ctx))
 					return
 				}
@@ -48,31 +48,34 @@ type mockAPIKeyLookup struct {
 type keyInfo struct {
 	userID uuid.UUID
 	role   string
+	keyID  uuid.UUID
 }
 
-func (m *mockAPIKeyLookup) ValidateAPIKey(_ context.Context, key string) (uuid.UUID, string, error) {
+func (m *mockAPIKeyLookup) ValidateAPIKey(_ context.Context, key string) (uuid.UUID, string, uuid.UUID, error) {
 	k, ok := m.keys[key]
 	if !ok {
-		return uuid.Nil, "", fmt.Errorf("invalid key")
+		return uuid.Nil, "", uuid.Nil, fmt.Errorf("invalid key")
 	}
-	return k.userID, k.role, nil
+	return k.userID, k.role, k.keyID, nil
 }
 
 // --- Tests ---
 
 func TestAuthMiddlewareAPIKey(t *testing.T) {
 	userID := uuid.New()
+	keyID := uuid.New()
 	apiKeys := &mockAPIKeyLookup{
 		keys: map[string]keyInfo{
-			"areg_abcdef1234567890abcdef1234567890": {userID: userID, role: "admin"},
+			"areg_abcdef1234567890abcdef1234567890": {userID: userID, role: "admin", keyID: keyID},
 		},
 	}
 	sessions := &mockSessionLookup{sessions: make(map[string]sessionInfo)}
 
-	var capturedUserID uuid.UUID
+	var capturedUserID, capturedKeyID uuid.UUID
 	var capturedAuthType string
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		capturedUserID, _ = auth.UserIDFromContext(r.Context())
+		capturedKeyID, _ = auth.APIKeyIDFromContext(r.Context())
 		capturedAuthType, _ = auth.AuthTypeFromContext(r.Context())
 		w.WriteHeader(http.StatusOK)
 	})
@@ -95,6 +98,9 @@ func TestAuthMiddlewareAPIKey(t *testing.T) {
 	if capturedAuthType != "apikey" {
 		t.Fatalf("expected auth type 'apikey', got %s", capturedAuthType)
 	}
+	if capturedKeyID != keyID {
+		t.Fatalf("expected API key ID %s, got %s", keyID, capturedKeyID)
+	}
 }
 
 func TestAuthMiddlewareInvalidAPIKey(t *testing.T) {
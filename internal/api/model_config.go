@@ -94,13 +94,18 @@ func (h *ModelConfigHandler) UpdateGlobal(w http.ResponseWriter, r *http.Request
 
 	applyModelConfigUpdates(config, &req)
 
-	if err := h.configs.Update(r.Context(), config, etag); err != nil {
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.configs.Update(ctx, config, etag); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_config.updated", "model_config", "global")
+	})
+	if err != nil {
 		RespondError(w, r, apierrors.Conflict("model config was modified by another request"))
 		return
 	}
 
 	h.auditLog(r, "model_config_update", "model_config", "global")
-	h.dispatchEvent(r, "model_config.updated", "model_config", "global")
 
 	RespondJSON(w, r, http.StatusOK, config)
 }
@@ -144,13 +149,18 @@ func (h *ModelConfigHandler) UpdateWorkspace(w http.ResponseWriter, r *http.Requ
 	}
 	applyModelConfigUpdates(config, &req)
 
-	if err := h.configs.Upsert(r.Context(), config); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.configs.Upsert(ctx, config); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_config.updated", "model_config", "workspace/"+wsID)
+	})
+	if err != nil {
 		RespondError(w, r, apierrors.Internal("failed to upsert workspace model config"))
 		return
 	}
 
 	h.auditLog(r, "model_config_update", "model_config", "workspace/"+wsID)
-	h.dispatchEvent(r, "model_config.updated", "model_config", "workspace/"+wsID)
 
 	RespondJSON(w, r, http.StatusOK, config)
 }
@@ -202,15 +212,16 @@ func (h *ModelConfigHandler) auditLog(r *http.Request, action, resourceType, res
 	}
 }
 
-func (h *ModelConfigHandler) dispatchEvent(r *http.Request, eventType, resourceType, resourceID string) {
+func (h *ModelConfigHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string) error {
 	if h.dispatcher == nil {
-		return
+		return nil
 	}
 	callerID, _ := auth.UserIDFromContext(r.Context())
-	h.dispatcher.Dispatch(notify.Event{
+	return h.dispatcher.Dispatch(ctx, notify.Event{
 		Type:         eventType,
 		ResourceType: resourceType,
 		ResourceID:   resourceID,
+		WorkspaceID:  chi.URLParam(r, "workspaceId"),
 		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
 		Actor:        callerID.String(),
 	})
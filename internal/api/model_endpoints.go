@@ -168,7 +168,13 @@ func (h *ModelEndpointsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	initialConfig := json.RawMessage(`{}`)
-	if err := h.endpoints.Create(r.Context(), ep, initialConfig, ""); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.endpoints.Create(ctx, ep, initialConfig, ""); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_endpoint.created", "model_endpoint", ep, nil)
+	})
+	if err != nil {
 		if strings.Contains(err.Error(), "CONFLICT") {
 			RespondError(w, r, apierrors.Conflict("model endpoint with this slug already exists"))
 			return
@@ -178,7 +184,6 @@ func (h *ModelEndpointsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "model_endpoint_create", "model_endpoint", ep.Slug)
-	h.dispatchEvent(r, "model_endpoint.created", "model_endpoint", ep.Slug)
 
 	RespondJSON(w, r, http.StatusCreated, ep)
 }
@@ -301,7 +306,13 @@ func (h *ModelEndpointsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		existing.IsActive = *req.IsActive
 	}
 
-	if err := h.endpoints.Update(r.Context(), existing, etag); err != nil {
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.endpoints.Update(ctx, existing, etag); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_endpoint.updated", "model_endpoint", existing, nil)
+	})
+	if err != nil {
 		if strings.Contains(err.Error(), "CONFLICT") {
 			RespondError(w, r, apierrors.Conflict("model endpoint was modified by another client"))
 			return
@@ -315,7 +326,6 @@ func (h *ModelEndpointsHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "model_endpoint_update", "model_endpoint", slug)
-	h.dispatchEvent(r, "model_endpoint.updated", "model_endpoint", slug)
 
 	w.Header().Set("ETag", existing.UpdatedAt.UTC().Format(time.RFC3339Nano))
 	RespondJSON(w, r, http.StatusOK, existing)
@@ -329,7 +339,17 @@ func (h *ModelEndpointsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.endpoints.Delete(r.Context(), slug); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		ep, err := h.endpoints.GetBySlug(ctx, slug)
+		if err != nil {
+			return err
+		}
+		if err := h.endpoints.Delete(ctx, slug); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_endpoint.deleted", "model_endpoint", ep, nil)
+	})
+	if err != nil {
 		if strings.Contains(err.Error(), "NOT_FOUND") {
 			RespondError(w, r, apierrors.NotFound("model_endpoint", slug))
 			return
@@ -339,7 +359,6 @@ func (h *ModelEndpointsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "model_endpoint_delete", "model_endpoint", slug)
-	h.dispatchEvent(r, "model_endpoint.deleted", "model_endpoint", slug)
 
 	RespondNoContent(w)
 }
@@ -384,14 +403,24 @@ func (h *ModelEndpointsHandler) CreateVersion(w http.ResponseWriter, r *http.Req
 
 	callerID, _ := auth.UserIDFromContext(r.Context())
 
-	v, err := h.endpoints.CreateVersion(r.Context(), ep.ID, req.Config, req.ChangeNote, callerID.String())
+	var v *store.ModelEndpointVersion
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		var err error
+		if v, err = h.endpoints.CreateVersion(ctx, ep.ID, req.Config, req.ChangeNote, callerID.String()); err != nil {
+			return err
+		}
+		change, err := h.versionChange(ctx, v)
+		if err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_endpoint_version.created", "model_endpoint_version", ep, change)
+	})
 	if err != nil {
 		RespondError(w, r, apierrors.Internal("failed to create version"))
 		return
 	}
 
 	h.auditLog(r, "model_endpoint_version_create", "model_endpoint_version", slug)
-	h.dispatchEvent(r, "model_endpoint_version.created", "model_endpoint_version", slug)
 
 	RespondJSON(w, r, http.StatusCreated, v)
 }
@@ -504,7 +533,18 @@ func (h *ModelEndpointsHandler) ActivateVersion(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	v, err := h.endpoints.ActivateVersion(r.Context(), ep.ID, version)
+	var v *store.ModelEndpointVersion
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		var err error
+		if v, err = h.endpoints.ActivateVersion(ctx, ep.ID, version); err != nil {
+			return err
+		}
+		change, err := h.versionChange(ctx, v)
+		if err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_endpoint_version.activated", "model_endpoint_version", ep, change)
+	})
 	if err != nil {
 		if strings.Contains(err.Error(), "NOT_FOUND") {
 			RespondError(w, r, apierrors.NotFound("model_endpoint_version", versionStr))
@@ -515,7 +555,6 @@ func (h *ModelEndpointsHandler) ActivateVersion(w http.ResponseWriter, r *http.R
 	}
 
 	h.auditLog(r, "model_endpoint_version_activate", "model_endpoint_version", slug)
-	h.dispatchEvent(r, "model_endpoint_version.activated", "model_endpoint_version", slug)
 
 	// Redact headers before returning to prevent secret leakage
 	v.Config = redactConfigHeaders(v.Config)
@@ -675,7 +714,13 @@ func (h *ModelEndpointsHandler) CreateForWorkspace(w http.ResponseWriter, r *htt
 		CreatedBy:     callerID.String(),
 	}
 
-	if err := h.endpoints.Create(r.Context(), ep, initialConfig, req.ChangeNote); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.endpoints.Create(ctx, ep, initialConfig, req.ChangeNote); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "model_endpoint.created", "model_endpoint", ep, nil)
+	})
+	if err != nil {
 		if strings.Contains(err.Error(), "CONFLICT") {
 			RespondError(w, r, apierrors.Conflict("model endpoint with this slug already exists"))
 			return
@@ -685,7 +730,6 @@ func (h *ModelEndpointsHandler) CreateForWorkspace(w http.ResponseWriter, r *htt
 	}
 
 	h.auditLog(r, "model_endpoint_create", "model_endpoint", ep.Slug)
-	h.dispatchEvent(r, "model_endpoint.created", "model_endpoint", ep.Slug)
 
 	RespondJSON(w, r, http.StatusCreated, ep)
 }
@@ -799,16 +843,47 @@ func (h *ModelEndpointsHandler) auditLog(r *http.Request, action, resourceType,
 	}
 }
 
-func (h *ModelEndpointsHandler) dispatchEvent(r *http.Request, eventType, resourceType, resourceID string) {
+// versionChange describes an endpoint version for webhook payloads, diffed
+// against the version before it. Config headers are redacted.
+func (h *ModelEndpointsHandler) versionChange(ctx context.Context, v *store.ModelEndpointVersion) (*notify.Change, error) {
 	if h.dispatcher == nil {
-		return
+		return nil, nil
+	}
+	snapshot := *v
+	snapshot.Config = redactConfigHeaders(v.Config)
+	change := &notify.Change{Version: v.Version, Snapshot: snapshot}
+	if v.Version > 1 {
+		previous, err := h.endpoints.GetVersion(ctx, v.EndpointID, v.Version-1)
+		if err != nil && !isNotFoundError(err) {
+			return nil, err
+		}
+		if previous != nil {
+			p := *previous
+			p.Config = redactConfigHeaders(previous.Config)
+			change.Previous = p
+		}
+	}
+	return change, nil
+}
+
+// dispatchEvent dispatches an event of ep, identified by its slug and
+// scoped to its workspace, if it has one.
+func (h *ModelEndpointsHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType string, ep *store.ModelEndpoint, change *notify.Change) error {
+	if h.dispatcher == nil {
+		return nil
+	}
+	var workspaceID string
+	if ep.WorkspaceID != nil {
+		workspaceID = *ep.WorkspaceID
 	}
 	callerID, _ := auth.UserIDFromContext(r.Context())
-	h.dispatcher.Dispatch(notify.Event{
+	return h.dispatcher.Dispatch(ctx, notify.Event{
 		Type:         eventType,
 		ResourceType: resourceType,
-		ResourceID:   resourceID,
+		ResourceID:   ep.Slug,
+		WorkspaceID:  workspaceID,
 		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
 		Actor:        callerID.String(),
+		Change:       change,
 	})
 }
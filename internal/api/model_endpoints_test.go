@@ -645,6 +645,30 @@ func TestModelEndpointsHandler_Delete(t *testing.T) {
 	}
 }
 
+func TestModelEndpointsHandler_DeleteDispatchesWorkspaceEvent(t *testing.T) {
+	epStore := newMockModelEndpointStore()
+	d := &recordingDispatcher{}
+	h := NewModelEndpointsHandler(epStore, &mockAuditStoreForAPI{}, nil, d)
+
+	wsID := "team-a"
+	epID := uuid.New()
+	epStore.endpoints[epID] = &store.ModelEndpoint{
+		ID: epID, Slug: "team-endpoint", Name: "Team", IsActive: true, WorkspaceID: &wsID,
+	}
+	epStore.slugs["team-endpoint"] = epID
+
+	req := withSlugParam(agentRequest(http.MethodDelete, "/api/v1/model-endpoints/team-endpoint", nil, "editor"), "team-endpoint")
+	w := httptest.NewRecorder()
+	h.Delete(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if len(d.events) != 1 || d.events[0].ResourceID != "team-endpoint" || d.events[0].WorkspaceID != wsID {
+		t.Fatalf("expected a model_endpoint.deleted event scoped to %s, got %+v", wsID, d.events)
+	}
+}
+
 // --- Version tests ---
 
 func TestModelEndpointsHandler_CreateVersion(t *testing.T) {
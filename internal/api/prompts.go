@@ -31,6 +31,7 @@ type PromptStoreForAPI interface {
 	Create(ctx context.Context, prompt *store.Prompt) error
 	Activate(ctx context.Context, id uuid.UUID) (*store.Prompt, error)
 	Rollback(ctx context.Context, agentID string, targetVersion int, actor string) (*store.Prompt, error)
+	GetByVersion(ctx context.Context, agentID string, version int) (*store.Prompt, error)
 }
 
 // AgentLookupForPrompts is the minimal interface prompts handler needs to check agent existence.
@@ -179,13 +180,18 @@ func (h *PromptsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		CreatedBy:    userID.String(),
 	}
 
-	if err := h.prompts.Create(r.Context(), prompt); err != nil {
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.prompts.Create(ctx, prompt); err != nil {
+			return err
+		}
+		return h.dispatchPromptEvent(ctx, r, "prompt.created", prompt)
+	})
+	if err != nil {
 		RespondError(w, r, apierrors.Internal("failed to create prompt"))
 		return
 	}
 
 	h.auditLog(r, "prompt_create", "prompt", prompt.ID.String())
-	h.dispatchEvent(r, "prompt.created", "prompt", prompt.ID.String())
 
 	RespondJSON(w, r, http.StatusCreated, prompt)
 }
@@ -199,14 +205,20 @@ func (h *PromptsHandler) Activate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prompt, err := h.prompts.Activate(r.Context(), promptID)
+	var prompt *store.Prompt
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		var err error
+		if prompt, err = h.prompts.Activate(ctx, promptID); err != nil {
+			return err
+		}
+		return h.dispatchPromptEvent(ctx, r, "prompt.activated", prompt)
+	})
 	if err != nil {
 		RespondError(w, r, apierrors.NotFound("prompt", promptIDStr))
 		return
 	}
 
 	h.auditLog(r, "prompt_activate", "prompt", promptIDStr)
-	h.dispatchEvent(r, "prompt.activated", "prompt", promptIDStr)
 
 	RespondJSON(w, r, http.StatusOK, prompt)
 }
@@ -232,7 +244,14 @@ func (h *PromptsHandler) Rollback(w http.ResponseWriter, r *http.Request) {
 
 	userID, _ := auth.UserIDFromContext(r.Context())
 
-	prompt, err := h.prompts.Rollback(r.Context(), agentID, *req.TargetVersion, userID.String())
+	var prompt *store.Prompt
+	err := withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		var err error
+		if prompt, err = h.prompts.Rollback(ctx, agentID, *req.TargetVersion, userID.String()); err != nil {
+			return err
+		}
+		return h.dispatchPromptEvent(ctx, r, "prompt.rolled_back", prompt)
+	})
 	if err != nil {
 		if isNotFoundError(err) {
 			RespondError(w, r, apierrors.NotFound("prompt_version", agentID))
@@ -243,7 +262,6 @@ func (h *PromptsHandler) Rollback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.auditLog(r, "prompt_rollback", "prompt", prompt.ID.String())
-	h.dispatchEvent(r, "prompt.rolled_back", "prompt", prompt.ID.String())
 
 	RespondJSON(w, r, http.StatusOK, prompt)
 }
@@ -265,16 +283,29 @@ func (h *PromptsHandler) auditLog(r *http.Request, action, resourceType, resourc
 	}
 }
 
-func (h *PromptsHandler) dispatchEvent(r *http.Request, eventType, resourceType, resourceID string) {
+// dispatchPromptEvent dispatches an event for a prompt version. Its change
+// is diffed against the agent's preceding prompt version, if any.
+func (h *PromptsHandler) dispatchPromptEvent(ctx context.Context, r *http.Request, eventType string, prompt *store.Prompt) error {
 	if h.dispatcher == nil {
-		return
+		return nil
+	}
+	change := &notify.Change{Version: prompt.Version, Snapshot: prompt}
+	if prompt.Version > 1 {
+		previous, err := h.prompts.GetByVersion(ctx, prompt.AgentID, prompt.Version-1)
+		if err != nil && !isNotFoundError(err) {
+			return err
+		}
+		if previous != nil {
+			change.Previous = previous
+		}
 	}
 	callerID, _ := auth.UserIDFromContext(r.Context())
-	h.dispatcher.Dispatch(notify.Event{
+	return h.dispatcher.Dispatch(ctx, notify.Event{
 		Type:         eventType,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
+		ResourceType: "prompt",
+		ResourceID:   prompt.ID.String(),
 		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
 		Actor:        callerID.String(),
+		Change:       change,
 	})
 }
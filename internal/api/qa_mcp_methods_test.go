@@ -117,6 +117,9 @@ func (m *qaMockPromptStore) Activate(_ context.Context, _ uuid.UUID) (*store.Pro
 func (m *qaMockPromptStore) Rollback(_ context.Context, _ string, _ int, _ string) (*store.Prompt, error) {
 	panic("unused")
 }
+func (m *qaMockPromptStore) GetByVersion(_ context.Context, _ string, _ int) (*store.Prompt, error) {
+	panic("unused")
+}
 
 type qaMockMCPServerStore struct {
 	servers []store.MCPServer
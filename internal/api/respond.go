@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
+
 	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
 )
 
@@ -22,10 +24,19 @@ type Meta struct {
 	RequestID string `json:"request_id"`
 }
 
+// requestID returns the request's ID: the caller's X-Request-Id, or the ID
+// generated by the request ID middleware.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get("X-Request-Id"); id != "" {
+		return id
+	}
+	return middleware.GetReqID(r.Context())
+}
+
 func newMeta(r *http.Request) Meta {
 	reqID := ""
 	if r != nil {
-		reqID = r.Header.Get("X-Request-Id")
+		reqID = requestID(r)
 	}
 	return Meta{
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
@@ -48,13 +59,17 @@ func RespondJSON(w http.ResponseWriter, r *http.Request, status int, data interf
 
 // RespondError writes an error JSON response with the standard envelope.
 func RespondError(w http.ResponseWriter, r *http.Request, err *apierrors.APIError) {
+	body := map[string]interface{}{
+		"code":    err.Code,
+		"message": err.Message,
+	}
+	if err.Details != nil {
+		body["details"] = err.Details
+	}
 	env := Envelope{
 		Success: false,
-		Error: map[string]string{
-			"code":    err.Code,
-			"message": err.Message,
-		},
-		Meta: newMeta(r),
+		Error:   body,
+		Meta:    newMeta(r),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -171,6 +171,30 @@ func TestRespondError(t *testing.T) {
 	}
 }
 
+func TestRespondError_Details(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	RespondError(w, r, apierrors.Validation("bad arguments").WithDetails([]string{"$.query: required"}))
+
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal body: %v", err)
+	}
+	errObj := body["error"].(map[string]any)
+	details, ok := errObj["details"].([]any)
+	if !ok || len(details) != 1 {
+		t.Errorf("error.details = %v, want one entry", errObj["details"])
+	}
+
+	w = httptest.NewRecorder()
+	RespondError(w, r, apierrors.Validation("bad arguments"))
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if _, ok := body["error"].(map[string]any)["details"]; ok {
+		t.Error("expected details to be omitted when unset")
+	}
+}
+
 func TestRespondNoContent(t *testing.T) {
 	w := httptest.NewRecorder()
 
@@ -35,15 +35,24 @@ type RouterConfig struct {
 	Agents        *AgentsHandler
 	Prompts       *PromptsHandler
 	MCPServers    *MCPServersHandler
+	ToolSchemas   *ToolSchemasHandler
+	Circuits      *CircuitsHandler
+	Recordings    *RecordingsHandler
 	TrustRules    *TrustRulesHandler
 	TrustDefaults *TrustDefaultsHandler
+	Budgets       *WorkspaceBudgetsHandler
+	WorkspaceSettings *WorkspaceSettingsHandler
+	WorkspaceMembers  *WorkspaceMembersHandler
+	EgressRules   *EgressRulesHandler
 	ModelConfig    *ModelConfigHandler
 	ModelEndpoints *ModelEndpointsHandler
 	Webhooks      *WebhooksHandler
+	WebhookDeliveries *WebhookDeliveriesHandler
 	Discovery     *DiscoveryHandler
 	A2A           *A2AHandler
 	MCP           *MCPHandler
 	MCPGateway    *MCPGatewayHandler
+	GatewayUsage  *GatewayUsageHandler
 	AuditLog      *AuditHandler
 	AuthMW        func(http.Handler) http.Handler
 	UserLookup    UserLookup             // For MustChangePassMiddleware (nil = no enforcement)
@@ -242,6 +251,21 @@ func NewRouter(cfg RouterConfig) chi.Router {
 				r.Get("/{serverId}", cfg.MCPServers.Get)
 				r.Put("/{serverId}", cfg.MCPServers.Update)
 				r.Delete("/{serverId}", cfg.MCPServers.Delete)
+				r.Delete("/{serverId}/cache", cfg.MCPServers.PurgeCache)
+				if cfg.ToolSchemas != nil {
+					r.Get("/{serverId}/tools", cfg.ToolSchemas.List)
+					r.Post("/{serverId}/tools/discover", cfg.ToolSchemas.Discover)
+					r.Put("/{serverId}/tools/{toolName}/overlay", cfg.ToolSchemas.SetOverlay)
+				}
+				if cfg.Circuits != nil {
+					r.Get("/{serverId}/circuit", cfg.Circuits.Get)
+					r.Post("/{serverId}/circuit/open", cfg.Circuits.Open)
+					r.Post("/{serverId}/circuit/close", cfg.Circuits.Close)
+				}
+				if cfg.Recordings != nil {
+					r.Get("/{serverId}/recordings", cfg.Recordings.List)
+					r.Delete("/{serverId}/recordings", cfg.Recordings.Delete)
+				}
 			})
 		}
 
@@ -254,6 +278,16 @@ func NewRouter(cfg RouterConfig) chi.Router {
 			})
 		}
 
+		// Egress Rules (admin only)
+		if cfg.EgressRules != nil {
+			r.Route("/egress-rules", func(r chi.Router) {
+				r.Use(RequireRole("admin"))
+				r.Get("/", cfg.EgressRules.List)
+				r.Post("/", cfg.EgressRules.Create)
+				r.Delete("/{ruleId}", cfg.EgressRules.Delete)
+			})
+		}
+
 		// Model Config (admin only for global)
 		if cfg.ModelConfig != nil {
 			r.Route("/model-config", func(r chi.Router) {
@@ -293,7 +327,29 @@ func NewRouter(cfg RouterConfig) chi.Router {
 				r.Use(RequireRole("admin"))
 				r.Get("/", cfg.Webhooks.List)
 				r.Post("/", cfg.Webhooks.Create)
+				r.Get("/event-types", cfg.Webhooks.EventTypes)
+				r.Get("/{webhookId}", cfg.Webhooks.Get)
+				r.Put("/{webhookId}", cfg.Webhooks.Update)
+				r.Patch("/{webhookId}", cfg.Webhooks.Patch)
 				r.Delete("/{webhookId}", cfg.Webhooks.Delete)
+				r.Post("/{webhookId}/pause", cfg.Webhooks.Pause)
+				r.Post("/{webhookId}/resume", cfg.Webhooks.Resume)
+				r.Post("/{webhookId}/ping", cfg.Webhooks.Ping)
+				r.Post("/{webhookId}/rotate-secret", cfg.Webhooks.RotateSecret)
+				if cfg.WebhookDeliveries != nil {
+					r.Get("/{webhookId}/deliveries", cfg.WebhookDeliveries.List)
+					r.Post("/{webhookId}/deliveries/redeliver", cfg.WebhookDeliveries.RedeliverFailed)
+					r.Get("/{webhookId}/deliveries/{deliveryId}", cfg.WebhookDeliveries.Get)
+					r.Post("/{webhookId}/deliveries/{deliveryId}/redeliver", cfg.WebhookDeliveries.Redeliver)
+				}
+			})
+		}
+
+		// Gateway usage analytics (admin only)
+		if cfg.GatewayUsage != nil {
+			r.Route("/gateway/usage", func(r chi.Router) {
+				r.Use(RequireRole("admin"))
+				r.Get("/", cfg.GatewayUsage.Query)
 			})
 		}
 
@@ -333,6 +389,35 @@ func NewRouter(cfg RouterConfig) chi.Router {
 				})
 			}
 
+			// Workspace Budget (admin only)
+			if cfg.Budgets != nil {
+				r.Route("/budget", func(r chi.Router) {
+					r.Use(RequireRole("admin"))
+					r.Get("/", cfg.Budgets.Get)
+					r.Put("/", cfg.Budgets.Put)
+					r.Delete("/", cfg.Budgets.Delete)
+				})
+			}
+
+			// Workspace Members (admin only)
+			if cfg.WorkspaceMembers != nil {
+				r.Route("/members", func(r chi.Router) {
+					r.Use(RequireRole("admin"))
+					r.Get("/", cfg.WorkspaceMembers.List)
+					r.Post("/", cfg.WorkspaceMembers.Add)
+					r.Delete("/{memberId}", cfg.WorkspaceMembers.Remove)
+				})
+			}
+
+			// Workspace Settings (admin only)
+			if cfg.WorkspaceSettings != nil {
+				r.Route("/settings", func(r chi.Router) {
+					r.Use(RequireRole("admin"))
+					r.Get("/", cfg.WorkspaceSettings.Get)
+					r.Put("/", cfg.WorkspaceSettings.Update)
+				})
+			}
+
 			// Model Config (workspace-scoped, editor+)
 			if cfg.ModelConfig != nil {
 				r.Route("/model-config", func(r chi.Router) {
@@ -99,13 +99,18 @@ func (h *TrustDefaultsHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	d.UpdatedAt = etag
 
-	if err := h.defaults.Update(r.Context(), d); err != nil {
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.defaults.Update(ctx, d); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "trust_default.changed", "trust_default", d.ID.String())
+	})
+	if err != nil {
 		RespondError(w, r, apierrors.Conflict("trust default was modified by another request"))
 		return
 	}
 
 	h.auditLog(r, "trust_default_update", "trust_default", d.ID.String())
-	h.dispatchEvent(r, "trust_default.changed", "trust_default", d.ID.String())
 
 	RespondJSON(w, r, http.StatusOK, d)
 }
@@ -127,12 +132,12 @@ func (h *TrustDefaultsHandler) auditLog(r *http.Request, action, resourceType, r
 	}
 }
 
-func (h *TrustDefaultsHandler) dispatchEvent(r *http.Request, eventType, resourceType, resourceID string) {
+func (h *TrustDefaultsHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string) error {
 	if h.dispatcher == nil {
-		return
+		return nil
 	}
 	callerID, _ := auth.UserIDFromContext(r.Context())
-	h.dispatcher.Dispatch(notify.Event{
+	return h.dispatcher.Dispatch(ctx, notify.Event{
 		Type:         eventType,
 		ResourceType: resourceType,
 		ResourceID:   resourceID,
@@ -120,13 +120,18 @@ func (h *TrustRulesHandler) Create(w http.ResponseWriter, r *http.Request) {
 		CreatedBy:   callerID.String(),
 	}
 
-	if err := h.rules.Upsert(r.Context(), rule); err != nil {
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.rules.Upsert(ctx, rule); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "trust_rule.changed", "trust_rule", rule.ID.String())
+	})
+	if err != nil {
 		RespondError(w, r, apierrors.Internal("failed to create trust rule"))
 		return
 	}
 
 	h.auditLog(r, "trust_rule_upsert", "trust_rule", rule.ID.String())
-	h.dispatchEvent(r, "trust_rule.changed", "trust_rule", rule.ID.String())
 
 	RespondJSON(w, r, http.StatusCreated, rule)
 }
@@ -163,13 +168,18 @@ func (h *TrustRulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.rules.Delete(r.Context(), ruleID); err != nil {
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.rules.Delete(ctx, ruleID); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "trust_rule.changed", "trust_rule", ruleID.String())
+	})
+	if err != nil {
 		RespondError(w, r, apierrors.NotFound("trust_rule", ruleID.String()))
 		return
 	}
 
 	h.auditLog(r, "trust_rule_delete", "trust_rule", ruleID.String())
-	h.dispatchEvent(r, "trust_rule.changed", "trust_rule", ruleID.String())
 
 	RespondNoContent(w)
 }
@@ -191,15 +201,16 @@ func (h *TrustRulesHandler) auditLog(r *http.Request, action, resourceType, reso
 	}
 }
 
-func (h *TrustRulesHandler) dispatchEvent(r *http.Request, eventType, resourceType, resourceID string) {
+func (h *TrustRulesHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string) error {
 	if h.dispatcher == nil {
-		return
+		return nil
 	}
 	callerID, _ := auth.UserIDFromContext(r.Context())
-	h.dispatcher.Dispatch(notify.Event{
+	return h.dispatcher.Dispatch(ctx, notify.Event{
 		Type:         eventType,
 		ResourceType: resourceType,
 		ResourceID:   resourceID,
+		WorkspaceID:  chi.URLParam(r, "workspaceId"),
 		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
 		Actor:        callerID.String(),
 	})
@@ -14,24 +14,33 @@ var canonicalIPRegex = regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`
 
 // validateEndpointURL checks that an endpoint is a valid HTTP(S) URL pointing to a public host.
 func validateEndpointURL(endpoint string) error {
+	u, err := parseEndpointURL(endpoint)
+	if err != nil {
+		return err
+	}
+	if isPrivateHost(u.Hostname()) {
+		return apierrors.Validation("endpoint must not point to a private or internal address")
+	}
+	return nil
+}
+
+// parseEndpointURL checks that an endpoint is a valid HTTP(S) URL with a host,
+// without restricting where the host points.
+func parseEndpointURL(endpoint string) (*url.URL, error) {
 	if len(endpoint) > 2000 {
-		return apierrors.Validation("endpoint must be at most 2000 characters")
+		return nil, apierrors.Validation("endpoint must be at most 2000 characters")
 	}
 	u, err := url.Parse(endpoint)
 	if err != nil {
-		return apierrors.Validation("endpoint is not a valid URL")
+		return nil, apierrors.Validation("endpoint is not a valid URL")
 	}
 	if u.Scheme != "http" && u.Scheme != "https" {
-		return apierrors.Validation("endpoint must use http or https scheme")
+		return nil, apierrors.Validation("endpoint must use http or https scheme")
 	}
-	host := u.Hostname()
-	if host == "" {
-		return apierrors.Validation("endpoint must have a valid host")
+	if u.Hostname() == "" {
+		return nil, apierrors.Validation("endpoint must have a valid host")
 	}
-	if isPrivateHost(host) {
-		return apierrors.Validation("endpoint must not point to a private or internal address")
-	}
-	return nil
+	return u, nil
 }
 
 // isPrivateHost returns true if the host is a private/internal/loopback address.
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// WebhookLookup loads a webhook subscription by ID.
+type WebhookLookup interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*store.WebhookSubscription, error)
+}
+
+// WebhookDeliveryStoreForAPI is the interface the webhook deliveries handler
+// needs from the store.
+type WebhookDeliveryStoreForAPI interface {
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, f store.WebhookDeliveryFilter, offset, limit int) ([]store.WebhookOutboxEntry, int, error)
+	GetDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*store.WebhookOutboxEntry, error)
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]store.WebhookDeliveryAttempt, error)
+	Redeliver(ctx context.Context, subscriptionID, id uuid.UUID) (*store.WebhookOutboxEntry, error)
+	RedeliverFailed(ctx context.Context, subscriptionID uuid.UUID, from, to time.Time) (int64, error)
+}
+
+var validDeliveryStatuses = map[string]bool{
+	store.OutboxPending:   true,
+	store.OutboxDelivered: true,
+	store.OutboxFailed:    true,
+}
+
+// WebhookDeliveriesHandler provides HTTP handlers to inspect and redeliver
+// the deliveries of a webhook subscription.
+type WebhookDeliveriesHandler struct {
+	webhooks   WebhookLookup
+	deliveries WebhookDeliveryStoreForAPI
+	audit      AuditStoreForAPI
+}
+
+// NewWebhookDeliveriesHandler creates a new WebhookDeliveriesHandler.
+func NewWebhookDeliveriesHandler(webhooks WebhookLookup, deliveries WebhookDeliveryStoreForAPI, audit AuditStoreForAPI) *WebhookDeliveriesHandler {
+	return &WebhookDeliveriesHandler{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		audit:      audit,
+	}
+}
+
+type webhookDeliveryResponse struct {
+	store.WebhookOutboxEntry
+	Attempts []store.WebhookDeliveryAttempt `json:"attempts"`
+}
+
+func (h *WebhookDeliveriesHandler) subscription(w http.ResponseWriter, r *http.Request) (*store.WebhookSubscription, bool) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid webhook ID"))
+		return nil, false
+	}
+	sub, err := h.webhooks.GetByID(r.Context(), webhookID)
+	if err != nil {
+		if isNotFoundError(err) {
+			RespondError(w, r, apierrors.NotFound("webhook_subscription", webhookID.String()))
+			return nil, false
+		}
+		RespondError(w, r, apierrors.Internal("failed to get webhook subscription"))
+		return nil, false
+	}
+	return sub, true
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp.
+func parseTimeParam(v, name string) (time.Time, *apierrors.APIError) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, apierrors.Validation(name + " must be an RFC 3339 timestamp")
+	}
+	return t.UTC(), nil
+}
+
+// List handles GET /api/v1/webhooks/{webhookId}/deliveries. The optional
+// status, event, from and to query parameters filter the list.
+func (h *WebhookDeliveriesHandler) List(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	f := store.WebhookDeliveryFilter{Status: q.Get("status"), EventType: q.Get("event")}
+	if f.Status != "" && !validDeliveryStatuses[f.Status] {
+		RespondError(w, r, apierrors.Validation("status must be one of pending, delivered, failed"))
+		return
+	}
+	var apiErr *apierrors.APIError
+	if f.From, apiErr = parseTimeParam(q.Get("from"), "from"); apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+	if f.To, apiErr = parseTimeParam(q.Get("to"), "to"); apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	deliveries, total, err := h.deliveries.ListDeliveries(r.Context(), sub.ID, f, offset, limit)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to list webhook deliveries"))
+		return
+	}
+	if deliveries == nil {
+		deliveries = []store.WebhookOutboxEntry{}
+	}
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"total":      total,
+	})
+}
+
+// Get handles GET /api/v1/webhooks/{webhookId}/deliveries/{deliveryId}. The
+// response includes every attempt of the delivery.
+func (h *WebhookDeliveriesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid delivery ID"))
+		return
+	}
+
+	delivery, err := h.deliveries.GetDelivery(r.Context(), sub.ID, deliveryID)
+	if err != nil {
+		if isNotFoundError(err) {
+			RespondError(w, r, apierrors.NotFound("webhook_delivery", deliveryID.String()))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to get webhook delivery"))
+		return
+	}
+	attempts, err := h.deliveries.ListAttempts(r.Context(), delivery.ID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to list webhook delivery attempts"))
+		return
+	}
+	if attempts == nil {
+		attempts = []store.WebhookDeliveryAttempt{}
+	}
+
+	RespondJSON(w, r, http.StatusOK, webhookDeliveryResponse{WebhookOutboxEntry: *delivery, Attempts: attempts})
+}
+
+// Redeliver handles POST /api/v1/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver.
+// It queues a new delivery of the same event.
+func (h *WebhookDeliveriesHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid delivery ID"))
+		return
+	}
+
+	delivery, err := h.deliveries.Redeliver(r.Context(), sub.ID, deliveryID)
+	if err != nil {
+		if isNotFoundError(err) {
+			RespondError(w, r, apierrors.NotFound("webhook_delivery", deliveryID.String()))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to redeliver webhook"))
+		return
+	}
+
+	h.auditLog(r, "webhook_redeliver", "webhook_delivery", deliveryID.String())
+
+	RespondJSON(w, r, http.StatusAccepted, delivery)
+}
+
+type redeliverFailedRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RedeliverFailed handles POST /api/v1/webhooks/{webhookId}/deliveries/redeliver.
+// It queues a new delivery for every failed delivery created in [from, to)
+// that has not been redelivered yet. to defaults to now.
+func (h *WebhookDeliveriesHandler) RedeliverFailed(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	var req redeliverFailedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+	if req.From == "" {
+		RespondError(w, r, apierrors.Validation("from is required"))
+		return
+	}
+	from, apiErr := parseTimeParam(req.From, "from")
+	if apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+	to, apiErr := parseTimeParam(req.To, "to")
+	if apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if !from.Before(to) {
+		RespondError(w, r, apierrors.Validation("from must be before to"))
+		return
+	}
+
+	n, err := h.deliveries.RedeliverFailed(r.Context(), sub.ID, from, to)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to redeliver webhooks"))
+		return
+	}
+
+	h.auditLog(r, "webhook_redeliver_failed", "webhook_subscription", sub.ID.String())
+
+	RespondJSON(w, r, http.StatusAccepted, map[string]interface{}{
+		"redelivered": n,
+	})
+}
+
+func (h *WebhookDeliveriesHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+type mockWebhookDeliveryStore struct {
+	deliveries []store.WebhookOutboxEntry
+	attempts   map[uuid.UUID][]store.WebhookDeliveryAttempt
+	lastFilter store.WebhookDeliveryFilter
+}
+
+func (m *mockWebhookDeliveryStore) ListDeliveries(_ context.Context, subscriptionID uuid.UUID, f store.WebhookDeliveryFilter, offset, limit int) ([]store.WebhookOutboxEntry, int, error) {
+	m.lastFilter = f
+	var matched []store.WebhookOutboxEntry
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && (f.Status == "" || d.Status == f.Status) &&
+			(f.EventType == "" || d.EventType == f.EventType) {
+			matched = append(matched, d)
+		}
+	}
+	total := len(matched)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+func (m *mockWebhookDeliveryStore) GetDelivery(_ context.Context, subscriptionID, id uuid.UUID) (*store.WebhookOutboxEntry, error) {
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.ID == id {
+			copied := d
+			return &copied, nil
+		}
+	}
+	return nil, apierrors.NotFound("webhook_delivery", id.String())
+}
+
+func (m *mockWebhookDeliveryStore) ListAttempts(_ context.Context, deliveryID uuid.UUID) ([]store.WebhookDeliveryAttempt, error) {
+	return m.attempts[deliveryID], nil
+}
+
+func (m *mockWebhookDeliveryStore) Redeliver(ctx context.Context, subscriptionID, id uuid.UUID) (*store.WebhookOutboxEntry, error) {
+	orig, err := m.GetDelivery(ctx, subscriptionID, id)
+	if err != nil {
+		return nil, err
+	}
+	d := store.WebhookOutboxEntry{
+		ID: uuid.New(), SubscriptionID: subscriptionID, EventType: orig.EventType, Payload: orig.Payload,
+		Status: store.OutboxPending, RedeliveryOf: &orig.ID, CreatedAt: time.Now(),
+	}
+	m.deliveries = append(m.deliveries, d)
+	return &d, nil
+}
+
+func (m *mockWebhookDeliveryStore) RedeliverFailed(_ context.Context, subscriptionID uuid.UUID, from, to time.Time) (int64, error) {
+	redelivered := make(map[uuid.UUID]bool)
+	for _, d := range m.deliveries {
+		if d.RedeliveryOf != nil {
+			redelivered[*d.RedeliveryOf] = true
+		}
+	}
+	var n int64
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.Status == store.OutboxFailed && !redelivered[d.ID] &&
+			!d.CreatedAt.Before(from) && d.CreatedAt.Before(to) {
+			id := d.ID
+			m.deliveries = append(m.deliveries, store.WebhookOutboxEntry{
+				ID: uuid.New(), SubscriptionID: subscriptionID, EventType: d.EventType,
+				Status: store.OutboxPending, RedeliveryOf: &id, CreatedAt: time.Now(),
+			})
+			n++
+		}
+	}
+	return n, nil
+}
+
+func newTestWebhookDeliveriesHandler(t *testing.T) (*WebhookDeliveriesHandler, *store.WebhookSubscription, *mockWebhookDeliveryStore, *mockAuditStoreForAPI) {
+	t.Helper()
+	webhooks := newMockWebhookStore()
+	sub := &store.WebhookSubscription{ID: uuid.New(), URL: "https://example.com/hook", Events: json.RawMessage(`["agent.created"]`), IsActive: true}
+	webhooks.subs[sub.ID] = sub
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := 503
+	deliveries := &mockWebhookDeliveryStore{attempts: make(map[uuid.UUID][]store.WebhookDeliveryAttempt)}
+	for i, st := range []string{store.OutboxDelivered, store.OutboxFailed, store.OutboxFailed, store.OutboxPending} {
+		d := store.WebhookOutboxEntry{
+			ID: uuid.New(), SubscriptionID: sub.ID, EventType: "agent.created",
+			Payload: json.RawMessage(`{"event":"agent.created"}`), Status: st,
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		}
+		if st == store.OutboxFailed {
+			d.LastStatusCode = &status
+		}
+		deliveries.deliveries = append(deliveries.deliveries, d)
+	}
+	failed := deliveries.deliveries[1]
+	deliveries.attempts[failed.ID] = []store.WebhookDeliveryAttempt{
+		{ID: uuid.New(), DeliveryID: failed.ID, Attempt: 1, StatusCode: &status, LatencyMS: 12, ResponseBody: "unavailable"},
+		{ID: uuid.New(), DeliveryID: failed.ID, Attempt: 2, LatencyMS: 5000, Error: "timeout"},
+	}
+
+	audit := &mockAuditStoreForAPI{}
+	return NewWebhookDeliveriesHandler(webhooks, deliveries, audit), sub, deliveries, audit
+}
+
+func deliveryRequest(method string, webhookID uuid.UUID, deliveryID string) *http.Request {
+	req := adminRequest(method, "/api/v1/webhooks/"+webhookID.String()+"/deliveries/"+deliveryID, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("webhookId", webhookID.String())
+	rctx.URLParams.Add("deliveryId", deliveryID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestWebhookDeliveries_List(t *testing.T) {
+	h, sub, deliveries, _ := newTestWebhookDeliveriesHandler(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTotal  int
+	}{
+		{"all", "", http.StatusOK, 4},
+		{"failed only", "?status=failed", http.StatusOK, 2},
+		{"by event", "?event=agent.deleted", http.StatusOK, 0},
+		{"time range", "?from=2026-03-01T13:00:00Z&to=2026-03-01T15:00:00Z", http.StatusOK, 4},
+		{"invalid status", "?status=lost", http.StatusBadRequest, 0},
+		{"invalid from", "?from=yesterday", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := adminRequest(http.MethodGet, "/api/v1/webhooks/"+sub.ID.String()+"/deliveries"+tt.query, nil)
+			req = withChiParam(req, "webhookId", sub.ID.String())
+			w := httptest.NewRecorder()
+			h.List(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var env struct {
+				Data struct {
+					Deliveries []store.WebhookOutboxEntry `json:"deliveries"`
+					Total      int                        `json:"total"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if env.Data.Total != tt.wantTotal || len(env.Data.Deliveries) != tt.wantTotal {
+				t.Errorf("expected %d deliveries, got total=%d len=%d", tt.wantTotal, env.Data.Total, len(env.Data.Deliveries))
+			}
+		})
+	}
+
+	// The time range is passed to the store.
+	want := time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)
+	if !deliveries.lastFilter.From.Equal(want) {
+		t.Errorf("expected from %v to reach the store, got %v", want, deliveries.lastFilter.From)
+	}
+}
+
+func TestWebhookDeliveries_UnknownWebhook(t *testing.T) {
+	h, _, _, _ := newTestWebhookDeliveriesHandler(t)
+	id := uuid.New().String()
+
+	req := adminRequest(http.MethodGet, "/api/v1/webhooks/"+id+"/deliveries", nil)
+	req = withChiParam(req, "webhookId", id)
+	w := httptest.NewRecorder()
+	h.List(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestWebhookDeliveries_GetIncludesAttempts(t *testing.T) {
+	h, sub, deliveries, _ := newTestWebhookDeliveriesHandler(t)
+	failed := deliveries.deliveries[1]
+
+	req := deliveryRequest(http.MethodGet, sub.ID, failed.ID.String())
+	w := httptest.NewRecorder()
+	h.Get(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var env struct {
+		Data struct {
+			ID             uuid.UUID                      `json:"id"`
+			Status         string                         `json:"status"`
+			LastStatusCode *int                           `json:"last_status_code"`
+			Attempts       []store.WebhookDeliveryAttempt `json:"attempts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if env.Data.ID != failed.ID || env.Data.Status != store.OutboxFailed {
+		t.Errorf("unexpected delivery: %+v", env.Data)
+	}
+	if env.Data.LastStatusCode == nil || *env.Data.LastStatusCode != 503 {
+		t.Errorf("expected last_status_code 503, got %v", env.Data.LastStatusCode)
+	}
+	if len(env.Data.Attempts) != 2 || env.Data.Attempts[0].ResponseBody != "unavailable" || env.Data.Attempts[1].Error != "timeout" {
+		t.Errorf("unexpected attempts: %+v", env.Data.Attempts)
+	}
+
+	// A delivery of another subscription is not found.
+	other := uuid.New().String()
+	req = deliveryRequest(http.MethodGet, sub.ID, other)
+	w = httptest.NewRecorder()
+	h.Get(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestWebhookDeliveries_Redeliver(t *testing.T) {
+	h, sub, deliveries, audit := newTestWebhookDeliveriesHandler(t)
+	orig := deliveries.deliveries[0]
+
+	req := deliveryRequest(http.MethodPost, sub.ID, orig.ID.String())
+	w := httptest.NewRecorder()
+	h.Redeliver(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var env struct {
+		Data store.WebhookOutboxEntry `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if env.Data.ID == orig.ID || env.Data.RedeliveryOf == nil || *env.Data.RedeliveryOf != orig.ID || env.Data.Status != store.OutboxPending {
+		t.Errorf("expected a new pending delivery of %s, got %+v", orig.ID, env.Data)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != "webhook_redeliver" || audit.entries[0].ResourceID != orig.ID.String() {
+		t.Errorf("expected a webhook_redeliver audit entry, got %+v", audit.entries)
+	}
+}
+
+func TestWebhookDeliveries_RedeliverFailed(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		want       int64
+	}{
+		{"whole range", map[string]interface{}{"from": "2026-03-01T00:00:00Z"}, http.StatusAccepted, 2},
+		{"partial range", map[string]interface{}{"from": "2026-03-01T13:00:00Z", "to": "2026-03-01T14:00:00Z"}, http.StatusAccepted, 1},
+		{"missing from", map[string]interface{}{}, http.StatusBadRequest, 0},
+		{"invalid to", map[string]interface{}{"from": "2026-03-01T00:00:00Z", "to": "soon"}, http.StatusBadRequest, 0},
+		{"empty range", map[string]interface{}{"from": "2026-03-02T00:00:00Z", "to": "2026-03-01T00:00:00Z"}, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, sub, _, audit := newTestWebhookDeliveriesHandler(t)
+			req := adminRequest(http.MethodPost, "/", tt.body)
+			req = withChiParam(req, "webhookId", sub.ID.String())
+			w := httptest.NewRecorder()
+			h.RedeliverFailed(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+			var env struct {
+				Data struct {
+					Redelivered int64 `json:"redelivered"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if env.Data.Redelivered != tt.want {
+				t.Errorf("expected %d redelivered, got %d", tt.want, env.Data.Redelivered)
+			}
+			if len(audit.entries) != 1 || audit.entries[0].Action != "webhook_redeliver_failed" {
+				t.Errorf("expected a webhook_redeliver_failed audit entry, got %+v", audit.entries)
+			}
+		})
+	}
+}
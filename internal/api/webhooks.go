@@ -2,29 +2,64 @@ package api
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/agent-smit/agentic-registry/internal/auth"
 	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/notify"
 	"github.com/agent-smit/agentic-registry/internal/store"
+	"github.com/agent-smit/agentic-registry/pkg/webhook"
 )
 
 // WebhookStoreForAPI is the interface the webhooks handler needs from the store.
 type WebhookStoreForAPI interface {
 	Create(ctx context.Context, sub *store.WebhookSubscription) error
 	List(ctx context.Context) ([]store.WebhookSubscription, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*store.WebhookSubscription, error)
+	Update(ctx context.Context, sub *store.WebhookSubscription) error
+	SetActive(ctx context.Context, id uuid.UUID, active bool) (*store.WebhookSubscription, error)
+	RotateSecret(ctx context.Context, id uuid.UUID, secret string, grace time.Duration) (*store.WebhookSubscription, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// WebhookPinger sends a synchronous test delivery to a subscription.
+type WebhookPinger interface {
+	Ping(ctx context.Context, id uuid.UUID, actor string) (*notify.Attempt, error)
+}
+
+const (
+	defaultSecretGracePeriod = 24 * time.Hour
+	maxSecretGracePeriod     = 7 * 24 * time.Hour
+)
+
+var validSignatureSchemes = map[string]bool{
+	store.SignatureHMACSHA256:       true,
+	store.SignatureStandardWebhooks: true,
+}
+
+var validPayloadFormats = map[string]bool{
+	store.PayloadFormatRegistry:    true,
+	store.PayloadFormatCloudEvents: true,
+}
+
 // WebhooksHandler provides HTTP handlers for webhook subscription endpoints.
 type WebhooksHandler struct {
 	webhooks WebhookStoreForAPI
 	audit    AuditStoreForAPI
+	pinger   WebhookPinger
 }
 
 // NewWebhooksHandler creates a new WebhooksHandler.
@@ -35,6 +70,11 @@ func NewWebhooksHandler(webhooks WebhookStoreForAPI, audit AuditStoreForAPI) *We
 	}
 }
 
+// SetPinger sets the pinger used by the ping endpoint.
+func (h *WebhooksHandler) SetPinger(p WebhookPinger) {
+	h.pinger = p
+}
+
 // List handles GET /api/v1/webhooks.
 func (h *WebhooksHandler) List(w http.ResponseWriter, r *http.Request) {
 	subs, err := h.webhooks.List(r.Context())
@@ -54,9 +94,197 @@ func (h *WebhooksHandler) List(w http.ResponseWriter, r *http.Request) {
 }
 
 type createWebhookRequest struct {
-	URL    string   `json:"url"`
-	Secret string   `json:"secret"`
-	Events []string `json:"events"`
+	URL             string   `json:"url"`
+	Secret          string   `json:"secret"`
+	SignatureScheme string   `json:"signature_scheme"`
+	PayloadFormat   string   `json:"payload_format"`
+	IncludeVersion  bool     `json:"include_version"`
+	IncludeSnapshot bool     `json:"include_snapshot"`
+	IncludeDiff     bool     `json:"include_diff"`
+	Events          []string `json:"events"`
+	ResourceTypes   []string `json:"resource_types"`
+	ResourceIDs     []string `json:"resource_ids"`
+	WorkspaceID     string   `json:"workspace_id"`
+}
+
+// webhookWithSecretResponse is a subscription with its secret, returned
+// only when the registry generated the secret.
+type webhookWithSecretResponse struct {
+	*store.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// validateWebhookURL checks that a subscription URL is an HTTP(S) URL that
+// does not point to a private address.
+func validateWebhookURL(raw string) *apierrors.APIError {
+	if raw == "" {
+		return apierrors.Validation("url is required")
+	}
+	if len(raw) > 2000 {
+		return apierrors.Validation("url must be at most 2000 characters")
+	}
+	parsedURL, err := url.Parse(raw)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return apierrors.Validation("url must be a valid HTTP or HTTPS URL")
+	}
+	if isPrivateHost(parsedURL.Hostname()) {
+		return apierrors.Validation("url must not point to a private or internal address")
+	}
+	return nil
+}
+
+func validateSignatureScheme(scheme string) *apierrors.APIError {
+	if !validSignatureSchemes[scheme] {
+		return apierrors.Validation("signature_scheme must be one of hmac_sha256, standard_webhooks")
+	}
+	return nil
+}
+
+func validatePayloadFormat(format string) *apierrors.APIError {
+	if !validPayloadFormats[format] {
+		return apierrors.Validation("payload_format must be one of registry, cloudevents")
+	}
+	return nil
+}
+
+// webhookSecret returns the given secret, or a generated one when it is
+// empty. Standard Webhooks secrets use the whsec_ format that the Standard
+// Webhooks libraries expect; an HMAC-SHA256 subscription may have no secret,
+// which leaves its deliveries unsigned.
+func webhookSecret(scheme, secret string, generate bool) (string, bool, *apierrors.APIError) {
+	if scheme != store.SignatureStandardWebhooks {
+		if secret == "" && generate {
+			s, err := generateWebhookSecret()
+			if err != nil {
+				return "", false, apierrors.Internal("failed to generate webhook secret")
+			}
+			return s, true, nil
+		}
+		return secret, false, nil
+	}
+	if secret == "" {
+		s, err := generateStandardWebhookSecret()
+		if err != nil {
+			return "", false, apierrors.Internal("failed to generate webhook secret")
+		}
+		return s, true, nil
+	}
+	if !strings.HasPrefix(secret, webhook.SecretPrefix) {
+		return "", false, apierrors.Validation("secret must be a whsec_ prefixed base64 key for standard_webhooks")
+	}
+	if _, err := webhook.Key(secret); err != nil {
+		return "", false, apierrors.Validation("secret must be a whsec_ prefixed base64 key for standard_webhooks")
+	}
+	return secret, false, nil
+}
+
+const (
+	maxWebhookFilterValues = 100
+	maxWebhookResourceID   = 200
+	maxWebhookWorkspaceID  = 100
+)
+
+// webhookFilters are the filters that select a subscription's events.
+// Events holds event type patterns such as "agent.created", "agent.*" or
+// "*"; the other filters are optional.
+type webhookFilters struct {
+	Events        []string
+	ResourceTypes []string
+	ResourceIDs   []string
+	WorkspaceID   string
+}
+
+// webhookFiltersOf decodes a stored subscription's filters.
+func webhookFiltersOf(sub *store.WebhookSubscription) webhookFilters {
+	var f webhookFilters
+	json.Unmarshal(sub.Events, &f.Events)
+	json.Unmarshal(sub.ResourceTypes, &f.ResourceTypes)
+	json.Unmarshal(sub.ResourceIDs, &f.ResourceIDs)
+	if sub.WorkspaceID != nil {
+		f.WorkspaceID = *sub.WorkspaceID
+	}
+	return f
+}
+
+// validate checks the filters against the event catalog. Every event
+// pattern and resource type must select at least one event type the
+// registry dispatches, given the other filters.
+func (f webhookFilters) validate() *apierrors.APIError {
+	if len(f.Events) == 0 {
+		return apierrors.Validation("events must be a non-empty array")
+	}
+	if len(f.Events) > maxWebhookFilterValues || len(f.ResourceTypes) > maxWebhookFilterValues || len(f.ResourceIDs) > maxWebhookFilterValues {
+		return apierrors.Validation(fmt.Sprintf("events, resource_types and resource_ids must have at most %d entries", maxWebhookFilterValues))
+	}
+	for _, id := range f.ResourceIDs {
+		if id == "" || len(id) > maxWebhookResourceID {
+			return apierrors.Validation(fmt.Sprintf("resource_ids must be non-empty strings of at most %d characters", maxWebhookResourceID))
+		}
+	}
+	if len(f.WorkspaceID) > maxWebhookWorkspaceID {
+		return apierrors.Validation(fmt.Sprintf("workspace_id must be at most %d characters", maxWebhookWorkspaceID))
+	}
+
+	selected := func(et notify.EventType) bool {
+		return (len(f.ResourceTypes) == 0 || slices.Contains(f.ResourceTypes, et.ResourceType)) &&
+			(f.WorkspaceID == "" || et.Workspace)
+	}
+	for _, pattern := range f.Events {
+		matched := notify.CatalogMatches(pattern)
+		if len(matched) == 0 {
+			return apierrors.Validation(fmt.Sprintf("event %q matches no event type", pattern))
+		}
+		if !slices.ContainsFunc(matched, selected) {
+			return apierrors.Validation(fmt.Sprintf("event %q matches no event type with the given resource_types and workspace_id", pattern))
+		}
+	}
+	for _, resourceType := range f.ResourceTypes {
+		if !slices.ContainsFunc(notify.Catalog, func(et notify.EventType) bool {
+			return et.ResourceType == resourceType && selected(et) &&
+				slices.ContainsFunc(f.Events, func(p string) bool { return notify.MatchEventType(p, et.Type) })
+		}) {
+			return apierrors.Validation(fmt.Sprintf("resource type %q matches no event type with the given events and workspace_id", resourceType))
+		}
+	}
+	return nil
+}
+
+// apply validates the filters and stores them in sub.
+func (f webhookFilters) apply(sub *store.WebhookSubscription) *apierrors.APIError {
+	if apiErr := f.validate(); apiErr != nil {
+		return apiErr
+	}
+	for _, v := range []struct {
+		dst    *json.RawMessage
+		values []string
+	}{
+		{&sub.Events, f.Events},
+		{&sub.ResourceTypes, f.ResourceTypes},
+		{&sub.ResourceIDs, f.ResourceIDs},
+	} {
+		if v.values == nil {
+			v.values = []string{}
+		}
+		encoded, err := json.Marshal(v.values)
+		if err != nil {
+			return apierrors.Internal("failed to encode webhook filters")
+		}
+		*v.dst = encoded
+	}
+	sub.WorkspaceID = nil
+	if f.WorkspaceID != "" {
+		sub.WorkspaceID = &f.WorkspaceID
+	}
+	return nil
+}
+
+// EventTypes handles GET /api/v1/webhooks/event-types. It lists the event
+// types subscriptions can filter on.
+func (h *WebhooksHandler) EventTypes(w http.ResponseWriter, r *http.Request) {
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"event_types": notify.Catalog,
+		"total":       len(notify.Catalog),
+	})
 }
 
 // Create handles POST /api/v1/webhooks.
@@ -67,51 +295,394 @@ func (h *WebhooksHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.URL == "" {
-		RespondError(w, r, apierrors.Validation("url is required"))
+	if apiErr := validateWebhookURL(req.URL); apiErr != nil {
+		RespondError(w, r, apiErr)
 		return
 	}
-	if len(req.URL) > 2000 {
-		RespondError(w, r, apierrors.Validation("url must be at most 2000 characters"))
+	if req.SignatureScheme == "" {
+		req.SignatureScheme = store.SignatureHMACSHA256
+	}
+	if apiErr := validateSignatureScheme(req.SignatureScheme); apiErr != nil {
+		RespondError(w, r, apiErr)
 		return
 	}
-	parsedURL, err := url.Parse(req.URL)
-	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
-		RespondError(w, r, apierrors.Validation("url must be a valid HTTP or HTTPS URL"))
+	if req.PayloadFormat == "" {
+		req.PayloadFormat = store.PayloadFormatRegistry
+	}
+	if apiErr := validatePayloadFormat(req.PayloadFormat); apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+	secret, generated, apiErr := webhookSecret(req.SignatureScheme, req.Secret, false)
+	if apiErr != nil {
+		RespondError(w, r, apiErr)
 		return
 	}
 
-	if isPrivateHost(parsedURL.Hostname()) {
-		RespondError(w, r, apierrors.Validation("url must not point to a private or internal address"))
+	sub := &store.WebhookSubscription{
+		URL:             req.URL,
+		Secret:          secret,
+		SignatureScheme: req.SignatureScheme,
+		PayloadFormat:   req.PayloadFormat,
+		IncludeVersion:  req.IncludeVersion,
+		IncludeSnapshot: req.IncludeSnapshot,
+		IncludeDiff:     req.IncludeDiff,
+		IsActive:        true,
+	}
+	filters := webhookFilters{
+		Events:        req.Events,
+		ResourceTypes: req.ResourceTypes,
+		ResourceIDs:   req.ResourceIDs,
+		WorkspaceID:   req.WorkspaceID,
+	}
+	if apiErr := filters.apply(sub); apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+
+	if err := h.webhooks.Create(r.Context(), sub); err != nil {
+		RespondError(w, r, apierrors.Internal("failed to create webhook subscription"))
 		return
 	}
 
-	if len(req.Events) == 0 {
-		RespondError(w, r, apierrors.Validation("events must be a non-empty array"))
+	h.auditLog(r, "webhook_create", "webhook_subscription", sub.ID.String())
+
+	if generated {
+		RespondJSON(w, r, http.StatusCreated, webhookWithSecretResponse{WebhookSubscription: sub, Secret: secret})
 		return
 	}
+	RespondJSON(w, r, http.StatusCreated, sub)
+}
 
-	eventsJSON, err := json.Marshal(req.Events)
+func (h *WebhooksHandler) subscription(w http.ResponseWriter, r *http.Request) (*store.WebhookSubscription, bool) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
 	if err != nil {
-		RespondError(w, r, apierrors.Internal("failed to encode events"))
+		RespondError(w, r, apierrors.Validation("invalid webhook ID"))
+		return nil, false
+	}
+	sub, err := h.webhooks.GetByID(r.Context(), webhookID)
+	if err != nil {
+		if isNotFoundError(err) {
+			RespondError(w, r, apierrors.NotFound("webhook_subscription", webhookID.String()))
+			return nil, false
+		}
+		RespondError(w, r, apierrors.Internal("failed to get webhook subscription"))
+		return nil, false
+	}
+	return sub, true
+}
+
+// Get handles GET /api/v1/webhooks/{webhookId}.
+func (h *WebhooksHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
 		return
 	}
+	RespondJSON(w, r, http.StatusOK, sub)
+}
 
-	sub := &store.WebhookSubscription{
-		URL:      req.URL,
-		Secret:   req.Secret,
-		Events:   eventsJSON,
-		IsActive: true,
+type updateWebhookRequest struct {
+	URL             *string   `json:"url"`
+	SignatureScheme *string   `json:"signature_scheme"`
+	Secret          *string   `json:"secret"`
+	PayloadFormat   *string   `json:"payload_format"`
+	IncludeVersion  *bool     `json:"include_version"`
+	IncludeSnapshot *bool     `json:"include_snapshot"`
+	IncludeDiff     *bool     `json:"include_diff"`
+	Events          *[]string `json:"events"`
+	ResourceTypes   *[]string `json:"resource_types"`
+	ResourceIDs     *[]string `json:"resource_ids"`
+	WorkspaceID     *string   `json:"workspace_id"`
+	IsActive        *bool     `json:"is_active"`
+}
+
+// Update handles PUT /api/v1/webhooks/{webhookId}. url and events are
+// required; the other fields keep their current values when omitted. An
+// empty workspace_id removes the workspace scope.
+func (h *WebhooksHandler) Update(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, true)
+}
+
+// Patch handles PATCH /api/v1/webhooks/{webhookId}. Only the given fields
+// change.
+func (h *WebhooksHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, false)
+}
+
+func (h *WebhooksHandler) update(w http.ResponseWriter, r *http.Request, replace bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		RespondError(w, r, apierrors.Validation("If-Match header is required for updates"))
+		return
 	}
 
-	if err := h.webhooks.Create(r.Context(), sub); err != nil {
-		RespondError(w, r, apierrors.Internal("failed to create webhook subscription"))
+	etag, err := time.Parse(time.RFC3339Nano, ifMatch)
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid If-Match value"))
 		return
 	}
 
-	h.auditLog(r, "webhook_create", "webhook_subscription", sub.ID.String())
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
 
-	RespondJSON(w, r, http.StatusCreated, sub)
+	var req updateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+	if replace && (req.URL == nil || req.Events == nil) {
+		RespondError(w, r, apierrors.Validation("url and events are required"))
+		return
+	}
+
+	if req.URL != nil {
+		if apiErr := validateWebhookURL(*req.URL); apiErr != nil {
+			RespondError(w, r, apiErr)
+			return
+		}
+		sub.URL = *req.URL
+	}
+	// A secret may not suit a new signature scheme: Standard Webhooks
+	// secrets must be whsec_ keys. Switching to standard_webhooks therefore
+	// sets the given secret, or a generated one that is returned once.
+	schemeChanged := req.SignatureScheme != nil && *req.SignatureScheme != sub.SignatureScheme
+	if req.Secret != nil && !schemeChanged {
+		RespondError(w, r, apierrors.Validation("secret can only be set with a new signature_scheme; use rotate-secret"))
+		return
+	}
+	var generated bool
+	if schemeChanged {
+		if apiErr := validateSignatureScheme(*req.SignatureScheme); apiErr != nil {
+			RespondError(w, r, apiErr)
+			return
+		}
+		var given string
+		if req.Secret != nil {
+			given = *req.Secret
+		}
+		if *req.SignatureScheme == store.SignatureStandardWebhooks || given != "" {
+			secret, gen, apiErr := webhookSecret(*req.SignatureScheme, given, false)
+			if apiErr != nil {
+				RespondError(w, r, apiErr)
+				return
+			}
+			sub.Secret, generated = secret, gen
+		}
+		sub.SignatureScheme = *req.SignatureScheme
+	}
+	if req.PayloadFormat != nil {
+		if apiErr := validatePayloadFormat(*req.PayloadFormat); apiErr != nil {
+			RespondError(w, r, apiErr)
+			return
+		}
+		sub.PayloadFormat = *req.PayloadFormat
+	}
+	if req.IncludeVersion != nil {
+		sub.IncludeVersion = *req.IncludeVersion
+	}
+	if req.IncludeSnapshot != nil {
+		sub.IncludeSnapshot = *req.IncludeSnapshot
+	}
+	if req.IncludeDiff != nil {
+		sub.IncludeDiff = *req.IncludeDiff
+	}
+	// Filters are validated together, but only when one of them changes,
+	// so subscriptions created before the event catalog stay editable.
+	if req.Events != nil || req.ResourceTypes != nil || req.ResourceIDs != nil || req.WorkspaceID != nil {
+		filters := webhookFiltersOf(sub)
+		if req.Events != nil {
+			filters.Events = *req.Events
+		}
+		if req.ResourceTypes != nil {
+			filters.ResourceTypes = *req.ResourceTypes
+		}
+		if req.ResourceIDs != nil {
+			filters.ResourceIDs = *req.ResourceIDs
+		}
+		if req.WorkspaceID != nil {
+			filters.WorkspaceID = *req.WorkspaceID
+		}
+		if apiErr := filters.apply(sub); apiErr != nil {
+			RespondError(w, r, apiErr)
+			return
+		}
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+	sub.UpdatedAt = etag
+
+	if err := h.webhooks.Update(r.Context(), sub); err != nil {
+		if isConflictError(err) {
+			RespondError(w, r, apierrors.Conflict("resource was modified by another client"))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to update webhook subscription"))
+		return
+	}
+
+	h.auditLog(r, "webhook_update", "webhook_subscription", sub.ID.String())
+
+	if generated {
+		RespondJSON(w, r, http.StatusOK, webhookWithSecretResponse{WebhookSubscription: sub, Secret: sub.Secret})
+		return
+	}
+	RespondJSON(w, r, http.StatusOK, sub)
+}
+
+// Pause handles POST /api/v1/webhooks/{webhookId}/pause. Events that occur
+// while a subscription is paused are still queued and are delivered once it
+// is resumed.
+func (h *WebhooksHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false, "webhook_pause")
+}
+
+// Resume handles POST /api/v1/webhooks/{webhookId}/resume.
+func (h *WebhooksHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true, "webhook_resume")
+}
+
+func (h *WebhooksHandler) setActive(w http.ResponseWriter, r *http.Request, active bool, action string) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid webhook ID"))
+		return
+	}
+
+	sub, err := h.webhooks.SetActive(r.Context(), webhookID, active)
+	if err != nil {
+		if isNotFoundError(err) {
+			RespondError(w, r, apierrors.NotFound("webhook_subscription", webhookID.String()))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to update webhook subscription"))
+		return
+	}
+
+	h.auditLog(r, action, "webhook_subscription", webhookID.String())
+
+	RespondJSON(w, r, http.StatusOK, sub)
+}
+
+type pingWebhookResponse struct {
+	Success      bool      `json:"success"`
+	DeliveryID   uuid.UUID `json:"delivery_id"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	LatencyMS    int64     `json:"latency_ms"`
+	ResponseBody string    `json:"response_body"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Ping handles POST /api/v1/webhooks/{webhookId}/ping. It sends a signed
+// webhook.ping event synchronously, also to paused subscriptions, and
+// returns the receiver's response. An unreachable or failing receiver is
+// reported in the body, not as an error status.
+func (h *WebhooksHandler) Ping(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+	if h.pinger == nil {
+		RespondError(w, r, apierrors.Internal("webhook delivery is not configured"))
+		return
+	}
+
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	attempt, err := h.pinger.Ping(r.Context(), sub.ID, callerID.String())
+	if err != nil {
+		if isNotFoundError(err) {
+			RespondError(w, r, apierrors.NotFound("webhook_subscription", sub.ID.String()))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to ping webhook"))
+		return
+	}
+
+	h.auditLog(r, "webhook_ping", "webhook_subscription", sub.ID.String())
+
+	RespondJSON(w, r, http.StatusOK, pingWebhookResponse{
+		Success:      attempt.Error == "",
+		DeliveryID:   attempt.DeliveryID,
+		StatusCode:   attempt.StatusCode,
+		LatencyMS:    attempt.Latency.Milliseconds(),
+		ResponseBody: attempt.ResponseBody,
+		Error:        attempt.Error,
+	})
+}
+
+type rotateWebhookSecretRequest struct {
+	Secret      string `json:"secret"`
+	GracePeriod string `json:"grace_period"`
+}
+
+// RotateSecret handles POST /api/v1/webhooks/{webhookId}/rotate-secret. The
+// new secret is generated, in the format of the subscription's signature
+// scheme, unless given, and is returned only in this response. Until the
+// grace period (default 24h, at most 168h, 0 to revoke the old secret at
+// once) ends, deliveries carry signatures with both the new and the old
+// secret.
+func (h *WebhooksHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.subscription(w, r)
+	if !ok {
+		return
+	}
+	webhookID := current.ID
+
+	var req rotateWebhookSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+
+	grace := defaultSecretGracePeriod
+	if req.GracePeriod != "" {
+		var err error
+		grace, err = time.ParseDuration(req.GracePeriod)
+		if err != nil || grace < 0 || grace > maxSecretGracePeriod {
+			RespondError(w, r, apierrors.Validation("grace_period must be a duration between 0s and 168h"))
+			return
+		}
+	}
+
+	secret, _, apiErr := webhookSecret(current.SignatureScheme, req.Secret, true)
+	if apiErr != nil {
+		RespondError(w, r, apiErr)
+		return
+	}
+
+	sub, err := h.webhooks.RotateSecret(r.Context(), webhookID, secret, grace)
+	if err != nil {
+		if isNotFoundError(err) {
+			RespondError(w, r, apierrors.NotFound("webhook_subscription", webhookID.String()))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to rotate webhook secret"))
+		return
+	}
+
+	h.auditLog(r, "webhook_rotate_secret", "webhook_subscription", webhookID.String())
+
+	RespondJSON(w, r, http.StatusOK, webhookWithSecretResponse{WebhookSubscription: sub, Secret: secret})
+}
+
+// generateWebhookSecret returns 32 random bytes, hex-encoded.
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// generateStandardWebhookSecret returns 32 random bytes as a whsec_ secret.
+func generateStandardWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating webhook secret: %w", err)
+	}
+	return webhook.SecretPrefix + base64.StdEncoding.EncodeToString(b), nil
 }
 
 // Delete handles DELETE /api/v1/webhooks/{webhookId}.
@@ -149,4 +720,15 @@ func (h *WebhooksHandler) auditLog(r *http.Request, action, resourceType, resour
 		ResourceID:   resourceID,
 		IPAddress:    clientIPFromRequest(r),
 	})
-}
\ No newline at end of file
+}
+
+// withEvents runs fn, which performs a mutation and dispatches its events
+// with the context it is given. When the dispatcher queues events
+// transactionally, fn runs in one transaction so the events are queued
+// exactly when the mutation commits.
+func withEvents(ctx context.Context, dispatcher notify.EventDispatcher, fn func(ctx context.Context) error) error {
+	if tx, ok := dispatcher.(notify.Transactor); ok {
+		return tx.InTx(ctx, fn)
+	}
+	return fn(ctx)
+}
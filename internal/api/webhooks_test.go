@@ -3,8 +3,16 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +20,7 @@ import (
 	"github.com/google/uuid"
 
 	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/notify"
 	"github.com/agent-smit/agentic-registry/internal/store"
 )
 
@@ -47,6 +56,56 @@ func (m *mockWebhookStore) List(_ context.Context) ([]store.WebhookSubscription,
 	return all, nil
 }
 
+func (m *mockWebhookStore) GetByID(_ context.Context, id uuid.UUID) (*store.WebhookSubscription, error) {
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, apierrors.NotFound("webhook_subscription", id.String())
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (m *mockWebhookStore) Update(_ context.Context, sub *store.WebhookSubscription) error {
+	existing, ok := m.subs[sub.ID]
+	if !ok || !existing.UpdatedAt.Equal(sub.UpdatedAt) {
+		return apierrors.Conflict("webhook subscription was modified by another request")
+	}
+	sub.UpdatedAt = time.Now()
+	copied := *sub
+	if sub.Secret == "" {
+		copied.Secret = existing.Secret
+	} else {
+		copied.PreviousSecret, copied.PreviousSecretExpiresAt = "", nil
+	}
+	m.subs[sub.ID] = &copied
+	return nil
+}
+
+func (m *mockWebhookStore) SetActive(_ context.Context, id uuid.UUID, active bool) (*store.WebhookSubscription, error) {
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, apierrors.NotFound("webhook_subscription", id.String())
+	}
+	sub.IsActive = active
+	sub.UpdatedAt = time.Now()
+	return sub, nil
+}
+
+func (m *mockWebhookStore) RotateSecret(_ context.Context, id uuid.UUID, secret string, grace time.Duration) (*store.WebhookSubscription, error) {
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, apierrors.NotFound("webhook_subscription", id.String())
+	}
+	sub.PreviousSecret, sub.PreviousSecretExpiresAt = "", nil
+	if grace > 0 {
+		expires := time.Now().Add(grace)
+		sub.PreviousSecret, sub.PreviousSecretExpiresAt = sub.Secret, &expires
+	}
+	sub.Secret = secret
+	sub.UpdatedAt = time.Now()
+	return sub, nil
+}
+
 func (m *mockWebhookStore) Delete(_ context.Context, id uuid.UUID) error {
 	if _, ok := m.subs[id]; !ok {
 		return apierrors.NotFound("webhook_subscription", id.String())
@@ -382,3 +441,675 @@ func TestWebhooksHandler_CreatePublicURLAllowed(t *testing.T) {
 		t.Fatalf("expected 201 for public URL, got %d; body: %s", w.Code, w.Body.String())
 	}
 }
+
+// mockWebhookPinger implements WebhookPinger.
+type mockWebhookPinger struct {
+	attempt *notify.Attempt
+	err     error
+	pinged  []uuid.UUID
+}
+
+func (p *mockWebhookPinger) Ping(_ context.Context, id uuid.UUID, _ string) (*notify.Attempt, error) {
+	p.pinged = append(p.pinged, id)
+	return p.attempt, p.err
+}
+
+func seedWebhook(whStore *mockWebhookStore) *store.WebhookSubscription {
+	sub := &store.WebhookSubscription{
+		ID:        uuid.New(),
+		URL:       "https://example.com/hook",
+		Secret:    "secret",
+		Events:    json.RawMessage(`["agent.created"]`),
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	whStore.subs[sub.ID] = sub
+	return sub
+}
+
+func TestWebhooksHandler_Get(t *testing.T) {
+	whStore := newMockWebhookStore()
+	h := NewWebhooksHandler(whStore, &mockAuditStoreForAPI{})
+	sub := seedWebhook(whStore)
+
+	req := withChiParam(adminRequest(http.MethodGet, "/api/v1/webhooks/"+sub.ID.String(), nil), "webhookId", sub.ID.String())
+	w := httptest.NewRecorder()
+	h.Get(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "secret\"") {
+		t.Fatalf("response must not contain the secret: %s", w.Body.String())
+	}
+
+	missing := uuid.New().String()
+	req = withChiParam(adminRequest(http.MethodGet, "/api/v1/webhooks/"+missing, nil), "webhookId", missing)
+	w = httptest.NewRecorder()
+	h.Get(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestWebhooksHandler_Update(t *testing.T) {
+	whStore := newMockWebhookStore()
+	audit := &mockAuditStoreForAPI{}
+	h := NewWebhooksHandler(whStore, audit)
+	sub := seedWebhook(whStore)
+	etag := sub.UpdatedAt.Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name     string
+		method   string
+		ifMatch  string
+		body     map[string]interface{}
+		wantCode int
+	}{
+		{"missing If-Match", http.MethodPut, "", map[string]interface{}{"url": "https://example.com/new", "events": []string{"agent.updated"}}, http.StatusBadRequest},
+		{"stale If-Match", http.MethodPut, time.Now().Add(-time.Hour).Format(time.RFC3339Nano), map[string]interface{}{"url": "https://example.com/new", "events": []string{"agent.updated"}}, http.StatusConflict},
+		{"put without events", http.MethodPut, etag, map[string]interface{}{"url": "https://example.com/new"}, http.StatusBadRequest},
+		{"private url", http.MethodPatch, etag, map[string]interface{}{"url": "http://10.0.0.1/hook"}, http.StatusBadRequest},
+		{"empty events", http.MethodPatch, etag, map[string]interface{}{"events": []string{}}, http.StatusBadRequest},
+		{"put", http.MethodPut, etag, map[string]interface{}{"url": "https://example.com/new", "events": []string{"agent.updated"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withChiParam(adminRequest(tt.method, "/api/v1/webhooks/"+sub.ID.String(), tt.body), "webhookId", sub.ID.String())
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			if tt.method == http.MethodPut {
+				h.Update(w, req)
+			} else {
+				h.Patch(w, req)
+			}
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d; body: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	updated := whStore.subs[sub.ID]
+	if updated.URL != "https://example.com/new" || string(updated.Events) != `["agent.updated"]` || !updated.IsActive {
+		t.Fatalf("unexpected subscription after PUT: %+v", updated)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != "webhook_update" {
+		t.Fatalf("expected one webhook_update audit entry, got %+v", audit.entries)
+	}
+}
+
+func TestWebhooksHandler_PatchKeepsOmittedFields(t *testing.T) {
+	whStore := newMockWebhookStore()
+	h := NewWebhooksHandler(whStore, &mockAuditStoreForAPI{})
+	sub := seedWebhook(whStore)
+
+	req := withChiParam(adminRequest(http.MethodPatch, "/api/v1/webhooks/"+sub.ID.String(), map[string]interface{}{
+		"is_active": false,
+	}), "webhookId", sub.ID.String())
+	req.Header.Set("If-Match", sub.UpdatedAt.Format(time.RFC3339Nano))
+	w := httptest.NewRecorder()
+	h.Patch(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	updated := whStore.subs[sub.ID]
+	if updated.IsActive || updated.URL != sub.URL || string(updated.Events) != string(sub.Events) {
+		t.Fatalf("unexpected subscription after PATCH: %+v", updated)
+	}
+}
+
+func TestWebhooksHandler_PauseResume(t *testing.T) {
+	whStore := newMockWebhookStore()
+	audit := &mockAuditStoreForAPI{}
+	h := NewWebhooksHandler(whStore, audit)
+	sub := seedWebhook(whStore)
+
+	for _, step := range []struct {
+		handler http.HandlerFunc
+		active  bool
+		action  string
+	}{
+		{h.Pause, false, "webhook_pause"},
+		{h.Resume, true, "webhook_resume"},
+	} {
+		req := withChiParam(adminRequest(http.MethodPost, "/api/v1/webhooks/"+sub.ID.String()+"/pause", nil), "webhookId", sub.ID.String())
+		w := httptest.NewRecorder()
+		step.handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d; body: %s", step.action, w.Code, w.Body.String())
+		}
+		if whStore.subs[sub.ID].IsActive != step.active {
+			t.Fatalf("%s: expected is_active %v", step.action, step.active)
+		}
+		if last := audit.entries[len(audit.entries)-1]; last.Action != step.action {
+			t.Fatalf("expected audit action %s, got %s", step.action, last.Action)
+		}
+	}
+
+	missing := uuid.New().String()
+	req := withChiParam(adminRequest(http.MethodPost, "/api/v1/webhooks/"+missing+"/pause", nil), "webhookId", missing)
+	w := httptest.NewRecorder()
+	h.Pause(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestWebhooksHandler_Ping(t *testing.T) {
+	whStore := newMockWebhookStore()
+	audit := &mockAuditStoreForAPI{}
+	h := NewWebhooksHandler(whStore, audit)
+	sub := seedWebhook(whStore)
+
+	req := withChiParam(adminRequest(http.MethodPost, "/api/v1/webhooks/"+sub.ID.String()+"/ping", nil), "webhookId", sub.ID.String())
+	w := httptest.NewRecorder()
+	h.Ping(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 without a pinger, got %d", w.Code)
+	}
+
+	pinger := &mockWebhookPinger{attempt: &notify.Attempt{
+		DeliveryID:   uuid.New(),
+		Attempt:      1,
+		StatusCode:   503,
+		Latency:      42 * time.Millisecond,
+		ResponseBody: "unavailable",
+		Error:        "status 503",
+	}}
+	h.SetPinger(pinger)
+
+	w = httptest.NewRecorder()
+	h.Ping(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if len(pinger.pinged) != 1 || pinger.pinged[0] != sub.ID {
+		t.Fatalf("expected one ping of %s, got %v", sub.ID, pinger.pinged)
+	}
+
+	env := parseEnvelope(t, w)
+	data := env.Data.(map[string]interface{})
+	if data["success"] != false || data["status_code"] != float64(503) || data["latency_ms"] != float64(42) ||
+		data["response_body"] != "unavailable" || data["error"] != "status 503" {
+		t.Fatalf("unexpected ping result: %v", data)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != "webhook_ping" {
+		t.Fatalf("expected one webhook_ping audit entry, got %+v", audit.entries)
+	}
+}
+
+func TestWebhooksHandler_RotateSecret(t *testing.T) {
+	whStore := newMockWebhookStore()
+	audit := &mockAuditStoreForAPI{}
+	h := NewWebhooksHandler(whStore, audit)
+	sub := seedWebhook(whStore)
+
+	rotate := func(body interface{}) *httptest.ResponseRecorder {
+		req := withChiParam(adminRequest(http.MethodPost, "/api/v1/webhooks/"+sub.ID.String()+"/rotate-secret", body), "webhookId", sub.ID.String())
+		w := httptest.NewRecorder()
+		h.RotateSecret(w, req)
+		return w
+	}
+
+	for _, grace := range []string{"-1h", "169h", "soon"} {
+		if w := rotate(map[string]string{"grace_period": grace}); w.Code != http.StatusBadRequest {
+			t.Fatalf("grace_period %q: expected 400, got %d", grace, w.Code)
+		}
+	}
+
+	w := rotate(nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	secret, _ := data["secret"].(string)
+	if len(secret) != 64 || whStore.subs[sub.ID].Secret != secret {
+		t.Fatalf("expected a generated 64-character secret, got %q", secret)
+	}
+	if whStore.subs[sub.ID].PreviousSecret != "secret" || data["previous_secret_expires_at"] == nil {
+		t.Fatalf("expected the old secret to stay valid during the default grace period: %v", data)
+	}
+
+	w = rotate(map[string]string{"secret": "chosen", "grace_period": "0s"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if got := whStore.subs[sub.ID]; got.Secret != "chosen" || got.PreviousSecret != "" {
+		t.Fatalf("expected immediate rotation to the given secret, got %+v", got)
+	}
+	if len(audit.entries) != 2 || audit.entries[1].Action != "webhook_rotate_secret" {
+		t.Fatalf("expected two webhook_rotate_secret audit entries, got %+v", audit.entries)
+	}
+}
+
+func TestWebhooksHandler_CreateSignatureScheme(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantCode   int
+		wantScheme string
+		wantSecret func(string) bool
+	}{
+		{
+			name:       "default scheme keeps the given secret",
+			body:       map[string]interface{}{"url": "https://example.com/hook", "secret": "s", "events": []string{"agent.created"}},
+			wantCode:   http.StatusCreated,
+			wantScheme: store.SignatureHMACSHA256,
+			wantSecret: func(s string) bool { return s == "" },
+		},
+		{
+			name:       "standard webhooks generates a secret",
+			body:       map[string]interface{}{"url": "https://example.com/hook", "signature_scheme": "standard_webhooks", "events": []string{"agent.created"}},
+			wantCode:   http.StatusCreated,
+			wantScheme: store.SignatureStandardWebhooks,
+			wantSecret: func(s string) bool { return strings.HasPrefix(s, "whsec_") },
+		},
+		{
+			name:       "standard webhooks with a whsec secret",
+			body:       map[string]interface{}{"url": "https://example.com/hook", "signature_scheme": "standard_webhooks", "secret": "whsec_c2VjcmV0", "events": []string{"agent.created"}},
+			wantCode:   http.StatusCreated,
+			wantScheme: store.SignatureStandardWebhooks,
+			wantSecret: func(s string) bool { return s == "" },
+		},
+		{
+			name:     "standard webhooks with a plain secret",
+			body:     map[string]interface{}{"url": "https://example.com/hook", "signature_scheme": "standard_webhooks", "secret": "plain", "events": []string{"agent.created"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown scheme",
+			body:     map[string]interface{}{"url": "https://example.com/hook", "signature_scheme": "md5", "events": []string{"agent.created"}},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewWebhooksHandler(newMockWebhookStore(), &mockAuditStoreForAPI{})
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/webhooks", tt.body))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d; body: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantCode != http.StatusCreated {
+				return
+			}
+			data := parseEnvelope(t, w).Data.(map[string]interface{})
+			if data["signature_scheme"] != tt.wantScheme {
+				t.Errorf("signature_scheme = %v, want %s", data["signature_scheme"], tt.wantScheme)
+			}
+			secret, _ := data["secret"].(string)
+			if !tt.wantSecret(secret) {
+				t.Errorf("unexpected secret in response: %q", secret)
+			}
+		})
+	}
+}
+
+func TestWebhooksHandler_UpdateSignatureScheme(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantCode   int
+		wantSecret func(got, returned string) bool
+	}{
+		{
+			name:       "switch to standard webhooks generates a whsec secret",
+			body:       map[string]interface{}{"signature_scheme": "standard_webhooks"},
+			wantCode:   http.StatusOK,
+			wantSecret: func(got, returned string) bool { return strings.HasPrefix(got, "whsec_") && returned == got },
+		},
+		{
+			name:       "switch to standard webhooks with a whsec secret",
+			body:       map[string]interface{}{"signature_scheme": "standard_webhooks", "secret": "whsec_c2VjcmV0"},
+			wantCode:   http.StatusOK,
+			wantSecret: func(got, returned string) bool { return got == "whsec_c2VjcmV0" && returned == "" },
+		},
+		{
+			name:     "switch to standard webhooks with a plain secret",
+			body:     map[string]interface{}{"signature_scheme": "standard_webhooks", "secret": "plain"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "secret without a scheme change",
+			body:     map[string]interface{}{"secret": "other"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:       "unchanged scheme keeps the secret",
+			body:       map[string]interface{}{"signature_scheme": "hmac_sha256"},
+			wantCode:   http.StatusOK,
+			wantSecret: func(got, returned string) bool { return got == "secret" && returned == "" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whStore := newMockWebhookStore()
+			h := NewWebhooksHandler(whStore, &mockAuditStoreForAPI{})
+			sub := seedWebhook(whStore)
+			sub.SignatureScheme = store.SignatureHMACSHA256
+
+			req := withChiParam(adminRequest(http.MethodPatch, "/api/v1/webhooks/"+sub.ID.String(), tt.body), "webhookId", sub.ID.String())
+			req.Header.Set("If-Match", sub.UpdatedAt.Format(time.RFC3339Nano))
+			w := httptest.NewRecorder()
+			h.Patch(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d; body: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				if got := whStore.subs[sub.ID]; got.Secret != "secret" || got.SignatureScheme != store.SignatureHMACSHA256 {
+					t.Fatalf("rejected update changed the subscription: %+v", got)
+				}
+				return
+			}
+			returned, _ := parseEnvelope(t, w).Data.(map[string]interface{})["secret"].(string)
+			if got := whStore.subs[sub.ID].Secret; !tt.wantSecret(got, returned) {
+				t.Errorf("unexpected secret %q, returned %q", got, returned)
+			}
+		})
+	}
+}
+
+func TestWebhooksHandler_RotateSecretStandardWebhooks(t *testing.T) {
+	whStore := newMockWebhookStore()
+	h := NewWebhooksHandler(whStore, &mockAuditStoreForAPI{})
+	sub := seedWebhook(whStore)
+	sub.SignatureScheme = store.SignatureStandardWebhooks
+
+	rotate := func(body interface{}) *httptest.ResponseRecorder {
+		req := withChiParam(adminRequest(http.MethodPost, "/api/v1/webhooks/"+sub.ID.String()+"/rotate-secret", body), "webhookId", sub.ID.String())
+		w := httptest.NewRecorder()
+		h.RotateSecret(w, req)
+		return w
+	}
+
+	if w := rotate(map[string]string{"secret": "plain"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a plain secret, got %d", w.Code)
+	}
+	w := rotate(nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if got := whStore.subs[sub.ID].Secret; !strings.HasPrefix(got, "whsec_") {
+		t.Fatalf("expected a generated whsec_ secret, got %q", got)
+	}
+}
+
+func TestWebhooksHandler_PayloadOptions(t *testing.T) {
+	whStore := newMockWebhookStore()
+	h := NewWebhooksHandler(whStore, &mockAuditStoreForAPI{})
+
+	w := httptest.NewRecorder()
+	h.Create(w, adminRequest(http.MethodPost, "/api/v1/webhooks", map[string]interface{}{
+		"url": "https://example.com/hook", "events": []string{"agent.created"}, "payload_format": "xml",
+	}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown payload format, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.Create(w, adminRequest(http.MethodPost, "/api/v1/webhooks", map[string]interface{}{
+		"url": "https://example.com/hook", "events": []string{"agent.created"},
+	}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	if data["payload_format"] != store.PayloadFormatRegistry || data["include_snapshot"] != false {
+		t.Fatalf("unexpected default payload options: %v", data)
+	}
+
+	sub := seedWebhook(whStore)
+	req := withChiParam(adminRequest(http.MethodPatch, "/api/v1/webhooks/"+sub.ID.String(), map[string]interface{}{
+		"payload_format": "cloudevents", "include_version": true, "include_diff": true,
+	}), "webhookId", sub.ID.String())
+	req.Header.Set("If-Match", sub.UpdatedAt.Format(time.RFC3339Nano))
+	w = httptest.NewRecorder()
+	h.Patch(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	updated := whStore.subs[sub.ID]
+	if updated.PayloadFormat != store.PayloadFormatCloudEvents || !updated.IncludeVersion || !updated.IncludeDiff || updated.IncludeSnapshot {
+		t.Fatalf("unexpected payload options after PATCH: %+v", updated)
+	}
+}
+
+func TestWebhooksHandler_CreateFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     map[string]interface{}
+		wantCode int
+	}{
+		{
+			name:     "wildcard",
+			body:     map[string]interface{}{"events": []string{"agent.*"}},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "resource selectors",
+			body:     map[string]interface{}{"events": []string{"*"}, "resource_types": []string{"agent", "prompt"}, "resource_ids": []string{"my_agent"}},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "workspace scope",
+			body:     map[string]interface{}{"events": []string{"workspace_budget.*", "workspace.*"}, "workspace_id": "3f1c9a62-51b4-4c55-8d0e-2a9f7c1e4b80"},
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "unknown event type",
+			body:     map[string]interface{}{"events": []string{"agent.renamed"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "wildcard matching nothing",
+			body:     map[string]interface{}{"events": []string{"widget.*"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unsupported wildcard",
+			body:     map[string]interface{}{"events": []string{"agent.c*"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown resource type",
+			body:     map[string]interface{}{"events": []string{"*"}, "resource_types": []string{"widget"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "resource type outside the events",
+			body:     map[string]interface{}{"events": []string{"agent.*"}, "resource_types": []string{"prompt"}},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "workspace scope on unscoped events",
+			body:     map[string]interface{}{"events": []string{"agent.*"}, "workspace_id": "3f1c9a62-51b4-4c55-8d0e-2a9f7c1e4b80"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "empty resource ID",
+			body:     map[string]interface{}{"events": []string{"agent.*"}, "resource_ids": []string{""}},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whStore := newMockWebhookStore()
+			h := NewWebhooksHandler(whStore, &mockAuditStoreForAPI{})
+			tt.body["url"] = "https://example.com/hook"
+			w := httptest.NewRecorder()
+			h.Create(w, adminRequest(http.MethodPost, "/api/v1/webhooks", tt.body))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d; body: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWebhooksHandler_PatchFilters(t *testing.T) {
+	whStore := newMockWebhookStore()
+	h := NewWebhooksHandler(whStore, &mockAuditStoreForAPI{})
+	sub := seedWebhook(whStore)
+
+	patch := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		req := withChiParam(adminRequest(http.MethodPatch, "/api/v1/webhooks/"+sub.ID.String(), body), "webhookId", sub.ID.String())
+		req.Header.Set("If-Match", whStore.subs[sub.ID].UpdatedAt.Format(time.RFC3339Nano))
+		w := httptest.NewRecorder()
+		h.Patch(w, req)
+		return w
+	}
+
+	// The seeded subscription receives agent events only, so a prompt
+	// resource type is validated against its existing events.
+	if w := patch(map[string]interface{}{"resource_types": []string{"prompt"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if w := patch(map[string]interface{}{"events": []string{"*"}, "resource_types": []string{"prompt"}}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	updated := whStore.subs[sub.ID]
+	if string(updated.Events) != `["*"]` || string(updated.ResourceTypes) != `["prompt"]` || string(updated.ResourceIDs) != `[]` {
+		t.Fatalf("unexpected filters after PATCH: events=%s resource_types=%s resource_ids=%s",
+			updated.Events, updated.ResourceTypes, updated.ResourceIDs)
+	}
+}
+
+func TestWebhooksHandler_EventTypes(t *testing.T) {
+	h := NewWebhooksHandler(newMockWebhookStore(), &mockAuditStoreForAPI{})
+	w := httptest.NewRecorder()
+	h.EventTypes(w, adminRequest(http.MethodGet, "/api/v1/webhooks/event-types", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	types := data["event_types"].([]interface{})
+	if len(types) != len(notify.Catalog) || int(data["total"].(float64)) != len(notify.Catalog) {
+		t.Fatalf("expected %d event types, got %d", len(notify.Catalog), len(types))
+	}
+	first := types[0].(map[string]interface{})
+	if first["type"] != "agent.created" || first["resource_type"] != "agent" {
+		t.Fatalf("unexpected first event type: %v", first)
+	}
+}
+
+// TestEventCatalogMatchesDispatchedEvents checks that notify.Catalog lists
+// exactly the event types the handlers in this package dispatch.
+func TestEventCatalogMatchesDispatchedEvents(t *testing.T) {
+	dispatched := dispatchedEventTypes(t)
+	for eventType := range dispatched {
+		if len(notify.CatalogMatches(eventType)) != 1 {
+			t.Errorf("%s is dispatched but missing from the event catalog", eventType)
+		}
+	}
+	for _, et := range notify.Catalog {
+		if !dispatched[et.Type] {
+			t.Errorf("%s is in the event catalog but never dispatched", et.Type)
+		}
+	}
+}
+
+// dispatchedEventTypes collects the event type literals passed to the
+// handlers' dispatch helpers, set as a notify.Event's Type, assigned to an
+// eventType variable or listed in circuitEventTypes.
+func dispatchedEventTypes(t *testing.T) map[string]bool {
+	t.Helper()
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]bool{}
+	addLiteral := func(e ast.Expr) {
+		if lit, ok := e.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if s, err := strconv.Unquote(lit.Value); err == nil {
+				types[s] = true
+			}
+		}
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if sel, ok := n.Fun.(*ast.SelectorExpr); ok && strings.HasPrefix(sel.Sel.Name, "dispatch") && len(n.Args) > 2 {
+					addLiteral(n.Args[2])
+				}
+			case *ast.CompositeLit:
+				if sel, ok := n.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Event" {
+					for _, elt := range n.Elts {
+						if kv, ok := elt.(*ast.KeyValueExpr); ok && fmt.Sprint(kv.Key) == "Type" {
+							addLiteral(kv.Value)
+						}
+					}
+				}
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok && id.Name == "eventType" && i < len(n.Rhs) {
+						addLiteral(n.Rhs[i])
+					}
+				}
+			case *ast.ValueSpec:
+				for i, id := range n.Names {
+					if id.Name != "circuitEventTypes" || i >= len(n.Values) {
+						continue
+					}
+					if lit, ok := n.Values[i].(*ast.CompositeLit); ok {
+						for _, elt := range lit.Elts {
+							addLiteral(elt.(*ast.KeyValueExpr).Value)
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	if len(types) == 0 {
+		t.Fatal("found no dispatched event types")
+	}
+	return types
+}
+
+// txDispatcher is a transactional notify.EventDispatcher. Events dispatched
+// inside InTx are committed only if fn succeeds.
+type txDispatcher struct {
+	committed   []notify.Event
+	dispatchErr error
+}
+
+type txDispatcherKey struct{}
+
+func (d *txDispatcher) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	var pending []notify.Event
+	if err := fn(context.WithValue(ctx, txDispatcherKey{}, &pending)); err != nil {
+		return err
+	}
+	d.committed = append(d.committed, pending...)
+	return nil
+}
+
+func (d *txDispatcher) Dispatch(ctx context.Context, event notify.Event) error {
+	if d.dispatchErr != nil {
+		return d.dispatchErr
+	}
+	pending, ok := ctx.Value(txDispatcherKey{}).(*[]notify.Event)
+	if !ok {
+		return errors.New("dispatched outside the mutation's transaction")
+	}
+	*pending = append(*pending, event)
+	return nil
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/notify"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// maxBudgetUnits bounds monthly limits and unit costs, keeping micro-unit
+// arithmetic far from overflow.
+const maxBudgetUnits = 1e9
+
+// WorkspaceBudgetStoreForAPI is the interface the workspace budget handler needs from the store.
+type WorkspaceBudgetStoreForAPI interface {
+	Get(ctx context.Context, workspaceID uuid.UUID) (*store.WorkspaceBudget, error)
+	Upsert(ctx context.Context, b *store.WorkspaceBudget) error
+	Delete(ctx context.Context, workspaceID uuid.UUID) error
+	GetSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time) (int64, int64, error)
+}
+
+// WorkspaceBudgetsHandler provides HTTP handlers for workspace budget endpoints.
+type WorkspaceBudgetsHandler struct {
+	budgets    WorkspaceBudgetStoreForAPI
+	audit      AuditStoreForAPI
+	dispatcher notify.EventDispatcher
+}
+
+// NewWorkspaceBudgetsHandler creates a new WorkspaceBudgetsHandler.
+func NewWorkspaceBudgetsHandler(budgets WorkspaceBudgetStoreForAPI, audit AuditStoreForAPI, dispatcher notify.EventDispatcher) *WorkspaceBudgetsHandler {
+	return &WorkspaceBudgetsHandler{
+		budgets:    budgets,
+		audit:      audit,
+		dispatcher: dispatcher,
+	}
+}
+
+// unitsToMicros converts a currency amount to micro-units. It reports false
+// for negative or oversized amounts and amounts with more than six decimals.
+func unitsToMicros(v float64) (int64, bool) {
+	if v < 0 || v > maxBudgetUnits || math.IsNaN(v) {
+		return 0, false
+	}
+	micros := math.Round(v * gateway.MicrosPerUnit)
+	if math.Abs(micros-v*gateway.MicrosPerUnit) > 1e-3 {
+		return 0, false
+	}
+	return int64(micros), true
+}
+
+type putWorkspaceBudgetRequest struct {
+	MonthlyLimit   *float64 `json:"monthly_limit"`
+	SoftThresholds *[]int   `json:"soft_thresholds"`
+	OnExhausted    string   `json:"on_exhausted"`
+}
+
+type workspaceBudgetResponse struct {
+	WorkspaceID    uuid.UUID `json:"workspace_id"`
+	MonthlyLimit   float64   `json:"monthly_limit"`
+	SoftThresholds []int     `json:"soft_thresholds"`
+	OnExhausted    string    `json:"on_exhausted"`
+	Period         string    `json:"period"`
+	Spent          float64   `json:"spent"`
+	Calls          int64     `json:"calls"`
+	PercentUsed    float64   `json:"percent_used"`
+	Exhausted      bool      `json:"exhausted"`
+	CreatedBy      string    `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// respondBudget writes a budget with its current-period spend.
+func (h *WorkspaceBudgetsHandler) respondBudget(w http.ResponseWriter, r *http.Request, status int, b *store.WorkspaceBudget) {
+	period := gateway.BudgetPeriod(time.Now())
+	spent, calls, err := h.budgets.GetSpend(r.Context(), b.WorkspaceID, period)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to load workspace spend"))
+		return
+	}
+	RespondJSON(w, r, status, workspaceBudgetResponse{
+		WorkspaceID:    b.WorkspaceID,
+		MonthlyLimit:   microsToUnits(b.MonthlyLimitMicros),
+		SoftThresholds: b.SoftThresholds,
+		OnExhausted:    b.OnExhausted,
+		Period:         period.Format("2006-01"),
+		Spent:          microsToUnits(spent),
+		Calls:          calls,
+		PercentUsed:    round2(float64(spent) / float64(b.MonthlyLimitMicros) * 100),
+		Exhausted:      spent >= b.MonthlyLimitMicros,
+		CreatedBy:      b.CreatedBy,
+		CreatedAt:      b.CreatedAt,
+		UpdatedAt:      b.UpdatedAt,
+	})
+}
+
+// Get handles GET /api/v1/workspaces/{workspaceId}/budget.
+func (h *WorkspaceBudgetsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+
+	b, err := h.budgets.Get(r.Context(), wsID)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			RespondError(w, r, apierrors.NotFound("workspace_budget", wsID.String()))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to get workspace budget"))
+		return
+	}
+
+	h.respondBudget(w, r, http.StatusOK, b)
+}
+
+// Put handles PUT /api/v1/workspaces/{workspaceId}/budget.
+func (h *WorkspaceBudgetsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+
+	var req putWorkspaceBudgetRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+
+	if req.MonthlyLimit == nil {
+		RespondError(w, r, apierrors.Validation("monthly_limit is required"))
+		return
+	}
+	limit, ok := unitsToMicros(*req.MonthlyLimit)
+	if !ok || limit == 0 {
+		RespondError(w, r, apierrors.Validation("monthly_limit must be a positive amount with at most 6 decimal places"))
+		return
+	}
+	thresholds := []int{80}
+	if req.SoftThresholds != nil {
+		thresholds = *req.SoftThresholds
+	}
+	if len(thresholds) > 10 {
+		RespondError(w, r, apierrors.Validation("soft_thresholds must contain at most 10 values"))
+		return
+	}
+	seen := make(map[int]bool, len(thresholds))
+	for _, pct := range thresholds {
+		if pct < 1 || pct > 99 {
+			RespondError(w, r, apierrors.Validation("soft_thresholds must be percentages between 1 and 99"))
+			return
+		}
+		if seen[pct] {
+			RespondError(w, r, apierrors.Validation("soft_thresholds must not contain duplicates"))
+			return
+		}
+		seen[pct] = true
+	}
+	sorted := append([]int{}, thresholds...)
+	sort.Ints(sorted)
+	if req.OnExhausted == "" {
+		req.OnExhausted = "reject"
+	}
+	if req.OnExhausted != "reject" && req.OnExhausted != "flag" {
+		RespondError(w, r, apierrors.Validation("on_exhausted must be one of: reject, flag"))
+		return
+	}
+
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	b := &store.WorkspaceBudget{
+		WorkspaceID:        wsID,
+		MonthlyLimitMicros: limit,
+		SoftThresholds:     sorted,
+		OnExhausted:        req.OnExhausted,
+		CreatedBy:          callerID.String(),
+	}
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.budgets.Upsert(ctx, b); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "workspace_budget.updated", "workspace_budget", wsID.String())
+	})
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to save workspace budget"))
+		return
+	}
+
+	h.auditLog(r, "workspace_budget_update", "workspace_budget", wsID.String())
+
+	h.respondBudget(w, r, http.StatusOK, b)
+}
+
+// Delete handles DELETE /api/v1/workspaces/{workspaceId}/budget.
+func (h *WorkspaceBudgetsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.budgets.Delete(ctx, wsID); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "workspace_budget.deleted", "workspace_budget", wsID.String())
+	})
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			RespondError(w, r, apierrors.NotFound("workspace_budget", wsID.String()))
+			return
+		}
+		RespondError(w, r, apierrors.Internal("failed to delete workspace budget"))
+		return
+	}
+
+	h.auditLog(r, "workspace_budget_delete", "workspace_budget", wsID.String())
+
+	RespondNoContent(w)
+}
+
+func (h *WorkspaceBudgetsHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
+
+func (h *WorkspaceBudgetsHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string) error {
+	if h.dispatcher == nil {
+		return nil
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	return h.dispatcher.Dispatch(ctx, notify.Event{
+		Type:         eventType,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		WorkspaceID:  chi.URLParam(r, "workspaceId"),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		Actor:        callerID.String(),
+	})
+}
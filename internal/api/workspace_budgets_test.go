@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// --- Mock workspace budget store ---
+
+type mockWorkspaceBudgetStore struct {
+	budgets map[uuid.UUID]*store.WorkspaceBudget
+	spend   map[uuid.UUID]int64
+	calls   map[uuid.UUID]int64
+}
+
+func newMockWorkspaceBudgetStore() *mockWorkspaceBudgetStore {
+	return &mockWorkspaceBudgetStore{
+		budgets: make(map[uuid.UUID]*store.WorkspaceBudget),
+		spend:   make(map[uuid.UUID]int64),
+		calls:   make(map[uuid.UUID]int64),
+	}
+}
+
+func (m *mockWorkspaceBudgetStore) Get(_ context.Context, workspaceID uuid.UUID) (*store.WorkspaceBudget, error) {
+	b, ok := m.budgets[workspaceID]
+	if !ok {
+		return nil, apierrors.NotFound("workspace_budget", workspaceID.String())
+	}
+	copied := *b
+	return &copied, nil
+}
+
+func (m *mockWorkspaceBudgetStore) Upsert(_ context.Context, b *store.WorkspaceBudget) error {
+	if existing, ok := m.budgets[b.WorkspaceID]; ok {
+		b.CreatedBy = existing.CreatedBy
+		b.CreatedAt = existing.CreatedAt
+	} else {
+		b.CreatedAt = time.Now()
+	}
+	b.UpdatedAt = time.Now()
+	copied := *b
+	m.budgets[b.WorkspaceID] = &copied
+	return nil
+}
+
+func (m *mockWorkspaceBudgetStore) Delete(_ context.Context, workspaceID uuid.UUID) error {
+	if _, ok := m.budgets[workspaceID]; !ok {
+		return apierrors.NotFound("workspace_budget", workspaceID.String())
+	}
+	delete(m.budgets, workspaceID)
+	return nil
+}
+
+func (m *mockWorkspaceBudgetStore) GetSpend(_ context.Context, workspaceID uuid.UUID, _ time.Time) (int64, int64, error) {
+	return m.spend[workspaceID], m.calls[workspaceID], nil
+}
+
+func (m *mockWorkspaceBudgetStore) AddSpend(_ context.Context, workspaceID uuid.UUID, _ time.Time, micros int64) (int64, error) {
+	m.spend[workspaceID] += micros
+	m.calls[workspaceID]++
+	return m.spend[workspaceID], nil
+}
+
+func (m *mockWorkspaceBudgetStore) ReserveSpend(_ context.Context, workspaceID uuid.UUID, _ time.Time, micros, limit int64) (int64, bool, error) {
+	if m.spend[workspaceID]+micros > limit {
+		return 0, false, nil
+	}
+	m.spend[workspaceID] += micros
+	m.calls[workspaceID]++
+	return m.spend[workspaceID], true, nil
+}
+
+func (m *mockWorkspaceBudgetStore) ReleaseSpend(_ context.Context, workspaceID uuid.UUID, _ time.Time, micros int64) error {
+	m.spend[workspaceID] -= micros
+	m.calls[workspaceID]--
+	return nil
+}
+
+func budgetRequest(method string, wsID uuid.UUID, body interface{}) *http.Request {
+	req := adminRequest(method, "/api/v1/workspaces/"+wsID.String()+"/budget", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("workspaceId", wsID.String())
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// --- Workspace budgets handler tests ---
+
+func TestWorkspaceBudgetsHandler_Put(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{"valid budget", map[string]interface{}{"monthly_limit": 500, "soft_thresholds": []int{90, 50}, "on_exhausted": "flag"}, http.StatusOK},
+		{"defaults", map[string]interface{}{"monthly_limit": 12.5}, http.StatusOK},
+		{"missing limit", map[string]interface{}{"on_exhausted": "reject"}, http.StatusBadRequest},
+		{"zero limit", map[string]interface{}{"monthly_limit": 0}, http.StatusBadRequest},
+		{"too precise", map[string]interface{}{"monthly_limit": 1.0000001}, http.StatusBadRequest},
+		{"threshold out of range", map[string]interface{}{"monthly_limit": 10, "soft_thresholds": []int{100}}, http.StatusBadRequest},
+		{"duplicate thresholds", map[string]interface{}{"monthly_limit": 10, "soft_thresholds": []int{80, 80}}, http.StatusBadRequest},
+		{"invalid on_exhausted", map[string]interface{}{"monthly_limit": 10, "on_exhausted": "throttle"}, http.StatusBadRequest},
+		{"unknown field", map[string]interface{}{"monthly_limit": 10, "currency": "EUR"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsID := uuid.New()
+			budgets := newMockWorkspaceBudgetStore()
+			audit := &mockAuditStoreForAPI{}
+			dispatcher := &recordingDispatcher{}
+			h := NewWorkspaceBudgetsHandler(budgets, audit, dispatcher)
+
+			w := httptest.NewRecorder()
+			h.Put(w, budgetRequest(http.MethodPut, wsID, tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if len(budgets.budgets) != 0 {
+					t.Error("invalid budget should not be stored")
+				}
+				return
+			}
+			if len(audit.entries) != 1 || audit.entries[0].Action != "workspace_budget_update" {
+				t.Errorf("expected workspace_budget_update audit entry, got %+v", audit.entries)
+			}
+			if len(dispatcher.events) != 1 || dispatcher.events[0].Type != "workspace_budget.updated" {
+				t.Errorf("expected workspace_budget.updated event, got %+v", dispatcher.events)
+			}
+		})
+	}
+}
+
+func TestWorkspaceBudgetsHandler_PutStoresMicros(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	h := NewWorkspaceBudgetsHandler(budgets, nil, nil)
+
+	w := httptest.NewRecorder()
+	h.Put(w, budgetRequest(http.MethodPut, wsID, map[string]interface{}{"monthly_limit": 0.29, "soft_thresholds": []int{90, 50}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	b := budgets.budgets[wsID]
+	if b.MonthlyLimitMicros != 290000 || b.OnExhausted != "reject" {
+		t.Errorf("unexpected stored budget: %+v", b)
+	}
+	if len(b.SoftThresholds) != 2 || b.SoftThresholds[0] != 50 || b.SoftThresholds[1] != 90 {
+		t.Errorf("thresholds should be sorted, got %v", b.SoftThresholds)
+	}
+}
+
+func TestWorkspaceBudgetsHandler_Get(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	budgets.budgets[wsID] = &store.WorkspaceBudget{WorkspaceID: wsID, MonthlyLimitMicros: 100000000, SoftThresholds: []int{80}, OnExhausted: "reject"}
+	budgets.spend[wsID] = 85000000
+	budgets.calls[wsID] = 42
+	h := NewWorkspaceBudgetsHandler(budgets, nil, nil)
+
+	w := httptest.NewRecorder()
+	h.Get(w, budgetRequest(http.MethodGet, wsID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	if data["monthly_limit"].(float64) != 100 || data["spent"].(float64) != 85 || data["calls"].(float64) != 42 {
+		t.Errorf("unexpected budget: %v", data)
+	}
+	if data["percent_used"].(float64) != 85 || data["exhausted"].(bool) {
+		t.Errorf("percent_used = %v, exhausted = %v", data["percent_used"], data["exhausted"])
+	}
+	if data["period"] != time.Now().UTC().Format("2006-01") {
+		t.Errorf("period = %v", data["period"])
+	}
+
+	w = httptest.NewRecorder()
+	h.Get(w, budgetRequest(http.MethodGet, uuid.New(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for workspace without budget, got %d", w.Code)
+	}
+}
+
+func TestWorkspaceBudgetsHandler_Delete(t *testing.T) {
+	wsID := uuid.New()
+	budgets := newMockWorkspaceBudgetStore()
+	budgets.budgets[wsID] = &store.WorkspaceBudget{WorkspaceID: wsID, MonthlyLimitMicros: 1000000}
+	dispatcher := &recordingDispatcher{}
+	h := NewWorkspaceBudgetsHandler(budgets, &mockAuditStoreForAPI{}, dispatcher)
+
+	w := httptest.NewRecorder()
+	h.Delete(w, budgetRequest(http.MethodDelete, wsID, nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(dispatcher.events) != 1 || dispatcher.events[0].Type != "workspace_budget.deleted" {
+		t.Errorf("expected workspace_budget.deleted event, got %+v", dispatcher.events)
+	}
+
+	w = httptest.NewRecorder()
+	h.Delete(w, budgetRequest(http.MethodDelete, wsID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 on second delete, got %d", w.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/notify"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// WorkspaceMemberStoreForAPI is the interface the workspace members handler needs from the store.
+type WorkspaceMemberStoreForAPI interface {
+	List(ctx context.Context, workspaceID uuid.UUID) ([]store.WorkspaceMember, error)
+	Add(ctx context.Context, m *store.WorkspaceMember) error
+	Remove(ctx context.Context, workspaceID, id uuid.UUID) error
+}
+
+// WorkspaceMembersHandler provides HTTP handlers for workspace member endpoints.
+type WorkspaceMembersHandler struct {
+	members    WorkspaceMemberStoreForAPI
+	audit      AuditStoreForAPI
+	dispatcher notify.EventDispatcher
+}
+
+// NewWorkspaceMembersHandler creates a new WorkspaceMembersHandler.
+func NewWorkspaceMembersHandler(members WorkspaceMemberStoreForAPI, audit AuditStoreForAPI, dispatcher notify.EventDispatcher) *WorkspaceMembersHandler {
+	return &WorkspaceMembersHandler{
+		members:    members,
+		audit:      audit,
+		dispatcher: dispatcher,
+	}
+}
+
+type addWorkspaceMemberRequest struct {
+	UserID   *string `json:"user_id"`
+	APIKeyID *string `json:"api_key_id"`
+}
+
+// List handles GET /api/v1/workspaces/{workspaceId}/members.
+func (h *WorkspaceMembersHandler) List(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+
+	members, err := h.members.List(r.Context(), wsID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to list workspace members"))
+		return
+	}
+
+	if members == nil {
+		members = []store.WorkspaceMember{}
+	}
+
+	RespondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"members": members,
+		"total":   len(members),
+	})
+}
+
+// Add handles POST /api/v1/workspaces/{workspaceId}/members. Exactly one of
+// user_id and api_key_id is required.
+func (h *WorkspaceMembersHandler) Add(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+
+	var req addWorkspaceMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+	if (req.UserID == nil) == (req.APIKeyID == nil) {
+		RespondError(w, r, apierrors.Validation("exactly one of user_id and api_key_id is required"))
+		return
+	}
+
+	principalType, rawID := store.PrincipalUser, req.UserID
+	if req.APIKeyID != nil {
+		principalType, rawID = store.PrincipalAPIKey, req.APIKeyID
+	}
+	principalID, err := uuid.Parse(*rawID)
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid "+principalType+" ID"))
+		return
+	}
+
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	member := &store.WorkspaceMember{
+		WorkspaceID:   wsID,
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
+		CreatedBy:     callerID.String(),
+	}
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.members.Add(ctx, member); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "workspace_member.added", "workspace_member", member.ID.String())
+	})
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to add workspace member"))
+		return
+	}
+
+	h.auditLog(r, "workspace_member_add", "workspace_member", member.ID.String())
+
+	RespondJSON(w, r, http.StatusCreated, member)
+}
+
+// Remove handles DELETE /api/v1/workspaces/{workspaceId}/members/{memberId}.
+func (h *WorkspaceMembersHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid member ID"))
+		return
+	}
+
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.members.Remove(ctx, wsID, memberID); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "workspace_member.removed", "workspace_member", memberID.String())
+	})
+	if err != nil {
+		RespondError(w, r, apierrors.NotFound("workspace_member", memberID.String()))
+		return
+	}
+
+	h.auditLog(r, "workspace_member_remove", "workspace_member", memberID.String())
+
+	RespondNoContent(w)
+}
+
+func (h *WorkspaceMembersHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
+
+func (h *WorkspaceMembersHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string) error {
+	if h.dispatcher == nil {
+		return nil
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	return h.dispatcher.Dispatch(ctx, notify.Event{
+		Type:         eventType,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		WorkspaceID:  chi.URLParam(r, "workspaceId"),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		Actor:        callerID.String(),
+	})
+}
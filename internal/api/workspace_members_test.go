@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// --- Mock workspace member store ---
+
+type mockWorkspaceMemberStore struct {
+	members map[uuid.UUID]*store.WorkspaceMember
+}
+
+func newMockWorkspaceMemberStore() *mockWorkspaceMemberStore {
+	return &mockWorkspaceMemberStore{members: make(map[uuid.UUID]*store.WorkspaceMember)}
+}
+
+func (m *mockWorkspaceMemberStore) List(_ context.Context, workspaceID uuid.UUID) ([]store.WorkspaceMember, error) {
+	var out []store.WorkspaceMember
+	for _, mem := range m.members {
+		if mem.WorkspaceID == workspaceID {
+			out = append(out, *mem)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWorkspaceMemberStore) Add(_ context.Context, mem *store.WorkspaceMember) error {
+	for _, existing := range m.members {
+		if existing.WorkspaceID == mem.WorkspaceID && existing.PrincipalType == mem.PrincipalType && existing.PrincipalID == mem.PrincipalID {
+			*mem = *existing
+			return nil
+		}
+	}
+	mem.ID = uuid.New()
+	mem.CreatedAt = time.Now()
+	copied := *mem
+	m.members[mem.ID] = &copied
+	return nil
+}
+
+func (m *mockWorkspaceMemberStore) Remove(_ context.Context, workspaceID, id uuid.UUID) error {
+	mem, ok := m.members[id]
+	if !ok || mem.WorkspaceID != workspaceID {
+		return apierrors.NotFound("workspace_member", id.String())
+	}
+	delete(m.members, id)
+	return nil
+}
+
+func (m *mockWorkspaceMemberStore) IsMember(_ context.Context, workspaceID uuid.UUID, principalType string, principalID uuid.UUID) (bool, error) {
+	for _, mem := range m.members {
+		if mem.WorkspaceID == workspaceID && mem.PrincipalType == principalType && mem.PrincipalID == principalID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func workspaceMembersRequest(method string, wsID uuid.UUID, memberID string, body interface{}) *http.Request {
+	req := adminRequest(method, "/api/v1/workspaces/"+wsID.String()+"/members", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("workspaceId", wsID.String())
+	if memberID != "" {
+		rctx.URLParams.Add("memberId", memberID)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// --- Workspace members handler tests ---
+
+func TestWorkspaceMembersHandler_Add(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		wantType   string
+	}{
+		{"user", map[string]interface{}{"user_id": uuid.New().String()}, http.StatusCreated, store.PrincipalUser},
+		{"api key", map[string]interface{}{"api_key_id": uuid.New().String()}, http.StatusCreated, store.PrincipalAPIKey},
+		{"neither", map[string]interface{}{}, http.StatusBadRequest, ""},
+		{"both", map[string]interface{}{"user_id": uuid.New().String(), "api_key_id": uuid.New().String()}, http.StatusBadRequest, ""},
+		{"invalid id", map[string]interface{}{"user_id": "not-a-uuid"}, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsID := uuid.New()
+			members := newMockWorkspaceMemberStore()
+			audit := &mockAuditStoreForAPI{}
+			dispatcher := &recordingDispatcher{}
+			h := NewWorkspaceMembersHandler(members, audit, dispatcher)
+
+			w := httptest.NewRecorder()
+			h.Add(w, workspaceMembersRequest(http.MethodPost, wsID, "", tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				if len(members.members) != 0 {
+					t.Error("invalid member should not be stored")
+				}
+				return
+			}
+			data := parseEnvelope(t, w).Data.(map[string]interface{})
+			if data["principal_type"] != tt.wantType || data["workspace_id"] != wsID.String() {
+				t.Errorf("unexpected member: %v", data)
+			}
+			if len(audit.entries) != 1 || audit.entries[0].Action != "workspace_member_add" {
+				t.Errorf("expected workspace_member_add audit entry, got %+v", audit.entries)
+			}
+			if len(dispatcher.events) != 1 || dispatcher.events[0].Type != "workspace_member.added" {
+				t.Errorf("expected workspace_member.added event, got %+v", dispatcher.events)
+			}
+		})
+	}
+}
+
+func TestWorkspaceMembersHandler_ListAndRemove(t *testing.T) {
+	wsID := uuid.New()
+	members := newMockWorkspaceMemberStore()
+	mem := &store.WorkspaceMember{WorkspaceID: wsID, PrincipalType: store.PrincipalUser, PrincipalID: uuid.New()}
+	members.Add(context.Background(), mem)
+	members.Add(context.Background(), &store.WorkspaceMember{WorkspaceID: uuid.New(), PrincipalType: store.PrincipalUser, PrincipalID: uuid.New()})
+	h := NewWorkspaceMembersHandler(members, nil, nil)
+
+	w := httptest.NewRecorder()
+	h.List(w, workspaceMembersRequest(http.MethodGet, wsID, "", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	if data["total"].(float64) != 1 {
+		t.Errorf("expected 1 member, got %v", data["total"])
+	}
+
+	w = httptest.NewRecorder()
+	h.Remove(w, workspaceMembersRequest(http.MethodDelete, uuid.New(), mem.ID.String(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 removing a member of another workspace, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.Remove(w, workspaceMembersRequest(http.MethodDelete, wsID, mem.ID.String(), nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(members.members) != 1 {
+		t.Errorf("expected member to be removed")
+	}
+}
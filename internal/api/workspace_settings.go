@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/internal/auth"
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/gateway"
+	"github.com/agent-smit/agentic-registry/internal/notify"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// Agent tool enforcement modes.
+const (
+	AgentToolsOff     = "off"
+	AgentToolsAudit   = "audit"
+	AgentToolsEnforce = "enforce"
+)
+
+var validAgentToolModes = map[string]bool{
+	AgentToolsOff:     true,
+	AgentToolsAudit:   true,
+	AgentToolsEnforce: true,
+}
+
+var validSandboxModes = map[string]bool{
+	gateway.SandboxOff:    true,
+	gateway.SandboxRecord: true,
+	gateway.SandboxReplay: true,
+}
+
+// maxSandboxServers bounds the per-server sandbox configs of a workspace.
+const maxSandboxServers = 100
+
+// WorkspaceSettingsStoreForAPI is the interface the workspace settings handler needs from the store.
+type WorkspaceSettingsStoreForAPI interface {
+	Get(ctx context.Context, workspaceID uuid.UUID) (*store.WorkspaceSettings, error)
+	Upsert(ctx context.Context, ws *store.WorkspaceSettings) error
+}
+
+// WorkspaceSettingsHandler provides HTTP handlers for workspace settings endpoints.
+type WorkspaceSettingsHandler struct {
+	settings   WorkspaceSettingsStoreForAPI
+	audit      AuditStoreForAPI
+	dispatcher notify.EventDispatcher
+}
+
+// NewWorkspaceSettingsHandler creates a new WorkspaceSettingsHandler.
+func NewWorkspaceSettingsHandler(settings WorkspaceSettingsStoreForAPI, audit AuditStoreForAPI, dispatcher notify.EventDispatcher) *WorkspaceSettingsHandler {
+	return &WorkspaceSettingsHandler{
+		settings:   settings,
+		audit:      audit,
+		dispatcher: dispatcher,
+	}
+}
+
+// loadWorkspaceSettings returns a workspace's stored settings, or the
+// defaults when none are stored.
+func loadWorkspaceSettings(ctx context.Context, s WorkspaceSettingsStoreForAPI, wsID uuid.UUID) (*store.WorkspaceSettings, error) {
+	ws, err := s.Get(ctx, wsID)
+	if err != nil {
+		var apiErr *apierrors.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return &store.WorkspaceSettings{
+				WorkspaceID:          wsID,
+				AgentToolEnforcement: AgentToolsOff,
+				Sandbox:              json.RawMessage(`{}`),
+			}, nil
+		}
+		return nil, err
+	}
+	return ws, nil
+}
+
+// workspaceSandbox decodes a workspace's per-server sandbox configs.
+func workspaceSandbox(ws *store.WorkspaceSettings) (map[string]gateway.SandboxConfig, error) {
+	configs := make(map[string]gateway.SandboxConfig)
+	if len(ws.Sandbox) == 0 {
+		return configs, nil
+	}
+	if err := json.Unmarshal(ws.Sandbox, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// validateSandbox checks per-server sandbox configs keyed by server label,
+// or "*" for every server of the workspace.
+func validateSandbox(configs map[string]gateway.SandboxConfig) string {
+	if len(configs) > maxSandboxServers {
+		return fmt.Sprintf("sandbox must not configure more than %d servers", maxSandboxServers)
+	}
+	for label, c := range configs {
+		if label == "" || len(label) > 200 {
+			return "sandbox server labels must be 1-200 characters"
+		}
+		if !validSandboxModes[c.Mode] {
+			return "sandbox." + label + ".mode must be one of: off, record, replay"
+		}
+		switch c.MissPolicy {
+		case "", gateway.ReplayMissError, gateway.ReplayMissStub:
+		default:
+			return "sandbox." + label + ".miss_policy must be one of: error, stub"
+		}
+		if c.MissPolicy != "" && c.Mode != gateway.SandboxReplay {
+			return "sandbox." + label + ".miss_policy only applies to replay mode"
+		}
+	}
+	return ""
+}
+
+type updateWorkspaceSettingsRequest struct {
+	AgentToolEnforcement *string                          `json:"agent_tool_enforcement"`
+	Sandbox              map[string]gateway.SandboxConfig `json:"sandbox"`
+}
+
+// Get handles GET /api/v1/workspaces/{workspaceId}/settings.
+func (h *WorkspaceSettingsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+
+	ws, err := loadWorkspaceSettings(r.Context(), h.settings, wsID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to get workspace settings"))
+		return
+	}
+
+	RespondJSON(w, r, http.StatusOK, ws)
+}
+
+// Update handles PUT /api/v1/workspaces/{workspaceId}/settings. Omitted
+// fields keep their current values.
+func (h *WorkspaceSettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	wsID, err := uuid.Parse(chi.URLParam(r, "workspaceId"))
+	if err != nil {
+		RespondError(w, r, apierrors.Validation("invalid workspace ID"))
+		return
+	}
+
+	var req updateWorkspaceSettingsRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		RespondError(w, r, apierrors.Validation("invalid request body"))
+		return
+	}
+
+	ws, err := loadWorkspaceSettings(r.Context(), h.settings, wsID)
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to get workspace settings"))
+		return
+	}
+	if req.AgentToolEnforcement != nil {
+		if !validAgentToolModes[*req.AgentToolEnforcement] {
+			RespondError(w, r, apierrors.Validation("agent_tool_enforcement must be one of: off, audit, enforce"))
+			return
+		}
+		ws.AgentToolEnforcement = *req.AgentToolEnforcement
+	}
+	if req.Sandbox != nil {
+		if msg := validateSandbox(req.Sandbox); msg != "" {
+			RespondError(w, r, apierrors.Validation(msg))
+			return
+		}
+		ws.Sandbox, _ = json.Marshal(req.Sandbox)
+	}
+
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	ws.UpdatedBy = callerID.String()
+	err = withEvents(r.Context(), h.dispatcher, func(ctx context.Context) error {
+		if err := h.settings.Upsert(ctx, ws); err != nil {
+			return err
+		}
+		return h.dispatchEvent(ctx, r, "workspace_settings.updated", "workspace_settings", wsID.String())
+	})
+	if err != nil {
+		RespondError(w, r, apierrors.Internal("failed to save workspace settings"))
+		return
+	}
+
+	h.auditLog(r, "workspace_settings_update", "workspace_settings", wsID.String())
+
+	RespondJSON(w, r, http.StatusOK, ws)
+}
+
+func (h *WorkspaceSettingsHandler) auditLog(r *http.Request, action, resourceType, resourceID string) {
+	if h.audit == nil {
+		return
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	if err := h.audit.Insert(r.Context(), &store.AuditEntry{
+		Actor:        callerID.String(),
+		ActorID:      &callerID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    clientIPFromRequest(r),
+	}); err != nil {
+		log.Printf("audit log failed for %s %s/%s: %v", action, resourceType, resourceID, err)
+	}
+}
+
+func (h *WorkspaceSettingsHandler) dispatchEvent(ctx context.Context, r *http.Request, eventType, resourceType, resourceID string) error {
+	if h.dispatcher == nil {
+		return nil
+	}
+	callerID, _ := auth.UserIDFromContext(r.Context())
+	return h.dispatcher.Dispatch(ctx, notify.Event{
+		Type:         eventType,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		WorkspaceID:  chi.URLParam(r, "workspaceId"),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		Actor:        callerID.String(),
+	})
+}
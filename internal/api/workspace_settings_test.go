@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	apierrors "github.com/agent-smit/agentic-registry/internal/errors"
+	"github.com/agent-smit/agentic-registry/internal/store"
+)
+
+// --- Mock workspace settings store ---
+
+type mockWorkspaceSettingsStore struct {
+	settings map[uuid.UUID]*store.WorkspaceSettings
+}
+
+func newMockWorkspaceSettingsStore() *mockWorkspaceSettingsStore {
+	return &mockWorkspaceSettingsStore{settings: make(map[uuid.UUID]*store.WorkspaceSettings)}
+}
+
+func (m *mockWorkspaceSettingsStore) Get(_ context.Context, workspaceID uuid.UUID) (*store.WorkspaceSettings, error) {
+	ws, ok := m.settings[workspaceID]
+	if !ok {
+		return nil, apierrors.NotFound("workspace_settings", workspaceID.String())
+	}
+	copied := *ws
+	return &copied, nil
+}
+
+func (m *mockWorkspaceSettingsStore) Upsert(_ context.Context, ws *store.WorkspaceSettings) error {
+	ws.UpdatedAt = time.Now()
+	copied := *ws
+	m.settings[ws.WorkspaceID] = &copied
+	return nil
+}
+
+func workspaceSettingsRequest(method string, wsID uuid.UUID, body interface{}) *http.Request {
+	req := adminRequest(method, "/api/v1/workspaces/"+wsID.String()+"/settings", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("workspaceId", wsID.String())
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// --- Workspace settings handler tests ---
+
+func TestWorkspaceSettingsHandler_GetDefaults(t *testing.T) {
+	h := NewWorkspaceSettingsHandler(newMockWorkspaceSettingsStore(), nil, nil)
+
+	w := httptest.NewRecorder()
+	h.Get(w, workspaceSettingsRequest(http.MethodGet, uuid.New(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data := parseEnvelope(t, w).Data.(map[string]interface{})
+	if data["agent_tool_enforcement"] != AgentToolsOff {
+		t.Errorf("agent_tool_enforcement = %v, want off", data["agent_tool_enforcement"])
+	}
+	if sandbox, ok := data["sandbox"].(map[string]interface{}); !ok || len(sandbox) != 0 {
+		t.Errorf("sandbox = %v, want empty object", data["sandbox"])
+	}
+}
+
+func TestWorkspaceSettingsHandler_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		wantMode   string
+	}{
+		{"audit", map[string]interface{}{"agent_tool_enforcement": "audit"}, http.StatusOK, AgentToolsAudit},
+		{"enforce", map[string]interface{}{"agent_tool_enforcement": "enforce"}, http.StatusOK, AgentToolsEnforce},
+		{"empty keeps current", map[string]interface{}{}, http.StatusOK, AgentToolsOff},
+		{"invalid mode", map[string]interface{}{"agent_tool_enforcement": "strict"}, http.StatusBadRequest, ""},
+		{"unknown field", map[string]interface{}{"agent_tools": "enforce"}, http.StatusBadRequest, ""},
+		{"sandbox", map[string]interface{}{"sandbox": map[string]interface{}{
+			"github": map[string]string{"mode": "replay", "miss_policy": "stub"}, "*": map[string]string{"mode": "record"},
+		}}, http.StatusOK, AgentToolsOff},
+		{"invalid sandbox mode", map[string]interface{}{"sandbox": map[string]interface{}{
+			"github": map[string]string{"mode": "mock"},
+		}}, http.StatusBadRequest, ""},
+		{"miss policy outside replay", map[string]interface{}{"sandbox": map[string]interface{}{
+			"github": map[string]string{"mode": "record", "miss_policy": "stub"},
+		}}, http.StatusBadRequest, ""},
+		{"unknown sandbox field", map[string]interface{}{"sandbox": map[string]interface{}{
+			"github": map[string]string{"mode": "replay", "policy": "stub"},
+		}}, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsID := uuid.New()
+			settings := newMockWorkspaceSettingsStore()
+			audit := &mockAuditStoreForAPI{}
+			dispatcher := &recordingDispatcher{}
+			h := NewWorkspaceSettingsHandler(settings, audit, dispatcher)
+
+			w := httptest.NewRecorder()
+			h.Update(w, workspaceSettingsRequest(http.MethodPut, wsID, tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d; body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if len(settings.settings) != 0 {
+					t.Error("invalid settings should not be stored")
+				}
+				return
+			}
+			if got := settings.settings[wsID].AgentToolEnforcement; got != tt.wantMode {
+				t.Errorf("stored mode = %q, want %q", got, tt.wantMode)
+			}
+			if len(audit.entries) != 1 || audit.entries[0].Action != "workspace_settings_update" {
+				t.Errorf("expected workspace_settings_update audit entry, got %+v", audit.entries)
+			}
+			if len(dispatcher.events) != 1 || dispatcher.events[0].Type != "workspace_settings.updated" {
+				t.Errorf("expected workspace_settings.updated event, got %+v", dispatcher.events)
+			}
+		})
+	}
+}
@@ -691,6 +691,7 @@ const (
 	contextKeyUserID   contextKey = "user_id"
 	contextKeyUserRole contextKey = "user_role"
 	contextKeyAuthType contextKey = "auth_type" // "session" or "apikey"
+	contextKeyAPIKeyID contextKey = "api_key_id"
 )
 
 // ContextWithUser adds user information to the context.
@@ -713,6 +714,19 @@ func UserRoleFromContext(ctx context.Context) (string, bool) {
 	return role, ok
 }
 
+// ContextWithAPIKeyID adds the ID of the API key a request authenticated
+// with to the context.
+func ContextWithAPIKeyID(ctx context.Context, keyID uuid.UUID) context.Context {
+	return context.WithValue(ctx, contextKeyAPIKeyID, keyID)
+}
+
+// APIKeyIDFromContext extracts the ID of the API key a request authenticated
+// with from the context.
+func APIKeyIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(contextKeyAPIKeyID).(uuid.UUID)
+	return id, ok
+}
+
 // AuthTypeFromContext extracts the authentication type from the context.
 func AuthTypeFromContext(ctx context.Context) (string, bool) {
 	at, ok := ctx.Value(contextKeyAuthType).(string)
@@ -20,11 +20,20 @@ type Config struct {
 	WebhookTimeoutS        int
 	WebhookRetries         int
 	WebhookWorkers         int
+	WebhookRetentionD      int
 	MCPEnabled             bool
 	A2ARegistryURL         string
 	GatewayMode            bool
 	GatewayTimeoutS        int
 	GatewayMaxBodySize     int64
+	GatewayHealthIntervalS int
+	GatewayCacheEntries    int
+	GatewayCircuitShared   bool
+	GatewayCircuitSyncS    int
+	GatewayUsageFlushS     int
+	GatewayUsageRetentionD int
+	GatewayTrustCacheTTLS  int
+	GatewayStdioEnabled    bool
 }
 
 // Load reads configuration from environment variables.
@@ -86,6 +95,10 @@ func LoadFrom(env map[string]string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.WebhookRetentionD, err = getIntOrDefault(get, "WEBHOOK_RETENTION_DAYS", 7)
+	if err != nil {
+		return nil, err
+	}
 
 	// Bool with default
 	cfg.MCPEnabled = getBoolOrDefault(get, "MCP_ENABLED", true)
@@ -103,6 +116,32 @@ func LoadFrom(env map[string]string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	cfg.GatewayHealthIntervalS, err = getIntOrDefault(get, "GATEWAY_HEALTH_INTERVAL", 30)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GatewayCacheEntries, err = getIntOrDefault(get, "GATEWAY_CACHE_MAX_ENTRIES", 10000)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GatewayCircuitShared = getBoolOrDefault(get, "GATEWAY_CIRCUIT_SHARED", false)
+	cfg.GatewayCircuitSyncS, err = getIntOrDefault(get, "GATEWAY_CIRCUIT_SYNC_INTERVAL", 5)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GatewayUsageFlushS, err = getIntOrDefault(get, "GATEWAY_USAGE_FLUSH_INTERVAL", 10)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GatewayUsageRetentionD, err = getIntOrDefault(get, "GATEWAY_USAGE_RETENTION_DAYS", 30)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GatewayTrustCacheTTLS, err = getIntOrDefault(get, "GATEWAY_TRUST_CACHE_TTL", 300)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GatewayStdioEnabled = getBoolOrDefault(get, "GATEWAY_STDIO_ENABLED", false)
 
 	return cfg, nil
 }
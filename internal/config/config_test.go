@@ -79,6 +79,7 @@ func TestLoad_Defaults(t *testing.T) {
 		{"WebhookTimeoutS", cfg.WebhookTimeoutS, 5},
 		{"WebhookRetries", cfg.WebhookRetries, 3},
 		{"WebhookWorkers", cfg.WebhookWorkers, 4},
+		{"WebhookRetentionD", cfg.WebhookRetentionD, 7},
 	}
 
 	for _, tc := range intTests {
@@ -244,6 +245,30 @@ func TestLoad_GatewayDefaults(t *testing.T) {
 	if cfg.GatewayMaxBodySize != 1048576 {
 		t.Errorf("GatewayMaxBodySize = %d, want 1048576", cfg.GatewayMaxBodySize)
 	}
+	if cfg.GatewayHealthIntervalS != 30 {
+		t.Errorf("GatewayHealthIntervalS = %d, want 30", cfg.GatewayHealthIntervalS)
+	}
+	if cfg.GatewayCacheEntries != 10000 {
+		t.Errorf("GatewayCacheEntries = %d, want 10000", cfg.GatewayCacheEntries)
+	}
+	if cfg.GatewayCircuitShared {
+		t.Error("GatewayCircuitShared should default to false")
+	}
+	if cfg.GatewayCircuitSyncS != 5 {
+		t.Errorf("GatewayCircuitSyncS = %d, want 5", cfg.GatewayCircuitSyncS)
+	}
+	if cfg.GatewayUsageFlushS != 10 {
+		t.Errorf("GatewayUsageFlushS = %d, want 10", cfg.GatewayUsageFlushS)
+	}
+	if cfg.GatewayUsageRetentionD != 30 {
+		t.Errorf("GatewayUsageRetentionD = %d, want 30", cfg.GatewayUsageRetentionD)
+	}
+	if cfg.GatewayTrustCacheTTLS != 300 {
+		t.Errorf("GatewayTrustCacheTTLS = %d, want 300", cfg.GatewayTrustCacheTTLS)
+	}
+	if cfg.GatewayStdioEnabled {
+		t.Error("GatewayStdioEnabled should default to false")
+	}
 }
 
 func TestLoad_GatewayCustomValues(t *testing.T) {
@@ -254,6 +279,7 @@ func TestLoad_GatewayCustomValues(t *testing.T) {
 		"GATEWAY_MODE":              "true",
 		"GATEWAY_TIMEOUT":           "60",
 		"GATEWAY_MAX_BODY_SIZE":     "2097152",
+		"GATEWAY_STDIO_ENABLED":     "true",
 	}
 
 	cfg, err := LoadFrom(env)
@@ -270,6 +296,9 @@ func TestLoad_GatewayCustomValues(t *testing.T) {
 	if cfg.GatewayMaxBodySize != 2097152 {
 		t.Errorf("GatewayMaxBodySize = %d, want 2097152", cfg.GatewayMaxBodySize)
 	}
+	if !cfg.GatewayStdioEnabled {
+		t.Error("GatewayStdioEnabled = false, want true")
+	}
 }
 
 func TestLoad_GatewayInvalidInt64(t *testing.T) {
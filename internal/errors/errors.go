@@ -7,12 +7,23 @@ type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Status  int    `json:"-"`
+
+	// Details carries optional machine-readable context, such as a list of
+	// field violations. It is omitted from the response when nil.
+	Details interface{} `json:"details,omitempty"`
 }
 
 func (e *APIError) Error() string {
 	return e.Message
 }
 
+// WithDetails returns a copy of the error carrying the given details.
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	c := *e
+	c.Details = details
+	return &c
+}
+
 func NotFound(resource, id string) *APIError {
 	return &APIError{
 		Code:    "NOT_FOUND",
@@ -92,3 +103,11 @@ func BadGateway(msg string) *APIError {
 		Status:  502,
 	}
 }
+
+func GatewayTimeout(msg string) *APIError {
+	return &APIError{
+		Code:    "GATEWAY_TIMEOUT",
+		Message: msg,
+		Status:  504,
+	}
+}
@@ -101,3 +101,14 @@ func TestConstructors(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIError_WithDetails(t *testing.T) {
+	base := Validation("bad input")
+	err := base.WithDetails([]string{"a"})
+	if err.Details == nil || err.Code != "VALIDATION_ERROR" || err.Status != 400 {
+		t.Errorf("WithDetails() = %+v, want validation error with details", err)
+	}
+	if base.Details != nil {
+		t.Error("WithDetails() must not modify the receiver")
+	}
+}
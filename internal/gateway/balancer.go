@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoHealthyEndpoint is returned when every endpoint in a server's pool is
+// unavailable (circuit open or drained).
+var ErrNoHealthyEndpoint = errors.New("no healthy upstream endpoint")
+
+// Load-balancing strategies.
+const (
+	BalanceRoundRobin   = "round_robin"
+	BalanceLeastLatency = "least_latency"
+)
+
+const (
+	// healthFailThreshold is the number of consecutive failed health checks
+	// that drain an endpoint. A single successful check restores it.
+	healthFailThreshold = 2
+	// latencyDecay weights the newest latency sample in the moving average.
+	latencyDecay = 0.3
+)
+
+// Endpoint is one upstream URL in a server's pool.
+type Endpoint struct {
+	URL            string `json:"url"`
+	Weight         int    `json:"weight,omitempty"`
+	HealthEndpoint string `json:"health_endpoint,omitempty"`
+}
+
+func (e Endpoint) weight() int {
+	if e.Weight <= 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+// EndpointKey returns the circuit breaker key for an endpoint of a label.
+// Servers with a single endpoint keep the label as key, so their circuit
+// state is unchanged by pooling.
+func EndpointKey(label string, pool []Endpoint, url string) string {
+	if len(pool) <= 1 {
+		return label
+	}
+	return label + "|" + url
+}
+
+type endpointState struct {
+	current        int           // Smooth weighted round-robin counter
+	latency        time.Duration // Exponentially weighted moving average
+	sampled        bool
+	healthFailures int
+	drained        bool
+}
+
+// LoadBalancer selects endpoints from per-label pools and tracks their
+// latency and health. It is safe for concurrent use.
+type LoadBalancer struct {
+	mu    sync.Mutex
+	pools map[string]map[string]*endpointState // label -> URL -> state
+}
+
+// NewLoadBalancer creates an empty LoadBalancer.
+func NewLoadBalancer() *LoadBalancer {
+	return &LoadBalancer{pools: make(map[string]map[string]*endpointState)}
+}
+
+func (lb *LoadBalancer) state(label, url string) *endpointState {
+	pool, ok := lb.pools[label]
+	if !ok {
+		pool = make(map[string]*endpointState)
+		lb.pools[label] = pool
+	}
+	s, ok := pool[url]
+	if !ok {
+		s = &endpointState{}
+		pool[url] = s
+	}
+	return s
+}
+
+// Pick selects an endpoint from the pool using the given strategy. ready
+// reports whether an endpoint's circuit currently admits a request; avoid,
+// if non-empty, is skipped when any other endpoint is available so retries
+// move to a different upstream. Drained endpoints are skipped unless the
+// whole pool is drained, in which case health is ignored rather than
+// failing every call.
+func (lb *LoadBalancer) Pick(label, strategy string, pool []Endpoint, avoid string, ready func(Endpoint) bool) (Endpoint, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	var undrained []Endpoint
+	for _, e := range pool {
+		if !lb.state(label, e.URL).drained {
+			undrained = append(undrained, e)
+		}
+	}
+	if len(undrained) == 0 {
+		undrained = pool
+	}
+
+	var candidates []Endpoint
+	for _, e := range undrained {
+		if ready == nil || ready(e) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) > 1 && avoid != "" {
+		filtered := candidates[:0:0]
+		for _, e := range candidates {
+			if e.URL != avoid {
+				filtered = append(filtered, e)
+			}
+		}
+		if len(filtered) > 0 {
+			candidates = filtered
+		}
+	}
+	if len(candidates) == 0 {
+		return Endpoint{}, ErrNoHealthyEndpoint
+	}
+
+	if strategy == BalanceLeastLatency {
+		return lb.pickLeastLatency(label, candidates), nil
+	}
+	return lb.pickRoundRobin(label, candidates), nil
+}
+
+// pickRoundRobin implements smooth weighted round-robin: every candidate
+// gains its weight, the highest wins and pays back the total.
+func (lb *LoadBalancer) pickRoundRobin(label string, candidates []Endpoint) Endpoint {
+	total := 0
+	var best Endpoint
+	var bestState *endpointState
+	for _, e := range candidates {
+		s := lb.state(label, e.URL)
+		s.current += e.weight()
+		total += e.weight()
+		if bestState == nil || s.current > bestState.current {
+			best, bestState = e, s
+		}
+	}
+	bestState.current -= total
+	return best
+}
+
+// pickLeastLatency picks the candidate with the lowest weighted average
+// latency. Endpoints without samples are tried first.
+func (lb *LoadBalancer) pickLeastLatency(label string, candidates []Endpoint) Endpoint {
+	var best Endpoint
+	var bestScore float64
+	for i, e := range candidates {
+		s := lb.state(label, e.URL)
+		if !s.sampled {
+			return e
+		}
+		score := float64(s.latency) / float64(e.weight())
+		if i == 0 || score < bestScore {
+			best, bestScore = e, score
+		}
+	}
+	return best
+}
+
+// ObserveLatency records the latency of a completed request to an endpoint.
+func (lb *LoadBalancer) ObserveLatency(label, url string, latency time.Duration) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	s := lb.state(label, url)
+	if !s.sampled {
+		s.latency = latency
+		s.sampled = true
+		return
+	}
+	s.latency = time.Duration(latencyDecay*float64(latency) + (1-latencyDecay)*float64(s.latency))
+}
+
+// RecordHealth records a health check result. It reports whether the
+// endpoint's drained state changed.
+func (lb *LoadBalancer) RecordHealth(label, url string, healthy bool) bool {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	s := lb.state(label, url)
+	wasDrained := s.drained
+	if healthy {
+		s.healthFailures = 0
+		s.drained = false
+	} else {
+		s.healthFailures++
+		if s.healthFailures >= healthFailThreshold {
+			s.drained = true
+		}
+	}
+	return s.drained != wasDrained
+}
+
+// Drained reports whether an endpoint is currently drained.
+func (lb *LoadBalancer) Drained(label, url string) bool {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	pool, ok := lb.pools[label]
+	if !ok {
+		return false
+	}
+	s, ok := pool[url]
+	return ok && s.drained
+}
@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testPool = []Endpoint{
+	{URL: "http://a", Weight: 3},
+	{URL: "http://b", Weight: 1},
+}
+
+func TestLoadBalancer_WeightedRoundRobin(t *testing.T) {
+	lb := NewLoadBalancer()
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		e, err := lb.Pick("svc", BalanceRoundRobin, testPool, "", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		counts[e.URL]++
+	}
+	if counts["http://a"] != 6 || counts["http://b"] != 2 {
+		t.Errorf("expected a 3:1 split over 8 picks, got %v", counts)
+	}
+}
+
+func TestLoadBalancer_LeastLatency(t *testing.T) {
+	lb := NewLoadBalancer()
+	pool := []Endpoint{{URL: "http://a"}, {URL: "http://b"}}
+
+	// Unsampled endpoints are tried first.
+	if e, _ := lb.Pick("svc", BalanceLeastLatency, pool, "", nil); e.URL != "http://a" {
+		t.Fatalf("expected first unsampled endpoint, got %s", e.URL)
+	}
+	lb.ObserveLatency("svc", "http://a", 200*time.Millisecond)
+	if e, _ := lb.Pick("svc", BalanceLeastLatency, pool, "", nil); e.URL != "http://b" {
+		t.Fatalf("expected unsampled endpoint b, got %s", e.URL)
+	}
+	lb.ObserveLatency("svc", "http://b", 20*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if e, _ := lb.Pick("svc", BalanceLeastLatency, pool, "", nil); e.URL != "http://b" {
+			t.Errorf("expected faster endpoint b, got %s", e.URL)
+		}
+	}
+}
+
+func TestLoadBalancer_SkipsUnreadyAndAvoided(t *testing.T) {
+	lb := NewLoadBalancer()
+	notA := func(e Endpoint) bool { return e.URL != "http://a" }
+	for i := 0; i < 4; i++ {
+		if e, _ := lb.Pick("svc", BalanceRoundRobin, testPool, "", notA); e.URL != "http://b" {
+			t.Fatalf("expected only ready endpoint b, got %s", e.URL)
+		}
+	}
+	if e, _ := lb.Pick("svc", BalanceRoundRobin, testPool, "http://a", nil); e.URL != "http://b" {
+		t.Errorf("expected avoided endpoint to be skipped, got %s", e.URL)
+	}
+
+	none := func(Endpoint) bool { return false }
+	if _, err := lb.Pick("svc", BalanceRoundRobin, testPool, "", none); !errors.Is(err, ErrNoHealthyEndpoint) {
+		t.Errorf("expected ErrNoHealthyEndpoint, got %v", err)
+	}
+}
+
+func TestLoadBalancer_DrainAfterFailedHealthChecks(t *testing.T) {
+	lb := NewLoadBalancer()
+
+	if lb.RecordHealth("svc", "http://a", false) {
+		t.Fatal("a single failed check should not drain")
+	}
+	if !lb.RecordHealth("svc", "http://a", false) || !lb.Drained("svc", "http://a") {
+		t.Fatal("expected endpoint drained after consecutive failures")
+	}
+	for i := 0; i < 4; i++ {
+		if e, _ := lb.Pick("svc", BalanceRoundRobin, testPool, "", nil); e.URL != "http://b" {
+			t.Fatalf("expected drained endpoint to be skipped, got %s", e.URL)
+		}
+	}
+
+	if !lb.RecordHealth("svc", "http://a", true) || lb.Drained("svc", "http://a") {
+		t.Error("expected a successful check to restore the endpoint")
+	}
+}
+
+func TestLoadBalancer_AllDrainedIgnoresHealth(t *testing.T) {
+	lb := NewLoadBalancer()
+	for _, e := range testPool {
+		lb.RecordHealth("svc", e.URL, false)
+		lb.RecordHealth("svc", e.URL, false)
+	}
+	if _, err := lb.Pick("svc", BalanceRoundRobin, testPool, "", nil); err != nil {
+		t.Errorf("expected a pick when the whole pool is drained, got %v", err)
+	}
+}
+
+func TestEndpointKey(t *testing.T) {
+	if got := EndpointKey("svc", testPool[:1], "http://a"); got != "svc" {
+		t.Errorf("single-endpoint key = %q, want label", got)
+	}
+	if got := EndpointKey("svc", testPool, "http://a"); got != "svc|http://a" {
+		t.Errorf("pooled key = %q, want svc|http://a", got)
+	}
+}
+
+func TestCheckTargets_DrainsUnhealthyEndpoint(t *testing.T) {
+	healthy := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("health check method = %s, want GET", r.Method)
+		}
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	lb := NewLoadBalancer()
+	source := func(context.Context) ([]HealthTarget, error) {
+		return []HealthTarget{{Label: "svc", Endpoint: "http://a", HealthURL: srv.URL, Request: ProxyRequest{ServerLabel: "svc"}}}, nil
+	}
+
+	CheckTargets(context.Background(), lb, pc, source, time.Second)
+	if lb.Drained("svc", "http://a") {
+		t.Fatal("healthy endpoint should not be drained")
+	}
+
+	healthy = false
+	CheckTargets(context.Background(), lb, pc, source, time.Second)
+	CheckTargets(context.Background(), lb, pc, source, time.Second)
+	if !lb.Drained("svc", "http://a") {
+		t.Fatal("expected endpoint drained after failing health checks")
+	}
+
+	healthy = true
+	CheckTargets(context.Background(), lb, pc, source, time.Second)
+	if lb.Drained("svc", "http://a") {
+		t.Error("expected endpoint restored after a passing health check")
+	}
+}
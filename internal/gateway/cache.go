@@ -0,0 +1,210 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DefaultCacheEntries bounds the number of responses a ResponseCache holds.
+const DefaultCacheEntries = 10000
+
+// maxCachedBodySize is the largest upstream response body that is cached.
+const maxCachedBodySize = 1 << 20
+
+// CachePolicy is a server's response cache configuration. A zero TTL
+// disables caching.
+type CachePolicy struct {
+	TTL   time.Duration
+	Tools []string // Tool name patterns eligible for caching; empty means all
+}
+
+// Caches reports whether calls to the tool may be served from the cache.
+func (p CachePolicy) Caches(toolName string) bool {
+	return p.TTL > 0 && (len(p.Tools) == 0 || matchAny(p.Tools, toolName))
+}
+
+// CacheKey identifies a cached response. Arguments are canonicalized so
+// that key order and whitespace do not matter; scope separates callers so
+// one caller never sees another's results.
+type CacheKey struct {
+	Server string
+	Tool   string
+	Args   json.RawMessage
+	Scope  string
+}
+
+func (k CacheKey) hash() (string, bool) {
+	args, ok := canonicalJSON(k.Args)
+	if !ok {
+		return "", false
+	}
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(k.Server), []byte(k.Tool), []byte(k.Scope), args} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// canonicalJSON re-encodes a JSON document with sorted object keys and no
+// insignificant whitespace. Numbers keep their original representation.
+func canonicalJSON(raw json.RawMessage) ([]byte, bool) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return []byte("{}"), true
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// CachedResponse is an upstream response served from the cache.
+type CachedResponse struct {
+	StatusCode int
+	Body       json.RawMessage
+	StoredAt   time.Time
+}
+
+type cacheEntry struct {
+	server  string
+	tool    string
+	resp    CachedResponse
+	expires time.Time
+}
+
+// ResponseCache is an in-memory, TTL-bounded cache of upstream tool
+// responses, safe for concurrent use.
+type ResponseCache struct {
+	mu         sync.Mutex
+	entries    map[string]*cacheEntry
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewResponseCache creates a cache holding at most maxEntries responses.
+func NewResponseCache(maxEntries int) *ResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheEntries
+	}
+	return &ResponseCache{
+		entries:    make(map[string]*cacheEntry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Get returns the unexpired response stored under key.
+func (c *ResponseCache) Get(key CacheKey) (*CachedResponse, bool) {
+	h, ok := key.hash()
+	if !ok {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[h]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, h)
+		return nil, false
+	}
+	resp := e.resp
+	return &resp, true
+}
+
+// Set stores a response under key for ttl. Only successful responses that
+// are not JSON-RPC errors are cached.
+func (c *ResponseCache) Set(key CacheKey, resp *ProxyResponse, ttl time.Duration) bool {
+	if ttl <= 0 || resp == nil || !Cacheable(resp) {
+		return false
+	}
+	h, ok := key.hash()
+	if !ok {
+		return false
+	}
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[h]; !exists && len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	c.entries[h] = &cacheEntry{
+		server:  key.Server,
+		tool:    key.Tool,
+		resp:    CachedResponse{StatusCode: resp.StatusCode, Body: resp.Body, StoredAt: now},
+		expires: now.Add(ttl),
+	}
+	return true
+}
+
+// evictLocked drops expired entries, or the entry closest to expiry when
+// none have expired.
+func (c *ResponseCache) evictLocked(now time.Time) {
+	var oldest string
+	var oldestExp time.Time
+	for h, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, h)
+			continue
+		}
+		if oldest == "" || e.expires.Before(oldestExp) {
+			oldest, oldestExp = h, e.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldest != "" {
+		delete(c.entries, oldest)
+	}
+}
+
+// Purge removes the cached responses for a server, or for one of its tools
+// when tool is non-empty, and returns how many were removed.
+func (c *ResponseCache) Purge(server, tool string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for h, e := range c.entries {
+		if e.server == server && (tool == "" || e.tool == tool) {
+			delete(c.entries, h)
+			n++
+		}
+	}
+	return n
+}
+
+// Cacheable reports whether an upstream response may be cached: a 200 whose
+// body is small enough and carries no JSON-RPC error.
+func Cacheable(resp *ProxyResponse) bool {
+	if resp.StatusCode != 200 || len(resp.Body) > maxCachedBodySize {
+		return false
+	}
+	var rpc struct {
+		Error  json.RawMessage `json:"error"`
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Body, &rpc); err != nil {
+		return false
+	}
+	if len(rpc.Error) > 0 && string(rpc.Error) != "null" {
+		return false
+	}
+	// MCP reports tool failures as a result with isError set.
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	if len(rpc.Result) > 0 && json.Unmarshal(rpc.Result, &result) == nil && result.IsError {
+		return false
+	}
+	return true
+}
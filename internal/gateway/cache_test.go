@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var okResponse = &ProxyResponse{StatusCode: 200, Body: json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`)}
+
+func TestResponseCache_CanonicalArguments(t *testing.T) {
+	c := NewResponseCache(10)
+	key := CacheKey{Server: "svc", Tool: "search", Args: json.RawMessage(`{"q":"go","limit":10}`), Scope: "u1"}
+	if !c.Set(key, okResponse, time.Minute) {
+		t.Fatal("expected response to be cached")
+	}
+
+	reordered := key
+	reordered.Args = json.RawMessage(`{ "limit": 10, "q": "go" }`)
+	if _, ok := c.Get(reordered); !ok {
+		t.Error("expected hit for reordered arguments")
+	}
+
+	for name, miss := range map[string]CacheKey{
+		"other args":  {Server: "svc", Tool: "search", Args: json.RawMessage(`{"q":"rust","limit":10}`), Scope: "u1"},
+		"other scope": {Server: "svc", Tool: "search", Args: key.Args, Scope: "u2"},
+		"other tool":  {Server: "svc", Tool: "get", Args: key.Args, Scope: "u1"},
+	} {
+		if _, ok := c.Get(miss); ok {
+			t.Errorf("%s: expected miss", name)
+		}
+	}
+}
+
+func TestResponseCache_Expiry(t *testing.T) {
+	c := NewResponseCache(10)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	key := CacheKey{Server: "svc", Tool: "search"}
+	c.Set(key, okResponse, time.Minute)
+
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get(key); !ok {
+		t.Fatal("expected hit before TTL")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get(key); ok {
+		t.Error("expected miss at TTL")
+	}
+}
+
+func TestResponseCache_OnlyCachesSuccess(t *testing.T) {
+	c := NewResponseCache(10)
+	key := CacheKey{Server: "svc", Tool: "search"}
+	for name, resp := range map[string]*ProxyResponse{
+		"5xx":        {StatusCode: 502, Body: json.RawMessage(`{}`)},
+		"rpc error":  {StatusCode: 200, Body: json.RawMessage(`{"error":{"code":-32000,"message":"boom"}}`)},
+		"tool error": {StatusCode: 200, Body: json.RawMessage(`{"result":{"isError":true}}`)},
+		"not json":   {StatusCode: 200, Body: json.RawMessage(`oops`)},
+	} {
+		if c.Set(key, resp, time.Minute) {
+			t.Errorf("%s: expected response not to be cached", name)
+		}
+	}
+}
+
+func TestResponseCache_EvictsWhenFull(t *testing.T) {
+	c := NewResponseCache(2)
+	for i, tool := range []string{"a", "b", "c"} {
+		c.Set(CacheKey{Server: "svc", Tool: tool}, okResponse, time.Duration(i+1)*time.Minute)
+	}
+	if _, ok := c.Get(CacheKey{Server: "svc", Tool: "a"}); ok {
+		t.Error("expected the entry closest to expiry to be evicted")
+	}
+	if _, ok := c.Get(CacheKey{Server: "svc", Tool: "c"}); !ok {
+		t.Error("expected newest entry to be kept")
+	}
+}
+
+func TestResponseCache_Purge(t *testing.T) {
+	c := NewResponseCache(10)
+	c.Set(CacheKey{Server: "svc", Tool: "a"}, okResponse, time.Minute)
+	c.Set(CacheKey{Server: "svc", Tool: "b"}, okResponse, time.Minute)
+	c.Set(CacheKey{Server: "other", Tool: "a"}, okResponse, time.Minute)
+
+	if n := c.Purge("svc", "a"); n != 1 {
+		t.Errorf("purge tool = %d, want 1", n)
+	}
+	if n := c.Purge("svc", ""); n != 1 {
+		t.Errorf("purge server = %d, want 1", n)
+	}
+	if _, ok := c.Get(CacheKey{Server: "other", Tool: "a"}); !ok {
+		t.Error("purge must not affect other servers")
+	}
+}
+
+func TestCachePolicy_Caches(t *testing.T) {
+	p := CachePolicy{TTL: time.Minute, Tools: []string{"search_*", "get_*"}}
+	if !p.Caches("search_docs") || p.Caches("delete_doc") {
+		t.Error("expected patterns to select cached tools")
+	}
+	if (CachePolicy{Tools: []string{"*"}}).Caches("search") {
+		t.Error("zero TTL must disable caching")
+	}
+	if !(CachePolicy{TTL: time.Minute}).Caches("anything") {
+		t.Error("empty tool list should cache all tools")
+	}
+}
@@ -0,0 +1,153 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUpstreamTimeout is returned when an upstream does not answer within the
+// call's timeout.
+var ErrUpstreamTimeout = errors.New("upstream timed out")
+
+// ErrConcurrencyLimit is returned when a server already has its maximum
+// number of calls in flight and the queue is full or the wait timed out.
+var ErrConcurrencyLimit = errors.New("server concurrency limit reached")
+
+// CallLimits bounds how long and how many calls run against one server.
+// Tool timeouts override the server timeout for matching tools; the first
+// matching pattern wins. Zero values mean no server-specific limit.
+type CallLimits struct {
+	Timeout        time.Duration
+	ToolTimeouts   []ToolTimeout
+	MaxConcurrency int
+	MaxQueue       int
+	QueueTimeout   time.Duration
+}
+
+// ToolTimeout is the timeout of tools matching a pattern.
+type ToolTimeout struct {
+	Pattern string
+	Timeout time.Duration
+}
+
+// TimeoutFor returns the timeout of one attempt to call the tool, or zero to
+// use the gateway default.
+func (l CallLimits) TimeoutFor(toolName string) time.Duration {
+	for _, t := range l.ToolTimeouts {
+		if matchGlob(t.Pattern, toolName) {
+			return t.Timeout
+		}
+	}
+	return l.Timeout
+}
+
+// ConcurrencyLimiter caps in-flight calls per server. Calls over the limit
+// wait in a FIFO queue for a free slot. Limits are read from each Acquire, so
+// config changes apply to the next call.
+type ConcurrencyLimiter struct {
+	mu      sync.Mutex
+	servers map[string]*concurrencyState
+}
+
+type concurrencyState struct {
+	max      int
+	inFlight int
+	queue    []chan struct{}
+}
+
+// NewConcurrencyLimiter creates an empty limiter.
+func NewConcurrencyLimiter() *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{servers: make(map[string]*concurrencyState)}
+}
+
+// Acquire takes a call slot for the server, waiting up to limits.QueueTimeout
+// in the queue when none is free. The returned release must be called once
+// the call has finished. Without a MaxConcurrency the call is not limited.
+func (c *ConcurrencyLimiter) Acquire(ctx context.Context, label string, limits CallLimits) (func(), error) {
+	if limits.MaxConcurrency <= 0 {
+		return func() {}, nil
+	}
+	c.mu.Lock()
+	st := c.servers[label]
+	if st == nil {
+		st = &concurrencyState{}
+		c.servers[label] = st
+	}
+	st.max = limits.MaxConcurrency
+	// A raised limit admits queued calls first.
+	for st.inFlight < st.max && len(st.queue) > 0 {
+		st.inFlight++
+		close(st.queue[0])
+		st.queue = st.queue[1:]
+	}
+	if st.inFlight < st.max && len(st.queue) == 0 {
+		st.inFlight++
+		c.mu.Unlock()
+		return c.releaser(label), nil
+	}
+	if len(st.queue) >= limits.MaxQueue || limits.QueueTimeout <= 0 {
+		c.mu.Unlock()
+		return nil, ErrConcurrencyLimit
+	}
+	granted := make(chan struct{})
+	st.queue = append(st.queue, granted)
+	c.mu.Unlock()
+
+	timer := time.NewTimer(limits.QueueTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-granted:
+		return c.releaser(label), nil
+	case <-timer.C:
+		err = ErrConcurrencyLimit
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, ch := range st.queue {
+		if ch == granted {
+			st.queue = append(st.queue[:i], st.queue[i+1:]...)
+			return nil, err
+		}
+	}
+	// The slot was handed over while giving up; pass it on.
+	c.releaseLocked(st)
+	return nil, err
+}
+
+// InFlight returns the number of calls holding a slot for the server.
+func (c *ConcurrencyLimiter) InFlight(label string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if st := c.servers[label]; st != nil {
+		return st.inFlight
+	}
+	return 0
+}
+
+func (c *ConcurrencyLimiter) releaser(label string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.releaseLocked(c.servers[label])
+		})
+	}
+}
+
+// releaseLocked frees a slot, handing it to the oldest waiter when the
+// server is still within its limit. Must be called with c.mu held.
+func (c *ConcurrencyLimiter) releaseLocked(st *concurrencyState) {
+	if len(st.queue) > 0 && st.inFlight <= st.max {
+		close(st.queue[0])
+		st.queue = st.queue[1:]
+		return
+	}
+	st.inFlight--
+}
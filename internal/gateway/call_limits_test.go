@@ -0,0 +1,151 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCallLimits_TimeoutFor(t *testing.T) {
+	limits := CallLimits{
+		Timeout: 5 * time.Second,
+		ToolTimeouts: []ToolTimeout{
+			{Pattern: "search_*", Timeout: time.Second},
+			{Pattern: "*", Timeout: 2 * time.Second},
+		},
+	}
+	if got := limits.TimeoutFor("search_docs"); got != time.Second {
+		t.Errorf("search_docs timeout = %s, want 1s", got)
+	}
+	if got := limits.TimeoutFor("write_file"); got != 2*time.Second {
+		t.Errorf("write_file timeout = %s, want 2s", got)
+	}
+	if got := (CallLimits{}).TimeoutFor("any"); got != 0 {
+		t.Errorf("default timeout = %s, want 0", got)
+	}
+}
+
+func TestConcurrencyLimiter_Unlimited(t *testing.T) {
+	c := NewConcurrencyLimiter()
+	for i := 0; i < 100; i++ {
+		if _, err := c.Acquire(context.Background(), "srv", CallLimits{}); err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+	}
+}
+
+func TestConcurrencyLimiter_RejectsWithoutQueue(t *testing.T) {
+	c := NewConcurrencyLimiter()
+	limits := CallLimits{MaxConcurrency: 2}
+	release, err := c.Acquire(context.Background(), "srv", limits)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := c.Acquire(context.Background(), "srv", limits); err != nil {
+		t.Fatalf("second Acquire: %v", err)
+	}
+	if _, err := c.Acquire(context.Background(), "srv", limits); !errors.Is(err, ErrConcurrencyLimit) {
+		t.Fatalf("third Acquire error = %v, want ErrConcurrencyLimit", err)
+	}
+	// Other servers have their own slots.
+	if _, err := c.Acquire(context.Background(), "other", limits); err != nil {
+		t.Fatalf("other server Acquire: %v", err)
+	}
+
+	release()
+	release() // releasing twice frees one slot only
+	if got := c.InFlight("srv"); got != 1 {
+		t.Errorf("InFlight = %d, want 1", got)
+	}
+	if _, err := c.Acquire(context.Background(), "srv", limits); err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+}
+
+func TestConcurrencyLimiter_QueuesInOrder(t *testing.T) {
+	c := NewConcurrencyLimiter()
+	limits := CallLimits{MaxConcurrency: 1, MaxQueue: 2, QueueTimeout: time.Second}
+	release, err := c.Acquire(context.Background(), "srv", limits)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	order := make(chan int, 2)
+	for i := 1; i <= 2; i++ {
+		go func(i int) {
+			rel, err := c.Acquire(context.Background(), "srv", limits)
+			if err != nil {
+				t.Errorf("queued Acquire %d: %v", i, err)
+				return
+			}
+			order <- i
+			time.Sleep(10 * time.Millisecond)
+			rel()
+		}(i)
+		time.Sleep(20 * time.Millisecond) // enqueue in order
+	}
+	if _, err := c.Acquire(context.Background(), "srv", limits); !errors.Is(err, ErrConcurrencyLimit) {
+		t.Fatalf("Acquire with full queue error = %v, want ErrConcurrencyLimit", err)
+	}
+
+	release()
+	if first, second := <-order, <-order; first != 1 || second != 2 {
+		t.Errorf("queued calls ran in order %d, %d; want 1, 2", first, second)
+	}
+}
+
+func TestConcurrencyLimiter_QueueTimeout(t *testing.T) {
+	c := NewConcurrencyLimiter()
+	limits := CallLimits{MaxConcurrency: 1, MaxQueue: 1, QueueTimeout: 30 * time.Millisecond}
+	release, _ := c.Acquire(context.Background(), "srv", limits)
+
+	start := time.Now()
+	if _, err := c.Acquire(context.Background(), "srv", limits); !errors.Is(err, ErrConcurrencyLimit) {
+		t.Fatalf("error = %v, want ErrConcurrencyLimit", err)
+	}
+	if waited := time.Since(start); waited < 30*time.Millisecond {
+		t.Errorf("waited %s, want at least the queue timeout", waited)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Acquire(ctx, "srv", CallLimits{MaxConcurrency: 1, MaxQueue: 1, QueueTimeout: time.Second}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled Acquire error = %v, want context.Canceled", err)
+	}
+
+	release()
+	if got := c.InFlight("srv"); got != 0 {
+		t.Errorf("InFlight = %d, want 0 after abandoned waits", got)
+	}
+}
+
+func TestConcurrencyLimiter_RaisedLimitAdmitsQueued(t *testing.T) {
+	c := NewConcurrencyLimiter()
+	limits := CallLimits{MaxConcurrency: 1, MaxQueue: 1, QueueTimeout: time.Second}
+	if _, err := c.Acquire(context.Background(), "srv", limits); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	admitted := make(chan error, 1)
+	go func() {
+		_, err := c.Acquire(context.Background(), "srv", limits)
+		admitted <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	raised := CallLimits{MaxConcurrency: 3, MaxQueue: 1, QueueTimeout: time.Second}
+	if _, err := c.Acquire(context.Background(), "srv", raised); err != nil {
+		t.Fatalf("Acquire with raised limit: %v", err)
+	}
+	select {
+	case err := <-admitted:
+		if err != nil {
+			t.Fatalf("queued Acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued call was not admitted after the limit was raised")
+	}
+	if got := c.InFlight("srv"); got != 3 {
+		t.Errorf("InFlight = %d, want 3", got)
+	}
+}
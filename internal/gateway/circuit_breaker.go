@@ -1,6 +1,10 @@
 package gateway
 
 import (
+	"context"
+	"log"
+	"math"
+	"sort"
 	"sync"
 	"time"
 )
@@ -14,23 +18,154 @@ const (
 	CircuitHalfOpen                     // Allowing a single probe request
 )
 
+// String returns the state's API name.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// ParseCircuitState parses a state's API name; unknown values are closed.
+func ParseCircuitState(s string) CircuitState {
+	switch s {
+	case "open":
+		return CircuitOpen
+	case "half_open":
+		return CircuitHalfOpen
+	default:
+		return CircuitClosed
+	}
+}
+
+// CircuitTransition describes a circuit changing state.
+type CircuitTransition struct {
+	Key    string
+	From   CircuitState
+	To     CircuitState
+	Forced bool   // Set by an operator rather than by traffic
+	Actor  string // Operator for forced transitions, empty otherwise
+}
+
+// CircuitStatus is a point-in-time view of one circuit.
+type CircuitStatus struct {
+	State    CircuitState
+	Failures int
+	OpenedAt time.Time
+	Forced   bool
+}
+
+// CircuitRecord is a circuit's persisted state, shared between replicas.
+// Half-open is never persisted: every replica probes on its own once the
+// open duration has elapsed.
+type CircuitRecord struct {
+	Key       string
+	State     CircuitState
+	Failures  int
+	OpenedAt  time.Time
+	Forced    bool
+	UpdatedAt time.Time
+}
+
+// CircuitStateStore persists circuit state so replicas share it.
+type CircuitStateStore interface {
+	ListCircuitStates(ctx context.Context) ([]CircuitRecord, error)
+	SaveCircuitState(ctx context.Context, rec CircuitRecord) error
+}
+
 // CircuitBreakerConfig holds per-server circuit breaker settings.
+//
+// By default the circuit opens after FailThreshold consecutive failures. A
+// non-zero Window selects sliding-window mode instead: once the window holds
+// MinRequests calls, the circuit opens when the failure percentage reaches
+// FailureRatePct or the p95 latency exceeds LatencyP95 (either may be zero
+// to disable it).
 type CircuitBreakerConfig struct {
-	FailThreshold int           // Failures before opening
-	OpenDuration  time.Duration // How long to stay open before half-open probe
+	FailThreshold int           // Consecutive failures before opening
+	OpenDuration  time.Duration // How long to stay open before half-open probes
+
+	Window         time.Duration // Sliding window length; zero for consecutive mode
+	MinRequests    int           // Calls in the window before it can trip
+	FailureRatePct float64       // Failure percentage that trips the circuit
+	LatencyP95     time.Duration // p95 latency that trips the circuit
+
+	HalfOpenProbes    int     // Concurrent half-open probes; default 1
+	ProbeSuccessRatio float64 // Fraction of probes that must succeed to close; default 1
+}
+
+// probes returns the number of half-open probes and how many of them must
+// succeed to close the circuit.
+func (c CircuitBreakerConfig) probes() (total, needed int) {
+	total = c.HalfOpenProbes
+	if total < 1 {
+		total = 1
+	}
+	ratio := c.ProbeSuccessRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	needed = int(math.Ceil(ratio * float64(total)))
+	if needed < 1 {
+		needed = 1
+	}
+	return total, needed
+}
+
+// maxWindowSamples bounds the calls a sliding window remembers per circuit.
+const maxWindowSamples = 1000
+
+type windowSample struct {
+	at      time.Time
+	failed  bool
+	latency time.Duration
 }
 
 type circuitEntry struct {
-	state         CircuitState
-	failures      int
-	openedAt      time.Time
-	probeInFlight bool
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	forced    bool      // Opened by an operator; stays open until closed by one
+	changedAt time.Time // Last state change, for ordering shared updates
+
+	probesStarted  int // Half-open probes admitted in the current round
+	probeSuccesses int
+	probeFailures  int
+
+	window []windowSample // Sliding-window mode samples, oldest first
+}
+
+// resetProbes clears half-open probe accounting.
+func (e *circuitEntry) resetProbes() {
+	e.probesStarted, e.probeSuccesses, e.probeFailures = 0, 0, 0
 }
 
 // CircuitBreaker tracks per-label circuit state.
 type CircuitBreaker struct {
 	mu      sync.Mutex
 	entries map[string]*circuitEntry
+
+	observer func(CircuitTransition)
+	store    CircuitStateStore
+}
+
+// SetObserver registers a function called after every state transition and
+// admin action, outside the breaker's lock.
+func (cb *CircuitBreaker) SetObserver(fn func(CircuitTransition)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.observer = fn
+}
+
+// SetStore enables sharing circuit state: open and closed transitions are
+// saved to store, and SyncFromStore applies other replicas' changes.
+func (cb *CircuitBreaker) SetStore(store CircuitStateStore) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.store = store
 }
 
 // NewCircuitBreaker creates a new circuit breaker.
@@ -40,52 +175,333 @@ func NewCircuitBreaker() *CircuitBreaker {
 	}
 }
 
-// Allow returns true if the label's circuit permits a request.
+// Allow returns true if the label's circuit permits a request. Once the open
+// duration has elapsed, up to cfg.HalfOpenProbes requests are admitted as
+// half-open probes.
 func (cb *CircuitBreaker) Allow(label string, cfg CircuitBreakerConfig) bool {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
 	e := cb.getOrCreate(label)
+	total, _ := cfg.probes()
 
 	switch e.state {
 	case CircuitClosed:
+		cb.mu.Unlock()
 		return true
 	case CircuitOpen:
-		if time.Since(e.openedAt) >= cfg.OpenDuration {
-			e.state = CircuitHalfOpen
-			e.probeInFlight = true
+		if !e.forced && time.Since(e.openedAt) >= cfg.OpenDuration {
+			t := cb.transitionLocked(label, e, CircuitHalfOpen, "")
+			e.resetProbes()
+			e.probesStarted = 1
+			cb.mu.Unlock()
+			cb.emit(t)
 			return true
 		}
-		return false
 	case CircuitHalfOpen:
-		return false
+		// A round whose probes never reported (e.g. canceled hedges) is
+		// restarted after another open duration.
+		if e.probesStarted >= total && time.Since(e.changedAt) >= cfg.OpenDuration {
+			e.resetProbes()
+			e.changedAt = time.Now()
+		}
+		if e.probesStarted < total {
+			e.probesStarted++
+			cb.mu.Unlock()
+			return true
+		}
 	}
+	cb.mu.Unlock()
 	return false
 }
 
-// RecordSuccess records a successful request.
-func (cb *CircuitBreaker) RecordSuccess(label string) {
+// Ready reports whether Allow would currently admit a request, without
+// starting a half-open probe.
+func (cb *CircuitBreaker) Ready(label string, cfg CircuitBreakerConfig) bool {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
-	e := cb.getOrCreate(label)
-	e.failures = 0
-	e.state = CircuitClosed
-	e.probeInFlight = false
+	e, ok := cb.entries[label]
+	if !ok {
+		return true
+	}
+	switch e.state {
+	case CircuitClosed:
+		return true
+	case CircuitOpen:
+		return !e.forced && time.Since(e.openedAt) >= cfg.OpenDuration
+	case CircuitHalfOpen:
+		total, _ := cfg.probes()
+		return e.probesStarted < total || time.Since(e.changedAt) >= cfg.OpenDuration
+	}
+	return false
+}
+
+// RecordSuccess records a successful request. A circuit forced open by an
+// operator is not closed by traffic.
+func (cb *CircuitBreaker) RecordSuccess(label string) {
+	cb.Observe(label, CircuitBreakerConfig{}, true, 0)
 }
 
 // RecordFailure records a failed request and may open the circuit.
 func (cb *CircuitBreaker) RecordFailure(label string, cfg CircuitBreakerConfig) {
+	cb.Observe(label, cfg, false, 0)
+}
+
+// Observe records the outcome and latency of a request. In half-open state
+// it counts toward the probe round: the circuit closes once enough probes
+// succeed and reopens once too many fail for the success ratio to be met.
+func (cb *CircuitBreaker) Observe(label string, cfg CircuitBreakerConfig, success bool, latency time.Duration) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	e := cb.getOrCreate(label)
+	if e.forced {
+		cb.mu.Unlock()
+		return
+	}
+	now := time.Now()
 
+	var t *CircuitTransition
+	switch {
+	case e.state == CircuitHalfOpen:
+		total, needed := cfg.probes()
+		// A probe slower than the latency threshold does not count as healthy.
+		if cfg.LatencyP95 > 0 && latency > cfg.LatencyP95 {
+			success = false
+		}
+		if success {
+			e.probeSuccesses++
+		} else {
+			e.probeFailures++
+		}
+		if e.probeSuccesses >= needed {
+			t = cb.closeLocked(label, e)
+		} else if e.probeFailures > total-needed {
+			t = cb.openLocked(label, e, now)
+		}
+	case cfg.Window > 0:
+		e.observeWindow(cfg, now, !success, latency)
+		if e.state == CircuitClosed && e.windowTripped(cfg) {
+			t = cb.openLocked(label, e, now)
+		}
+	case success:
+		t = cb.closeLocked(label, e)
+	default:
+		e.failures++
+		if e.failures >= cfg.FailThreshold {
+			t = cb.openLocked(label, e, now)
+		}
+	}
+	cb.mu.Unlock()
+	cb.emit(t)
+}
+
+// openLocked opens e's circuit. cb.mu must be held.
+func (cb *CircuitBreaker) openLocked(label string, e *circuitEntry, now time.Time) *CircuitTransition {
+	t := cb.transitionLocked(label, e, CircuitOpen, "")
+	e.openedAt = now
+	e.resetProbes()
+	e.window = nil
+	return t
+}
+
+// closeLocked closes e's circuit and clears its failure history. cb.mu must
+// be held.
+func (cb *CircuitBreaker) closeLocked(label string, e *circuitEntry) *CircuitTransition {
+	e.failures = 0
+	e.resetProbes()
+	e.window = nil
+	return cb.transitionLocked(label, e, CircuitClosed, "")
+}
+
+// observeWindow adds a sample to the sliding window and drops samples older
+// than the window.
+func (e *circuitEntry) observeWindow(cfg CircuitBreakerConfig, now time.Time, failed bool, latency time.Duration) {
+	cutoff := now.Add(-cfg.Window)
+	i := 0
+	for i < len(e.window) && !e.window[i].at.After(cutoff) {
+		i++
+	}
+	if len(e.window)-i >= maxWindowSamples {
+		i = len(e.window) - maxWindowSamples + 1
+	}
+	e.window = append(e.window[i:], windowSample{at: now, failed: failed, latency: latency})
+
+	e.failures = 0
+	for _, s := range e.window {
+		if s.failed {
+			e.failures++
+		}
+	}
+}
+
+// windowTripped reports whether the sliding window exceeds the failure rate
+// or latency threshold.
+func (e *circuitEntry) windowTripped(cfg CircuitBreakerConfig) bool {
+	n := len(e.window)
+	if n == 0 || n < cfg.MinRequests {
+		return false
+	}
+	if cfg.FailureRatePct > 0 && float64(e.failures)*100/float64(n) >= cfg.FailureRatePct {
+		return true
+	}
+	if cfg.LatencyP95 > 0 {
+		latencies := make([]time.Duration, n)
+		for i, s := range e.window {
+			latencies[i] = s.latency
+		}
+		sort.Slice(latencies, func(a, b int) bool { return latencies[a] < latencies[b] })
+		idx := int(math.Ceil(0.95*float64(n))) - 1
+		if latencies[idx] > cfg.LatencyP95 {
+			return true
+		}
+	}
+	return false
+}
+
+// ForceOpen opens a circuit until ForceClose is called, regardless of its
+// open duration or traffic.
+func (cb *CircuitBreaker) ForceOpen(label, actor string) {
+	cb.mu.Lock()
 	e := cb.getOrCreate(label)
-	e.failures++
+	e.forced = true
+	e.resetProbes()
+	e.window = nil
+	e.openedAt = time.Now()
+	t := cb.transitionLocked(label, e, CircuitOpen, actor)
+	if t == nil {
+		// Already open from traffic: record that it is now held open.
+		t = &CircuitTransition{Key: label, From: CircuitOpen, To: CircuitOpen, Forced: true, Actor: actor}
+		e.changedAt = time.Now()
+	}
+	cb.mu.Unlock()
+	cb.emit(t)
+}
+
+// ForceClose closes a circuit and clears its failure count. The close is
+// saved even when the circuit is closed locally, since another replica may
+// have opened it before this one synced.
+func (cb *CircuitBreaker) ForceClose(label, actor string) {
+	cb.mu.Lock()
+	e := cb.getOrCreate(label)
+	e.forced = false
+	e.failures = 0
+	e.resetProbes()
+	e.window = nil
+	t := cb.transitionLocked(label, e, CircuitClosed, actor)
+	if t == nil {
+		t = &CircuitTransition{Key: label, From: CircuitClosed, To: CircuitClosed, Forced: true, Actor: actor}
+		e.changedAt = time.Now()
+	}
+	cb.mu.Unlock()
+	cb.emit(t)
+}
+
+// Status returns a snapshot of a label's circuit.
+func (cb *CircuitBreaker) Status(label string) CircuitStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	e, ok := cb.entries[label]
+	if !ok {
+		return CircuitStatus{State: CircuitClosed}
+	}
+	return CircuitStatus{State: e.state, Failures: e.failures, OpenedAt: e.openedAt, Forced: e.forced}
+}
+
+// Apply sets a circuit from a shared record if the record is newer than the
+// local state. It neither notifies the observer nor saves the record back.
+func (cb *CircuitBreaker) Apply(rec CircuitRecord) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	e := cb.getOrCreate(rec.Key)
+	if !rec.UpdatedAt.After(e.changedAt) {
+		return false
+	}
+	e.state = rec.State
+	e.failures = rec.Failures
+	e.openedAt = rec.OpenedAt
+	e.forced = rec.Forced
+	e.resetProbes()
+	e.window = nil
+	e.changedAt = rec.UpdatedAt
+	return true
+}
+
+// SyncFromStore applies circuit state saved by other replicas.
+func (cb *CircuitBreaker) SyncFromStore(ctx context.Context) error {
+	cb.mu.Lock()
+	store := cb.store
+	cb.mu.Unlock()
+	if store == nil {
+		return nil
+	}
+	records, err := store.ListCircuitStates(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		cb.Apply(rec)
+	}
+	return nil
+}
+
+// RunCircuitSync periodically applies shared circuit state until ctx is
+// canceled.
+func RunCircuitSync(ctx context.Context, cb *CircuitBreaker, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := cb.SyncFromStore(ctx); err != nil {
+			log.Printf("gateway: circuit state sync failed: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
 
-	if e.state == CircuitHalfOpen || e.failures >= cfg.FailThreshold {
-		e.state = CircuitOpen
-		e.openedAt = time.Now()
-		e.probeInFlight = false
+// transitionLocked moves e to state and returns the transition, or nil if
+// the state is unchanged. cb.mu must be held.
+func (cb *CircuitBreaker) transitionLocked(label string, e *circuitEntry, to CircuitState, actor string) *CircuitTransition {
+	if e.state == to {
+		return nil
+	}
+	t := &CircuitTransition{Key: label, From: e.state, To: to, Forced: actor != "", Actor: actor}
+	e.state = to
+	e.changedAt = time.Now()
+	return t
+}
+
+// emit notifies the observer of a state change or an admin action and saves
+// open and closed states for other replicas. It must be called without cb.mu
+// held.
+func (cb *CircuitBreaker) emit(t *CircuitTransition) {
+	if t == nil {
+		return
+	}
+	cb.mu.Lock()
+	observer, store := cb.observer, cb.store
+	var rec CircuitRecord
+	if e, ok := cb.entries[t.Key]; ok {
+		rec = CircuitRecord{Key: t.Key, State: e.state, Failures: e.failures, OpenedAt: e.openedAt, Forced: e.forced, UpdatedAt: e.changedAt}
+	}
+	cb.mu.Unlock()
+
+	if store != nil && rec.State != CircuitHalfOpen {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := store.SaveCircuitState(ctx, rec); err != nil {
+				log.Printf("gateway: saving circuit state for %s failed: %v", rec.Key, err)
+			}
+		}()
+	}
+	if observer != nil && (t.From != t.To || t.Forced) {
+		observer(*t)
 	}
 }
 
@@ -1,6 +1,8 @@
 package gateway
 
 import (
+	"context"
+	"sync"
 	"testing"
 	"time"
 )
@@ -217,3 +219,284 @@ func TestCircuitBreaker_Reset(t *testing.T) {
 		t.Error("reset should clear all state")
 	}
 }
+
+func TestCircuitBreaker_ReadyDoesNotStartProbe(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{FailThreshold: 1, OpenDuration: 10 * time.Millisecond}
+
+	cb.RecordFailure("svc", cfg)
+	if cb.Ready("svc", cfg) {
+		t.Fatal("open circuit should not be ready")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if !cb.Ready("svc", cfg) || !cb.Ready("svc", cfg) {
+		t.Fatal("expected circuit to be ready after the open duration")
+	}
+	if cb.State("svc") != CircuitOpen {
+		t.Errorf("Ready must not transition state, got %v", cb.State("svc"))
+	}
+	if !cb.Allow("svc", cfg) {
+		t.Fatal("expected probe to be allowed")
+	}
+	if cb.Ready("svc", cfg) {
+		t.Error("half-open circuit with a probe in flight should not be ready")
+	}
+}
+
+func TestCircuitBreaker_ObserverTransitions(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{FailThreshold: 1, OpenDuration: 10 * time.Millisecond}
+
+	var got []CircuitTransition
+	cb.SetObserver(func(tr CircuitTransition) { got = append(got, tr) })
+
+	cb.RecordFailure("srv", cfg)
+	time.Sleep(15 * time.Millisecond)
+	cb.Allow("srv", cfg)
+	cb.RecordSuccess("srv")
+	cb.RecordSuccess("srv") // already closed, no transition
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d transitions, got %+v", len(want), got)
+	}
+	for i, to := range want {
+		if got[i].To != to || got[i].Key != "srv" || got[i].Forced {
+			t.Errorf("transition %d = %+v, want to %s", i, got[i], to)
+		}
+	}
+}
+
+func TestCircuitBreaker_ForceOpenHoldsAgainstTraffic(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{FailThreshold: 1, OpenDuration: time.Millisecond}
+
+	var got []CircuitTransition
+	cb.SetObserver(func(tr CircuitTransition) { got = append(got, tr) })
+
+	cb.ForceOpen("srv", "admin")
+	time.Sleep(5 * time.Millisecond)
+	if cb.Allow("srv", cfg) || cb.Ready("srv", cfg) {
+		t.Error("forced open circuit should not admit a probe after its open duration")
+	}
+	cb.RecordSuccess("srv")
+	if s := cb.Status("srv"); s.State != CircuitOpen || !s.Forced {
+		t.Errorf("status = %+v, want forced open", s)
+	}
+
+	cb.ForceClose("srv", "admin")
+	if !cb.Allow("srv", cfg) {
+		t.Error("closed circuit should allow requests")
+	}
+	if len(got) != 2 || !got[0].Forced || got[0].Actor != "admin" || got[1].To != CircuitClosed {
+		t.Errorf("unexpected transitions: %+v", got)
+	}
+}
+
+func TestCircuitBreaker_ApplyOrdersByUpdateTime(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cb.ForceOpen("srv", "admin")
+
+	if cb.Apply(CircuitRecord{Key: "srv", State: CircuitClosed, UpdatedAt: time.Now().Add(-time.Minute)}) {
+		t.Error("older record should not be applied")
+	}
+	if cb.State("srv") != CircuitOpen {
+		t.Fatal("circuit should still be open")
+	}
+
+	openedAt := time.Now().Add(-time.Second)
+	if !cb.Apply(CircuitRecord{Key: "srv", State: CircuitOpen, Failures: 4, OpenedAt: openedAt, UpdatedAt: time.Now().Add(time.Second)}) {
+		t.Fatal("newer record should be applied")
+	}
+	if s := cb.Status("srv"); s.Forced || s.Failures != 4 || !s.OpenedAt.Equal(openedAt) {
+		t.Errorf("status = %+v, want applied record", s)
+	}
+}
+
+type mockCircuitStateStore struct {
+	mu      sync.Mutex
+	records []CircuitRecord
+	saved   []CircuitRecord
+}
+
+func (m *mockCircuitStateStore) ListCircuitStates(_ context.Context) ([]CircuitRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.records, nil
+}
+
+func (m *mockCircuitStateStore) SaveCircuitState(_ context.Context, rec CircuitRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved = append(m.saved, rec)
+	return nil
+}
+
+func (m *mockCircuitStateStore) getSaved() []CircuitRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CircuitRecord(nil), m.saved...)
+}
+
+func TestCircuitBreaker_SharedStore(t *testing.T) {
+	store := &mockCircuitStateStore{
+		records: []CircuitRecord{{Key: "other", State: CircuitOpen, OpenedAt: time.Now(), UpdatedAt: time.Now()}},
+	}
+	cb := NewCircuitBreaker()
+	cb.SetStore(store)
+	cfg := CircuitBreakerConfig{FailThreshold: 1, OpenDuration: time.Minute}
+
+	if err := cb.SyncFromStore(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if cb.Allow("other", cfg) {
+		t.Error("circuit opened by another replica should reject requests")
+	}
+
+	cb.RecordFailure("srv", cfg)
+	time.Sleep(50 * time.Millisecond)
+	saved := store.getSaved()
+	if len(saved) != 1 || saved[0].Key != "srv" || saved[0].State != CircuitOpen || saved[0].UpdatedAt.IsZero() {
+		t.Errorf("expected open state to be saved, got %+v", saved)
+	}
+}
+
+func TestCircuitBreaker_ForceCloseWhenClosedLocally(t *testing.T) {
+	store := &mockCircuitStateStore{}
+	cb := NewCircuitBreaker()
+	cb.SetStore(store)
+	var got []CircuitTransition
+	cb.SetObserver(func(tr CircuitTransition) { got = append(got, tr) })
+
+	// Another replica opened the circuit, but this one has not synced yet.
+	openedElsewhere := CircuitRecord{Key: "srv", State: CircuitOpen, OpenedAt: time.Now(), UpdatedAt: time.Now()}
+	time.Sleep(time.Millisecond)
+	cb.ForceClose("srv", "admin")
+	time.Sleep(50 * time.Millisecond)
+
+	saved := store.getSaved()
+	if len(saved) != 1 || saved[0].State != CircuitClosed || !saved[0].UpdatedAt.After(openedElsewhere.UpdatedAt) {
+		t.Fatalf("expected a newer closed state to be saved, got %+v", saved)
+	}
+	if cb.Apply(openedElsewhere) {
+		t.Error("the earlier open record should not override the forced close")
+	}
+	if len(got) != 1 || got[0].To != CircuitClosed || !got[0].Forced || got[0].Actor != "admin" {
+		t.Errorf("expected a forced close event, got %+v", got)
+	}
+}
+
+func TestCircuitBreaker_WindowFailureRate(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{OpenDuration: time.Minute, Window: time.Minute, MinRequests: 10, FailureRatePct: 50}
+
+	// Interleaved failures never trip consecutive counting, and the window
+	// does not trip below the minimum volume.
+	for i := 0; i < 9; i++ {
+		cb.Observe("srv", cfg, i%2 == 0, 10*time.Millisecond)
+	}
+	if cb.State("srv") != CircuitClosed {
+		t.Fatal("circuit should stay closed below min_requests")
+	}
+
+	cb.Observe("srv", cfg, false, 10*time.Millisecond)
+	if cb.State("srv") != CircuitOpen {
+		t.Errorf("expected circuit to open at 50%% failures, status %+v", cb.Status("srv"))
+	}
+}
+
+func TestCircuitBreaker_WindowBelowFailureRate(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{OpenDuration: time.Minute, Window: time.Minute, MinRequests: 5, FailureRatePct: 50}
+
+	for i := 0; i < 20; i++ {
+		cb.Observe("srv", cfg, i%4 != 0, 0)
+	}
+	if cb.State("srv") != CircuitClosed {
+		t.Errorf("25%% failures should not trip a 50%% threshold")
+	}
+}
+
+func TestCircuitBreaker_WindowExpiresSamples(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{OpenDuration: time.Minute, Window: 20 * time.Millisecond, MinRequests: 4, FailureRatePct: 100}
+
+	for i := 0; i < 3; i++ {
+		cb.Observe("srv", cfg, false, 0)
+	}
+	time.Sleep(30 * time.Millisecond)
+	cb.Observe("srv", cfg, false, 0)
+	if cb.State("srv") != CircuitClosed {
+		t.Error("failures outside the window should not count")
+	}
+}
+
+func TestCircuitBreaker_WindowLatencyP95(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{OpenDuration: time.Minute, Window: time.Minute, MinRequests: 20, LatencyP95: 500 * time.Millisecond}
+
+	// 19 of 20 fast calls: the p95 (19th fastest) is still fast.
+	for i := 0; i < 19; i++ {
+		cb.Observe("srv", cfg, true, 100*time.Millisecond)
+	}
+	cb.Observe("srv", cfg, true, 2*time.Second)
+	if cb.State("srv") != CircuitClosed {
+		t.Fatal("p95 below threshold should not trip")
+	}
+
+	cb.Observe("srv", cfg, true, 2*time.Second)
+	if cb.State("srv") != CircuitOpen {
+		t.Error("p95 above threshold should open the circuit")
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbeRatio(t *testing.T) {
+	tests := []struct {
+		name      string
+		outcomes  []bool
+		wantState CircuitState
+	}{
+		{"enough successes close", []bool{true, false, true}, CircuitClosed},
+		{"too many failures reopen", []bool{false, true, false}, CircuitOpen},
+		{"pending round stays half-open", []bool{true}, CircuitHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker()
+			cfg := CircuitBreakerConfig{FailThreshold: 1, OpenDuration: 10 * time.Millisecond, HalfOpenProbes: 3, ProbeSuccessRatio: 0.6}
+
+			cb.RecordFailure("srv", cfg)
+			time.Sleep(15 * time.Millisecond)
+			for i := 0; i < 3; i++ {
+				if !cb.Allow("srv", cfg) {
+					t.Fatalf("probe %d should be admitted", i+1)
+				}
+			}
+			if cb.Allow("srv", cfg) {
+				t.Fatal("a fourth concurrent probe should be rejected")
+			}
+
+			for _, ok := range tt.outcomes {
+				cb.Observe("srv", cfg, ok, 0)
+			}
+			if got := cb.State("srv"); got != tt.wantState {
+				t.Errorf("state = %s, want %s", got, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_SlowProbeFails(t *testing.T) {
+	cb := NewCircuitBreaker()
+	cfg := CircuitBreakerConfig{OpenDuration: 10 * time.Millisecond, Window: time.Minute, MinRequests: 1, LatencyP95: 100 * time.Millisecond}
+
+	cb.Observe("srv", cfg, true, time.Second)
+	time.Sleep(15 * time.Millisecond)
+	cb.Allow("srv", cfg)
+	cb.Observe("srv", cfg, true, time.Second)
+	if cb.State("srv") != CircuitOpen {
+		t.Error("a probe slower than the latency threshold should reopen the circuit")
+	}
+}
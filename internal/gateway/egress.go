@@ -0,0 +1,321 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEgressDenied is returned when a connection to an upstream host is not
+// permitted by the egress policy.
+var ErrEgressDenied = errors.New("egress denied")
+
+// Egress rule actions.
+const (
+	EgressAllow = "allow"
+	EgressDeny  = "deny"
+)
+
+// EgressRuleRecord is a global allow/deny entry. Exactly one of CIDR or Hostname is set.
+type EgressRuleRecord struct {
+	Action   string
+	CIDR     string
+	Hostname string
+}
+
+// EgressRuleProvider loads the admin-managed global egress rules.
+type EgressRuleProvider interface {
+	List(ctx context.Context) ([]EgressRuleRecord, error)
+}
+
+// EgressPolicy is a per-server allowlist. Addresses its CIDRs cover may be
+// reached even in private ranges; hosts it covers may resolve into private
+// ranges but not to loopback, link-local or metadata addresses.
+type EgressPolicy struct {
+	AllowCIDRs []string `json:"allow_cidrs,omitempty"`
+	AllowHosts []string `json:"allow_hosts,omitempty"`
+}
+
+// IsZero reports whether the policy allows nothing beyond the defaults.
+func (p EgressPolicy) IsZero() bool {
+	return len(p.AllowCIDRs) == 0 && len(p.AllowHosts) == 0
+}
+
+// Validate checks that every CIDR parses and every host pattern is well-formed.
+func (p EgressPolicy) Validate() error {
+	for _, c := range p.AllowCIDRs {
+		if _, err := ParseEgressCIDR(c); err != nil {
+			return err
+		}
+	}
+	for _, h := range p.AllowHosts {
+		if err := ValidateHostPattern(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p EgressPolicy) fingerprint() string {
+	return strings.Join(p.AllowCIDRs, ",") + "|" + strings.Join(p.AllowHosts, ",")
+}
+
+// ParseEgressCIDR parses a CIDR, also accepting a bare IP as a single-address range.
+func ParseEgressCIDR(s string) (*net.IPNet, error) {
+	if ip := net.ParseIP(s); ip != nil {
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q", s)
+	}
+	return ipNet, nil
+}
+
+// ValidateHostPattern checks a hostname or "*.suffix" wildcard pattern.
+func ValidateHostPattern(pattern string) error {
+	host := strings.TrimPrefix(pattern, "*.")
+	if host == "" || len(pattern) > 253 || strings.ContainsAny(host, "*/:@ ") {
+		return fmt.Errorf("invalid host pattern %q", pattern)
+	}
+	return nil
+}
+
+// matchHostPattern reports whether host matches an exact hostname or a
+// "*.suffix" pattern (which matches subdomains, not the bare suffix).
+func matchHostPattern(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+type compiledEgressRules struct {
+	allowNets  []*net.IPNet
+	allowHosts []string
+	denyNets   []*net.IPNet
+	denyHosts  []string
+}
+
+func compileEgressRules(records []EgressRuleRecord) compiledEgressRules {
+	var c compiledEgressRules
+	for _, r := range records {
+		var ipNet *net.IPNet
+		if r.CIDR != "" {
+			n, err := ParseEgressCIDR(r.CIDR)
+			if err != nil {
+				continue
+			}
+			ipNet = n
+		}
+		switch {
+		case r.Action == EgressDeny && ipNet != nil:
+			c.denyNets = append(c.denyNets, ipNet)
+		case r.Action == EgressDeny && r.Hostname != "":
+			c.denyHosts = append(c.denyHosts, r.Hostname)
+		case r.Action == EgressAllow && ipNet != nil:
+			c.allowNets = append(c.allowNets, ipNet)
+		case r.Action == EgressAllow && r.Hostname != "":
+			c.allowHosts = append(c.allowHosts, r.Hostname)
+		}
+	}
+	return c
+}
+
+// EgressGuard decides whether the gateway may connect to an upstream host.
+//
+// Evaluation order: a global deny always wins; otherwise an address is allowed
+// if a CIDR of the server's allowlist or a global allow rule covers it.
+// Loopback, link-local and metadata addresses are denied otherwise. Other
+// private addresses are allowed only if a hostname of the server's allowlist
+// or a global allow rule covers the host, and public addresses always are.
+type EgressGuard struct {
+	provider EgressRuleProvider
+	ttl      time.Duration
+	resolver *net.Resolver
+
+	mu       sync.RWMutex
+	rules    compiledEgressRules
+	loaded   bool
+	loadedAt time.Time
+}
+
+// NewEgressGuard creates a guard that reloads global rules from provider at
+// most once per ttl. A nil provider applies only per-server allowlists and the
+// private-range default.
+func NewEgressGuard(provider EgressRuleProvider, ttl time.Duration) *EgressGuard {
+	return &EgressGuard{provider: provider, ttl: ttl, resolver: net.DefaultResolver}
+}
+
+// Invalidate forces the next check to reload the global rules.
+func (g *EgressGuard) Invalidate() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.loadedAt = time.Time{}
+}
+
+func (g *EgressGuard) currentRules(ctx context.Context) (compiledEgressRules, error) {
+	if g.provider == nil {
+		return compiledEgressRules{}, nil
+	}
+
+	g.mu.RLock()
+	if g.loaded && time.Since(g.loadedAt) < g.ttl {
+		rules := g.rules
+		g.mu.RUnlock()
+		return rules, nil
+	}
+	g.mu.RUnlock()
+
+	records, err := g.provider.List(ctx)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err != nil {
+		// Keep enforcing the last known rules; with none loaded yet, fail closed.
+		if g.loaded {
+			return g.rules, nil
+		}
+		return compiledEgressRules{}, fmt.Errorf("%w: loading egress rules: %v", ErrEgressDenied, err)
+	}
+	g.rules = compileEgressRules(records)
+	g.loaded = true
+	g.loadedAt = time.Now()
+	return g.rules, nil
+}
+
+// Check verifies that host, resolved to ips, may be reached under policy.
+// Every address must be permitted.
+func (g *EgressGuard) Check(ctx context.Context, host string, ips []net.IP, policy *EgressPolicy) error {
+	rules, err := g.currentRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, pattern := range rules.denyHosts {
+		if matchHostPattern(pattern, host) {
+			return fmt.Errorf("%w: host %s is on the global deny list", ErrEgressDenied, host)
+		}
+	}
+
+	hostAllowed := false
+	var serverNets []*net.IPNet
+	if policy != nil {
+		for _, pattern := range policy.AllowHosts {
+			if matchHostPattern(pattern, host) {
+				hostAllowed = true
+			}
+		}
+		for _, c := range policy.AllowCIDRs {
+			if n, err := ParseEgressCIDR(c); err == nil {
+				serverNets = append(serverNets, n)
+			}
+		}
+	}
+	for _, pattern := range rules.allowHosts {
+		if matchHostPattern(pattern, host) {
+			hostAllowed = true
+		}
+	}
+
+	for _, ip := range ips {
+		if containsIP(rules.denyNets, ip) {
+			return fmt.Errorf("%w: resolved IP %s is on the global deny list", ErrEgressDenied, ip)
+		}
+		if containsIP(serverNets, ip) || containsIP(rules.allowNets, ip) {
+			continue
+		}
+		// A hostname allow only lifts the private-range default: DNS for an
+		// allowed name must not be able to point it at the node itself or
+		// at a metadata service.
+		if isRestrictedIP(ip) {
+			return fmt.Errorf("%w: resolved IP %s is a loopback, link-local or metadata address", ErrEgressDenied, ip)
+		}
+		if hostAllowed {
+			continue
+		}
+		if isPrivateIP(ip) {
+			return fmt.Errorf("%w: resolved IP %s is in a private range", ErrEgressDenied, ip)
+		}
+	}
+	return nil
+}
+
+// Resolve looks up host (or parses it as an IP literal) and checks the result.
+func (g *EgressGuard) Resolve(ctx context.Context, host string, policy *EgressPolicy) ([]net.IP, error) {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := g.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("DNS lookup failed: %w", err)
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("DNS lookup returned no addresses for %s", host)
+	}
+	if err := g.Check(ctx, host, ips, policy); err != nil {
+		return nil, err
+	}
+	return ips, nil
+}
+
+// DialContext returns a dial function enforcing the guard for one server's
+// policy. It connects to the addresses it checked rather than resolving the
+// host again, so DNS rebinding cannot swap in a different address.
+func (g *EgressGuard) DialContext(dialer *net.Dialer, policy *EgressPolicy) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address: %w", err)
+		}
+		ips, err := g.Resolve(ctx, host, policy)
+		if err != nil {
+			return nil, err
+		}
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// ec2IPv6Metadata is the IPv6 address of the EC2 instance metadata service.
+// Unlike its IPv4 address it is not link-local but in the ULA range.
+var ec2IPv6Metadata = net.ParseIP("fd00:ec2::254")
+
+// isRestrictedIP reports whether ip is unspecified, loopback, link-local
+// (including the 169.254.169.254 metadata address) or a metadata address.
+func isRestrictedIP(ip net.IP) bool {
+	return ip.IsUnspecified() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.Equal(ec2IPv6Metadata)
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
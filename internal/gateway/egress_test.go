@@ -0,0 +1,191 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mockEgressRules struct {
+	rules []EgressRuleRecord
+	err   error
+	calls int
+}
+
+func (m *mockEgressRules) List(_ context.Context) ([]EgressRuleRecord, error) {
+	m.calls++
+	return m.rules, m.err
+}
+
+func TestEgressGuard_Check(t *testing.T) {
+	global := &mockEgressRules{rules: []EgressRuleRecord{
+		{Action: EgressAllow, CIDR: "10.20.0.0/16"},
+		{Action: EgressAllow, Hostname: "*.svc.cluster.local"},
+		{Action: EgressDeny, CIDR: "203.0.113.0/24"},
+		{Action: EgressDeny, Hostname: "blocked.example.com"},
+	}}
+	guard := NewEgressGuard(global, time.Minute)
+
+	tests := []struct {
+		name    string
+		host    string
+		ip      string
+		policy  *EgressPolicy
+		wantErr bool
+	}{
+		{"public address", "mcp.example.com", "93.184.216.34", nil, false},
+		{"private address blocked by default", "internal.example.com", "10.1.2.3", nil, true},
+		{"loopback blocked by default", "localhost", "127.0.0.1", nil, true},
+		{"unspecified address blocked", "zero", "0.0.0.0", nil, true},
+		{"global allow CIDR", "internal.example.com", "10.20.5.5", nil, false},
+		{"global allow hostname", "mcp.tools.svc.cluster.local", "10.99.0.1", nil, false},
+		{"server allow CIDR", "internal.example.com", "10.1.2.3", &EgressPolicy{AllowCIDRs: []string{"10.1.0.0/16"}}, false},
+		{"server allow CIDR does not cover address", "internal.example.com", "10.2.2.3", &EgressPolicy{AllowCIDRs: []string{"10.1.0.0/16"}}, true},
+		{"server allow hostname", "mcp.internal", "192.168.1.10", &EgressPolicy{AllowHosts: []string{"mcp.internal"}}, false},
+		{"server allow hostname does not reach metadata", "mcp.internal", "169.254.169.254", &EgressPolicy{AllowHosts: []string{"mcp.internal"}}, true},
+		{"server allow hostname does not reach loopback", "mcp.internal", "127.0.0.1", &EgressPolicy{AllowHosts: []string{"mcp.internal"}}, true},
+		{"global allow hostname does not reach IPv6 metadata", "mcp.tools.svc.cluster.local", "fd00:ec2::254", nil, true},
+		{"server allow CIDR covers link-local", "mcp.internal", "169.254.10.1", &EgressPolicy{AllowCIDRs: []string{"169.254.10.0/24"}}, false},
+		{"server allow single IP", "mcp.internal", "192.168.1.10", &EgressPolicy{AllowCIDRs: []string{"192.168.1.10"}}, false},
+		{"global deny CIDR beats public default", "mcp.example.com", "203.0.113.7", nil, true},
+		{"global deny CIDR beats server allow", "mcp.example.com", "203.0.113.7", &EgressPolicy{AllowCIDRs: []string{"203.0.113.0/24"}}, true},
+		{"global deny hostname", "blocked.example.com", "93.184.216.34", &EgressPolicy{AllowHosts: []string{"blocked.example.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := guard.Check(context.Background(), tt.host, []net.IP{net.ParseIP(tt.ip)}, tt.policy)
+			if tt.wantErr && !errors.Is(err, ErrEgressDenied) {
+				t.Errorf("expected ErrEgressDenied, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEgressGuard_AllResolvedAddressesMustBePermitted(t *testing.T) {
+	guard := NewEgressGuard(nil, 0)
+	ips := []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.1")}
+	if err := guard.Check(context.Background(), "mixed.example.com", ips, nil); !errors.Is(err, ErrEgressDenied) {
+		t.Errorf("expected ErrEgressDenied when any address is private, got %v", err)
+	}
+}
+
+func TestEgressGuard_CachesAndInvalidates(t *testing.T) {
+	global := &mockEgressRules{}
+	guard := NewEgressGuard(global, time.Hour)
+	ips := []net.IP{net.ParseIP("93.184.216.34")}
+
+	guard.Check(context.Background(), "a.example.com", ips, nil)
+	guard.Check(context.Background(), "a.example.com", ips, nil)
+	if global.calls != 1 {
+		t.Errorf("provider called %d times, want 1", global.calls)
+	}
+
+	global.rules = []EgressRuleRecord{{Action: EgressDeny, CIDR: "93.184.216.0/24"}}
+	guard.Invalidate()
+	if err := guard.Check(context.Background(), "a.example.com", ips, nil); !errors.Is(err, ErrEgressDenied) {
+		t.Errorf("expected new deny rule to apply after Invalidate, got %v", err)
+	}
+}
+
+func TestEgressGuard_FailsClosedWithoutRules(t *testing.T) {
+	global := &mockEgressRules{err: errors.New("db down")}
+	guard := NewEgressGuard(global, time.Minute)
+	err := guard.Check(context.Background(), "mcp.example.com", []net.IP{net.ParseIP("93.184.216.34")}, nil)
+	if !errors.Is(err, ErrEgressDenied) {
+		t.Errorf("expected ErrEgressDenied when rules cannot be loaded, got %v", err)
+	}
+}
+
+func TestEgressPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  EgressPolicy
+		wantErr bool
+	}{
+		{"empty", EgressPolicy{}, false},
+		{"valid", EgressPolicy{AllowCIDRs: []string{"10.0.0.0/8", "fd00::1"}, AllowHosts: []string{"*.svc.cluster.local", "mcp.internal"}}, false},
+		{"bad CIDR", EgressPolicy{AllowCIDRs: []string{"10.0.0.0/33"}}, true},
+		{"bad host", EgressPolicy{AllowHosts: []string{"http://mcp.internal"}}, true},
+		{"bare wildcard", EgressPolicy{AllowHosts: []string{"*."}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProxyClient_EgressPolicy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	// SSRF protection stays on: the loopback test server is only reachable via an allowlist.
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2})
+	req := ProxyRequest{
+		ServerEndpoint: upstream.URL, ServerLabel: "local", ToolName: "test",
+		Arguments: json.RawMessage(`{}`), AuthType: "none",
+	}
+
+	if _, err := pc.Forward(context.Background(), req); !errors.Is(err, ErrEgressDenied) {
+		t.Fatalf("expected ErrEgressDenied without allowlist, got %v", err)
+	}
+
+	req.Egress = &EgressPolicy{AllowCIDRs: []string{"127.0.0.0/8"}}
+	if _, err := pc.Forward(context.Background(), req); err != nil {
+		t.Fatalf("expected allowlisted call to succeed, got %v", err)
+	}
+
+	other := req
+	other.ServerLabel = "other"
+	other.Egress = nil
+	if _, err := pc.Forward(context.Background(), other); !errors.Is(err, ErrEgressDenied) {
+		t.Errorf("allowlist of one server must not apply to another, got %v", err)
+	}
+}
+
+func TestProxyClient_EgressPolicyCoversTokenEndpoint(t *testing.T) {
+	tokens, calls := newTokenServer(t, 3600, http.StatusOK)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2})
+	req := ProxyRequest{
+		ServerEndpoint: upstream.URL, ServerLabel: "local", ToolName: "test",
+		Arguments: json.RawMessage(`{}`), AuthType: "oauth2_client_credentials",
+		OAuth2: &OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: tokens.URL},
+		Egress: &EgressPolicy{AllowCIDRs: []string{"127.0.0.0/8"}},
+	}
+	resp, err := pc.Forward(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected the allowlist to cover the token endpoint, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || calls.Load() != 1 {
+		t.Errorf("status = %d after %d token fetches", resp.StatusCode, calls.Load())
+	}
+
+	other := req
+	other.ServerLabel = "other"
+	other.Egress = nil
+	if _, err := pc.Forward(context.Background(), other); !errors.Is(err, ErrTokenFetch) {
+		t.Errorf("allowlist of one server must not apply to another's token endpoint, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// HealthTarget is one endpoint to health-check. Request carries the server's
+// TLS material, headers and egress policy so the check uses the same
+// transport as tool calls.
+type HealthTarget struct {
+	Label     string
+	Endpoint  string
+	HealthURL string
+	Request   ProxyRequest
+}
+
+// HealthTargetSource lists the endpoints that have a health URL configured.
+type HealthTargetSource func(ctx context.Context) ([]HealthTarget, error)
+
+// HealthChecker probes a single health URL.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context, req ProxyRequest, healthURL string) error
+}
+
+// CheckHealth sends a GET to healthURL through the server's transport and
+// succeeds on any 2xx response.
+func (pc *ProxyClient) CheckHealth(ctx context.Context, req ProxyRequest, healthURL string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return fmt.Errorf("create health request: %w", err)
+	}
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	client := pc.client
+	if req.TLS != nil || req.Egress != nil {
+		client, err = pc.clientForServer(req)
+		if err != nil {
+			return err
+		}
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("health request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RunHealthChecks probes every target on each interval and drains or restores
+// endpoints in the load balancer. It blocks until ctx is canceled.
+func RunHealthChecks(ctx context.Context, lb *LoadBalancer, checker HealthChecker, source HealthTargetSource, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			CheckTargets(ctx, lb, checker, source, timeout)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CheckTargets runs one round of health checks.
+func CheckTargets(ctx context.Context, lb *LoadBalancer, checker HealthChecker, source HealthTargetSource, timeout time.Duration) {
+	targets, err := source(ctx)
+	if err != nil {
+		log.Printf("gateway health checks: listing targets: %v", err)
+		return
+	}
+	for _, t := range targets {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := checker.CheckHealth(checkCtx, t.Request, t.HealthURL)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if lb.RecordHealth(t.Label, t.Endpoint, err == nil) {
+			if err != nil {
+				log.Printf("gateway: draining %s endpoint %s: %v", t.Label, t.Endpoint, err)
+			} else {
+				log.Printf("gateway: restoring %s endpoint %s", t.Label, t.Endpoint)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"sort"
+	"time"
+)
+
+// MicrosPerUnit converts currency units to the micro-units costs and budgets
+// are metered in.
+const MicrosPerUnit = 1000000
+
+// Pricing is a server's per-call cost, in micro-units of the billing
+// currency. Tool prices override the server's unit cost for matching tools;
+// the first matching pattern wins.
+type Pricing struct {
+	UnitCostMicros int64
+	Tools          []ToolPrice
+}
+
+// ToolPrice is the per-call cost of tools matching a pattern.
+type ToolPrice struct {
+	Pattern        string
+	UnitCostMicros int64
+}
+
+// CostMicros returns the cost of one call to the tool.
+func (p Pricing) CostMicros(toolName string) int64 {
+	for _, t := range p.Tools {
+		if matchGlob(t.Pattern, toolName) {
+			return t.UnitCostMicros
+		}
+	}
+	return p.UnitCostMicros
+}
+
+// BudgetPeriod returns the start of the monthly budget period containing t.
+func BudgetPeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// CrossedThresholds returns the percentage thresholds of limit that spend
+// reached when it moved from before to after, in ascending order. Because
+// spend increments are applied atomically, each threshold is crossed by
+// exactly one call per period.
+func CrossedThresholds(limit, before, after int64, thresholds []int) []int {
+	if limit <= 0 || after <= before {
+		return nil
+	}
+	var crossed []int
+	for _, pct := range thresholds {
+		mark := limit * int64(pct) / 100
+		if before < mark && after >= mark {
+			crossed = append(crossed, pct)
+		}
+	}
+	sort.Ints(crossed)
+	return crossed
+}
@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPricing_CostMicros(t *testing.T) {
+	p := Pricing{
+		UnitCostMicros: 10000,
+		Tools: []ToolPrice{
+			{Pattern: "search_*", UnitCostMicros: 2000},
+			{Pattern: "search_web", UnitCostMicros: 50000},
+			{Pattern: "ping", UnitCostMicros: 0},
+		},
+	}
+	tests := []struct {
+		tool string
+		want int64
+	}{
+		{"search_web", 2000}, // first matching pattern wins
+		{"search_docs", 2000},
+		{"ping", 0},
+		{"create_issue", 10000},
+	}
+	for _, tt := range tests {
+		if got := p.CostMicros(tt.tool); got != tt.want {
+			t.Errorf("CostMicros(%q) = %d, want %d", tt.tool, got, tt.want)
+		}
+	}
+	if got := (Pricing{}).CostMicros("anything"); got != 0 {
+		t.Errorf("unpriced server cost = %d, want 0", got)
+	}
+}
+
+func TestBudgetPeriod(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	got := BudgetPeriod(time.Date(2026, 3, 1, 5, 0, 0, 0, loc))
+	want := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("BudgetPeriod = %v, want %v", got, want)
+	}
+}
+
+func TestCrossedThresholds(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after int64
+		want          []int
+	}{
+		{"below all", 0, 400, nil},
+		{"reaches 50 exactly", 400, 500, []int{50}},
+		{"jumps over several", 400, 1000, []int{50, 80, 100}},
+		{"already past", 850, 900, nil},
+		{"no change", 500, 500, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CrossedThresholds(1000, tt.before, tt.after, []int{100, 80, 50})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CrossedThresholds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
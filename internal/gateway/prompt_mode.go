@@ -0,0 +1,33 @@
+package gateway
+
+// Prompt modes an agent's active prompt can declare.
+const (
+	PromptModeRAGReadonly     = "rag_readonly"
+	PromptModeToolcallingSafe = "toolcalling_safe"
+	PromptModeToolcallingAuto = "toolcalling_auto"
+)
+
+// PromptModeDecision is what the gateway does with a call under a prompt
+// mode.
+type PromptModeDecision string
+
+const (
+	PromptModeAllow   PromptModeDecision = "allow"
+	PromptModeDeny    PromptModeDecision = "deny"
+	PromptModeApprove PromptModeDecision = "approval_required"
+)
+
+// ApplyPromptMode decides a call to a tool in the given trust tier under a
+// prompt mode. rag_readonly only allows auto-tier tools, which are read-only
+// by policy; toolcalling_safe routes review-tier tools to approval; any
+// other mode, including none, follows the trust tier as-is, denying both
+// review and block.
+func ApplyPromptMode(mode string, tier TrustTier) PromptModeDecision {
+	if tier == TrustAuto {
+		return PromptModeAllow
+	}
+	if tier == TrustReview && mode == PromptModeToolcallingSafe {
+		return PromptModeApprove
+	}
+	return PromptModeDeny
+}
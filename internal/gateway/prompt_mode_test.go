@@ -0,0 +1,28 @@
+package gateway
+
+import "testing"
+
+func TestApplyPromptMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		tier TrustTier
+		want PromptModeDecision
+	}{
+		{PromptModeRAGReadonly, TrustAuto, PromptModeAllow},
+		{PromptModeRAGReadonly, TrustReview, PromptModeDeny},
+		{PromptModeRAGReadonly, TrustBlock, PromptModeDeny},
+		{PromptModeToolcallingSafe, TrustAuto, PromptModeAllow},
+		{PromptModeToolcallingSafe, TrustReview, PromptModeApprove},
+		{PromptModeToolcallingSafe, TrustBlock, PromptModeDeny},
+		{PromptModeToolcallingAuto, TrustAuto, PromptModeAllow},
+		{PromptModeToolcallingAuto, TrustReview, PromptModeDeny},
+		{PromptModeToolcallingAuto, TrustBlock, PromptModeDeny},
+		{"", TrustAuto, PromptModeAllow},
+		{"", TrustReview, PromptModeDeny},
+	}
+	for _, tt := range tests {
+		if got := ApplyPromptMode(tt.mode, tt.tier); got != tt.want {
+			t.Errorf("ApplyPromptMode(%q, %q) = %q, want %q", tt.mode, tt.tier, got, tt.want)
+		}
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -21,14 +23,14 @@ const (
 // privateIPRanges contains CIDR blocks for private/internal IP ranges.
 // These are blocked to prevent SSRF attacks via DNS rebinding.
 var privateIPRanges = []string{
-	"10.0.0.0/8",       // RFC 1918
-	"172.16.0.0/12",    // RFC 1918
-	"192.168.0.0/16",   // RFC 1918
-	"127.0.0.0/8",      // Loopback
-	"169.254.0.0/16",   // Link-local (AWS metadata)
-	"::1/128",          // IPv6 loopback
-	"fc00::/7",         // IPv6 private
-	"fe80::/10",        // IPv6 link-local
+	"10.0.0.0/8",     // RFC 1918
+	"172.16.0.0/12",  // RFC 1918
+	"192.168.0.0/16", // RFC 1918
+	"127.0.0.0/8",    // Loopback
+	"169.254.0.0/16", // Link-local (AWS metadata)
+	"::1/128",        // IPv6 loopback
+	"fc00::/7",       // IPv6 private
+	"fe80::/10",      // IPv6 link-local
 }
 
 var privateCIDRs []*net.IPNet
@@ -58,8 +60,18 @@ type ProxyRequest struct {
 	ServerEndpoint string          // MCP server endpoint URL
 	ToolName       string          // Tool to call
 	Arguments      json.RawMessage // Tool arguments as JSON
-	AuthType       string          // "none", "bearer", "basic"
+	AuthType       string          // "none", "bearer", "basic", "oauth2_client_credentials"
 	AuthCredential string          // Decrypted credential (plaintext)
+
+	OAuth2 *OAuth2ClientCredentials // Decrypted client credentials (oauth2_client_credentials only)
+
+	ServerLabel string            // Upstream server label; keys the per-server transport cache
+	TLS         *TLSConfig        // Decrypted client certificate / CA bundle, nil for defaults
+	Headers     map[string]string // Decrypted custom headers sent with every call
+	Egress      *EgressPolicy     // Per-server egress allowlist, nil for defaults
+	Stdio       *StdioConfig      // Local command for stdio servers; replaces the HTTP transport
+	Timeout     time.Duration     // Bound on one attempt; zero uses the client's default
+	Trace       *TraceContext     // Trace context and request ID propagated upstream, nil for none
 }
 
 // ProxyResponse contains the upstream response and metadata.
@@ -75,17 +87,29 @@ type ProxyResponse struct {
 type ProxyClientConfig struct {
 	Timeout             time.Duration
 	MaxIdleConnsPerHost int
-	AllowPrivateIPs     bool // If true, disables SSRF protection (for testing only)
+	AllowPrivateIPs     bool             // If true, disables SSRF protection (for testing only)
+	Egress              *EgressGuard     // Global egress rules; nil applies the private-range default only
+	Stdio               *StdioSupervisor // Runs stdio servers; nil rejects stdio requests
 }
 
 // ProxyClient forwards tool calls to upstream MCP servers.
 type ProxyClient struct {
-	client *http.Client
+	client      *http.Client
+	tokenClient *http.Client
+	tokens      *TokenCache
+
+	cfg    ProxyClientConfig
+	dialer *net.Dialer
+	guard  *EgressGuard
+
+	mu            sync.Mutex
+	serverClients map[string]*serverClient
 }
 
 // NewProxyClient creates a configured HTTP client for MCP proxying.
-// The client includes SSRF protection via a custom dialer that blocks private IPs,
-// unless AllowPrivateIPs is set to true (for testing only).
+// The client includes SSRF protection via a custom dialer that blocks private IPs
+// unless a server's egress allowlist or a global allow rule covers them.
+// AllowPrivateIPs disables the protection entirely (for testing only).
 func NewProxyClient(cfg ProxyClientConfig) *ProxyClient {
 	// Create a custom dialer that validates resolved IPs to prevent SSRF attacks
 	dialer := &net.Dialer{
@@ -93,61 +117,169 @@ func NewProxyClient(cfg ProxyClientConfig) *ProxyClient {
 		KeepAlive: 30 * time.Second,
 	}
 
-	transport := &http.Transport{
-		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+	pc := &ProxyClient{
+		cfg:           cfg,
+		dialer:        dialer,
+		serverClients: make(map[string]*serverClient),
 	}
 
 	// Add SSRF protection unless explicitly disabled (for testing)
 	if !cfg.AllowPrivateIPs {
-		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-			// Resolve the address to get actual IPs
-			host, port, err := net.SplitHostPort(addr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid address: %w", err)
-			}
-
-			// Look up all IPs for this host
-			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
-			if err != nil {
-				return nil, fmt.Errorf("DNS lookup failed: %w", err)
-			}
-
-			// Check if any resolved IP is private
-			for _, ipAddr := range ips {
-				if isPrivateIP(ipAddr.IP) {
-					return nil, fmt.Errorf("SSRF protection: resolved IP %s is in a private range", ipAddr.IP)
-				}
-			}
-
-			// All IPs are public, proceed with connection
-			return dialer.DialContext(ctx, network, net.JoinHostPort(host, port))
-		}
-	}
-
-	return &ProxyClient{
-		client: &http.Client{
-			Timeout:   cfg.Timeout,
-			Transport: transport,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse // Do not follow redirects
-			},
+		pc.guard = cfg.Egress
+		if pc.guard == nil {
+			pc.guard = NewEgressGuard(nil, 0)
+		}
+	}
+
+	pc.client = pc.newHTTPClient(&http.Transport{
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		DialContext:         pc.dialFor(nil),
+	})
+
+	pc.tokenClient = pc.newTokenClient(pc.client)
+	pc.tokens = NewTokenCache()
+	return pc
+}
+
+// newTokenClient returns the client for token endpoints, sharing the
+// transport of the server client so token fetches are subject to the same
+// egress policy and TLS settings. Upstream calls are bounded per request
+// instead of by the client.
+func (pc *ProxyClient) newTokenClient(client *http.Client) *http.Client {
+	return &http.Client{
+		Timeout:       pc.cfg.Timeout,
+		Transport:     client.Transport,
+		CheckRedirect: client.CheckRedirect,
+	}
+}
+
+// dialFor returns the dial function for a server's egress policy.
+func (pc *ProxyClient) dialFor(policy *EgressPolicy) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if pc.guard == nil {
+		return pc.dialer.DialContext
+	}
+	return pc.guard.DialContext(pc.dialer, policy)
+}
+
+func (pc *ProxyClient) newHTTPClient(transport *http.Transport) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse // Do not follow redirects
 		},
 	}
 }
 
 // Forward sends a tool call to the upstream MCP server using JSON-RPC 2.0.
+// The trace context is also sent in params._meta so that stdio servers and
+// servers behind proxies that drop headers receive it.
 func (pc *ProxyClient) Forward(ctx context.Context, req ProxyRequest) (*ProxyResponse, error) {
+	params := map[string]interface{}{
+		"name":      req.ToolName,
+		"arguments": req.Arguments,
+	}
+	if req.Trace != nil {
+		params["_meta"] = req.Trace.Meta()
+	}
+	return pc.call(ctx, req, "tools/call", params)
+}
+
+// UpstreamTool is a tool advertised by an upstream server's tools/list.
+type UpstreamTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// maxToolListPages bounds tools/list pagination.
+const maxToolListPages = 20
+
+// ListTools fetches the upstream server's tool definitions, following
+// pagination cursors.
+func (pc *ProxyClient) ListTools(ctx context.Context, req ProxyRequest) ([]UpstreamTool, error) {
+	var tools []UpstreamTool
+	cursor := ""
+	for page := 0; page < maxToolListPages; page++ {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		resp, err := pc.call(ctx, req, "tools/list", params)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("tools/list returned status %d", resp.StatusCode)
+		}
+		var rpcResp struct {
+			Result *struct {
+				Tools      []UpstreamTool `json:"tools"`
+				NextCursor string         `json:"nextCursor"`
+			} `json:"result"`
+			Error *struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(resp.Body, &rpcResp); err != nil {
+			return nil, fmt.Errorf("decode tools/list response: %w", err)
+		}
+		if rpcResp.Error != nil {
+			return nil, fmt.Errorf("tools/list error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		}
+		if rpcResp.Result == nil {
+			return nil, fmt.Errorf("tools/list response has no result")
+		}
+		tools = append(tools, rpcResp.Result.Tools...)
+		if rpcResp.Result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = rpcResp.Result.NextCursor
+	}
+	return nil, fmt.Errorf("tools/list exceeded %d pages", maxToolListPages)
+}
+
+// call sends a JSON-RPC 2.0 request to the upstream MCP server.
+func (pc *ProxyClient) call(ctx context.Context, req ProxyRequest, method string, params interface{}) (*ProxyResponse, error) {
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = pc.cfg.Timeout
+	}
+	callCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var resp *ProxyResponse
+	var err error
+	if req.Stdio != nil {
+		if pc.cfg.Stdio == nil {
+			return nil, fmt.Errorf("%w: stdio transport disabled", ErrStdioUnavailable)
+		}
+		resp, err = pc.cfg.Stdio.Call(callCtx, req.ServerLabel, *req.Stdio, method, params)
+	} else {
+		resp, err = pc.callHTTP(callCtx, req, method, params)
+	}
+	// Only the timeout set here is reported as an upstream timeout; the
+	// caller's own cancellation or deadline is returned unchanged.
+	if err != nil && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return nil, fmt.Errorf("%w after %s", ErrUpstreamTimeout, timeout)
+	}
+	return resp, err
+}
+
+func (pc *ProxyClient) callHTTP(ctx context.Context, req ProxyRequest, method string, params interface{}) (*ProxyResponse, error) {
 	start := time.Now()
 
 	// Build JSON-RPC 2.0 request
+	id := rand.Int63()
 	rpcReq := map[string]interface{}{
 		"jsonrpc": "2.0",
-		"method":  "tools/call",
-		"id":      rand.Int63(),
-		"params": map[string]interface{}{
-			"name":      req.ToolName,
-			"arguments": req.Arguments,
-		},
+		"method":  method,
+		"id":      id,
+		"params":  params,
 	}
 
 	body, err := json.Marshal(rpcReq)
@@ -155,35 +287,33 @@ func (pc *ProxyClient) Forward(ctx context.Context, req ProxyRequest) (*ProxyRes
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.ServerEndpoint, bytes.NewReader(body))
+	client, httpReq, err := pc.newUpstreamRequest(ctx, req, body)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// Inject authentication
-	switch req.AuthType {
-	case "bearer":
-		httpReq.Header.Set("Authorization", "Bearer "+req.AuthCredential)
-	case "basic":
-		httpReq.Header.Set("Authorization", "Basic "+req.AuthCredential)
-	case "none":
-		// No auth header
-	default:
-		return nil, fmt.Errorf("unsupported auth type: %s", req.AuthType)
-	}
-
-	resp, err := pc.client.Do(httpReq)
+	resp, err := client.Do(httpReq)
 	if err != nil {
+		if ctx.Err() != nil {
+			go pc.notifyCancelled(req, id, ctx.Err())
+		}
 		return nil, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// A rejected token is dropped so the next call fetches a fresh one
+	// instead of reusing it until expiry.
+	if resp.StatusCode == http.StatusUnauthorized && req.OAuth2 != nil {
+		pc.tokens.Invalidate(req.cacheKey(), *req.OAuth2)
+	}
+
 	// Limit response body size to prevent memory exhaustion from malicious upstream
 	limitedBody := io.LimitReader(resp.Body, MaxUpstreamResponseSize)
 	respBody, err := io.ReadAll(limitedBody)
 	if err != nil {
+		if ctx.Err() != nil {
+			go pc.notifyCancelled(req, id, ctx.Err())
+		}
 		return nil, fmt.Errorf("read response: %w", err)
 	}
 
@@ -197,3 +327,166 @@ func (pc *ProxyClient) Forward(ctx context.Context, req ProxyRequest) (*ProxyRes
 		ResponseSize: int64(len(respBody)),
 	}, nil
 }
+
+// newUpstreamRequest builds the POST of a JSON-RPC message to a server with
+// its custom headers and authentication, and picks the client to send it.
+func (pc *ProxyClient) newUpstreamRequest(ctx context.Context, req ProxyRequest, body []byte) (*http.Client, *http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.ServerEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("create request: %w", err)
+	}
+
+	// Custom headers go first so they can never override the content type,
+	// trace context or authentication headers below.
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if req.Trace != nil {
+		if req.Trace.TraceParent != "" {
+			httpReq.Header.Set("traceparent", req.Trace.TraceParent)
+		}
+		if req.Trace.TraceState != "" {
+			httpReq.Header.Set("tracestate", req.Trace.TraceState)
+		}
+		if req.Trace.RequestID != "" {
+			httpReq.Header.Set("X-Request-Id", req.Trace.RequestID)
+		}
+	}
+
+	client, tokenClient := pc.client, pc.tokenClient
+	if req.TLS != nil || req.Egress != nil {
+		sc, err := pc.serverClientFor(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		client, tokenClient = sc.client, sc.tokenClient
+	}
+
+	// Inject authentication
+	switch req.AuthType {
+	case "bearer":
+		httpReq.Header.Set("Authorization", "Bearer "+req.AuthCredential)
+	case "basic":
+		httpReq.Header.Set("Authorization", "Basic "+req.AuthCredential)
+	case "oauth2_client_credentials":
+		if req.OAuth2 == nil {
+			return nil, nil, fmt.Errorf("%w: missing client credentials", ErrTokenFetch)
+		}
+		tok, err := pc.tokens.Token(ctx, tokenClient, req.cacheKey(), *req.OAuth2)
+		if err != nil {
+			return nil, nil, err
+		}
+		tok.SetAuthHeader(httpReq)
+	case "none":
+		// No auth header
+	default:
+		return nil, nil, fmt.Errorf("unsupported auth type: %s", req.AuthType)
+	}
+	return client, httpReq, nil
+}
+
+// cancelNotifyTimeout bounds the best-effort notifications/cancelled sent
+// after a call is abandoned.
+const cancelNotifyTimeout = 5 * time.Second
+
+// notifyCancelled tells an upstream that the gateway stopped waiting for a
+// request, so it can abandon the work.
+func (pc *ProxyClient) notifyCancelled(req ProxyRequest, id int64, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelNotifyTimeout)
+	defer cancel()
+	body, _ := json.Marshal(cancelledNotification(id, cause))
+	client, httpReq, err := pc.newUpstreamRequest(ctx, req, body)
+	if err != nil {
+		return
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+}
+
+// cancelledNotification is the MCP notification for an abandoned request.
+func cancelledNotification(id int64, cause error) map[string]interface{} {
+	reason := "request cancelled by caller"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		reason = "request timed out"
+	}
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/cancelled",
+		"params":  map[string]interface{}{"requestId": id, "reason": reason},
+	}
+}
+
+// cacheKey identifies the upstream server of a request in the per-server
+// client and token caches.
+func (req ProxyRequest) cacheKey() string {
+	if req.ServerLabel != "" {
+		return req.ServerLabel
+	}
+	return req.ServerEndpoint
+}
+
+// serverClient is a per-server HTTP client carrying that server's TLS material
+// and egress policy.
+type serverClient struct {
+	fingerprint string
+	client      *http.Client
+	tokenClient *http.Client
+	transport   *http.Transport
+}
+
+// clientForServer returns the cached HTTP client for an upstream server,
+// building a dedicated transport on first use or when the TLS material or
+// egress policy has changed. Transports are never shared between servers so
+// one server's client certificate or allowlist cannot apply to another.
+func (pc *ProxyClient) clientForServer(req ProxyRequest) (*http.Client, error) {
+	sc, err := pc.serverClientFor(req)
+	if err != nil {
+		return nil, err
+	}
+	return sc.client, nil
+}
+
+// serverClientFor returns the cached per-server clients for an upstream
+// server, building them as described for clientForServer.
+func (pc *ProxyClient) serverClientFor(req ProxyRequest) (*serverClient, error) {
+	key := req.cacheKey()
+	fp := ""
+	if req.TLS != nil {
+		fp = req.TLS.fingerprint()
+	}
+	if req.Egress != nil {
+		fp += "|" + req.Egress.fingerprint()
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if cached, ok := pc.serverClients[key]; ok && cached.fingerprint == fp {
+		return cached, nil
+	}
+
+	transport := &http.Transport{
+		MaxIdleConnsPerHost: pc.cfg.MaxIdleConnsPerHost,
+		DialContext:         pc.dialFor(req.Egress),
+	}
+	if req.TLS != nil {
+		tlsCfg, err := BuildTLSClientConfig(*req.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsCfg
+	}
+
+	if stale, ok := pc.serverClients[key]; ok {
+		stale.transport.CloseIdleConnections()
+	}
+	client := pc.newHTTPClient(transport)
+	entry := &serverClient{fingerprint: fp, client: client, tokenClient: pc.newTokenClient(client), transport: transport}
+	pc.serverClients[key] = entry
+	return entry, nil
+}
@@ -3,6 +3,7 @@ package gateway
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -195,6 +196,84 @@ func TestProxyClient_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestProxyClient_RequestTimeoutOverridesDefault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 50 * time.Millisecond, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	req := ProxyRequest{ServerEndpoint: srv.URL, ToolName: "slow", Arguments: json.RawMessage(`{}`), AuthType: "none"}
+	if _, err := pc.Forward(context.Background(), req); !errors.Is(err, ErrUpstreamTimeout) {
+		t.Fatalf("default timeout error = %v, want ErrUpstreamTimeout", err)
+	}
+
+	req.Timeout = time.Second
+	if _, err := pc.Forward(context.Background(), req); err != nil {
+		t.Fatalf("longer per-request timeout: %v", err)
+	}
+}
+
+func TestProxyClient_SendsCancelledNotification(t *testing.T) {
+	type rpcMessage struct {
+		ID     *int64 `json:"id"`
+		Method string `json:"method"`
+		Params struct {
+			RequestID int64  `json:"requestId"`
+			Reason    string `json:"reason"`
+		} `json:"params"`
+	}
+	calls := make(chan int64, 1)
+	cancelled := make(chan rpcMessage, 1)
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg rpcMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		if msg.Method == "notifications/cancelled" {
+			gotAuth = r.Header.Get("Authorization")
+			cancelled <- msg
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		calls <- *msg.ID
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 10 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-calls
+		cancel()
+	}()
+	_, err := pc.Forward(ctx, ProxyRequest{
+		ServerEndpoint: srv.URL, ToolName: "slow", Arguments: json.RawMessage(`{}`),
+		AuthType: "bearer", AuthCredential: "tok",
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+
+	select {
+	case msg := <-cancelled:
+		if msg.ID != nil {
+			t.Error("notifications/cancelled must not carry an id")
+		}
+		if msg.Params.RequestID == 0 || msg.Params.Reason != "request cancelled by caller" {
+			t.Errorf("params = %+v", msg.Params)
+		}
+		if gotAuth != "Bearer tok" {
+			t.Errorf("Authorization = %q, want the server's credentials", gotAuth)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notifications/cancelled received")
+	}
+}
+
 func TestProxyClient_Non200Status(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -318,6 +397,52 @@ func TestProxyClient_JSONRPCFormat(t *testing.T) {
 	}
 }
 
+func TestProxyClient_PropagatesTraceContext(t *testing.T) {
+	var headers http.Header
+	var params struct {
+		Meta map[string]string `json:"_meta"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		var body struct {
+			Params json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.Unmarshal(body.Params, &params)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	defer srv.Close()
+
+	trace := TraceContext{
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		TraceState:  "vendor=abc",
+		RequestID:   "req-123",
+	}
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	_, err := pc.Forward(context.Background(), ProxyRequest{
+		ServerEndpoint: srv.URL, ToolName: "my_tool", Arguments: json.RawMessage(`{}`), AuthType: "none",
+		Headers: map[string]string{"traceparent": "custom"},
+		Trace:   &trace,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := headers.Get("traceparent"); got != trace.TraceParent {
+		t.Errorf("traceparent = %q, want %q", got, trace.TraceParent)
+	}
+	if got := headers.Get("tracestate"); got != "vendor=abc" {
+		t.Errorf("tracestate = %q, want vendor=abc", got)
+	}
+	if got := headers.Get("X-Request-Id"); got != "req-123" {
+		t.Errorf("X-Request-Id = %q, want req-123", got)
+	}
+	if params.Meta[MetaTraceParent] != trace.TraceParent || params.Meta[MetaTraceState] != "vendor=abc" ||
+		params.Meta[MetaRequestID] != "req-123" {
+		t.Errorf("params._meta = %v, want the trace context", params.Meta)
+	}
+}
+
 func TestProxyClient_LatencyMeasurement(t *testing.T) {
 	delay := 100 * time.Millisecond
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -387,3 +512,51 @@ func TestProxyClient_ConnectionRefused(t *testing.T) {
 		t.Fatal("expected connection error")
 	}
 }
+
+func TestProxyClient_ListToolsFollowsCursor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "tools/list" {
+			t.Errorf("method = %q, want tools/list", req.Method)
+		}
+		result := map[string]interface{}{
+			"tools":      []map[string]interface{}{{"name": "search", "inputSchema": map[string]interface{}{"type": "object"}}},
+			"nextCursor": "page2",
+		}
+		if req.Params["cursor"] == "page2" {
+			result = map[string]interface{}{
+				"tools": []map[string]interface{}{{"name": "fetch", "description": "Fetch a URL"}},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	tools, err := pc.ListTools(context.Background(), ProxyRequest{ServerEndpoint: srv.URL, AuthType: "none"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "search" || tools[1].Name != "fetch" {
+		t.Fatalf("tools = %+v, want search and fetch", tools)
+	}
+	if string(tools[0].InputSchema) != `{"type":"object"}` {
+		t.Errorf("inputSchema = %s", tools[0].InputSchema)
+	}
+}
+
+func TestProxyClient_ListToolsRPCError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`)
+	}))
+	defer srv.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	if _, err := pc.ListTools(context.Background(), ProxyRequest{ServerEndpoint: srv.URL, AuthType: "none"}); err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("expected rpc error, got %v", err)
+	}
+}
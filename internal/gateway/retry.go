@@ -0,0 +1,193 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures retries and hedging for one upstream server.
+// The zero value makes a single attempt.
+type RetryPolicy struct {
+	MaxAttempts       int           // Total attempts including the first; <= 1 disables retries
+	InitialBackoff    time.Duration // Backoff before the second attempt; doubles per attempt
+	MaxBackoff        time.Duration // Upper bound for the backoff
+	RetryableStatuses []int         // Upstream HTTP statuses that are retried
+	IdempotentTools   []string      // Glob patterns of tools safe to retry regardless of trust tier
+	HedgeTools        []string      // Glob patterns of read tools that may be hedged
+	HedgeDelay        time.Duration // Delay before a hedged request is sent
+}
+
+// IsIdempotent reports whether the tool is explicitly marked safe to retry.
+func (p RetryPolicy) IsIdempotent(toolName string) bool {
+	return matchAny(p.IdempotentTools, toolName)
+}
+
+// ShouldHedge reports whether calls to the tool may be hedged.
+func (p RetryPolicy) ShouldHedge(toolName string) bool {
+	return p.HedgeDelay > 0 && matchAny(p.HedgeTools, toolName)
+}
+
+func (p RetryPolicy) retryableStatus(status int) bool {
+	for _, s := range p.RetryableStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the given attempt (2-based), with jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff << (attempt - 2)
+	if p.MaxBackoff > 0 && (d > p.MaxBackoff || d <= 0) {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Jitter in [d/2, d) spreads retries from concurrent callers.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Attempt describes the outcome of one upstream request.
+type Attempt struct {
+	Number   int            // 1-based attempt number; a hedge shares its primary's number
+	Hedged   bool           // True for the hedged duplicate of an attempt
+	Endpoint string         // Upstream endpoint, set by the ForwardFunc
+	Response *ProxyResponse // Upstream response, nil on error
+	Err      error          // Transport or setup error
+	Latency  time.Duration
+	Canceled bool // The attempt lost a hedge race and was canceled
+}
+
+// RetryHooks lets the caller account for each attempt.
+type RetryHooks struct {
+	// BeforeAttempt is called before every attempt after the first, including
+	// hedges. Returning false stops further attempts (e.g. the circuit opened).
+	BeforeAttempt func() bool
+	// OnAttempt is called once per finished attempt, including canceled hedges.
+	OnAttempt func(Attempt)
+}
+
+// ForwardFunc performs a single upstream request. It may record the endpoint
+// it chose in attempt.Endpoint.
+type ForwardFunc func(ctx context.Context, attempt *Attempt) (*ProxyResponse, error)
+
+// ForwardWithRetry runs forward according to policy. Retries happen only when
+// retry is true; hedging only when hedge is true. It returns the first good
+// result, or the last attempt's result when every attempt failed.
+func ForwardWithRetry(ctx context.Context, forward ForwardFunc, policy RetryPolicy, retry, hedge bool, hooks RetryHooks) (*ProxyResponse, int, error) {
+	maxAttempts := 1
+	if retry && policy.MaxAttempts > 1 {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	var resp *ProxyResponse
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		if hedge {
+			resp, err = hedgedAttempt(ctx, forward, policy, attempt, hooks)
+		} else {
+			resp, err = singleAttempt(ctx, forward, attempt, false, hooks)
+		}
+		if !shouldRetry(ctx, policy, resp, err) || attempt >= maxAttempts {
+			return resp, attempt, err
+		}
+		if hooks.BeforeAttempt != nil && !hooks.BeforeAttempt() {
+			return resp, attempt, err
+		}
+		select {
+		case <-time.After(policy.backoff(attempt + 1)):
+		case <-ctx.Done():
+			return resp, attempt, err
+		}
+	}
+}
+
+// shouldRetry reports whether a result is transient. Policy-level refusals
+// (egress, TLS setup) and an exhausted endpoint pool are never retried.
+func shouldRetry(ctx context.Context, policy RetryPolicy, resp *ProxyResponse, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrEgressDenied) && !errors.Is(err, ErrInvalidTLSConfig) &&
+			!errors.Is(err, ErrNoHealthyEndpoint)
+	}
+	return policy.retryableStatus(resp.StatusCode)
+}
+
+func singleAttempt(ctx context.Context, forward ForwardFunc, number int, hedged bool, hooks RetryHooks) (*ProxyResponse, error) {
+	a := Attempt{Number: number, Hedged: hedged}
+	start := time.Now()
+	a.Response, a.Err = forward(ctx, &a)
+	a.Latency = time.Since(start)
+	if hooks.OnAttempt != nil {
+		hooks.OnAttempt(a)
+	}
+	return a.Response, a.Err
+}
+
+// hedgedAttempt sends the request and, if it has not completed after the hedge
+// delay, a duplicate. The first good result wins and the other is canceled.
+func hedgedAttempt(ctx context.Context, forward ForwardFunc, policy RetryPolicy, number int, hooks RetryHooks) (*ProxyResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan Attempt, 2)
+	launch := func(hedged bool) {
+		go func() {
+			a := Attempt{Number: number, Hedged: hedged}
+			start := time.Now()
+			a.Response, a.Err = forward(ctx, &a)
+			a.Latency = time.Since(start)
+			results <- a
+		}()
+	}
+
+	launch(false)
+	inFlight := 1
+	timer := time.NewTimer(policy.HedgeDelay)
+	defer timer.Stop()
+
+	var winner *Attempt
+	var last Attempt
+	for inFlight > 0 {
+		select {
+		case <-timer.C:
+			if winner == nil && (hooks.BeforeAttempt == nil || hooks.BeforeAttempt()) {
+				launch(true)
+				inFlight++
+			}
+		case res := <-results:
+			inFlight--
+			res.Canceled = winner != nil && errors.Is(res.Err, context.Canceled)
+			if hooks.OnAttempt != nil {
+				hooks.OnAttempt(res)
+			}
+			last = res
+			if winner == nil && !shouldRetry(ctx, policy, res.Response, res.Err) {
+				r := res
+				winner = &r
+				timer.Stop()
+				cancel()
+			}
+		}
+	}
+	if winner != nil {
+		return winner.Response, winner.Err
+	}
+	return last.Response, last.Err
+}
@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedForward returns the scripted results in order, one per call.
+func scriptedForward(results ...interface{}) (ForwardFunc, *atomic.Int32) {
+	var calls atomic.Int32
+	return func(ctx context.Context, _ *Attempt) (*ProxyResponse, error) {
+		n := int(calls.Add(1)) - 1
+		if n >= len(results) {
+			n = len(results) - 1
+		}
+		switch r := results[n].(type) {
+		case int:
+			return &ProxyResponse{StatusCode: r}, nil
+		case error:
+			return nil, r
+		}
+		return nil, nil
+	}, &calls
+}
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:       3,
+	InitialBackoff:    time.Millisecond,
+	MaxBackoff:        2 * time.Millisecond,
+	RetryableStatuses: []int{502, 503},
+}
+
+func TestForwardWithRetry_RetriesTransientStatus(t *testing.T) {
+	forward, calls := scriptedForward(502, 503, 200)
+	var attempts []Attempt
+	resp, n, err := ForwardWithRetry(context.Background(), forward, testRetryPolicy, true, false, RetryHooks{
+		OnAttempt: func(a Attempt) { attempts = append(attempts, a) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || n != 3 || calls.Load() != 3 {
+		t.Errorf("status=%d attempts=%d calls=%d, want 200/3/3", resp.StatusCode, n, calls.Load())
+	}
+	if len(attempts) != 3 || attempts[0].Number != 1 || attempts[2].Number != 3 {
+		t.Errorf("unexpected attempt records: %+v", attempts)
+	}
+}
+
+func TestForwardWithRetry_StopsAtMaxAttempts(t *testing.T) {
+	forward, calls := scriptedForward(502)
+	resp, n, _ := ForwardWithRetry(context.Background(), forward, testRetryPolicy, true, false, RetryHooks{})
+	if resp.StatusCode != 502 || n != 3 || calls.Load() != 3 {
+		t.Errorf("status=%d attempts=%d calls=%d, want 502/3/3", resp.StatusCode, n, calls.Load())
+	}
+}
+
+func TestForwardWithRetry_NoRetryWhenNotAllowed(t *testing.T) {
+	forward, calls := scriptedForward(502, 200)
+	_, n, _ := ForwardWithRetry(context.Background(), forward, testRetryPolicy, false, false, RetryHooks{})
+	if n != 1 || calls.Load() != 1 {
+		t.Errorf("attempts=%d calls=%d, want 1/1", n, calls.Load())
+	}
+}
+
+func TestForwardWithRetry_NonRetryableStatus(t *testing.T) {
+	forward, calls := scriptedForward(500, 200)
+	resp, _, _ := ForwardWithRetry(context.Background(), forward, testRetryPolicy, true, false, RetryHooks{})
+	if resp.StatusCode != 500 || calls.Load() != 1 {
+		t.Errorf("status=%d calls=%d, want 500/1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestForwardWithRetry_RetriesTransportErrorsButNotEgressDenials(t *testing.T) {
+	forward, calls := scriptedForward(errors.New("connection reset"), 200)
+	if _, _, err := ForwardWithRetry(context.Background(), forward, testRetryPolicy, true, false, RetryHooks{}); err != nil {
+		t.Errorf("expected transport error to be retried, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+
+	forward, calls = scriptedForward(ErrEgressDenied, 200)
+	if _, _, err := ForwardWithRetry(context.Background(), forward, testRetryPolicy, true, false, RetryHooks{}); !errors.Is(err, ErrEgressDenied) {
+		t.Errorf("expected ErrEgressDenied, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestForwardWithRetry_BeforeAttemptStopsRetries(t *testing.T) {
+	forward, calls := scriptedForward(502, 200)
+	_, n, _ := ForwardWithRetry(context.Background(), forward, testRetryPolicy, true, false, RetryHooks{
+		BeforeAttempt: func() bool { return false },
+	})
+	if n != 1 || calls.Load() != 1 {
+		t.Errorf("attempts=%d calls=%d, want 1/1", n, calls.Load())
+	}
+}
+
+func TestForwardWithRetry_HedgeWinsAndCancelsSlowPrimary(t *testing.T) {
+	var calls atomic.Int32
+	forward := func(ctx context.Context, _ *Attempt) (*ProxyResponse, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done() // slow primary, only finishes when canceled
+			return nil, ctx.Err()
+		}
+		return &ProxyResponse{StatusCode: 200}, nil
+	}
+	policy := testRetryPolicy
+	policy.HedgeDelay = 5 * time.Millisecond
+
+	var mu sync.Mutex
+	var attempts []Attempt
+	resp, _, err := ForwardWithRetry(context.Background(), forward, policy, true, true, RetryHooks{
+		OnAttempt: func(a Attempt) {
+			mu.Lock()
+			attempts = append(attempts, a)
+			mu.Unlock()
+		},
+	})
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("resp=%+v err=%v, want 200 from hedge", resp, err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempt records, got %d", len(attempts))
+	}
+	if !attempts[0].Hedged || attempts[0].Canceled {
+		t.Errorf("first finished attempt should be the successful hedge: %+v", attempts[0])
+	}
+	if attempts[1].Hedged || !attempts[1].Canceled {
+		t.Errorf("second finished attempt should be the canceled primary: %+v", attempts[1])
+	}
+}
+
+func TestForwardWithRetry_NoHedgeWhenPrimaryIsFast(t *testing.T) {
+	forward, calls := scriptedForward(200)
+	policy := testRetryPolicy
+	policy.HedgeDelay = 50 * time.Millisecond
+	if _, _, err := ForwardWithRetry(context.Background(), forward, policy, true, true, RetryHooks{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestRetryPolicy_Matching(t *testing.T) {
+	p := RetryPolicy{IdempotentTools: []string{"get_*"}, HedgeTools: []string{"search"}, HedgeDelay: time.Millisecond}
+	if !p.IsIdempotent("get_user") || p.IsIdempotent("delete_user") {
+		t.Error("IsIdempotent mismatch")
+	}
+	if !p.ShouldHedge("search") || p.ShouldHedge("get_user") {
+		t.Error("ShouldHedge mismatch")
+	}
+	p.HedgeDelay = 0
+	if p.ShouldHedge("search") {
+		t.Error("hedging requires a delay")
+	}
+}
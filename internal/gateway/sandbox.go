@@ -0,0 +1,70 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Sandbox modes. In record mode calls go upstream and their responses are
+// stored; in replay mode calls are answered from stored responses and never
+// reach the upstream.
+const (
+	SandboxOff    = "off"
+	SandboxRecord = "record"
+	SandboxReplay = "replay"
+)
+
+// Replay miss policies decide how a replayed call without a recording is
+// answered: with an error, or with a stub tool result flagged as an error.
+const (
+	ReplayMissError = "error"
+	ReplayMissStub  = "stub"
+)
+
+// SandboxConfig is a workspace's sandbox mode for one server.
+type SandboxConfig struct {
+	Mode       string `json:"mode"`
+	MissPolicy string `json:"miss_policy,omitempty"`
+}
+
+// SandboxFor returns the sandbox config of a server from a workspace's
+// per-server configs. A config for the server's label wins over the "*"
+// config; without either the server is live.
+func SandboxFor(configs map[string]SandboxConfig, label string) SandboxConfig {
+	if c, ok := configs[label]; ok {
+		return c
+	}
+	if c, ok := configs["*"]; ok {
+		return c
+	}
+	return SandboxConfig{Mode: SandboxOff}
+}
+
+// RecordingHash identifies the recording of a tool call. Arguments are
+// canonicalized so that key order and whitespace do not matter. It reports
+// false when the arguments are not valid JSON.
+func RecordingHash(toolName string, args json.RawMessage) (string, bool) {
+	canonical, ok := canonicalJSON(args)
+	if !ok {
+		return "", false
+	}
+	h := sha256.New()
+	h.Write([]byte(toolName))
+	h.Write([]byte{0})
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// ReplayMissStubBody returns the JSON-RPC tool result served for a replayed
+// call without a recording under the stub miss policy.
+func ReplayMissStubBody(toolName string) json.RawMessage {
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"result": map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": "no recording for " + toolName + " with these arguments"}},
+			"isError": true,
+		},
+	})
+	return body
+}
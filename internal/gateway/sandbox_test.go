@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSandboxFor(t *testing.T) {
+	configs := map[string]SandboxConfig{
+		"github": {Mode: SandboxReplay, MissPolicy: ReplayMissStub},
+		"*":      {Mode: SandboxRecord},
+	}
+	if got := SandboxFor(configs, "github"); got.Mode != SandboxReplay || got.MissPolicy != ReplayMissStub {
+		t.Errorf("github = %+v, want replay with stub misses", got)
+	}
+	if got := SandboxFor(configs, "jira"); got.Mode != SandboxRecord {
+		t.Errorf("jira = %+v, want the wildcard record mode", got)
+	}
+	if got := SandboxFor(nil, "jira"); got.Mode != SandboxOff {
+		t.Errorf("unconfigured = %+v, want off", got)
+	}
+}
+
+func TestRecordingHash(t *testing.T) {
+	a, ok := RecordingHash("search", json.RawMessage(`{"q":"go","limit":5}`))
+	if !ok {
+		t.Fatal("valid arguments should hash")
+	}
+	b, _ := RecordingHash("search", json.RawMessage(` { "limit": 5, "q": "go" } `))
+	if a != b {
+		t.Error("key order and whitespace should not change the hash")
+	}
+	if c, _ := RecordingHash("fetch", json.RawMessage(`{"q":"go","limit":5}`)); c == a {
+		t.Error("different tools should hash differently")
+	}
+	if d, _ := RecordingHash("search", json.RawMessage(`{"q":"go","limit":6}`)); d == a {
+		t.Error("different arguments should hash differently")
+	}
+	empty, _ := RecordingHash("search", nil)
+	if braces, _ := RecordingHash("search", json.RawMessage(`{}`)); empty != braces {
+		t.Error("missing arguments should match empty arguments")
+	}
+	if _, ok := RecordingHash("search", json.RawMessage(`{`)); ok {
+		t.Error("invalid arguments should not hash")
+	}
+}
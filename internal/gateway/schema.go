@@ -0,0 +1,473 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// maxSchemaViolations caps the violations reported for one call.
+	maxSchemaViolations = 50
+	// maxSchemaDepth bounds recursion into nested schemas and arguments.
+	maxSchemaDepth = 32
+)
+
+// SchemaViolation is one failed JSON Schema constraint. Path uses "$" for the
+// arguments object, ".name" for properties and "[i]" for array items.
+type SchemaViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// supportedSchemaKeywords is the JSON Schema subset the gateway enforces.
+// Other keywords in upstream schemas (title, description, format, $ref, ...)
+// are ignored; overlays may only use these.
+var supportedSchemaKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"allOf": true, "anyOf": true, "oneOf": true,
+	"description": true, "title": true,
+}
+
+// ValidateArguments checks tool arguments against each schema in turn, for
+// example the upstream inputSchema followed by an admin overlay. Empty
+// schemas are skipped. The error is non-nil only when a schema or the
+// arguments are not valid JSON.
+func ValidateArguments(args json.RawMessage, schemas ...json.RawMessage) ([]SchemaViolation, error) {
+	value, err := decodeJSON(args)
+	if err != nil {
+		return nil, fmt.Errorf("decode arguments: %w", err)
+	}
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+	v := &schemaValidator{}
+	for _, raw := range schemas {
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		schema, err := decodeJSON(raw)
+		if err != nil {
+			return nil, fmt.Errorf("decode schema: %w", err)
+		}
+		v.validate(schema, value, "$", 0)
+	}
+	return v.violations, nil
+}
+
+// CheckSchema verifies that a schema only uses supported keywords with
+// well-formed values. It is used for admin-supplied overlays.
+func CheckSchema(raw json.RawMessage) error {
+	schema, err := decodeJSON(raw)
+	if err != nil {
+		return fmt.Errorf("schema is not valid JSON")
+	}
+	return checkSchema(schema, "$", 0)
+}
+
+func decodeJSON(raw json.RawMessage) (interface{}, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func checkSchema(node interface{}, path string, depth int) error {
+	if depth > maxSchemaDepth {
+		return fmt.Errorf("%s: schema is nested too deeply", path)
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: schema must be an object", path)
+	}
+	for key, val := range schema {
+		if !supportedSchemaKeywords[key] {
+			return fmt.Errorf("%s: unsupported keyword %q", path, key)
+		}
+		switch key {
+		case "type":
+			for _, t := range schemaTypes(val) {
+				if !validSchemaType(t) {
+					return fmt.Errorf("%s: invalid type %q", path, t)
+				}
+			}
+			if len(schemaTypes(val)) == 0 {
+				return fmt.Errorf("%s: type must be a string or array of strings", path)
+			}
+		case "enum":
+			if list, ok := val.([]interface{}); !ok || len(list) == 0 {
+				return fmt.Errorf("%s: enum must be a non-empty array", path)
+			}
+		case "properties":
+			props, ok := val.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: properties must be an object", path)
+			}
+			for name, sub := range props {
+				if err := checkSchema(sub, path+"."+name, depth+1); err != nil {
+					return err
+				}
+			}
+		case "required":
+			list, ok := val.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s: required must be an array of strings", path)
+			}
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return fmt.Errorf("%s: required must be an array of strings", path)
+				}
+			}
+		case "additionalProperties":
+			if _, ok := val.(bool); !ok {
+				if err := checkSchema(val, path+".additionalProperties", depth+1); err != nil {
+					return err
+				}
+			}
+		case "items":
+			if err := checkSchema(val, path+"[]", depth+1); err != nil {
+				return err
+			}
+		case "minItems", "maxItems", "minLength", "maxLength":
+			if n, ok := schemaNumber(val); !ok || n < 0 || n != math.Trunc(n) {
+				return fmt.Errorf("%s: %s must be a non-negative integer", path, key)
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			if _, ok := schemaNumber(val); !ok {
+				return fmt.Errorf("%s: %s must be a number", path, key)
+			}
+		case "pattern":
+			s, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("%s: pattern must be a string", path)
+			}
+			if _, err := regexp.Compile(s); err != nil {
+				return fmt.Errorf("%s: invalid pattern: %v", path, err)
+			}
+		case "allOf", "anyOf", "oneOf":
+			list, ok := val.([]interface{})
+			if !ok || len(list) == 0 {
+				return fmt.Errorf("%s: %s must be a non-empty array of schemas", path, key)
+			}
+			for i, sub := range list {
+				if err := checkSchema(sub, fmt.Sprintf("%s.%s[%d]", path, key, i), depth+1); err != nil {
+					return err
+				}
+			}
+		case "description", "title":
+			if _, ok := val.(string); !ok {
+				return fmt.Errorf("%s: %s must be a string", path, key)
+			}
+		}
+	}
+	return nil
+}
+
+type schemaValidator struct {
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) fail(path, format string, args ...interface{}) {
+	if len(v.violations) >= maxSchemaViolations {
+		return
+	}
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether value satisfies schema without recording violations.
+func (v *schemaValidator) matches(schema, value interface{}, path string, depth int) bool {
+	sub := &schemaValidator{}
+	sub.validate(schema, value, path, depth)
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(node, value interface{}, path string, depth int) {
+	schema, ok := node.(map[string]interface{})
+	if !ok || depth > maxSchemaDepth {
+		return
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := schemaTypes(t)
+		matched := len(types) == 0
+		for _, name := range types {
+			if jsonTypeMatches(name, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "expected %s, got %s", joinTypes(types), jsonTypeName(value))
+			return
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "must be one of %s", compactJSON(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, value) {
+		v.fail(path, "must equal %s", compactJSON(c))
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, val, path, depth)
+	case []interface{}:
+		v.validateArray(schema, val, path, depth)
+	case string:
+		n := float64(utf8.RuneCountInString(val))
+		if min, ok := schemaNumber(schema["minLength"]); ok && n < min {
+			v.fail(path, "must be at least %s characters", formatNumber(min))
+		}
+		if max, ok := schemaNumber(schema["maxLength"]); ok && n > max {
+			v.fail(path, "must be at most %s characters", formatNumber(max))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(val) {
+				v.fail(path, "must match pattern %q", pattern)
+			}
+		}
+	case json.Number:
+		n, err := val.Float64()
+		if err != nil {
+			break
+		}
+		if min, ok := schemaNumber(schema["minimum"]); ok && n < min {
+			v.fail(path, "must be >= %s", formatNumber(min))
+		}
+		if max, ok := schemaNumber(schema["maximum"]); ok && n > max {
+			v.fail(path, "must be <= %s", formatNumber(max))
+		}
+		if min, ok := schemaNumber(schema["exclusiveMinimum"]); ok && n <= min {
+			v.fail(path, "must be > %s", formatNumber(min))
+		}
+		if max, ok := schemaNumber(schema["exclusiveMaximum"]); ok && n >= max {
+			v.fail(path, "must be < %s", formatNumber(max))
+		}
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(sub, value, path, depth+1)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.matches(sub, value, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "must match at least one schema in anyOf")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.matches(sub, value, path, depth+1) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(path, "must match exactly one schema in oneOf, matched %d", count)
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(schema, obj map[string]interface{}, path string, depth int) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, ok := r.(string)
+			if !ok {
+				continue
+			}
+			if _, present := obj[name]; !present {
+				v.fail(path+"."+name, "is required")
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names) // deterministic violation order
+	for _, name := range names {
+		if sub, ok := props[name]; ok {
+			v.validate(sub, obj[name], path+"."+name, depth+1)
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.fail(path+"."+name, "is not an allowed property")
+			}
+		case map[string]interface{}:
+			v.validate(extra, obj[name], path+"."+name, depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(schema map[string]interface{}, arr []interface{}, path string, depth int) {
+	n := float64(len(arr))
+	if min, ok := schemaNumber(schema["minItems"]); ok && n < min {
+		v.fail(path, "must contain at least %s items", formatNumber(min))
+	}
+	if max, ok := schemaNumber(schema["maxItems"]); ok && n > max {
+		v.fail(path, "must contain at most %s items", formatNumber(max))
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range arr {
+			v.validate(items, item, path+"["+strconv.Itoa(i)+"]", depth+1)
+		}
+	}
+}
+
+func schemaTypes(t interface{}) []string {
+	switch tv := t.(type) {
+	case string:
+		return []string{tv}
+	case []interface{}:
+		var out []string
+		for _, item := range tv {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func validSchemaType(t string) bool {
+	switch t {
+	case "object", "array", "string", "number", "integer", "boolean", "null":
+		return true
+	}
+	return false
+}
+
+func jsonTypeMatches(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	return false
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return "unknown"
+}
+
+func joinTypes(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	out := ""
+	for i, t := range types {
+		if i > 0 {
+			out += " or "
+		}
+		out += t
+	}
+	return out
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// jsonEqual compares decoded JSON values, treating numbers by value.
+func jsonEqual(a, b interface{}) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, aerr := an.Float64()
+		bf, berr := bn.Float64()
+		return aerr == nil && berr == nil && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compactJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "?"
+	}
+	if len(b) > 200 {
+		return string(b[:200]) + "..."
+	}
+	return string(b)
+}
@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const searchSchema = `{
+	"type": "object",
+	"properties": {
+		"query": {"type": "string", "minLength": 1},
+		"limit": {"type": "integer", "minimum": 1, "maximum": 100},
+		"mode":  {"enum": ["fast", "exact"]},
+		"tags":  {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+	},
+	"required": ["query"],
+	"additionalProperties": false
+}`
+
+func TestValidateArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		wantPaths []string
+	}{
+		{name: "valid", args: `{"query":"go","limit":10,"mode":"fast","tags":["a"]}`},
+		{name: "missing required", args: `{}`, wantPaths: []string{"$.query"}},
+		{name: "empty arguments", args: ``, wantPaths: []string{"$.query"}},
+		{name: "wrong type", args: `{"query":5}`, wantPaths: []string{"$.query"}},
+		{name: "integer with fraction", args: `{"query":"go","limit":1.5}`, wantPaths: []string{"$.limit"}},
+		{name: "above maximum", args: `{"query":"go","limit":101}`, wantPaths: []string{"$.limit"}},
+		{name: "not in enum", args: `{"query":"go","mode":"slow"}`, wantPaths: []string{"$.mode"}},
+		{name: "array item type", args: `{"query":"go","tags":["a",1]}`, wantPaths: []string{"$.tags[1]"}},
+		{name: "too many items", args: `{"query":"go","tags":["a","b","c"]}`, wantPaths: []string{"$.tags"}},
+		{name: "additional property", args: `{"query":"go","extra":true}`, wantPaths: []string{"$.extra"}},
+		{name: "multiple violations", args: `{"query":"","limit":0}`, wantPaths: []string{"$.limit", "$.query"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := ValidateArguments(json.RawMessage(tc.args), json.RawMessage(searchSchema))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(violations) != len(tc.wantPaths) {
+				t.Fatalf("violations = %+v, want paths %v", violations, tc.wantPaths)
+			}
+			for i, v := range violations {
+				if v.Path != tc.wantPaths[i] {
+					t.Errorf("violation %d path = %q, want %q", i, v.Path, tc.wantPaths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateArguments_OverlayTightensUpstream(t *testing.T) {
+	overlay := json.RawMessage(`{"properties":{"query":{"pattern":"^[a-z]+$"},"limit":{"maximum":10}}}`)
+
+	violations, err := ValidateArguments(json.RawMessage(`{"query":"go","limit":5}`), json.RawMessage(searchSchema), overlay)
+	if err != nil || len(violations) != 0 {
+		t.Fatalf("expected valid arguments, got %+v, %v", violations, err)
+	}
+	violations, _ = ValidateArguments(json.RawMessage(`{"query":"Go!","limit":50}`), json.RawMessage(searchSchema), overlay)
+	if len(violations) != 2 {
+		t.Errorf("expected overlay violations for query and limit, got %+v", violations)
+	}
+}
+
+func TestValidateArguments_Combinators(t *testing.T) {
+	schema := json.RawMessage(`{"oneOf":[{"type":"object","required":["id"]},{"type":"object","required":["name"]}]}`)
+
+	if v, _ := ValidateArguments(json.RawMessage(`{"id":1}`), schema); len(v) != 0 {
+		t.Errorf("expected exactly one branch to match, got %+v", v)
+	}
+	if v, _ := ValidateArguments(json.RawMessage(`{"id":1,"name":"x"}`), schema); len(v) == 0 {
+		t.Error("expected oneOf violation when both branches match")
+	}
+	if v, _ := ValidateArguments(json.RawMessage(`{}`), schema); len(v) == 0 {
+		t.Error("expected oneOf violation when no branch matches")
+	}
+}
+
+func TestValidateArguments_IgnoresUnknownKeywords(t *testing.T) {
+	schema := json.RawMessage(`{"$schema":"http://json-schema.org/draft-07/schema#","type":"object","properties":{"url":{"type":"string","format":"uri"}}}`)
+	if v, err := ValidateArguments(json.RawMessage(`{"url":"not a uri"}`), schema); err != nil || len(v) != 0 {
+		t.Errorf("expected unknown keywords to be ignored, got %+v, %v", v, err)
+	}
+}
+
+func TestValidateArguments_InvalidJSON(t *testing.T) {
+	if _, err := ValidateArguments(json.RawMessage(`{"query":`), json.RawMessage(searchSchema)); err == nil {
+		t.Error("expected error for malformed arguments")
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{name: "empty", schema: `{}`},
+		{name: "supported keywords", schema: searchSchema},
+		{name: "unsupported keyword", schema: `{"format":"uri"}`, wantErr: true},
+		{name: "invalid type", schema: `{"type":"date"}`, wantErr: true},
+		{name: "invalid pattern", schema: `{"pattern":"("}`, wantErr: true},
+		{name: "empty enum", schema: `{"enum":[]}`, wantErr: true},
+		{name: "not an object", schema: `[]`, wantErr: true},
+		{name: "nested unsupported", schema: `{"properties":{"a":{"$ref":"#/x"}}}`, wantErr: true},
+		{name: "malformed", schema: `{`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckSchema(json.RawMessage(tc.schema))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("CheckSchema() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
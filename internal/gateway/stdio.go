@@ -0,0 +1,525 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ErrStdioUnavailable is returned for calls to a stdio server that is not
+// running: it is disabled, backing off after a crash or failed to start.
+var ErrStdioUnavailable = errors.New("stdio server unavailable")
+
+// stdioProtocolVersion is the MCP protocol version sent in initialize.
+const stdioProtocolVersion = "2025-03-26"
+
+// StdioConfig is the command the gateway launches for a stdio MCP server.
+// Env is the child's entire environment apart from PATH; nothing else is
+// inherited from the gateway.
+type StdioConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// fingerprint identifies a config so a changed command restarts the process.
+func (c StdioConfig) fingerprint() string {
+	b, _ := json.Marshal(c)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// StdioSupervisorConfig configures stdio process supervision.
+type StdioSupervisorConfig struct {
+	InitTimeout time.Duration // Bound on the initialize handshake (default 10s)
+	MinBackoff  time.Duration // Restart delay after the first crash (default 1s)
+	MaxBackoff  time.Duration // Cap on the doubling restart delay (default 1m)
+	StableAfter time.Duration // Uptime after which a crash resets the backoff (default 1m)
+}
+
+// StdioSupervisor launches stdio MCP servers as child processes on first
+// use, multiplexes JSON-RPC calls over their stdin/stdout and restarts them
+// with exponential backoff after they exit.
+type StdioSupervisor struct {
+	cfg StdioSupervisorConfig
+
+	mu      sync.Mutex
+	servers map[string]*stdioServer
+	closed  bool
+}
+
+// stdioServer is the supervision state of one server label. At most one
+// start is in progress at a time, so concurrent calls never launch
+// duplicate processes.
+type stdioServer struct {
+	fingerprint string
+
+	mu       sync.Mutex
+	proc     *stdioProcess
+	starting *stdioStart
+	failures int
+	retryAt  time.Time
+	stopped  bool
+}
+
+// stdioStart is a start in progress. err is set before done is closed.
+type stdioStart struct {
+	done chan struct{}
+	err  error
+}
+
+// stop terminates the server's process and rejects further calls to it.
+func (srv *stdioServer) stop() {
+	srv.mu.Lock()
+	srv.stopped = true
+	proc := srv.proc
+	srv.proc = nil
+	srv.mu.Unlock()
+	if proc != nil {
+		proc.kill()
+	}
+}
+
+// NewStdioSupervisor creates a supervisor. Call Close to stop every process.
+func NewStdioSupervisor(cfg StdioSupervisorConfig) *StdioSupervisor {
+	if cfg.InitTimeout <= 0 {
+		cfg.InitTimeout = 10 * time.Second
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = time.Minute
+		if cfg.MaxBackoff < cfg.MinBackoff {
+			cfg.MaxBackoff = cfg.MinBackoff
+		}
+	}
+	if cfg.StableAfter <= 0 {
+		cfg.StableAfter = time.Minute
+	}
+	return &StdioSupervisor{cfg: cfg, servers: make(map[string]*stdioServer)}
+}
+
+// Call sends a JSON-RPC request to a server's process, starting it first if
+// needed, and returns the raw JSON-RPC response.
+func (s *StdioSupervisor) Call(ctx context.Context, label string, cfg StdioConfig, method string, params interface{}) (*ProxyResponse, error) {
+	start := time.Now()
+	proc, err := s.process(ctx, label, cfg)
+	if err != nil {
+		return nil, err
+	}
+	req, resp, err := proc.call(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	return &ProxyResponse{
+		StatusCode:   200,
+		Body:         resp,
+		Latency:      time.Since(start),
+		RequestSize:  int64(len(req)),
+		ResponseSize: int64(len(resp)),
+	}, nil
+}
+
+// Stop terminates a server's process, for example after its config changed
+// or it was deleted. The next call starts it again without backoff.
+func (s *StdioSupervisor) Stop(label string) {
+	s.mu.Lock()
+	srv := s.servers[label]
+	delete(s.servers, label)
+	s.mu.Unlock()
+	if srv != nil {
+		srv.stop()
+	}
+}
+
+// Close terminates every process and rejects further calls.
+func (s *StdioSupervisor) Close() {
+	s.mu.Lock()
+	s.closed = true
+	servers := s.servers
+	s.servers = make(map[string]*stdioServer)
+	s.mu.Unlock()
+	for _, srv := range servers {
+		srv.stop()
+	}
+}
+
+// process returns the running process for a server, starting one when none
+// is running and the server is not backing off. Callers wait for a start in
+// progress until their context ends; the start itself is not canceled.
+func (s *StdioSupervisor) process(ctx context.Context, label string, cfg StdioConfig) (*stdioProcess, error) {
+	fp := cfg.fingerprint()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: supervisor closed", ErrStdioUnavailable)
+	}
+	srv := s.servers[label]
+	if srv == nil || srv.fingerprint != fp {
+		// A changed config replaces the process and forgets past crashes.
+		if srv != nil {
+			go srv.stop()
+		}
+		srv = &stdioServer{fingerprint: fp}
+		s.servers[label] = srv
+	}
+	s.mu.Unlock()
+
+	for {
+		srv.mu.Lock()
+		if srv.stopped {
+			srv.mu.Unlock()
+			return nil, fmt.Errorf("%w: server stopped", ErrStdioUnavailable)
+		}
+		if srv.proc != nil {
+			select {
+			case <-srv.proc.done:
+				s.recordExit(srv)
+			default:
+				proc := srv.proc
+				srv.mu.Unlock()
+				return proc, nil
+			}
+		}
+		st := srv.starting
+		if st == nil {
+			if wait := time.Until(srv.retryAt); wait > 0 {
+				srv.mu.Unlock()
+				return nil, fmt.Errorf("%w: restarting in %s", ErrStdioUnavailable, wait.Round(time.Millisecond))
+			}
+			st = &stdioStart{done: make(chan struct{})}
+			srv.starting = st
+			go s.start(srv, st, label, cfg)
+		}
+		srv.mu.Unlock()
+
+		select {
+		case <-st.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if st.err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStdioUnavailable, st.err)
+		}
+	}
+}
+
+// start launches a server's process and performs the initialize handshake
+// without holding srv.mu, then publishes the result to st.
+func (s *StdioSupervisor) start(srv *stdioServer, st *stdioStart, label string, cfg StdioConfig) {
+	proc, err := startStdioProcess(label, cfg)
+	if err == nil {
+		if err = proc.initialize(context.Background(), s.cfg.InitTimeout); err != nil {
+			proc.kill()
+		}
+	}
+
+	var orphan *stdioProcess
+	srv.mu.Lock()
+	srv.starting = nil
+	switch {
+	case err != nil:
+		srv.failures++
+		srv.retryAt = time.Now().Add(s.backoff(srv.failures))
+		log.Printf("stdio server %s failed to start: %v", label, err)
+	case srv.stopped:
+		// stop ran while the process was starting.
+		orphan = proc
+		err = errors.New("server stopped")
+	default:
+		srv.proc = proc
+	}
+	st.err = err
+	srv.mu.Unlock()
+	close(st.done)
+	if orphan != nil {
+		orphan.kill()
+	}
+}
+
+// recordExit schedules the restart of a server whose process has exited.
+// Must be called with srv.mu held.
+func (s *StdioSupervisor) recordExit(srv *stdioServer) {
+	proc := srv.proc
+	srv.proc = nil
+	if proc.exitedAt.Sub(proc.startedAt) >= s.cfg.StableAfter {
+		srv.failures = 0
+	}
+	srv.failures++
+	srv.retryAt = proc.exitedAt.Add(s.backoff(srv.failures))
+}
+
+// backoff returns the restart delay after the given number of consecutive
+// failures.
+func (s *StdioSupervisor) backoff(failures int) time.Duration {
+	d := s.cfg.MinBackoff
+	for i := 1; i < failures && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > s.cfg.MaxBackoff {
+		d = s.cfg.MaxBackoff
+	}
+	return d
+}
+
+// stdioProcess is a running stdio server with in-flight calls keyed by
+// JSON-RPC ID.
+type stdioProcess struct {
+	label     string
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	startedAt time.Time
+
+	writes chan stdioWrite
+
+	mu       sync.Mutex
+	nextID   int64
+	pending  map[int64]chan json.RawMessage
+	exitedAt time.Time
+	exitErr  error
+
+	stderrDone chan struct{}
+	done       chan struct{}
+}
+
+func startStdioProcess(label string, cfg StdioConfig) (*stdioProcess, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &stdioProcess{
+		label: label, cmd: cmd, stdin: stdin, startedAt: time.Now(),
+		pending:    make(map[int64]chan json.RawMessage),
+		writes:     make(chan stdioWrite),
+		stderrDone: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go p.logStderr(stderr)
+	go p.readLoop(stdout)
+	go p.writeLoop()
+	return p, nil
+}
+
+// readLoop delivers responses to waiting calls until stdout closes or
+// cannot be read, then reaps the process and fails every call still waiting.
+func (p *stdioProcess) readLoop(stdout io.Reader) {
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 64*1024), MaxUpstreamResponseSize)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg struct {
+			ID     *int64  `json:"id"`
+			Method *string `json:"method"`
+		}
+		// Requests and notifications from the server are ignored.
+		if err := json.Unmarshal(line, &msg); err != nil || msg.ID == nil || msg.Method != nil {
+			continue
+		}
+		p.mu.Lock()
+		ch, ok := p.pending[*msg.ID]
+		delete(p.pending, *msg.ID)
+		p.mu.Unlock()
+		if ok {
+			ch <- append(json.RawMessage(nil), line...)
+		}
+	}
+	readErr := sc.Err()
+	if readErr != nil {
+		// The child may still be running, for example after an oversized
+		// line, and Wait would block until it exits on its own.
+		p.terminate()
+	}
+	// Wait closes the pipes, so the stderr copy must finish first.
+	<-p.stderrDone
+	err := p.cmd.Wait()
+	if err == nil {
+		err = readErr
+	}
+	p.mu.Lock()
+	p.exitedAt = time.Now()
+	p.exitErr = err
+	p.pending = nil
+	p.mu.Unlock()
+	close(p.done)
+	log.Printf("stdio server %s exited: %v", p.label, err)
+}
+
+// logStderr copies the server's stderr to the gateway log.
+func (p *stdioProcess) logStderr(stderr io.Reader) {
+	defer close(p.stderrDone)
+	sc := bufio.NewScanner(stderr)
+	for sc.Scan() {
+		log.Printf("stdio server %s: %s", p.label, sc.Text())
+	}
+}
+
+// call sends one JSON-RPC request and waits for its response. It returns the
+// encoded request along with the response.
+func (p *stdioProcess) call(ctx context.Context, method string, params interface{}) ([]byte, json.RawMessage, error) {
+	ch := make(chan json.RawMessage, 1)
+	p.mu.Lock()
+	if p.pending == nil {
+		p.mu.Unlock()
+		return nil, nil, fmt.Errorf("%w: process exited", ErrStdioUnavailable)
+	}
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	req, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0", "id": id, "method": method, "params": params,
+	})
+	if err != nil {
+		p.forget(id)
+		return nil, nil, fmt.Errorf("marshal request: %w", err)
+	}
+	if err := p.write(ctx, req); err != nil {
+		p.forget(id)
+		return nil, nil, fmt.Errorf("send request: %w", err)
+	}
+
+	select {
+	case resp := <-ch:
+		return req, resp, nil
+	case <-p.done:
+		select {
+		case resp := <-ch:
+			return req, resp, nil
+		default:
+		}
+		return nil, nil, fmt.Errorf("%w: process exited: %v", ErrStdioUnavailable, p.exitErr)
+	case <-ctx.Done():
+		p.forget(id)
+		// Sent asynchronously so a child that stopped reading stdin cannot
+		// hold up the caller.
+		if notif, err := json.Marshal(cancelledNotification(id, ctx.Err())); err == nil {
+			go p.write(context.Background(), notif)
+		}
+		return nil, nil, ctx.Err()
+	}
+}
+
+// initialize performs the MCP initialize handshake.
+func (p *stdioProcess) initialize(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, resp, err := p.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": stdioProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "agentic-registry-gateway", "version": "1.0"},
+	})
+	if err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	var rpcResp struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp, &rpcResp); err != nil {
+		return fmt.Errorf("decode initialize response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("initialize error: %s", rpcResp.Error.Message)
+	}
+	notif, _ := json.Marshal(map[string]string{"jsonrpc": "2.0", "method": "notifications/initialized"})
+	return p.write(ctx, notif)
+}
+
+// stdioWrite is a message queued for the process's stdin.
+type stdioWrite struct {
+	msg []byte
+	err chan error
+}
+
+// write sends one newline-delimited message to the process. It gives up
+// when ctx ends or the process exits, so a child that stops reading stdin
+// cannot hold up the caller; a message already being written is still
+// written whole.
+func (p *stdioProcess) write(ctx context.Context, msg []byte) error {
+	w := stdioWrite{msg: append(msg, '\n'), err: make(chan error, 1)}
+	select {
+	case p.writes <- w:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return fmt.Errorf("%w: process exited", ErrStdioUnavailable)
+	}
+	select {
+	case err := <-w.err:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return fmt.Errorf("%w: process exited", ErrStdioUnavailable)
+	}
+}
+
+// writeLoop writes queued messages to stdin one at a time until the process
+// exits.
+func (p *stdioProcess) writeLoop() {
+	for {
+		select {
+		case w := <-p.writes:
+			_, err := p.stdin.Write(w.msg)
+			w.err <- err
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *stdioProcess) forget(id int64) {
+	p.mu.Lock()
+	if p.pending != nil {
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+}
+
+// kill terminates the process and waits for it to be reaped.
+func (p *stdioProcess) kill() {
+	p.terminate()
+	<-p.done
+}
+
+// terminate closes the process's stdin and kills it without waiting.
+func (p *stdioProcess) terminate() {
+	p.stdin.Close()
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+}
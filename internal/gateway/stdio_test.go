@@ -0,0 +1,415 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestStdioHelperProcess is not a real test: it is the MCP server launched by
+// the stdio tests. It answers tools/call by echoing the call back after the
+// delay in arguments.delay_ms, exits on the "crash" tool, reports its
+// environment on the "env" tool, writes an oversized line and hangs on
+// the "oversized" tool and stops reading stdin on the "deaf" tool.
+func TestStdioHelperProcess(t *testing.T) {
+	if os.Getenv("GATEWAY_STDIO_HELPER") != "1" {
+		t.Skip("helper process")
+	}
+	var mu sync.Mutex
+	out := json.NewEncoder(os.Stdout)
+	reply := func(id json.RawMessage, result interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		out.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+	}
+	if os.Getenv("HELPER_FAIL_INIT") == "1" {
+		os.Exit(3)
+	}
+	in := bufio.NewScanner(os.Stdin)
+	for in.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Name      string `json:"name"`
+				Arguments struct {
+					DelayMS int `json:"delay_ms"`
+				} `json:"arguments"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		switch {
+		case req.Method == "initialize":
+			if delay, _ := strconv.Atoi(os.Getenv("HELPER_INIT_DELAY_MS")); delay > 0 {
+				time.Sleep(time.Duration(delay) * time.Millisecond)
+			}
+			// A server-initiated notification must not confuse the client.
+			mu.Lock()
+			out.Encode(map[string]string{"jsonrpc": "2.0", "method": "notifications/message"})
+			mu.Unlock()
+			reply(req.ID, map[string]interface{}{"protocolVersion": stdioProtocolVersion})
+		case req.ID == nil:
+		case req.Params.Name == "crash":
+			os.Exit(1)
+		case req.Params.Name == "oversized":
+			mu.Lock()
+			os.Stdout.Write(bytes.Repeat([]byte("x"), MaxUpstreamResponseSize+1))
+			select {}
+		case req.Params.Name == "deaf":
+			select {}
+		case req.Params.Name == "env":
+			reply(req.ID, map[string]interface{}{"env": os.Environ(), "pid": os.Getpid()})
+		default:
+			go func(id json.RawMessage, name string, delay int) {
+				time.Sleep(time.Duration(delay) * time.Millisecond)
+				reply(id, map[string]interface{}{"tool": name, "pid": os.Getpid()})
+			}(req.ID, req.Params.Name, req.Params.Arguments.DelayMS)
+		}
+	}
+	os.Exit(0)
+}
+
+func helperStdioConfig(extraEnv map[string]string) StdioConfig {
+	env := map[string]string{"GATEWAY_STDIO_HELPER": "1"}
+	for k, v := range extraEnv {
+		env[k] = v
+	}
+	return StdioConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestStdioHelperProcess$"},
+		Env:     env,
+	}
+}
+
+type helperResult struct {
+	Tool string   `json:"tool"`
+	PID  int      `json:"pid"`
+	Env  []string `json:"env"`
+}
+
+func callHelper(t *testing.T, s *StdioSupervisor, cfg StdioConfig, tool string, delayMS int) (helperResult, error) {
+	t.Helper()
+	resp, err := s.Call(context.Background(), "helper", cfg, "tools/call", map[string]interface{}{
+		"name": tool, "arguments": map[string]int{"delay_ms": delayMS},
+	})
+	if err != nil {
+		return helperResult{}, err
+	}
+	var rpc struct {
+		Result helperResult `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Body, &rpc); err != nil {
+		t.Fatalf("decode response %s: %v", resp.Body, err)
+	}
+	return rpc.Result, nil
+}
+
+func TestStdioSupervisor_Call(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+
+	res, err := callHelper(t, s, helperStdioConfig(nil), "echo", 0)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if res.Tool != "echo" {
+		t.Errorf("tool = %q, want echo", res.Tool)
+	}
+
+	again, err := callHelper(t, s, helperStdioConfig(nil), "echo", 0)
+	if err != nil {
+		t.Fatalf("second Call: %v", err)
+	}
+	if again.PID != res.PID {
+		t.Errorf("second call ran in pid %d, want reused pid %d", again.PID, res.PID)
+	}
+}
+
+func TestStdioSupervisor_IsolatedEnv(t *testing.T) {
+	t.Setenv("GATEWAY_SECRET_FOR_TEST", "leak")
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+
+	res, err := callHelper(t, s, helperStdioConfig(map[string]string{"API_TOKEN": "t0k"}), "env", 0)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, kv := range res.Env {
+		got[kv] = true
+	}
+	if !got["API_TOKEN=t0k"] {
+		t.Errorf("configured env missing from %v", res.Env)
+	}
+	if got["GATEWAY_SECRET_FOR_TEST=leak"] {
+		t.Error("gateway environment leaked into the child process")
+	}
+}
+
+func TestStdioSupervisor_MultiplexesConcurrentCalls(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+	cfg := helperStdioConfig(nil)
+
+	// Slow calls answered out of order must each get their own response.
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tool := fmt.Sprintf("tool-%d", i)
+			res, err := callHelper(t, s, cfg, tool, (10-i)*10)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if res.Tool != tool {
+				errs <- fmt.Errorf("call %s got response for %s", tool, res.Tool)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestStdioSupervisor_RestartsWithBackoff(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{MinBackoff: 200 * time.Millisecond, MaxBackoff: time.Second})
+	defer s.Close()
+	cfg := helperStdioConfig(nil)
+
+	first, err := callHelper(t, s, cfg, "echo", 0)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if _, err := callHelper(t, s, cfg, "crash", 0); !errors.Is(err, ErrStdioUnavailable) {
+		t.Fatalf("crash error = %v, want ErrStdioUnavailable", err)
+	}
+	if _, err := callHelper(t, s, cfg, "echo", 0); !errors.Is(err, ErrStdioUnavailable) {
+		t.Fatalf("call during backoff error = %v, want ErrStdioUnavailable", err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	res, err := callHelper(t, s, cfg, "echo", 0)
+	if err != nil {
+		t.Fatalf("Call after backoff: %v", err)
+	}
+	if res.PID == first.PID {
+		t.Error("expected a restarted process")
+	}
+}
+
+func TestStdioSupervisor_FailedStartBacksOff(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{MinBackoff: time.Minute})
+	defer s.Close()
+	cfg := helperStdioConfig(map[string]string{"HELPER_FAIL_INIT": "1"})
+
+	if _, err := callHelper(t, s, cfg, "echo", 0); !errors.Is(err, ErrStdioUnavailable) {
+		t.Fatalf("error = %v, want ErrStdioUnavailable", err)
+	}
+	start := time.Now()
+	if _, err := callHelper(t, s, cfg, "echo", 0); !errors.Is(err, ErrStdioUnavailable) {
+		t.Fatalf("retry error = %v, want ErrStdioUnavailable", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("call during backoff should fail without starting a process")
+	}
+
+	// A fixed config is started immediately.
+	if _, err := callHelper(t, s, helperStdioConfig(nil), "echo", 0); err != nil {
+		t.Fatalf("Call with new config: %v", err)
+	}
+}
+
+func TestStdioSupervisor_ConfigChangeRestarts(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+
+	first, err := callHelper(t, s, helperStdioConfig(map[string]string{"VERSION": "1"}), "echo", 0)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	second, err := callHelper(t, s, helperStdioConfig(map[string]string{"VERSION": "2"}), "echo", 0)
+	if err != nil {
+		t.Fatalf("Call with new config: %v", err)
+	}
+	if second.PID == first.PID {
+		t.Error("changed config should start a new process")
+	}
+}
+
+func TestStdioSupervisor_Stop(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{MinBackoff: time.Minute})
+	defer s.Close()
+	cfg := helperStdioConfig(nil)
+
+	first, err := callHelper(t, s, cfg, "echo", 0)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	s.Stop("helper")
+	// Stopping is not a crash, so the next call starts without backoff.
+	res, err := callHelper(t, s, cfg, "echo", 0)
+	if err != nil {
+		t.Fatalf("Call after Stop: %v", err)
+	}
+	if res.PID == first.PID {
+		t.Error("expected a new process after Stop")
+	}
+
+	s.Close()
+	if _, err := callHelper(t, s, cfg, "echo", 0); !errors.Is(err, ErrStdioUnavailable) {
+		t.Errorf("Call after Close error = %v, want ErrStdioUnavailable", err)
+	}
+}
+
+func TestStdioSupervisor_WaitForStartHonorsContext(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+	cfg := helperStdioConfig(map[string]string{"HELPER_INIT_DELAY_MS": "500"})
+
+	started := make(chan error, 1)
+	go func() {
+		_, err := callHelper(t, s, cfg, "echo", 0)
+		started <- err
+	}()
+
+	// A second caller gives up on its own deadline instead of waiting for
+	// the handshake to finish.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	_, err := s.Call(ctx, "helper", cfg, "tools/call", map[string]interface{}{"name": "echo"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if waited := time.Since(begin); waited > 300*time.Millisecond {
+		t.Errorf("canceled caller waited %s for the start", waited)
+	}
+
+	if err := <-started; err != nil {
+		t.Fatalf("Call waiting for the start: %v", err)
+	}
+}
+
+func TestStdioSupervisor_OversizedLineKillsProcess(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{MinBackoff: time.Millisecond})
+	defer s.Close()
+	cfg := helperStdioConfig(nil)
+
+	first, err := callHelper(t, s, cfg, "echo", 0)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := callHelper(t, s, cfg, "oversized", 0)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrStdioUnavailable) {
+			t.Fatalf("error = %v, want ErrStdioUnavailable", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("call did not fail after the process wrote an oversized line")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	again, err := callHelper(t, s, cfg, "echo", 0)
+	if err != nil {
+		t.Fatalf("Call after restart: %v", err)
+	}
+	if again.PID == first.PID {
+		t.Errorf("call ran in the old pid %d, want a restarted process", first.PID)
+	}
+}
+
+func TestStdioSupervisor_StalledStdinHonorsContext(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+	cfg := helperStdioConfig(nil)
+
+	call := func(tool string, payload string) (time.Duration, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := s.Call(ctx, "helper", cfg, "tools/call", map[string]interface{}{
+			"name": tool, "arguments": map[string]string{"payload": payload},
+		})
+		return time.Since(start), err
+	}
+	if _, err := call("deaf", ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("deaf call error = %v, want context.DeadlineExceeded", err)
+	}
+	// The child no longer reads stdin, so this request fills the pipe.
+	big := strings.Repeat("x", 1<<20)
+	for _, payload := range []string{big, ""} {
+		took, err := call("echo", payload)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+		}
+		if took > time.Second {
+			t.Errorf("call took %s despite a 100ms deadline", took)
+		}
+	}
+}
+
+func TestStdioSupervisor_ContextCanceled(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+	cfg := helperStdioConfig(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := s.Call(ctx, "helper", cfg, "tools/call", map[string]interface{}{
+		"name": "slow", "arguments": map[string]int{"delay_ms": 2000},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	// The process keeps serving other calls.
+	if _, err := callHelper(t, s, cfg, "echo", 0); err != nil {
+		t.Fatalf("Call after cancel: %v", err)
+	}
+}
+
+func TestProxyClient_StdioDisabled(t *testing.T) {
+	pc := NewProxyClient(ProxyClientConfig{Timeout: time.Second})
+	cfg := helperStdioConfig(nil)
+	_, err := pc.Forward(context.Background(), ProxyRequest{ServerLabel: "helper", ToolName: "echo", Stdio: &cfg})
+	if !errors.Is(err, ErrStdioUnavailable) {
+		t.Errorf("error = %v, want ErrStdioUnavailable", err)
+	}
+}
+
+func TestProxyClient_StdioForward(t *testing.T) {
+	s := NewStdioSupervisor(StdioSupervisorConfig{})
+	defer s.Close()
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, Stdio: s})
+	cfg := helperStdioConfig(nil)
+
+	resp, err := pc.Forward(context.Background(), ProxyRequest{
+		ServerLabel: "helper", ToolName: "echo", Arguments: json.RawMessage(`{}`), Stdio: &cfg,
+	})
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	if resp.StatusCode != 200 || resp.ResponseSize == 0 {
+		t.Errorf("resp = %+v, want status 200 with a body", resp)
+	}
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTLSConfig is returned when an upstream server's client certificate,
+// key or CA bundle cannot be loaded.
+var ErrInvalidTLSConfig = errors.New("invalid upstream TLS configuration")
+
+// TLSConfig holds the PEM-encoded TLS material used to reach an upstream server.
+// ClientCert and ClientKey enable mutual TLS and must be set together; CABundle
+// replaces the system roots when verifying the upstream certificate.
+type TLSConfig struct {
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	CABundle   string `json:"ca_bundle,omitempty"`
+}
+
+// IsZero reports whether no TLS material is configured.
+func (c TLSConfig) IsZero() bool {
+	return c.ClientCert == "" && c.ClientKey == "" && c.CABundle == ""
+}
+
+// fingerprint identifies the TLS material so a cached transport can be
+// rebuilt when a server's certificate or CA bundle is rotated.
+func (c TLSConfig) fingerprint() string {
+	h := sha256.New()
+	for _, part := range []string{c.ClientCert, c.ClientKey, c.CABundle} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// BuildTLSClientConfig parses the PEM material into a *tls.Config.
+func BuildTLSClientConfig(c TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, fmt.Errorf("%w: client certificate and key must be provided together", ErrInvalidTLSConfig)
+		}
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTLSConfig, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CABundle)) {
+			return nil, fmt.Errorf("%w: CA bundle contains no PEM certificates", ErrInvalidTLSConfig)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newClientCert returns a self-signed client certificate and key as PEM.
+func newClientCert(t *testing.T, cn string) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+// newMTLSServer starts a TLS upstream that requires a client certificate signed by clientCA.
+// It reports the common name of the presented certificate in the X-Client-CN response header.
+func newMTLSServer(t *testing.T, clientCA string) (*httptest.Server, string) {
+	t.Helper()
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(clientCA)) {
+		t.Fatal("invalid client CA")
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Client-CN", r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	return srv, serverCA
+}
+
+func TestProxyClient_MutualTLS(t *testing.T) {
+	cert, key := newClientCert(t, "gateway")
+	srv, serverCA := newMTLSServer(t, cert)
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	resp, err := pc.Forward(context.Background(), ProxyRequest{
+		ServerEndpoint: srv.URL,
+		ServerLabel:    "secure",
+		ToolName:       "test",
+		Arguments:      json.RawMessage(`{}`),
+		AuthType:       "none",
+		TLS:            &TLSConfig{ClientCert: cert, ClientKey: key, CABundle: serverCA},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestProxyClient_MutualTLSWithoutClientCertFails(t *testing.T) {
+	cert, _ := newClientCert(t, "gateway")
+	srv, serverCA := newMTLSServer(t, cert)
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	_, err := pc.Forward(context.Background(), ProxyRequest{
+		ServerEndpoint: srv.URL,
+		ServerLabel:    "secure",
+		ToolName:       "test",
+		Arguments:      json.RawMessage(`{}`),
+		AuthType:       "none",
+		TLS:            &TLSConfig{CABundle: serverCA},
+	})
+	if err == nil {
+		t.Fatal("expected handshake failure without client certificate")
+	}
+}
+
+func TestProxyClient_TLSTransportCachedAndRebuiltOnRotation(t *testing.T) {
+	certA, keyA := newClientCert(t, "cert-a")
+	certB, keyB := newClientCert(t, "cert-b")
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM([]byte(certA))
+	pool.AppendCertsFromPEM([]byte(certB))
+
+	var lastCN string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastCN = r.TLS.PeerCertificates[0].Subject.CommonName
+		w.Write([]byte(`{}`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	forward := func(cert, key string) {
+		t.Helper()
+		_, err := pc.Forward(context.Background(), ProxyRequest{
+			ServerEndpoint: srv.URL, ServerLabel: "secure", ToolName: "test",
+			Arguments: json.RawMessage(`{}`), AuthType: "none",
+			TLS: &TLSConfig{ClientCert: cert, ClientKey: key, CABundle: serverCA},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	forward(certA, keyA)
+	cachedReq := ProxyRequest{ServerLabel: "secure", TLS: &TLSConfig{ClientCert: certA, ClientKey: keyA, CABundle: serverCA}}
+	first, _ := pc.clientForServer(cachedReq)
+	forward(certA, keyA)
+	second, _ := pc.clientForServer(cachedReq)
+	if first != second {
+		t.Error("expected the per-server client to be reused for unchanged TLS material")
+	}
+	if lastCN != "cert-a" {
+		t.Errorf("presented cert = %q, want cert-a", lastCN)
+	}
+
+	forward(certB, keyB)
+	if lastCN != "cert-b" {
+		t.Errorf("presented cert after rotation = %q, want cert-b", lastCN)
+	}
+}
+
+func TestProxyClient_InvalidTLSConfig(t *testing.T) {
+	cert, _ := newClientCert(t, "gateway")
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	_, err := pc.Forward(context.Background(), ProxyRequest{
+		ServerEndpoint: "https://127.0.0.1:1",
+		ServerLabel:    "broken",
+		ToolName:       "test",
+		Arguments:      json.RawMessage(`{}`),
+		AuthType:       "none",
+		TLS:            &TLSConfig{ClientCert: cert},
+	})
+	if !errors.Is(err, ErrInvalidTLSConfig) {
+		t.Fatalf("error = %v, want ErrInvalidTLSConfig", err)
+	}
+}
+
+func TestProxyClient_CustomHeaders(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	_, err := pc.Forward(context.Background(), ProxyRequest{
+		ServerEndpoint: upstream.URL,
+		ToolName:       "test",
+		Arguments:      json.RawMessage(`{}`),
+		AuthType:       "bearer",
+		AuthCredential: "tok",
+		Headers: map[string]string{
+			"X-Tenant-ID":   "acme",
+			"Content-Type":  "text/plain",
+			"Authorization": "Bearer spoofed",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("X-Tenant-ID") != "acme" {
+		t.Errorf("X-Tenant-ID = %q, want acme", got.Get("X-Tenant-ID"))
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got.Get("Content-Type"))
+	}
+	if got.Get("Authorization") != "Bearer tok" {
+		t.Errorf("Authorization = %q, want Bearer tok", got.Get("Authorization"))
+	}
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// ErrTokenFetch is returned when an OAuth2 access token cannot be obtained
+// from an upstream server's token endpoint.
+var ErrTokenFetch = errors.New("oauth2 token fetch failed")
+
+// OAuth2ClientCredentials holds the client-credentials grant settings for an upstream server.
+type OAuth2ClientCredentials struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	TokenURL     string   `json:"token_url"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// cacheKey identifies a credential set. The secret is part of the key so that
+// rotating it on the MCP server record forces a fresh token.
+func (c OAuth2ClientCredentials) cacheKey() string {
+	h := sha256.New()
+	for _, part := range []string{c.TokenURL, c.ClientID, c.ClientSecret, strings.Join(c.Scopes, " ")} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type cachedToken struct {
+	key   string
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// TokenCache fetches, caches and refreshes OAuth2 client-credentials access tokens.
+// Tokens are refreshed shortly before expiry; concurrent callers for the same
+// credentials share a single fetch. It holds one token per upstream server,
+// replaced when the server's credentials change, so rotated credentials do
+// not accumulate.
+type TokenCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedToken // Keyed by server
+}
+
+// NewTokenCache creates an empty token cache.
+func NewTokenCache() *TokenCache {
+	return &TokenCache{
+		entries: make(map[string]*cachedToken),
+	}
+}
+
+// Token returns a valid access token for a server's creds, fetching a new one
+// through client if the cached token is missing, about to expire or for
+// other credentials. client should be the server's own, so the fetch is
+// subject to its egress policy and TLS settings.
+func (tc *TokenCache) Token(ctx context.Context, client *http.Client, server string, creds OAuth2ClientCredentials) (*oauth2.Token, error) {
+	e := tc.entry(server, creds.cacheKey())
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.token.Valid() {
+		return e.token, nil
+	}
+
+	cfg := clientcredentials.Config{
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		TokenURL:     creds.TokenURL,
+		Scopes:       creds.Scopes,
+	}
+	tok, err := cfg.Token(context.WithValue(ctx, oauth2.HTTPClient, client))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenFetch, err)
+	}
+	e.token = tok
+	return tok, nil
+}
+
+// Invalidate drops a server's cached token for creds so the next call
+// fetches a fresh one.
+func (tc *TokenCache) Invalidate(server string, creds OAuth2ClientCredentials) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if e, ok := tc.entries[server]; ok && e.key == creds.cacheKey() {
+		delete(tc.entries, server)
+	}
+}
+
+func (tc *TokenCache) entry(server, key string) *cachedToken {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	e, ok := tc.entries[server]
+	if !ok || e.key != key {
+		e = &cachedToken{key: key}
+		tc.entries[server] = e
+	}
+	return e
+}
@@ -0,0 +1,234 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer returns a token endpoint that issues sequential tokens and counts requests.
+func newTokenServer(t *testing.T, expiresIn int, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		if got := r.Form.Get("grant_type"); got != "client_credentials" {
+			t.Errorf("grant_type = %q, want client_credentials", got)
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token-" + string(rune('0'+n)),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestTokenCache_FetchesAndCaches(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600, http.StatusOK)
+	tc := NewTokenCache()
+	creds := OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL, Scopes: []string{"tools.read"}}
+
+	for i := 0; i < 3; i++ {
+		tok, err := tc.Token(context.Background(), srv.Client(), "srv", creds)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tok.AccessToken != "token-1" {
+			t.Errorf("access token = %q, want token-1", tok.AccessToken)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("token endpoint called %d times, want 1", got)
+	}
+}
+
+func TestTokenCache_RefreshesExpiredToken(t *testing.T) {
+	// expires_in below the 10s expiry delta makes every cached token stale immediately.
+	srv, calls := newTokenServer(t, 1, http.StatusOK)
+	tc := NewTokenCache()
+	creds := OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL}
+
+	if _, err := tc.Token(context.Background(), srv.Client(), "srv", creds); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	tok, err := tc.Token(context.Background(), srv.Client(), "srv", creds)
+	if err != nil {
+		t.Fatalf("second fetch: %v", err)
+	}
+	if tok.AccessToken != "token-2" {
+		t.Errorf("access token = %q, want token-2", tok.AccessToken)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want 2", got)
+	}
+}
+
+func TestTokenCache_InvalidateForcesRefetch(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600, http.StatusOK)
+	tc := NewTokenCache()
+	creds := OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL}
+
+	tc.Token(context.Background(), srv.Client(), "srv", creds)
+	tc.Invalidate("srv", creds)
+	tc.Token(context.Background(), srv.Client(), "srv", creds)
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want 2", got)
+	}
+}
+
+func TestTokenCache_SecretRotationUsesNewEntry(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600, http.StatusOK)
+	tc := NewTokenCache()
+
+	tc.Token(context.Background(), srv.Client(), "srv", OAuth2ClientCredentials{ClientID: "id", ClientSecret: "old", TokenURL: srv.URL})
+	tc.Token(context.Background(), srv.Client(), "srv", OAuth2ClientCredentials{ClientID: "id", ClientSecret: "new", TokenURL: srv.URL})
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want 2", got)
+	}
+	if got := len(tc.entries); got != 1 {
+		t.Errorf("cache holds %d tokens, want the old credentials' token replaced", got)
+	}
+}
+
+func TestTokenCache_KeepsOneTokenPerServer(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600, http.StatusOK)
+	tc := NewTokenCache()
+	creds := OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL}
+
+	a, _ := tc.Token(context.Background(), srv.Client(), "a", creds)
+	b, _ := tc.Token(context.Background(), srv.Client(), "b", creds)
+	if a.AccessToken == b.AccessToken || calls.Load() != 2 {
+		t.Errorf("servers should not share a token, got %q and %q", a.AccessToken, b.AccessToken)
+	}
+
+	// Invalidating a server's previous credentials keeps its current token.
+	tc.Token(context.Background(), srv.Client(), "a", OAuth2ClientCredentials{ClientID: "id", ClientSecret: "new", TokenURL: srv.URL})
+	tc.Invalidate("a", creds)
+	tc.Token(context.Background(), srv.Client(), "a", OAuth2ClientCredentials{ClientID: "id", ClientSecret: "new", TokenURL: srv.URL})
+	if got := calls.Load(); got != 3 {
+		t.Errorf("token endpoint called %d times, want 3", got)
+	}
+}
+
+func TestTokenCache_ConcurrentCallersShareFetch(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600, http.StatusOK)
+	tc := NewTokenCache()
+	creds := OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tc.Token(context.Background(), srv.Client(), "srv", creds); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("token endpoint called %d times, want 1", got)
+	}
+}
+
+func TestTokenCache_FetchErrorWrapsErrTokenFetch(t *testing.T) {
+	srv, _ := newTokenServer(t, 3600, http.StatusUnauthorized)
+	tc := NewTokenCache()
+
+	_, err := tc.Token(context.Background(), srv.Client(), "srv", OAuth2ClientCredentials{ClientID: "id", ClientSecret: "bad", TokenURL: srv.URL})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !errors.Is(err, ErrTokenFetch) {
+		t.Errorf("error = %v, want ErrTokenFetch", err)
+	}
+}
+
+func TestProxyClient_OAuth2ClientCredentials(t *testing.T) {
+	tokenSrv, tokenCalls := newTokenServer(t, 3600, http.StatusOK)
+
+	var gotAuth []string
+	var mu sync.Mutex
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		n := len(gotAuth)
+		mu.Unlock()
+		if n == 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	defer upstream.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	req := ProxyRequest{
+		ServerEndpoint: upstream.URL,
+		ToolName:       "test",
+		Arguments:      json.RawMessage(`{}`),
+		AuthType:       "oauth2_client_credentials",
+		OAuth2:         &OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: tokenSrv.URL},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := pc.Forward(context.Background(), req); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	want := []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}
+	for i, w := range want {
+		if gotAuth[i] != w {
+			t.Errorf("call %d Authorization = %q, want %q", i+1, gotAuth[i], w)
+		}
+	}
+	if got := tokenCalls.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want 2 (refetch after upstream 401)", got)
+	}
+}
+
+func TestProxyClient_OAuth2TokenFailure(t *testing.T) {
+	tokenSrv, _ := newTokenServer(t, 3600, http.StatusInternalServerError)
+	upstreamCalled := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalled = true
+	}))
+	defer upstream.Close()
+
+	pc := NewProxyClient(ProxyClientConfig{Timeout: 5 * time.Second, MaxIdleConnsPerHost: 2, AllowPrivateIPs: true})
+	_, err := pc.Forward(context.Background(), ProxyRequest{
+		ServerEndpoint: upstream.URL,
+		ToolName:       "test",
+		Arguments:      json.RawMessage(`{}`),
+		AuthType:       "oauth2_client_credentials",
+		OAuth2:         &OAuth2ClientCredentials{ClientID: "id", ClientSecret: "secret", TokenURL: tokenSrv.URL},
+	})
+	if !errors.Is(err, ErrTokenFetch) {
+		t.Fatalf("error = %v, want ErrTokenFetch", err)
+	}
+	if upstreamCalled {
+		t.Error("upstream must not be called when token fetch fails")
+	}
+}
@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// maxTraceStateLen is the longest tracestate propagated; the W3C Trace
+// Context spec requires vendors to propagate at least 512 characters.
+const maxTraceStateLen = 512
+
+// maxRequestIDLen bounds the request ID propagated to upstreams.
+const maxRequestIDLen = 200
+
+// Keys of the trace context in a tools/call request's params._meta.
+const (
+	MetaTraceParent = "traceparent"
+	MetaTraceState  = "tracestate"
+	MetaRequestID   = "agentic-registry/request_id"
+)
+
+// TraceContext is the W3C trace context and request ID of a gateway call,
+// propagated to upstream servers.
+type TraceContext struct {
+	TraceParent string
+	TraceState  string
+	RequestID   string
+}
+
+// NewTraceContext continues the caller's trace when traceparent is valid
+// and starts a new trace otherwise. A continued trace keeps the caller's
+// trace ID and flags with a new parent ID for the gateway's span, in a
+// version-00 traceparent. An invalid traceparent also discards tracestate,
+// as the spec requires.
+func NewTraceContext(traceparent, tracestate, requestID string) TraceContext {
+	tc := TraceContext{RequestID: requestID}
+	if len(tc.RequestID) > maxRequestIDLen {
+		tc.RequestID = tc.RequestID[:maxRequestIDLen]
+	}
+	if validTraceParent(traceparent) {
+		tc.TraceParent = "00-" + traceparent[3:35] + "-" + randomHex(8) + "-" + traceparent[53:55]
+		if len(tracestate) <= maxTraceStateLen {
+			tc.TraceState = tracestate
+		}
+		return tc
+	}
+	tc.TraceParent = "00-" + randomHex(16) + "-" + randomHex(8) + "-01"
+	return tc
+}
+
+// TraceID returns the trace ID of the trace context, or "" when it has none.
+func (tc TraceContext) TraceID() string {
+	if !validTraceParent(tc.TraceParent) {
+		return ""
+	}
+	return tc.TraceParent[3:35]
+}
+
+// Meta returns the trace context as the entries of a JSON-RPC request's
+// params._meta.
+func (tc TraceContext) Meta() map[string]string {
+	meta := make(map[string]string, 3)
+	if tc.TraceParent != "" {
+		meta[MetaTraceParent] = tc.TraceParent
+	}
+	if tc.TraceState != "" {
+		meta[MetaTraceState] = tc.TraceState
+	}
+	if tc.RequestID != "" {
+		meta[MetaRequestID] = tc.RequestID
+	}
+	return meta
+}
+
+// validTraceParent reports whether s is a version-00 traceparent, or a
+// later version with a compatible prefix, with lowercase hex fields and
+// non-zero IDs.
+func validTraceParent(s string) bool {
+	if len(s) < 55 || (len(s) > 55 && s[55] != '-') {
+		return false
+	}
+	parts := strings.Split(s[:55], "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return false
+	}
+	for _, p := range parts {
+		if !isHex(p) {
+			return false
+		}
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(s) != 55) {
+		return false
+	}
+	return strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != ""
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type traceContextKey struct{}
+
+// ContextWithTrace returns a copy of ctx carrying the trace context.
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceFromContext returns the trace context carried by ctx.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
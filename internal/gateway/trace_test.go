@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewTraceContext(t *testing.T) {
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name        string
+		traceparent string
+		tracestate  string
+		wantKept    bool
+	}{
+		{"valid", parent, "vendor=abc", true},
+		{"future version with extra fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "vendor=abc", true},
+		{"missing", "", "vendor=abc", false},
+		{"uppercase", strings.ToUpper(parent), "vendor=abc", false},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false},
+		{"zero parent ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false},
+		{"version 00 with extra fields", parent + "-extra", "", false},
+		{"malformed", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := NewTraceContext(tt.traceparent, tt.tracestate, "req-1")
+			if tc.RequestID != "req-1" {
+				t.Errorf("RequestID = %q, want req-1", tc.RequestID)
+			}
+			if tt.wantKept {
+				want := "00-4bf92f3577b34da6a3ce929d0e0e4736-"
+				if !strings.HasPrefix(tc.TraceParent, want) || !strings.HasSuffix(tc.TraceParent, "-01") ||
+					len(tc.TraceParent) != 55 || tc.TraceParent[36:52] == "00f067aa0ba902b7" || tc.TraceState != tt.tracestate {
+					t.Errorf("got %+v, want the caller's trace with a new parent ID", tc)
+				}
+				if !validTraceParent(tc.TraceParent) {
+					t.Errorf("TraceParent = %q is not valid", tc.TraceParent)
+				}
+				if tc.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+					t.Errorf("TraceID = %q", tc.TraceID())
+				}
+				return
+			}
+			if tc.TraceParent == tt.traceparent || !validTraceParent(tc.TraceParent) {
+				t.Errorf("TraceParent = %q, want a new valid traceparent", tc.TraceParent)
+			}
+			if tc.TraceState != "" {
+				t.Errorf("TraceState = %q, want it discarded with an invalid traceparent", tc.TraceState)
+			}
+		})
+	}
+}
+
+func TestNewTraceContext_Bounds(t *testing.T) {
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tc := NewTraceContext(parent, strings.Repeat("a", maxTraceStateLen+1), strings.Repeat("r", 500))
+	if tc.TraceState != "" {
+		t.Error("oversized tracestate should be dropped")
+	}
+	if len(tc.RequestID) != maxRequestIDLen {
+		t.Errorf("request ID length = %d, want %d", len(tc.RequestID), maxRequestIDLen)
+	}
+	if a, b := NewTraceContext(parent, "", ""), NewTraceContext(parent, "", ""); a.TraceParent == b.TraceParent {
+		t.Error("continued traces should have distinct parent IDs")
+	}
+	if tc := NewTraceContext("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "", ""); !strings.HasSuffix(tc.TraceParent, "-00") {
+		t.Errorf("TraceParent = %q, want the caller's flags kept", tc.TraceParent)
+	}
+	if a, b := NewTraceContext("", "", ""), NewTraceContext("", "", ""); a.TraceID() == b.TraceID() {
+		t.Error("new traces should have distinct trace IDs")
+	}
+}
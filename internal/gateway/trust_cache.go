@@ -0,0 +1,195 @@
+package gateway
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTrustCacheEntries bounds the cached policies. The cache is flushed when
+// it is full, so a burst of distinct agents or workspaces cannot grow memory
+// without limit.
+const maxTrustCacheEntries = 10000
+
+// Trust change scopes.
+const (
+	TrustScopeRules    = "trust_rule"    // Key is the workspace ID
+	TrustScopeDefaults = "trust_default" // Key is empty
+	TrustScopeAgent    = "agent"         // Key is the agent ID
+)
+
+// TrustChange identifies trust policy that changed. An empty Key covers the
+// whole scope and an empty Scope covers all policy.
+type TrustChange struct {
+	Scope string
+	Key   string
+}
+
+// TrustChangeSource delivers trust policy changes made on any replica.
+// Listen blocks until ctx is canceled or the subscription is lost, calling
+// ready once changes are being delivered.
+type TrustChangeSource interface {
+	Listen(ctx context.Context, ready func(), onChange func(TrustChange)) error
+}
+
+// trustPattern is one compiled pattern of a trust policy.
+type trustPattern struct {
+	pattern string
+	tier    TrustTier
+}
+
+// trustPolicy is an ordered list of patterns; the first match wins.
+type trustPolicy []trustPattern
+
+func (p trustPolicy) match(toolName string) (TrustTier, bool) {
+	for _, tp := range p {
+		if matchGlob(tp.pattern, toolName) {
+			return tp.tier, true
+		}
+	}
+	return "", false
+}
+
+// compileOverrides orders agent overrides by pattern so matching is
+// deterministic.
+func compileOverrides(overrides map[string]string) trustPolicy {
+	p := make(trustPolicy, 0, len(overrides))
+	for pattern, tier := range overrides {
+		p = append(p, trustPattern{pattern: pattern, tier: normalizeTrustTier(tier)})
+	}
+	sort.Slice(p, func(i, j int) bool { return p[i].pattern < p[j].pattern })
+	return p
+}
+
+func compileRules(rules []TrustRuleRecord) trustPolicy {
+	p := make(trustPolicy, len(rules))
+	for i, r := range rules {
+		p[i] = trustPattern{pattern: r.ToolPattern, tier: normalizeTrustTier(r.Tier)}
+	}
+	return p
+}
+
+func compileDefaults(defaults []TrustDefaultRecord) trustPolicy {
+	p := make(trustPolicy, len(defaults))
+	for i, d := range defaults {
+		p[i] = trustPattern{pattern: d.ToolPattern, tier: normalizeTrustTier(d.Tier)}
+	}
+	return p
+}
+
+type cachedTrustPolicy struct {
+	policy   trustPolicy
+	loadedAt time.Time
+}
+
+// TrustPolicyCache keeps compiled trust policy in memory between tool calls.
+//
+// The cache only serves entries while it is subscribed to policy changes.
+// Before the subscription is established, and whenever it is lost, every
+// lookup reads through to the providers, so a replica that may have missed
+// a change never classifies with stale policy. Load errors are never cached.
+// Entries also expire after ttl as a backstop.
+type TrustPolicyCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	active  bool
+	gen     uint64
+	entries map[string]cachedTrustPolicy
+}
+
+// NewTrustPolicyCache creates an inactive cache whose entries expire after ttl.
+func NewTrustPolicyCache(ttl time.Duration) *TrustPolicyCache {
+	return &TrustPolicyCache{ttl: ttl, entries: make(map[string]cachedTrustPolicy)}
+}
+
+// Activate starts serving cached policy. It is called once changes are being
+// delivered, and flushes anything loaded before then.
+func (c *TrustPolicyCache) Activate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+	c.active = true
+}
+
+// Deactivate stops serving cached policy, for when changes may be missed.
+func (c *TrustPolicyCache) Deactivate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+	c.active = false
+}
+
+// Invalidate drops the cached policy covered by a change.
+func (c *TrustPolicyCache) Invalidate(change TrustChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	switch {
+	case change.Scope == "":
+		c.flushLocked()
+	case change.Key == "":
+		prefix := change.Scope + ":"
+		for key := range c.entries {
+			if strings.HasPrefix(key, prefix) {
+				delete(c.entries, key)
+			}
+		}
+	default:
+		delete(c.entries, change.Scope+":"+change.Key)
+	}
+}
+
+func (c *TrustPolicyCache) flushLocked() {
+	c.gen++
+	c.entries = make(map[string]cachedTrustPolicy)
+}
+
+// get returns the cached policy for key, loading it on a miss. A policy
+// loaded while an invalidation happened is returned but not stored.
+func (c *TrustPolicyCache) get(key string, load func() (trustPolicy, error)) (trustPolicy, error) {
+	c.mu.Lock()
+	active, gen := c.active, c.gen
+	if e, ok := c.entries[key]; ok && active && time.Since(e.loadedAt) < c.ttl {
+		c.mu.Unlock()
+		return e.policy, nil
+	}
+	c.mu.Unlock()
+
+	policy, err := load()
+	if err != nil || !active {
+		return policy, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active && c.gen == gen {
+		if len(c.entries) >= maxTrustCacheEntries {
+			c.entries = make(map[string]cachedTrustPolicy)
+		}
+		c.entries[key] = cachedTrustPolicy{policy: policy, loadedAt: time.Now()}
+	}
+	return policy, nil
+}
+
+// RunTrustPolicyInvalidation applies trust policy changes from src to cache
+// until ctx is canceled, resubscribing after retry when the subscription is
+// lost. The cache is only active while subscribed.
+func RunTrustPolicyInvalidation(ctx context.Context, cache *TrustPolicyCache, src TrustChangeSource, retry time.Duration) {
+	for {
+		err := src.Listen(ctx, cache.Activate, cache.Invalidate)
+		cache.Deactivate()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("gateway: trust policy subscription lost, reading through until resubscribed: %v", err)
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type countingRuleProvider struct {
+	mockTrustRuleProvider
+	calls atomic.Int32
+}
+
+func (m *countingRuleProvider) List(ctx context.Context, workspaceID uuid.UUID) ([]TrustRuleRecord, error) {
+	m.calls.Add(1)
+	return m.mockTrustRuleProvider.List(ctx, workspaceID)
+}
+
+type countingDefaultProvider struct {
+	mockTrustDefaultProvider
+	calls atomic.Int32
+}
+
+func (m *countingDefaultProvider) List(ctx context.Context) ([]TrustDefaultRecord, error) {
+	m.calls.Add(1)
+	return m.mockTrustDefaultProvider.List(ctx)
+}
+
+func newCachedClassifier(rules *countingRuleProvider, defaults *countingDefaultProvider, ttl time.Duration) (*TrustClassifier, *TrustPolicyCache) {
+	cache := NewTrustPolicyCache(ttl)
+	cache.Activate()
+	tc := NewTrustClassifier(rules, defaults, nil)
+	tc.SetCache(cache)
+	return tc, cache
+}
+
+func mustClassify(t *testing.T, tc *TrustClassifier, input ClassifyInput) TrustTier {
+	t.Helper()
+	tier, err := tc.Classify(context.Background(), input)
+	if err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+	return tier
+}
+
+func TestTrustPolicyCache_ServesCachedPolicy(t *testing.T) {
+	wsID := uuid.New()
+	rules := &countingRuleProvider{mockTrustRuleProvider: mockTrustRuleProvider{rules: []TrustRuleRecord{{ToolPattern: "deploy_*", Tier: "block"}}}}
+	defaults := &countingDefaultProvider{mockTrustDefaultProvider: mockTrustDefaultProvider{defaults: []TrustDefaultRecord{{ToolPattern: "*", Tier: "review"}}}}
+	tc, _ := newCachedClassifier(rules, defaults, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if tier := mustClassify(t, tc, ClassifyInput{ToolName: "deploy_app", WorkspaceID: &wsID}); tier != TrustBlock {
+			t.Fatalf("tier = %s, want block", tier)
+		}
+		if tier := mustClassify(t, tc, ClassifyInput{ToolName: "read_file", WorkspaceID: &wsID}); tier != TrustReview {
+			t.Fatalf("tier = %s, want review", tier)
+		}
+	}
+	if rules.calls.Load() != 1 || defaults.calls.Load() != 1 {
+		t.Errorf("expected one load per policy, got rules=%d defaults=%d", rules.calls.Load(), defaults.calls.Load())
+	}
+}
+
+func TestTrustPolicyCache_Invalidate(t *testing.T) {
+	wsA, wsB := uuid.New(), uuid.New()
+	rules := &countingRuleProvider{mockTrustRuleProvider: mockTrustRuleProvider{rules: []TrustRuleRecord{{ToolPattern: "deploy_*", Tier: "auto"}}}}
+	defaults := &countingDefaultProvider{}
+	tc, cache := newCachedClassifier(rules, defaults, time.Minute)
+
+	mustClassify(t, tc, ClassifyInput{ToolName: "deploy_app", WorkspaceID: &wsA})
+	mustClassify(t, tc, ClassifyInput{ToolName: "deploy_app", WorkspaceID: &wsB})
+
+	rules.rules = []TrustRuleRecord{{ToolPattern: "deploy_*", Tier: "block"}}
+	cache.Invalidate(TrustChange{Scope: TrustScopeRules, Key: wsA.String()})
+
+	if tier := mustClassify(t, tc, ClassifyInput{ToolName: "deploy_app", WorkspaceID: &wsA}); tier != TrustBlock {
+		t.Errorf("invalidated workspace tier = %s, want block", tier)
+	}
+	if tier := mustClassify(t, tc, ClassifyInput{ToolName: "deploy_app", WorkspaceID: &wsB}); tier != TrustAuto {
+		t.Errorf("other workspace should stay cached, got %s", tier)
+	}
+
+	cache.Invalidate(TrustChange{})
+	if tier := mustClassify(t, tc, ClassifyInput{ToolName: "deploy_app", WorkspaceID: &wsB}); tier != TrustBlock {
+		t.Errorf("full invalidation tier = %s, want block", tier)
+	}
+}
+
+func TestTrustPolicyCache_ReadsThroughWhenInactive(t *testing.T) {
+	defaults := &countingDefaultProvider{}
+	tc, cache := newCachedClassifier(&countingRuleProvider{}, defaults, time.Minute)
+	cache.Deactivate()
+
+	mustClassify(t, tc, ClassifyInput{ToolName: "read_file"})
+	mustClassify(t, tc, ClassifyInput{ToolName: "read_file"})
+	if defaults.calls.Load() != 2 {
+		t.Errorf("inactive cache should read through, got %d loads", defaults.calls.Load())
+	}
+}
+
+func TestTrustPolicyCache_DoesNotCacheErrors(t *testing.T) {
+	defaults := &countingDefaultProvider{mockTrustDefaultProvider: mockTrustDefaultProvider{err: errors.New("db down")}}
+	tc, _ := newCachedClassifier(&countingRuleProvider{}, defaults, time.Minute)
+
+	if _, err := tc.Classify(context.Background(), ClassifyInput{ToolName: "read_file"}); err == nil {
+		t.Fatal("expected load error to fail classification")
+	}
+	defaults.err = nil
+	defaults.defaults = []TrustDefaultRecord{{ToolPattern: "*", Tier: "block"}}
+	if tier := mustClassify(t, tc, ClassifyInput{ToolName: "read_file"}); tier != TrustBlock {
+		t.Errorf("tier = %s, want block after recovery", tier)
+	}
+}
+
+func TestTrustPolicyCache_ExpiresAfterTTL(t *testing.T) {
+	defaults := &countingDefaultProvider{}
+	tc, _ := newCachedClassifier(&countingRuleProvider{}, defaults, 10*time.Millisecond)
+
+	mustClassify(t, tc, ClassifyInput{ToolName: "read_file"})
+	time.Sleep(20 * time.Millisecond)
+	mustClassify(t, tc, ClassifyInput{ToolName: "read_file"})
+	if defaults.calls.Load() != 2 {
+		t.Errorf("expired entry should reload, got %d loads", defaults.calls.Load())
+	}
+}
+
+// scriptedChangeSource delivers changes and then loses its subscription.
+type scriptedChangeSource struct {
+	changes []TrustChange
+	listens atomic.Int32
+	active  chan bool
+	cache   *TrustPolicyCache
+}
+
+func (s *scriptedChangeSource) Listen(ctx context.Context, ready func(), onChange func(TrustChange)) error {
+	s.listens.Add(1)
+	ready()
+	s.active <- s.cache.active
+	for _, c := range s.changes {
+		onChange(c)
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRunTrustPolicyInvalidation(t *testing.T) {
+	cache := NewTrustPolicyCache(time.Minute)
+	src := &scriptedChangeSource{changes: []TrustChange{{Scope: TrustScopeDefaults}}, active: make(chan bool, 1), cache: cache}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunTrustPolicyInvalidation(ctx, cache, src, time.Millisecond)
+		close(done)
+	}()
+
+	if !<-src.active {
+		t.Error("cache should be active once subscribed")
+	}
+	cancel()
+	<-done
+	if cache.active {
+		t.Error("cache should be inactive after the subscription ends")
+	}
+}
@@ -49,6 +49,7 @@ type TrustClassifier struct {
 	rules    TrustRuleProvider
 	defaults TrustDefaultProvider
 	agents   AgentTrustProvider
+	cache    *TrustPolicyCache
 }
 
 // NewTrustClassifier creates a new trust classifier.
@@ -60,6 +61,11 @@ func NewTrustClassifier(rules TrustRuleProvider, defaults TrustDefaultProvider,
 	}
 }
 
+// SetCache keeps compiled policy in cache between classifications.
+func (tc *TrustClassifier) SetCache(cache *TrustPolicyCache) {
+	tc.cache = cache
+}
+
 // ClassifyInput contains context for trust classification.
 type ClassifyInput struct {
 	ToolName    string
@@ -75,40 +81,43 @@ type ClassifyInput struct {
 func (tc *TrustClassifier) Classify(ctx context.Context, input ClassifyInput) (TrustTier, error) {
 	// Level 1: Agent overrides
 	if input.AgentID != "" && tc.agents != nil {
-		overrides, err := tc.agents.GetTrustOverrides(ctx, input.AgentID)
+		policy, err := tc.policy(TrustScopeAgent+":"+input.AgentID, func() (trustPolicy, error) {
+			overrides, err := tc.agents.GetTrustOverrides(ctx, input.AgentID)
+			return compileOverrides(overrides), err
+		})
 		if err != nil {
 			return "", err
 		}
-		for pattern, tier := range overrides {
-			if matchGlob(pattern, input.ToolName) {
-				return normalizeTrustTier(tier), nil
-			}
+		if tier, ok := policy.match(input.ToolName); ok {
+			return tier, nil
 		}
 	}
 
 	// Level 2: Workspace rules
 	if input.WorkspaceID != nil && tc.rules != nil {
-		rules, err := tc.rules.List(ctx, *input.WorkspaceID)
+		policy, err := tc.policy(TrustScopeRules+":"+input.WorkspaceID.String(), func() (trustPolicy, error) {
+			rules, err := tc.rules.List(ctx, *input.WorkspaceID)
+			return compileRules(rules), err
+		})
 		if err != nil {
 			return "", err
 		}
-		for _, rule := range rules {
-			if matchGlob(rule.ToolPattern, input.ToolName) {
-				return normalizeTrustTier(rule.Tier), nil
-			}
+		if tier, ok := policy.match(input.ToolName); ok {
+			return tier, nil
 		}
 	}
 
 	// Level 3: System defaults (ordered by priority)
 	if tc.defaults != nil {
-		defaults, err := tc.defaults.List(ctx)
+		policy, err := tc.policy(TrustScopeDefaults+":", func() (trustPolicy, error) {
+			defaults, err := tc.defaults.List(ctx)
+			return compileDefaults(defaults), err
+		})
 		if err != nil {
 			return "", err
 		}
-		for _, def := range defaults {
-			if matchGlob(def.ToolPattern, input.ToolName) {
-				return normalizeTrustTier(def.Tier), nil
-			}
+		if tier, ok := policy.match(input.ToolName); ok {
+			return tier, nil
 		}
 	}
 
@@ -116,6 +125,14 @@ func (tc *TrustClassifier) Classify(ctx context.Context, input ClassifyInput) (T
 	return TrustAuto, nil
 }
 
+// policy loads a compiled policy, through the cache when one is set.
+func (tc *TrustClassifier) policy(key string, load func() (trustPolicy, error)) (trustPolicy, error) {
+	if tc.cache == nil {
+		return load()
+	}
+	return tc.cache.get(key, load)
+}
+
 // normalizeTrustTier validates and normalizes a trust tier string.
 // Returns TrustBlock for invalid/unknown values (safest default).
 func normalizeTrustTier(tier string) TrustTier {
@@ -0,0 +1,266 @@
+package gateway
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// UsageBucketWidth is the time resolution of gateway usage metrics.
+const UsageBucketWidth = time.Minute
+
+// maxPendingUsageBuckets bounds the buckets held in memory between flushes,
+// so an unreachable metrics store cannot grow memory without limit.
+const maxPendingUsageBuckets = 100000
+
+// LatencyBoundsMS are the upper bounds, in milliseconds, of the latency
+// histogram buckets. Histograms have one more bucket for slower calls.
+var LatencyBoundsMS = []int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+// UsageSample is one gateway tool call.
+type UsageSample struct {
+	At          time.Time
+	Server      string
+	Tool        string
+	AgentID     string
+	WorkspaceID string
+	CallerID    string
+	Outcome     string
+	Forwarded   bool // The call reached an upstream; only these have a latency
+	Latency     time.Duration
+	BytesIn     int
+	BytesOut    int
+	CostMicros  int64 // Metered cost of the call, zero when not charged
+}
+
+// UsageBucket aggregates the calls sharing a time bucket and dimensions.
+type UsageBucket struct {
+	Start        time.Time
+	Server       string
+	Tool         string
+	AgentID      string
+	WorkspaceID  string
+	CallerID     string
+	Outcome      string
+	Calls        int64
+	LatencySumMS int64
+	Latency      []int64 // Counts per LatencyBoundsMS bucket, then overflow
+	BytesIn      int64
+	BytesOut     int64
+	CostMicros   int64
+}
+
+type usageKey struct {
+	start                                                 int64
+	server, tool, agentID, workspaceID, callerID, outcome string
+}
+
+func (b *UsageBucket) key() usageKey {
+	return usageKey{b.Start.Unix(), b.Server, b.Tool, b.AgentID, b.WorkspaceID, b.CallerID, b.Outcome}
+}
+
+func (b *UsageBucket) merge(o *UsageBucket) {
+	b.Calls += o.Calls
+	b.LatencySumMS += o.LatencySumMS
+	b.BytesIn += o.BytesIn
+	b.BytesOut += o.BytesOut
+	b.CostMicros += o.CostMicros
+	for i := range b.Latency {
+		if i < len(o.Latency) {
+			b.Latency[i] += o.Latency[i]
+		}
+	}
+}
+
+// IsErrorOutcome reports whether a call outcome counts as an integration
+// failure. Calls rejected by policy (trust, rate limits, argument
+// validation) are not errors.
+func IsErrorOutcome(outcome string) bool {
+	switch outcome {
+	case "upstream_5xx", "upstream_error", "token_error", "circuit_open", "egress_denied":
+		return true
+	}
+	return false
+}
+
+// UsageRecorder aggregates gateway calls into per-minute buckets in memory
+// until they are drained to a UsageSink. It is safe for concurrent use.
+type UsageRecorder struct {
+	mu      sync.Mutex
+	buckets map[usageKey]*UsageBucket
+	dropped int
+}
+
+// NewUsageRecorder creates an empty usage recorder.
+func NewUsageRecorder() *UsageRecorder {
+	return &UsageRecorder{buckets: make(map[usageKey]*UsageBucket)}
+}
+
+// Record adds a call to its bucket.
+func (u *UsageRecorder) Record(s UsageSample) {
+	if s.At.IsZero() {
+		s.At = time.Now()
+	}
+	b := &UsageBucket{
+		Start:       s.At.UTC().Truncate(UsageBucketWidth),
+		Server:      truncate(s.Server, 100),
+		Tool:        truncate(s.Tool, 200),
+		AgentID:     truncate(s.AgentID, 100),
+		WorkspaceID: s.WorkspaceID,
+		CallerID:    s.CallerID,
+		Outcome:     s.Outcome,
+		Calls:       1,
+		Latency:     make([]int64, len(LatencyBoundsMS)+1),
+		BytesIn:     int64(s.BytesIn),
+		BytesOut:    int64(s.BytesOut),
+		CostMicros:  s.CostMicros,
+	}
+	if s.Forwarded {
+		ms := s.Latency.Milliseconds()
+		b.LatencySumMS = ms
+		b.Latency[latencyBucket(ms)] = 1
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.addLocked(b)
+}
+
+func (u *UsageRecorder) addLocked(b *UsageBucket) {
+	k := b.key()
+	if existing, ok := u.buckets[k]; ok {
+		existing.merge(b)
+		return
+	}
+	if len(u.buckets) >= maxPendingUsageBuckets {
+		u.dropped++
+		return
+	}
+	u.buckets[k] = b
+}
+
+// Drain returns and clears the pending buckets.
+func (u *UsageRecorder) Drain() []UsageBucket {
+	u.mu.Lock()
+	pending, dropped := u.buckets, u.dropped
+	u.buckets = make(map[usageKey]*UsageBucket)
+	u.dropped = 0
+	u.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("gateway: dropped %d usage samples; metrics store is not keeping up", dropped)
+	}
+	out := make([]UsageBucket, 0, len(pending))
+	for _, b := range pending {
+		out = append(out, *b)
+	}
+	return out
+}
+
+// Restore merges buckets back after a failed flush so they are retried.
+func (u *UsageRecorder) Restore(buckets []UsageBucket) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for i := range buckets {
+		b := buckets[i]
+		u.addLocked(&b)
+	}
+}
+
+// UsageSink persists aggregated usage buckets, adding them to any stored
+// counts for the same bucket.
+type UsageSink interface {
+	SaveUsage(ctx context.Context, buckets []UsageBucket) error
+}
+
+// FlushUsage drains the recorder into sink, restoring the buckets on failure.
+func FlushUsage(ctx context.Context, u *UsageRecorder, sink UsageSink) error {
+	buckets := u.Drain()
+	if len(buckets) == 0 {
+		return nil
+	}
+	if err := sink.SaveUsage(ctx, buckets); err != nil {
+		u.Restore(buckets)
+		return err
+	}
+	return nil
+}
+
+// RunUsageFlush periodically flushes usage until ctx is canceled, then
+// flushes once more.
+func RunUsageFlush(ctx context.Context, u *UsageRecorder, sink UsageSink, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := FlushUsage(ctx, u, sink); err != nil {
+				log.Printf("gateway: usage flush failed: %v", err)
+			}
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := FlushUsage(flushCtx, u, sink); err != nil {
+				log.Printf("gateway: final usage flush failed: %v", err)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// latencyBucket returns the histogram bucket index for a latency.
+func latencyBucket(ms int64) int {
+	for i, bound := range LatencyBoundsMS {
+		if ms <= bound {
+			return i
+		}
+	}
+	return len(LatencyBoundsMS)
+}
+
+// LatencyQuantile estimates the q-quantile (0–1) in milliseconds from a
+// latency histogram, interpolating linearly within the bucket. Calls in the
+// overflow bucket report the largest bound. It returns 0 for an empty
+// histogram.
+func LatencyQuantile(hist []int64, q float64) float64 {
+	var total int64
+	for _, c := range hist {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var cum int64
+	for i, c := range hist {
+		if c == 0 {
+			continue
+		}
+		if float64(cum+c) >= rank {
+			if i >= len(LatencyBoundsMS) {
+				return float64(LatencyBoundsMS[len(LatencyBoundsMS)-1])
+			}
+			var lower float64
+			if i > 0 {
+				lower = float64(LatencyBoundsMS[i-1])
+			}
+			upper := float64(LatencyBoundsMS[i])
+			return lower + (upper-lower)*(rank-float64(cum))/float64(c)
+		}
+		cum += c
+	}
+	return float64(LatencyBoundsMS[len(LatencyBoundsMS)-1])
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
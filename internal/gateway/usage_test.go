@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestUsageRecorder_AggregatesPerMinute(t *testing.T) {
+	u := NewUsageRecorder()
+	at := time.Date(2026, 2, 15, 12, 0, 10, 0, time.UTC)
+
+	u.Record(UsageSample{At: at, Server: "gh", Tool: "search", Outcome: "success", Forwarded: true, Latency: 40 * time.Millisecond, BytesIn: 10, BytesOut: 100})
+	u.Record(UsageSample{At: at.Add(30 * time.Second), Server: "gh", Tool: "search", Outcome: "success", Forwarded: true, Latency: 3 * time.Second, BytesIn: 5, BytesOut: 50})
+	u.Record(UsageSample{At: at.Add(time.Minute), Server: "gh", Tool: "search", Outcome: "success", Forwarded: true, Latency: time.Millisecond})
+	u.Record(UsageSample{At: at, Server: "gh", Tool: "search", Outcome: "rate_limited"})
+
+	buckets := u.Drain()
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(buckets))
+	}
+	var first *UsageBucket
+	for i := range buckets {
+		b := &buckets[i]
+		if b.Start.Equal(at.Truncate(time.Minute)) && b.Outcome == "success" {
+			first = b
+		}
+		if b.Outcome == "rate_limited" && b.LatencySumMS != 0 {
+			t.Errorf("calls that were not forwarded should have no latency, got %d", b.LatencySumMS)
+		}
+	}
+	if first == nil {
+		t.Fatal("missing first-minute success bucket")
+	}
+	if first.Calls != 2 || first.BytesIn != 15 || first.BytesOut != 150 || first.LatencySumMS != 3040 {
+		t.Errorf("unexpected bucket: %+v", first)
+	}
+	if first.Latency[latencyBucket(40)] != 1 || first.Latency[latencyBucket(3000)] != 1 {
+		t.Errorf("unexpected histogram: %v", first.Latency)
+	}
+
+	if len(u.Drain()) != 0 {
+		t.Error("drain should clear pending buckets")
+	}
+}
+
+type failingUsageSink struct {
+	err   error
+	saved []UsageBucket
+}
+
+func (s *failingUsageSink) SaveUsage(_ context.Context, buckets []UsageBucket) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.saved = append(s.saved, buckets...)
+	return nil
+}
+
+func TestFlushUsage_RestoresOnFailure(t *testing.T) {
+	u := NewUsageRecorder()
+	u.Record(UsageSample{Server: "gh", Tool: "search", Outcome: "success"})
+
+	sink := &failingUsageSink{err: errors.New("db down")}
+	if err := FlushUsage(context.Background(), u, sink); err == nil {
+		t.Fatal("expected flush error")
+	}
+
+	u.Record(UsageSample{Server: "gh", Tool: "search", Outcome: "success"})
+	sink.err = nil
+	if err := FlushUsage(context.Background(), u, sink); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(sink.saved) != 1 || sink.saved[0].Calls != 2 {
+		t.Errorf("expected restored calls to be merged, got %+v", sink.saved)
+	}
+}
+
+func TestLatencyQuantile(t *testing.T) {
+	hist := make([]int64, len(LatencyBoundsMS)+1)
+	if got := LatencyQuantile(hist, 0.5); got != 0 {
+		t.Errorf("empty histogram: got %v, want 0", got)
+	}
+
+	// 100 calls between 50ms and 100ms.
+	hist[latencyBucket(80)] = 100
+	if got := LatencyQuantile(hist, 0.5); math.Abs(got-75) > 0.01 {
+		t.Errorf("p50 = %v, want 75", got)
+	}
+	if got := LatencyQuantile(hist, 0.99); math.Abs(got-99.5) > 0.01 {
+		t.Errorf("p99 = %v, want 99.5", got)
+	}
+
+	// Calls slower than the largest bound report that bound.
+	hist[len(LatencyBoundsMS)] = 100
+	if got := LatencyQuantile(hist, 0.99); got != 30000 {
+		t.Errorf("overflow p99 = %v, want 30000", got)
+	}
+}
+
+func TestIsErrorOutcome(t *testing.T) {
+	for _, o := range []string{"upstream_5xx", "upstream_error", "circuit_open"} {
+		if !IsErrorOutcome(o) {
+			t.Errorf("%s should be an error", o)
+		}
+	}
+	for _, o := range []string{"success", "cache_hit", "trust_denied", "rate_limited", "invalid_arguments"} {
+		if IsErrorOutcome(o) {
+			t.Errorf("%s should not be an error", o)
+		}
+	}
+}
+
+func TestUsageRecorder_TruncatesDimensions(t *testing.T) {
+	u := NewUsageRecorder()
+	u.Record(UsageSample{Server: "gh", Tool: strings.Repeat("é", 150), Outcome: "success"})
+
+	b := u.Drain()[0]
+	if len(b.Tool) > 200 || !utf8.ValidString(b.Tool) {
+		t.Errorf("tool name not truncated to valid UTF-8: %d bytes", len(b.Tool))
+	}
+}
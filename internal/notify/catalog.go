@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"slices"
+	"strings"
+)
+
+// EventType describes an event type the registry dispatches. Events of
+// Workspace types carry a workspace_id when their resource belongs to a
+// workspace, so subscriptions scoped to that workspace receive them.
+type EventType struct {
+	Type         string `json:"type"`
+	ResourceType string `json:"resource_type"`
+	Workspace    bool   `json:"workspace"`
+	Description  string `json:"description"`
+}
+
+// Catalog lists every event type dispatched to webhook subscriptions.
+// Subscription filters are validated against it.
+var Catalog = []EventType{
+	{Type: "agent.created", ResourceType: "agent", Description: "Agent created"},
+	{Type: "agent.updated", ResourceType: "agent", Description: "Agent modified"},
+	{Type: "agent.deleted", ResourceType: "agent", Description: "Agent removed"},
+	{Type: "agent.rolled_back", ResourceType: "agent", Description: "Agent rolled back to an earlier version"},
+	{Type: "prompt.created", ResourceType: "prompt", Description: "Prompt version created"},
+	{Type: "prompt.activated", ResourceType: "prompt", Description: "Prompt version set as active"},
+	{Type: "prompt.rolled_back", ResourceType: "prompt", Description: "Prompt rolled back to an earlier version"},
+	{Type: "mcp_server.created", ResourceType: "mcp_server", Description: "MCP server registered"},
+	{Type: "mcp_server.updated", ResourceType: "mcp_server", Description: "MCP server modified"},
+	{Type: "mcp_server.deleted", ResourceType: "mcp_server", Description: "MCP server removed"},
+	{Type: "mcp_server.circuit_opened", ResourceType: "mcp_circuit", Description: "Gateway circuit opened, by failures or an admin"},
+	{Type: "mcp_server.circuit_half_opened", ResourceType: "mcp_circuit", Description: "Gateway circuit admitted a probe after its open duration"},
+	{Type: "mcp_server.circuit_closed", ResourceType: "mcp_circuit", Description: "Gateway circuit closed, by a successful probe or an admin"},
+	{Type: "model_config.updated", ResourceType: "model_config", Workspace: true, Description: "Global or workspace model configuration modified"},
+	{Type: "model_endpoint.created", ResourceType: "model_endpoint", Workspace: true, Description: "Model endpoint created"},
+	{Type: "model_endpoint.updated", ResourceType: "model_endpoint", Workspace: true, Description: "Model endpoint modified"},
+	{Type: "model_endpoint.deleted", ResourceType: "model_endpoint", Workspace: true, Description: "Model endpoint removed"},
+	{Type: "model_endpoint_version.created", ResourceType: "model_endpoint_version", Workspace: true, Description: "Model endpoint config version created"},
+	{Type: "model_endpoint_version.activated", ResourceType: "model_endpoint_version", Workspace: true, Description: "Model endpoint config version set as active"},
+	{Type: "trust_default.changed", ResourceType: "trust_default", Description: "Default trust classification modified"},
+	{Type: "trust_rule.changed", ResourceType: "trust_rule", Workspace: true, Description: "Workspace trust rule created or removed"},
+	{Type: "workspace_settings.updated", ResourceType: "workspace_settings", Workspace: true, Description: "Workspace settings modified"},
+	{Type: "workspace_member.added", ResourceType: "workspace_member", Workspace: true, Description: "Member added to a workspace"},
+	{Type: "workspace_member.removed", ResourceType: "workspace_member", Workspace: true, Description: "Member removed from a workspace"},
+	{Type: "workspace_budget.updated", ResourceType: "workspace_budget", Workspace: true, Description: "Workspace budget set or modified"},
+	{Type: "workspace_budget.deleted", ResourceType: "workspace_budget", Workspace: true, Description: "Workspace budget removed"},
+	{Type: "workspace.budget_threshold_reached", ResourceType: "workspace_budget", Workspace: true, Description: "Workspace gateway spend crossed a soft budget threshold"},
+	{Type: "workspace.budget_exhausted", ResourceType: "workspace_budget", Workspace: true, Description: "Workspace gateway spend reached its monthly limit"},
+}
+
+// MatchEventType reports whether eventType matches pattern: an exact event
+// type, "*" for every type, or a dot-terminated prefix followed by "*",
+// such as "agent.*".
+func MatchEventType(pattern, eventType string) bool {
+	if pattern == "*" || pattern == eventType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasSuffix(prefix, ".") && strings.HasPrefix(eventType, prefix)
+}
+
+// CatalogMatches returns the catalog entries whose type matches pattern.
+func CatalogMatches(pattern string) []EventType {
+	var matched []EventType
+	for _, et := range Catalog {
+		if MatchEventType(pattern, et.Type) {
+			matched = append(matched, et)
+		}
+	}
+	return matched
+}
+
+// matches reports whether a subscription receives event.
+func (s Subscription) matches(event Event) bool {
+	if !slices.ContainsFunc(s.Events, func(p string) bool { return MatchEventType(p, event.Type) }) {
+		return false
+	}
+	if len(s.ResourceTypes) > 0 && !slices.Contains(s.ResourceTypes, event.ResourceType) {
+		return false
+	}
+	if len(s.ResourceIDs) > 0 && !slices.Contains(s.ResourceIDs, event.ResourceID) {
+		return false
+	}
+	return s.WorkspaceID == "" || s.WorkspaceID == event.WorkspaceID
+}
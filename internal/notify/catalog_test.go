@@ -0,0 +1,68 @@
+package notify
+
+import "testing"
+
+func TestMatchEventType(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventType string
+		want      bool
+	}{
+		{"agent.created", "agent.created", true},
+		{"agent.created", "agent.updated", false},
+		{"agent.*", "agent.updated", true},
+		{"agent.*", "agent_version.created", false},
+		{"mcp_server.*", "mcp_server.circuit_opened", true},
+		{"workspace.*", "workspace_budget.updated", false},
+		{"*", "prompt.activated", true},
+		{"agent*", "agent.created", false},
+		{"agent.c*", "agent.created", false},
+	}
+	for _, tt := range tests {
+		if got := MatchEventType(tt.pattern, tt.eventType); got != tt.want {
+			t.Errorf("MatchEventType(%q, %q) = %v, want %v", tt.pattern, tt.eventType, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogMatches(t *testing.T) {
+	if got := len(CatalogMatches("agent.*")); got != 4 {
+		t.Errorf("agent.* matched %d event types, want 4", got)
+	}
+	if got := len(CatalogMatches("*")); got != len(Catalog) {
+		t.Errorf("* matched %d event types, want %d", got, len(Catalog))
+	}
+	if got := CatalogMatches("nothing.*"); got != nil {
+		t.Errorf("nothing.* matched %v", got)
+	}
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	agentUpdated := Event{Type: "agent.updated", ResourceType: "agent", ResourceID: "a1"}
+	budgetUpdated := Event{Type: "workspace_budget.updated", ResourceType: "workspace_budget", ResourceID: "w1", WorkspaceID: "w1"}
+
+	tests := []struct {
+		name  string
+		sub   Subscription
+		event Event
+		want  bool
+	}{
+		{"exact type", Subscription{Events: []string{"agent.updated"}}, agentUpdated, true},
+		{"other type", Subscription{Events: []string{"agent.created"}}, agentUpdated, false},
+		{"wildcard", Subscription{Events: []string{"agent.*"}}, agentUpdated, true},
+		{"resource type", Subscription{Events: []string{"*"}, ResourceTypes: []string{"agent"}}, agentUpdated, true},
+		{"other resource type", Subscription{Events: []string{"*"}, ResourceTypes: []string{"prompt"}}, agentUpdated, false},
+		{"resource ID", Subscription{Events: []string{"agent.*"}, ResourceIDs: []string{"a0", "a1"}}, agentUpdated, true},
+		{"other resource ID", Subscription{Events: []string{"agent.*"}, ResourceIDs: []string{"a2"}}, agentUpdated, false},
+		{"workspace", Subscription{Events: []string{"*"}, WorkspaceID: "w1"}, budgetUpdated, true},
+		{"other workspace", Subscription{Events: []string{"*"}, WorkspaceID: "w2"}, budgetUpdated, false},
+		{"workspace scope skips unscoped events", Subscription{Events: []string{"*"}, WorkspaceID: "w1"}, agentUpdated, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.matches(tt.event); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,72 +7,180 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/pkg/webhook"
 )
 
-// Event represents a webhook event to dispatch.
+// Event represents a webhook event to dispatch. WorkspaceID is set by events
+// of resources that belong to a workspace. Details holds facts specific to
+// the event type, such as the budget threshold crossed, and is sent to every
+// subscription. Change is set by events of versioned resources; its details
+// are sent only to subscriptions whose payload options ask for them.
 type Event struct {
-	Type         string `json:"event"`
-	ResourceType string `json:"resource_type"`
-	ResourceID   string `json:"resource_id"`
-	Timestamp    string `json:"timestamp"`
-	Actor        string `json:"actor"`
+	Type         string         `json:"event"`
+	ResourceType string         `json:"resource_type"`
+	ResourceID   string         `json:"resource_id"`
+	WorkspaceID  string         `json:"workspace_id,omitempty"`
+	Timestamp    string         `json:"timestamp"`
+	Actor        string         `json:"actor"`
+	Details      map[string]any `json:"details,omitempty"`
+	Change       *Change        `json:"-"`
 }
 
+// Signature schemes. Deliveries are signed with SignatureHMACSHA256 unless
+// a subscription opts into SignatureStandardWebhooks.
+const (
+	SignatureHMACSHA256       = "hmac_sha256"
+	SignatureStandardWebhooks = "standard_webhooks"
+)
+
 // Subscription holds webhook subscription data for delivery.
+// PreviousSecret is set while a rotated-out secret is still valid. Events
+// holds event type patterns; ResourceTypes, ResourceIDs and WorkspaceID
+// narrow the events received further when set.
 type Subscription struct {
-	ID     uuid.UUID
-	URL    string
-	Secret string
-	Events []string
+	ID              uuid.UUID
+	URL             string
+	Secret          string
+	PreviousSecret  string
+	SignatureScheme string
+	Payload         PayloadOptions
+	Events          []string
+	ResourceTypes   []string
+	ResourceIDs     []string
+	WorkspaceID     string
 }
 
-// SubscriptionLoader loads active webhook subscriptions.
+// SubscriptionLoader loads webhook subscriptions.
 type SubscriptionLoader interface {
 	ListActive(ctx context.Context) ([]Subscription, error)
+	Get(ctx context.Context, id uuid.UUID) (*Subscription, error)
+}
+
+// OutboxEntry is an event queued for delivery to one subscription.
+type OutboxEntry struct {
+	SubscriptionID uuid.UUID
+	EventType      string
+	Payload        json.RawMessage
+	ContentType    string
+}
+
+// Delivery is an outbox entry claimed for delivery. MessageID identifies the
+// event sent: redeliveries share the ID of the original delivery. Attempts
+// counts this attempt.
+type Delivery struct {
+	ID              uuid.UUID
+	MessageID       uuid.UUID
+	SubscriptionID  uuid.UUID
+	URL             string
+	Secret          string
+	PreviousSecret  string
+	SignatureScheme string
+	EventType       string
+	Payload         json.RawMessage
+	ContentType     string
+	Attempts        int
+}
+
+// Attempt is the outcome of one delivery attempt. StatusCode is 0 when no
+// response was received.
+type Attempt struct {
+	DeliveryID   uuid.UUID
+	Attempt      int
+	StatusCode   int
+	Latency      time.Duration
+	ResponseBody string
+	Error        string
+}
+
+// Outbox is the durable queue of pending deliveries. Enqueue must join the
+// transaction started by InTx when ctx carries one. Claim must not return
+// an entry another caller holds until its lease expires.
+type Outbox interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Enqueue(ctx context.Context, entries []OutboxEntry) error
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID) error
+	MarkRetry(ctx context.Context, id uuid.UUID, next time.Time, lastErr string) error
+	MarkFailed(ctx context.Context, id uuid.UUID, lastErr string) error
+	RecordAttempt(ctx context.Context, attempt Attempt) error
 }
 
 // EventDispatcher is the interface handlers use to dispatch events.
 type EventDispatcher interface {
-	Dispatch(event Event)
+	Dispatch(ctx context.Context, event Event) error
+}
+
+// Transactor is implemented by dispatchers whose Dispatch can join the
+// transaction of the mutation that produced the event.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // Config holds dispatcher configuration.
 type Config struct {
-	Workers    int
-	MaxRetries int
-	Timeout    time.Duration
+	Workers      int
+	MaxRetries   int
+	Timeout      time.Duration
+	PollInterval time.Duration
 }
 
-// Dispatcher manages async webhook delivery via a worker pool.
+// claimBatch is how many deliveries a worker claims at a time.
+const claimBatch = 10
+
+// maxBackoff caps the delay between delivery attempts.
+const maxBackoff = time.Hour
+
+// maxResponseBody bounds the response body kept with a delivery attempt.
+const maxResponseBody = 4096
+
+// Dispatcher queues webhook deliveries in a durable outbox and delivers
+// them from a worker pool. Workers of every replica share the outbox, and
+// an entry is delivered at least once.
 type Dispatcher struct {
-	loader     SubscriptionLoader
-	client     *http.Client
-	eventCh    chan Event
-	workers    int
-	maxRetries int
-	wg         sync.WaitGroup
-	stopped    atomic.Bool
+	loader       SubscriptionLoader
+	outbox       Outbox
+	client       *http.Client
+	workers      int
+	maxRetries   int
+	lease        time.Duration
+	pollInterval time.Duration
+	wake         chan struct{}
+	stop         chan struct{}
+	stopOnce     sync.Once
+	wg           sync.WaitGroup
 }
 
-// NewDispatcher creates a dispatcher with a buffered channel (size 1000).
-func NewDispatcher(loader SubscriptionLoader, cfg Config) *Dispatcher {
+// NewDispatcher creates a dispatcher that queues deliveries in outbox.
+func NewDispatcher(loader SubscriptionLoader, outbox Outbox, cfg Config) *Dispatcher {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
 	return &Dispatcher{
-		loader:     loader,
-		client:     &http.Client{Timeout: cfg.Timeout},
-		eventCh:    make(chan Event, 1000),
-		workers:    cfg.Workers,
-		maxRetries: cfg.MaxRetries,
+		loader:       loader,
+		outbox:       outbox,
+		client:       &http.Client{Timeout: cfg.Timeout},
+		workers:      cfg.Workers,
+		maxRetries:   cfg.MaxRetries,
+		lease:        cfg.Timeout + 30*time.Second,
+		pollInterval: pollInterval,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
 	}
 }
 
-// Start launches worker goroutines that consume from the event channel.
+// Start launches worker goroutines that deliver due outbox entries.
 func (d *Dispatcher) Start() {
 	for i := 0; i < d.workers; i++ {
 		d.wg.Add(1)
@@ -80,96 +188,285 @@ func (d *Dispatcher) Start() {
 	}
 }
 
-// Stop signals workers to drain remaining events and exit.
+// Stop delivers the entries that are due and waits for workers to exit.
+// Entries waiting for a retry stay in the outbox for the next start.
 func (d *Dispatcher) Stop() {
-	d.stopped.Store(true)
-	close(d.eventCh)
+	d.stopOnce.Do(func() { close(d.stop) })
 	d.wg.Wait()
 }
 
-// Dispatch performs a non-blocking send to the event channel.
-func (d *Dispatcher) Dispatch(event Event) {
-	if d.stopped.Load() {
-		return
+// InTx runs fn in an outbox transaction, so events dispatched with the
+// context passed to fn are queued only if the transaction commits.
+func (d *Dispatcher) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := d.outbox.InTx(ctx, fn); err != nil {
+		return err
 	}
+	d.notify()
+	return nil
+}
+
+// Dispatch queues an event for every active subscription whose filters
+// match it. It joins the transaction ctx carries, if any.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) error {
+	subs, err := d.loader.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("loading webhook subscriptions: %w", err)
+	}
+
+	payloads := newPayloadBuilder(event, uuid.NewString())
+	var entries []OutboxEntry
+	for _, sub := range subs {
+		if !sub.matches(event) {
+			continue
+		}
+		body, contentType, err := payloads.build(sub.Payload)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, OutboxEntry{
+			SubscriptionID: sub.ID,
+			EventType:      event.Type,
+			Payload:        body,
+			ContentType:    contentType,
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := d.outbox.Enqueue(ctx, entries); err != nil {
+		return err
+	}
+	d.notify()
+	return nil
+}
+
+// PingEvent is the event type of test deliveries sent by Ping.
+const PingEvent = "webhook.ping"
+
+// Ping sends a signed test event to a subscription, paused or not, and
+// returns the outcome. The delivery is not queued, retried or recorded.
+func (d *Dispatcher) Ping(ctx context.Context, id uuid.UUID, actor string) (*Attempt, error) {
+	sub, err := d.loader.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	dlID := uuid.New()
+	body, contentType, err := newPayloadBuilder(Event{
+		Type:         PingEvent,
+		ResourceType: "webhook_subscription",
+		ResourceID:   id.String(),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		Actor:        actor,
+	}, dlID.String()).build(sub.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	dl := Delivery{
+		ID:              dlID,
+		SubscriptionID:  sub.ID,
+		URL:             sub.URL,
+		Secret:          sub.Secret,
+		PreviousSecret:  sub.PreviousSecret,
+		SignatureScheme: sub.SignatureScheme,
+		EventType:       PingEvent,
+		Payload:         body,
+		ContentType:     contentType,
+		Attempts:        1,
+	}
+	start := time.Now()
+	status, respBody, err := d.post(ctx, dl)
+	attempt := &Attempt{
+		DeliveryID:   dl.ID,
+		Attempt:      1,
+		StatusCode:   status,
+		Latency:      time.Since(start),
+		ResponseBody: respBody,
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt, nil
+}
+
+// notify wakes one idle worker.
+func (d *Dispatcher) notify() {
 	select {
-	case d.eventCh <- event:
+	case d.wake <- struct{}{}:
 	default:
-		log.Printf("webhook event channel full, dropping event: %s", event.Type)
 	}
 }
 
 func (d *Dispatcher) worker() {
 	defer d.wg.Done()
-	for event := range d.eventCh {
-		d.processEvent(event)
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	for {
+		if n := d.deliverDue(); n == claimBatch {
+			continue
+		}
+		select {
+		case <-d.stop:
+			for d.deliverDue() == claimBatch {
+			}
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue claims and delivers a batch of due entries and returns how
+// many were claimed.
+func (d *Dispatcher) deliverDue() int {
+	ctx := context.Background()
+	deliveries, err := d.outbox.Claim(ctx, claimBatch, d.lease)
+	if err != nil {
+		log.Printf("failed to claim webhook deliveries: %v", err)
+		return 0
 	}
+	for _, dl := range deliveries {
+		d.deliver(ctx, dl)
+	}
+	return len(deliveries)
 }
 
-func (d *Dispatcher) processEvent(event Event) {
-	subs, err := d.loader.ListActive(context.Background())
+func (d *Dispatcher) deliver(ctx context.Context, dl Delivery) {
+	start := time.Now()
+	status, body, err := d.post(ctx, dl)
+	attempt := Attempt{
+		DeliveryID:   dl.ID,
+		Attempt:      dl.Attempts,
+		StatusCode:   status,
+		Latency:      time.Since(start),
+		ResponseBody: body,
+	}
 	if err != nil {
-		log.Printf("failed to load webhook subscriptions: %v", err)
+		attempt.Error = err.Error()
+	}
+	if err := d.outbox.RecordAttempt(ctx, attempt); err != nil {
+		log.Printf("webhook delivery %s: %v", dl.ID, err)
+	}
+
+	if err == nil {
+		if err := d.outbox.MarkDelivered(ctx, dl.ID); err != nil {
+			log.Printf("webhook delivery %s: %v", dl.ID, err)
+		}
 		return
 	}
 
-	for _, sub := range subs {
-		if matchesEvent(sub.Events, event.Type) {
-			d.deliver(sub, event)
+	log.Printf("webhook delivery %s attempt %d failed: url=%s event=%s err=%v",
+		dl.ID, dl.Attempts, dl.URL, dl.EventType, err)
+	if dl.Attempts > d.maxRetries {
+		log.Printf("webhook delivery %s exhausted all retries: url=%s event=%s",
+			dl.ID, dl.URL, dl.EventType)
+		if err := d.outbox.MarkFailed(ctx, dl.ID, err.Error()); err != nil {
+			log.Printf("webhook delivery %s: %v", dl.ID, err)
 		}
+		return
+	}
+	next := time.Now().Add(backoff(dl.Attempts))
+	if err := d.outbox.MarkRetry(ctx, dl.ID, next, err.Error()); err != nil {
+		log.Printf("webhook delivery %s: %v", dl.ID, err)
 	}
 }
 
-func matchesEvent(events []string, eventType string) bool {
-	for _, e := range events {
-		if e == eventType {
-			return true
-		}
+// backoff returns the delay after the given failed attempt: 1s, 2s, 4s, ...
+func backoff(attempt int) time.Duration {
+	if attempt > 12 {
+		return maxBackoff
 	}
-	return false
+	return min(time.Duration(1<<uint(attempt-1))*time.Second, maxBackoff)
 }
 
-func (d *Dispatcher) deliver(sub Subscription, event Event) {
-	body, err := json.Marshal(event)
+// post sends one delivery attempt and returns the response status and the
+// start of its body. X-Registry-Delivery carries the message ID, which is the
+// same for retries and redeliveries of an event, so receivers can discard
+// duplicates.
+func (d *Dispatcher) post(ctx context.Context, dl Delivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", dl.URL, bytes.NewReader(dl.Payload))
 	if err != nil {
-		log.Printf("failed to marshal webhook event: %v", err)
-		return
+		return 0, "", fmt.Errorf("creating request: %w", err)
+	}
+	contentType := dl.ContentType
+	if contentType == "" {
+		contentType = contentTypeJSON
 	}
-	deliveryID := uuid.New().String()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Webhook-Event", dl.EventType)
+	req.Header.Set("X-Registry-Delivery", dl.messageID())
 
-	for attempt := 0; attempt <= d.maxRetries; attempt++ {
-		if attempt > 0 {
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
-			time.Sleep(backoff)
+	if dl.SignatureScheme == SignatureStandardWebhooks {
+		if err := signStandardWebhooks(req.Header, dl, time.Now()); err != nil {
+			return 0, "", err
 		}
+	} else if sig := signatureHeader(dl.Secret, dl.PreviousSecret, dl.Payload); sig != "" {
+		req.Header.Set("X-Webhook-Signature", sig)
+	}
 
-		req, err := http.NewRequest("POST", sub.URL, bytes.NewReader(body))
-		if err != nil {
-			log.Printf("failed to create webhook request: %v", err)
-			return
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Webhook-Event", event.Type)
-		req.Header.Set("X-Registry-Delivery", deliveryID)
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body := responseSnippet(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, body, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, body, nil
+}
 
-		if sub.Secret != "" {
-			sig := computeHMAC(sub.Secret, body)
-			req.Header.Set("X-Webhook-Signature", "sha256="+sig)
+// messageID returns the delivery's message ID, falling back to its own ID.
+func (dl Delivery) messageID() string {
+	if dl.MessageID == uuid.Nil {
+		return dl.ID.String()
+	}
+	return dl.MessageID.String()
+}
+
+// responseSnippet reads up to maxResponseBody bytes of r as valid UTF-8
+// text without NUL characters, which Postgres text columns reject.
+func responseSnippet(r io.Reader) string {
+	b, _ := io.ReadAll(io.LimitReader(r, maxResponseBody))
+	return strings.ToValidUTF8(strings.ReplaceAll(string(b), "\x00", ""), "")
+}
+
+// signatureHeader returns the X-Webhook-Signature value for body: the
+// signature with secret, followed during a secret rotation grace period by
+// the signature with the previous secret.
+func signatureHeader(secret, previous string, body []byte) string {
+	var sigs []string
+	for _, s := range []string{secret, previous} {
+		if s != "" {
+			sigs = append(sigs, "sha256="+computeHMAC(s, body))
 		}
+	}
+	return strings.Join(sigs, ",")
+}
 
-		resp, err := d.client.Do(req)
-		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			resp.Body.Close()
-			return
+// signStandardWebhooks sets the Standard Webhooks headers of a delivery
+// attempt made at ts. webhook-id is the message ID, which stays the same
+// across retries and redeliveries as the specification requires. The signed
+// content includes it and ts, so receivers can reject replays.
+func signStandardWebhooks(h http.Header, dl Delivery, ts time.Time) error {
+	id := dl.messageID()
+	var sigs []string
+	for _, s := range []string{dl.Secret, dl.PreviousSecret} {
+		if s == "" {
+			continue
 		}
-		if resp != nil {
-			resp.Body.Close()
+		key, err := webhook.Key(s)
+		if err != nil {
+			return err
 		}
-		log.Printf("webhook delivery %s attempt %d failed: url=%s event=%s err=%v",
-			deliveryID, attempt+1, sub.URL, event.Type, err)
+		sigs = append(sigs, webhook.Sign(key, id, ts, dl.Payload))
+	}
+	h.Set(webhook.HeaderID, id)
+	h.Set(webhook.HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	if len(sigs) > 0 {
+		h.Set(webhook.HeaderSignature, strings.Join(sigs, " "))
 	}
-	log.Printf("webhook delivery %s exhausted all retries: url=%s event=%s",
-		deliveryID, sub.URL, event.Type)
+	return nil
 }
 
 func computeHMAC(secret string, body []byte) string {
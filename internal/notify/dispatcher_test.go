@@ -6,23 +6,184 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/agent-smit/agentic-registry/pkg/webhook"
 )
 
 // mockLoader implements SubscriptionLoader for testing.
 type mockLoader struct {
 	subs []Subscription
+	err  error
 }
 
 func (m *mockLoader) ListActive(_ context.Context) ([]Subscription, error) {
-	return m.subs, nil
+	return m.subs, m.err
+}
+
+func (m *mockLoader) Get(_ context.Context, id uuid.UUID) (*Subscription, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, sub := range m.subs {
+		if sub.ID == id {
+			return &sub, nil
+		}
+	}
+	return nil, errors.New("subscription not found")
+}
+
+// memOutbox implements Outbox in memory. Entries enqueued inside InTx are
+// kept only if fn succeeds.
+type memOutbox struct {
+	mu       sync.Mutex
+	entries  []*memEntry
+	subs     map[uuid.UUID]Subscription
+	attempts []Attempt
+}
+
+type memEntry struct {
+	id          uuid.UUID
+	entry       OutboxEntry
+	status      string
+	attempts    int
+	nextAttempt time.Time
+	lastErr     string
+}
+
+type memTxKey struct{}
+
+func newMemOutbox(subs ...Subscription) *memOutbox {
+	m := &memOutbox{subs: make(map[uuid.UUID]Subscription)}
+	for _, s := range subs {
+		m.subs[s.ID] = s
+	}
+	return m
+}
+
+func (m *memOutbox) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	var pending []*memEntry
+	if err := fn(context.WithValue(ctx, memTxKey{}, &pending)); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.entries = append(m.entries, pending...)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memOutbox) Enqueue(ctx context.Context, entries []OutboxEntry) error {
+	var added []*memEntry
+	for _, e := range entries {
+		added = append(added, &memEntry{id: uuid.New(), entry: e, status: "pending", nextAttempt: time.Now()})
+	}
+	if pending, ok := ctx.Value(memTxKey{}).(*[]*memEntry); ok {
+		*pending = append(*pending, added...)
+		return nil
+	}
+	m.mu.Lock()
+	m.entries = append(m.entries, added...)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memOutbox) Claim(_ context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []Delivery
+	now := time.Now()
+	for _, e := range m.entries {
+		if len(claimed) == limit {
+			break
+		}
+		if e.status != "pending" || e.nextAttempt.After(now) {
+			continue
+		}
+		e.attempts++
+		e.nextAttempt = now.Add(lease)
+		sub := m.subs[e.entry.SubscriptionID]
+		claimed = append(claimed, Delivery{
+			ID:              e.id,
+			SubscriptionID:  sub.ID,
+			URL:             sub.URL,
+			Secret:          sub.Secret,
+			PreviousSecret:  sub.PreviousSecret,
+			SignatureScheme: sub.SignatureScheme,
+			EventType:       e.entry.EventType,
+			Payload:         e.entry.Payload,
+			ContentType:     e.entry.ContentType,
+			Attempts:        e.attempts,
+		})
+	}
+	return claimed, nil
+}
+
+func (m *memOutbox) update(id uuid.UUID, fn func(e *memEntry)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.entries {
+		if e.id == id {
+			fn(e)
+			return nil
+		}
+	}
+	return fmt.Errorf("entry %s not found", id)
+}
+
+func (m *memOutbox) MarkDelivered(_ context.Context, id uuid.UUID) error {
+	return m.update(id, func(e *memEntry) { e.status = "delivered" })
+}
+
+func (m *memOutbox) MarkRetry(_ context.Context, id uuid.UUID, next time.Time, lastErr string) error {
+	return m.update(id, func(e *memEntry) { e.nextAttempt, e.lastErr = next, lastErr })
+}
+
+func (m *memOutbox) MarkFailed(_ context.Context, id uuid.UUID, lastErr string) error {
+	return m.update(id, func(e *memEntry) { e.status, e.lastErr = "failed", lastErr })
+}
+
+func (m *memOutbox) RecordAttempt(_ context.Context, a Attempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = append(m.attempts, a)
+	return nil
+}
+
+func (m *memOutbox) recordedAttempts() []Attempt {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Attempt(nil), m.attempts...)
+}
+
+func (m *memOutbox) all() []memEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]memEntry, len(m.entries))
+	for i, e := range m.entries {
+		out[i] = *e
+	}
+	return out
+}
+
+func (m *memOutbox) byStatus(status string) []memEntry {
+	var out []memEntry
+	for _, e := range m.all() {
+		if e.status == status {
+			out = append(out, e)
+		}
+	}
+	return out
 }
 
 func TestDispatchDeliversToMatchingSub(t *testing.T) {
@@ -46,7 +207,7 @@ func TestDispatchDeliversToMatchingSub(t *testing.T) {
 		}},
 	}
 
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 3, Timeout: 5 * time.Second})
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, MaxRetries: 3, Timeout: 5 * time.Second})
 	d.Start()
 	defer d.Stop()
 
@@ -57,7 +218,7 @@ func TestDispatchDeliversToMatchingSub(t *testing.T) {
 		Timestamp:    time.Now().UTC().Format(time.RFC3339),
 		Actor:        "user1",
 	}
-	d.Dispatch(event)
+	d.Dispatch(context.Background(), event)
 
 	select {
 	case req := <-received:
@@ -109,11 +270,11 @@ func TestHMACSignature(t *testing.T) {
 		}},
 	}
 
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
 	d.Start()
 	defer d.Stop()
 
-	d.Dispatch(Event{
+	d.Dispatch(context.Background(), Event{
 		Type:         "agent.updated",
 		ResourceType: "agent",
 		ResourceID:   "abc",
@@ -137,6 +298,147 @@ func TestHMACSignature(t *testing.T) {
 	}
 }
 
+func TestSignatureDuringSecretRotation(t *testing.T) {
+	bodyReceived := make(chan []byte, 1)
+	sigReceived := make(chan string, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyReceived <- body
+		sigReceived <- r.Header.Get("X-Webhook-Signature")
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	loader := &mockLoader{
+		subs: []Subscription{{
+			ID:             uuid.New(),
+			URL:            srv.URL,
+			Secret:         "new-secret",
+			PreviousSecret: "old-secret",
+			Events:         []string{"agent.updated"},
+		}},
+	}
+
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, Timeout: 5 * time.Second})
+	d.Start()
+	defer d.Stop()
+
+	d.Dispatch(context.Background(), Event{Type: "agent.updated"})
+
+	select {
+	case body := <-bodyReceived:
+		sig := <-sigReceived
+		expected := "sha256=" + computeHMAC("new-secret", body) + ",sha256=" + computeHMAC("old-secret", body)
+		if sig != expected {
+			t.Errorf("signature mismatch:\n  got:  %s\n  want: %s", sig, expected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook delivery")
+	}
+}
+
+func TestStandardWebhooksSignature(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		received <- r
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	loader := &mockLoader{
+		subs: []Subscription{{
+			ID:              uuid.New(),
+			URL:             srv.URL,
+			Secret:          "whsec_bmV3LXNlY3JldA==",
+			PreviousSecret:  "whsec_b2xkLXNlY3JldA==",
+			SignatureScheme: SignatureStandardWebhooks,
+			Events:          []string{"agent.updated"},
+		}},
+	}
+
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, Timeout: 5 * time.Second})
+	d.Start()
+	defer d.Stop()
+
+	d.Dispatch(context.Background(), Event{Type: "agent.updated"})
+
+	select {
+	case body := <-bodies:
+		r := <-received
+		if r.Header.Get("X-Webhook-Signature") != "" {
+			t.Error("standard_webhooks deliveries should not carry X-Webhook-Signature")
+		}
+		if r.Header.Get(webhook.HeaderID) != r.Header.Get("X-Registry-Delivery") {
+			t.Errorf("webhook-id %q should be the delivery ID %q", r.Header.Get(webhook.HeaderID), r.Header.Get("X-Registry-Delivery"))
+		}
+		if n := len(strings.Fields(r.Header.Get(webhook.HeaderSignature))); n != 2 {
+			t.Errorf("expected signatures with the new and the previous secret, got %d", n)
+		}
+		for _, secret := range []string{"whsec_bmV3LXNlY3JldA==", "whsec_b2xkLXNlY3JldA=="} {
+			v, err := webhook.NewVerifier(secret)
+			if err != nil {
+				t.Fatalf("NewVerifier: %v", err)
+			}
+			if err := v.Verify(body, r.Header); err != nil {
+				t.Errorf("Verify with %s: %v", secret, err)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook delivery")
+	}
+}
+
+func TestPing(t *testing.T) {
+	var got Event
+	var sig, deliveryID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		if r.Header.Get("X-Webhook-Signature") == "sha256="+computeHMAC("s3cret", body) {
+			sig = "ok"
+		}
+		deliveryID = r.Header.Get("X-Registry-Delivery")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("pong"))
+	}))
+	defer srv.Close()
+
+	sub := Subscription{ID: uuid.New(), URL: srv.URL, Secret: "s3cret", Events: []string{"agent.created"}}
+	outbox := newMemOutbox(sub)
+	d := NewDispatcher(&mockLoader{subs: []Subscription{sub}}, outbox, Config{Timeout: 5 * time.Second})
+
+	attempt, err := d.Ping(context.Background(), sub.ID, "admin")
+	if err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if attempt.StatusCode != http.StatusTeapot || attempt.ResponseBody != "pong" || attempt.Error == "" {
+		t.Errorf("unexpected attempt: %+v", attempt)
+	}
+	if got.Type != PingEvent || got.ResourceID != sub.ID.String() || got.Actor != "admin" {
+		t.Errorf("unexpected ping event: %+v", got)
+	}
+	if sig != "ok" {
+		t.Error("ping was not signed with the subscription secret")
+	}
+	if deliveryID != attempt.DeliveryID.String() {
+		t.Errorf("X-Registry-Delivery = %q, want %s", deliveryID, attempt.DeliveryID)
+	}
+	if len(outbox.all()) != 0 || len(outbox.recordedAttempts()) != 0 {
+		t.Error("ping should not be queued or recorded")
+	}
+}
+
+func TestPingUnknownSubscription(t *testing.T) {
+	d := NewDispatcher(&mockLoader{}, newMemOutbox(), Config{})
+	if _, err := d.Ping(context.Background(), uuid.New(), "admin"); err == nil {
+		t.Fatal("expected error for unknown subscription")
+	}
+}
+
 func TestDispatchSkipsNonMatchingEvents(t *testing.T) {
 	var callCount atomic.Int32
 
@@ -155,11 +457,11 @@ func TestDispatchSkipsNonMatchingEvents(t *testing.T) {
 		}},
 	}
 
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
 	d.Start()
 	defer d.Stop()
 
-	d.Dispatch(Event{
+	d.Dispatch(context.Background(), Event{
 		Type:         "agent.deleted",
 		ResourceType: "agent",
 		ResourceID:   "xyz",
@@ -197,11 +499,11 @@ func TestRetryOnFailure(t *testing.T) {
 		}},
 	}
 
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 3, Timeout: 5 * time.Second})
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, MaxRetries: 3, Timeout: 5 * time.Second})
 	d.Start()
 	defer d.Stop()
 
-	d.Dispatch(Event{
+	d.Dispatch(context.Background(), Event{
 		Type:         "agent.created",
 		ResourceType: "agent",
 		ResourceID:   "retry-test",
@@ -235,11 +537,11 @@ func TestDeliveryHeaders(t *testing.T) {
 		}},
 	}
 
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
 	d.Start()
 	defer d.Stop()
 
-	d.Dispatch(Event{
+	d.Dispatch(context.Background(), Event{
 		Type:         "prompt.created",
 		ResourceType: "prompt",
 		ResourceID:   "p1",
@@ -266,6 +568,65 @@ func TestDeliveryHeaders(t *testing.T) {
 	}
 }
 
+func TestRedeliveryKeepsMessageID(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(&mockLoader{}, newMemOutbox(), Config{Timeout: 5 * time.Second})
+	original := uuid.New()
+	if _, _, err := d.post(context.Background(), Delivery{
+		ID:        uuid.New(),
+		MessageID: original,
+		URL:       srv.URL,
+		EventType: "agent.updated",
+		Payload:   json.RawMessage(`{}`),
+	}); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if got.Get("X-Registry-Delivery") != original.String() {
+		t.Errorf("X-Registry-Delivery = %q, want the original delivery ID %s", got.Get("X-Registry-Delivery"), original)
+	}
+}
+
+func TestStandardWebhooksRedeliveryKeepsWebhookID(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	secret := "whsec_c2VjcmV0LWtleQ=="
+	body := json.RawMessage(`{"event":"agent.updated"}`)
+	d := NewDispatcher(&mockLoader{}, newMemOutbox(), Config{Timeout: 5 * time.Second})
+	original := uuid.New()
+	if _, _, err := d.post(context.Background(), Delivery{
+		ID:              uuid.New(),
+		MessageID:       original,
+		URL:             srv.URL,
+		Secret:          secret,
+		SignatureScheme: SignatureStandardWebhooks,
+		EventType:       "agent.updated",
+		Payload:         body,
+	}); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if got.Get(webhook.HeaderID) != original.String() {
+		t.Errorf("webhook-id = %q, want the original delivery ID %s", got.Get(webhook.HeaderID), original)
+	}
+	v, err := webhook.NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	if err := v.Verify(body, got); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
 func TestDispatchWithNoSubscriptions(t *testing.T) {
 	var callCount atomic.Int32
 
@@ -279,11 +640,11 @@ func TestDispatchWithNoSubscriptions(t *testing.T) {
 		subs: []Subscription{},
 	}
 
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
 	d.Start()
 	defer d.Stop()
 
-	d.Dispatch(Event{
+	d.Dispatch(context.Background(), Event{
 		Type:         "agent.created",
 		ResourceType: "agent",
 		ResourceID:   "no-sub-test",
@@ -316,12 +677,12 @@ func TestDispatcherStopDrainsEvents(t *testing.T) {
 		}},
 	}
 
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
+	d := NewDispatcher(loader, newMemOutbox(loader.subs...), Config{PollInterval: 20 * time.Millisecond, Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
 	d.Start()
 
 	// Send several events
 	for i := 0; i < 5; i++ {
-		d.Dispatch(Event{
+		d.Dispatch(context.Background(), Event{
 			Type:         "test.event",
 			ResourceType: "test",
 			ResourceID:   "drain-test",
@@ -339,20 +700,256 @@ func TestDispatcherStopDrainsEvents(t *testing.T) {
 	}
 }
 
-func TestDispatchAfterStopIsIgnored(t *testing.T) {
-	loader := &mockLoader{subs: []Subscription{}}
-	d := NewDispatcher(loader, Config{Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
+func TestDispatchAfterStopIsQueued(t *testing.T) {
+	loader := &mockLoader{subs: []Subscription{{ID: uuid.New(), URL: "http://127.0.0.1:1", Events: []string{"test.event"}}}}
+	outbox := newMemOutbox(loader.subs...)
+	d := NewDispatcher(loader, outbox, Config{Workers: 1, MaxRetries: 0, Timeout: 5 * time.Second})
 	d.Start()
 	d.Stop()
 
-	// Should not panic or send to closed channel
-	d.Dispatch(Event{
+	err := d.Dispatch(context.Background(), Event{
 		Type:         "test.event",
 		ResourceType: "test",
 		ResourceID:   "post-stop",
 		Timestamp:    time.Now().UTC().Format(time.RFC3339),
 		Actor:        "user",
 	})
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if n := len(outbox.byStatus("pending")); n != 1 {
+		t.Errorf("expected the event to stay queued for the next start, got %d pending", n)
+	}
+}
+
+func TestDispatchInRolledBackTxIsDiscarded(t *testing.T) {
+	loader := &mockLoader{subs: []Subscription{{ID: uuid.New(), URL: "http://127.0.0.1:1", Events: []string{"agent.created"}}}}
+	outbox := newMemOutbox(loader.subs...)
+	d := NewDispatcher(loader, outbox, Config{Workers: 1, Timeout: 5 * time.Second})
+
+	mutationErr := errors.New("mutation failed")
+	err := d.InTx(context.Background(), func(ctx context.Context) error {
+		if err := d.Dispatch(ctx, Event{Type: "agent.created", ResourceType: "agent", ResourceID: "a1"}); err != nil {
+			return err
+		}
+		return mutationErr
+	})
+	if !errors.Is(err, mutationErr) {
+		t.Fatalf("expected mutation error, got %v", err)
+	}
+	if n := len(outbox.all()); n != 0 {
+		t.Errorf("expected no queued entries after rollback, got %d", n)
+	}
+
+	err = d.InTx(context.Background(), func(ctx context.Context) error {
+		return d.Dispatch(ctx, Event{Type: "agent.created", ResourceType: "agent", ResourceID: "a1"})
+	})
+	if err != nil {
+		t.Fatalf("InTx: %v", err)
+	}
+	if n := len(outbox.byStatus("pending")); n != 1 {
+		t.Errorf("expected 1 queued entry after commit, got %d", n)
+	}
+}
+
+func TestDispatchLoaderErrorIsReturned(t *testing.T) {
+	d := NewDispatcher(&mockLoader{err: errors.New("db down")}, newMemOutbox(), Config{Workers: 1})
+	if err := d.Dispatch(context.Background(), Event{Type: "agent.created"}); err == nil {
+		t.Fatal("expected an error when subscriptions cannot be loaded")
+	}
+}
+
+func TestDeliveryMarkedFailedAfterRetries(t *testing.T) {
+	var attempts atomic.Int32
+	deliveryIDs := make(chan string, 4)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		deliveryIDs <- r.Header.Get("X-Registry-Delivery")
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+
+	loader := &mockLoader{subs: []Subscription{{ID: uuid.New(), URL: srv.URL, Events: []string{"agent.created"}}}}
+	outbox := newMemOutbox(loader.subs...)
+	d := NewDispatcher(loader, outbox, Config{Workers: 2, MaxRetries: 1, Timeout: 5 * time.Second, PollInterval: 20 * time.Millisecond})
+	d.Start()
+	defer d.Stop()
+
+	d.Dispatch(context.Background(), Event{Type: "agent.created", ResourceType: "agent", ResourceID: "fail-test"})
+
+	deadline := time.After(5 * time.Second)
+	for len(outbox.byStatus("failed")) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timeout waiting for delivery to be marked failed")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	if count := attempts.Load(); count != 2 {
+		t.Errorf("expected 2 attempts (1 initial + 1 retry), got %d", count)
+	}
+	first, second := <-deliveryIDs, <-deliveryIDs
+	if first == "" || first != second {
+		t.Errorf("expected the same delivery ID on every attempt, got %q and %q", first, second)
+	}
+	entry := outbox.byStatus("failed")[0]
+	if entry.attempts != 2 || entry.lastErr != "status 503" {
+		t.Errorf("expected attempts=2 last_error=%q, got attempts=%d last_error=%q", "status 503", entry.attempts, entry.lastErr)
+	}
+}
+
+func TestDeliveryAttemptsAreRecorded(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(500)
+			w.Write([]byte(strings.Repeat("x", maxResponseBody+100)))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	loader := &mockLoader{subs: []Subscription{{ID: uuid.New(), URL: srv.URL, Events: []string{"agent.created"}}}}
+	outbox := newMemOutbox(loader.subs...)
+	d := NewDispatcher(loader, outbox, Config{Workers: 1, MaxRetries: 1, Timeout: 5 * time.Second, PollInterval: 20 * time.Millisecond})
+	d.Start()
+	defer d.Stop()
+
+	d.Dispatch(context.Background(), Event{Type: "agent.created", ResourceType: "agent", ResourceID: "a1"})
+
+	deadline := time.After(5 * time.Second)
+	for len(outbox.byStatus("delivered")) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timeout waiting for delivery")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	attempts := outbox.recordedAttempts()
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 recorded attempts, got %d", len(attempts))
+	}
+	first, second := attempts[0], attempts[1]
+	if first.Attempt != 1 || first.StatusCode != 500 || first.Error != "status 500" || len(first.ResponseBody) != maxResponseBody {
+		t.Errorf("unexpected first attempt: attempt=%d status=%d error=%q body=%d bytes",
+			first.Attempt, first.StatusCode, first.Error, len(first.ResponseBody))
+	}
+	if second.Attempt != 2 || second.StatusCode != 200 || second.Error != "" || second.ResponseBody != "ok" {
+		t.Errorf("unexpected second attempt: %+v", second)
+	}
+	if first.DeliveryID != second.DeliveryID || first.Latency <= 0 {
+		t.Errorf("expected both attempts of one delivery with a latency, got %+v and %+v", first, second)
+	}
+}
+
+func TestDeliveryAttemptWithoutResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	loader := &mockLoader{subs: []Subscription{{ID: uuid.New(), URL: url, Events: []string{"agent.created"}}}}
+	outbox := newMemOutbox(loader.subs...)
+	d := NewDispatcher(loader, outbox, Config{Workers: 1, Timeout: 5 * time.Second, PollInterval: 20 * time.Millisecond})
+	d.Start()
+	d.Dispatch(context.Background(), Event{Type: "agent.created"})
+
+	deadline := time.After(5 * time.Second)
+	for len(outbox.byStatus("failed")) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timeout waiting for failed delivery")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	d.Stop()
+
+	attempts := outbox.recordedAttempts()
+	if len(attempts) != 1 || attempts[0].StatusCode != 0 || attempts[0].Error == "" {
+		t.Fatalf("expected one attempt without a status and with an error, got %+v", attempts)
+	}
+}
+
+func TestResponseSnippet(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"short", "accepted", "accepted"},
+		{"nul removed", "a\x00b", "ab"},
+		{"invalid utf-8 removed", "ok\xff", "ok"},
+		{"truncated", strings.Repeat("é", maxResponseBody), strings.Repeat("é", maxResponseBody/2)},
+		{"split rune dropped", "a" + strings.Repeat("é", maxResponseBody/2), "a" + strings.Repeat("é", maxResponseBody/2-1)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := responseSnippet(strings.NewReader(tc.body)); got != tc.want {
+				t.Errorf("responseSnippet = %d bytes, want %d", len(got), len(tc.want))
+			}
+		})
+	}
+}
+
+func TestClaimedDeliveryIsNotDeliveredTwice(t *testing.T) {
+	var delivered atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	// Two dispatchers sharing an outbox stand in for two replicas.
+	loader := &mockLoader{subs: []Subscription{{ID: uuid.New(), URL: srv.URL, Events: []string{"test.event"}}}}
+	outbox := newMemOutbox(loader.subs...)
+	a := NewDispatcher(loader, outbox, Config{Workers: 2, Timeout: 5 * time.Second, PollInterval: 10 * time.Millisecond})
+	b := NewDispatcher(loader, outbox, Config{Workers: 2, Timeout: 5 * time.Second, PollInterval: 10 * time.Millisecond})
+	a.Start()
+	b.Start()
+
+	for i := 0; i < 20; i++ {
+		a.Dispatch(context.Background(), Event{Type: "test.event", ResourceType: "test", ResourceID: "shared"})
+	}
+
+	deadline := time.After(5 * time.Second)
+	for len(outbox.byStatus("delivered")) < 20 {
+		select {
+		case <-deadline:
+			t.Fatal("timeout waiting for deliveries")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	a.Stop()
+	b.Stop()
+
+	if count := delivered.Load(); count != 20 {
+		t.Errorf("expected 20 deliveries across both dispatchers, got %d", count)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{12, 2048 * time.Second},
+		{13, time.Hour},
+		{64, time.Hour},
+	}
+	for _, tc := range tests {
+		if got := backoff(tc.attempt); got != tc.want {
+			t.Errorf("backoff(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
 }
 
 func TestComputeHMAC(t *testing.T) {
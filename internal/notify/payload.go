@@ -0,0 +1,266 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Payload formats. PayloadRegistry sends the event as a flat JSON object;
+// PayloadCloudEvents wraps it in a CloudEvents 1.0 structured-mode envelope.
+const (
+	PayloadRegistry    = "registry"
+	PayloadCloudEvents = "cloudevents"
+)
+
+// Content types of delivery bodies.
+const (
+	contentTypeJSON        = "application/json"
+	contentTypeCloudEvents = "application/cloudevents+json"
+)
+
+// cloudEventSource is the CloudEvents source attribute of every event.
+const cloudEventSource = "/agentic-registry"
+
+// redacted replaces the values of secret fields in snapshots.
+const redacted = "[REDACTED]"
+
+// secretKeys are field names, or underscore-separated suffixes of field
+// names, whose values are redacted from snapshots. Keys are compared in
+// lower case with dashes read as underscores.
+var secretKeys = []string{
+	"secret", "password", "token", "api_key", "apikey", "authorization",
+	"credential", "credentials", "private_key", "client_secret",
+}
+
+// Change describes the resource version an event produced. Snapshot and
+// Previous are JSON-encodable representations of the resource at Version
+// and at the version before it; Previous is nil for a first version.
+type Change struct {
+	Version  int
+	Snapshot any
+	Previous any
+}
+
+// PayloadOptions selects what a subscription's deliveries contain.
+type PayloadOptions struct {
+	Format          string
+	IncludeVersion  bool
+	IncludeSnapshot bool
+	IncludeDiff     bool
+}
+
+// PatchOp is one RFC 6902 JSON Patch operation.
+type PatchOp struct {
+	Op    string
+	Path  string
+	Value any
+}
+
+// MarshalJSON encodes the operation with a value unless it is a remove,
+// which has none; a null value is kept.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	return json.Marshal(struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+type registryPayload struct {
+	Event
+	Version  *int      `json:"version,omitempty"`
+	Snapshot any       `json:"snapshot,omitempty"`
+	Diff     []PatchOp `json:"diff,omitempty"`
+}
+
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            registryPayload `json:"data"`
+}
+
+// payloadBuilder renders an event for each subscription's payload options.
+// The snapshot and diff are computed once per event, and only when a
+// subscription includes them.
+type payloadBuilder struct {
+	event    Event
+	id       string
+	snapshot any
+	diff     []PatchOp
+	prepared bool
+}
+
+func newPayloadBuilder(event Event, id string) *payloadBuilder {
+	return &payloadBuilder{event: event, id: id}
+}
+
+// build returns the delivery body and its content type.
+func (b *payloadBuilder) build(opts PayloadOptions) (json.RawMessage, string, error) {
+	payload := registryPayload{Event: b.event}
+	if c := b.event.Change; c != nil {
+		if opts.IncludeSnapshot || opts.IncludeDiff {
+			if err := b.prepare(); err != nil {
+				return nil, "", err
+			}
+		}
+		if opts.IncludeVersion {
+			payload.Version = &c.Version
+		}
+		if opts.IncludeSnapshot {
+			payload.Snapshot = b.snapshot
+		}
+		if opts.IncludeDiff {
+			payload.Diff = b.diff
+		}
+	}
+
+	if opts.Format != PayloadCloudEvents {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, "", fmt.Errorf("marshaling webhook event: %w", err)
+		}
+		return body, contentTypeJSON, nil
+	}
+
+	body, err := json.Marshal(cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              b.id,
+		Source:          cloudEventSource,
+		Type:            b.event.Type,
+		Subject:         b.event.ResourceID,
+		Time:            b.event.Timestamp,
+		DataContentType: contentTypeJSON,
+		Data:            payload,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("marshaling webhook event: %w", err)
+	}
+	return body, contentTypeCloudEvents, nil
+}
+
+func (b *payloadBuilder) prepare() error {
+	if b.prepared {
+		return nil
+	}
+	c := b.event.Change
+	snapshot, err := redactedValue(c.Snapshot)
+	if err != nil {
+		return fmt.Errorf("encoding resource snapshot: %w", err)
+	}
+	b.snapshot = snapshot
+	if c.Previous != nil {
+		previous, err := redactedValue(c.Previous)
+		if err != nil {
+			return fmt.Errorf("encoding previous resource snapshot: %w", err)
+		}
+		b.diff = jsonDiff(previous, snapshot, "")
+		if b.diff == nil {
+			b.diff = []PatchOp{}
+		}
+	}
+	b.prepared = true
+	return nil
+}
+
+// redactedValue round-trips v through JSON and redacts its secret fields.
+func redactedValue(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	return redact(decoded), nil
+}
+
+func redact(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if isSecretKey(k) {
+				if val != nil && val != "" {
+					t[k] = redacted
+				}
+				continue
+			}
+			t[k] = redact(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = redact(val)
+		}
+	}
+	return v
+}
+
+func isSecretKey(key string) bool {
+	k := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	for _, s := range secretKeys {
+		if k == s || strings.HasSuffix(k, "_"+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonDiff returns the JSON Patch that turns from into to. Objects are
+// compared field by field; arrays and scalars that differ are replaced
+// whole.
+func jsonDiff(from, to any, path string) []PatchOp {
+	fromObj, fromIsObj := from.(map[string]any)
+	toObj, toIsObj := to.(map[string]any)
+	if !fromIsObj || !toIsObj {
+		if reflect.DeepEqual(from, to) {
+			return nil
+		}
+		return []PatchOp{{Op: "replace", Path: path, Value: to}}
+	}
+
+	keys := make([]string, 0, len(fromObj)+len(toObj))
+	for k := range fromObj {
+		keys = append(keys, k)
+	}
+	for k := range toObj {
+		if _, ok := fromObj[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var ops []PatchOp
+	for _, k := range keys {
+		p := path + "/" + escapePointer(k)
+		fv, inFrom := fromObj[k]
+		tv, inTo := toObj[k]
+		switch {
+		case !inTo:
+			ops = append(ops, PatchOp{Op: "remove", Path: p})
+		case !inFrom:
+			ops = append(ops, PatchOp{Op: "add", Path: p, Value: tv})
+		default:
+			ops = append(ops, jsonDiff(fv, tv, p)...)
+		}
+	}
+	return ops
+}
+
+// escapePointer escapes a key for use in a JSON Pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type testAgent struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Version int               `json:"version"`
+	Tools   []string          `json:"tools"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+func testChangeEvent() Event {
+	return Event{
+		Type:         "agent.updated",
+		ResourceType: "agent",
+		ResourceID:   "a1",
+		Timestamp:    "2026-02-15T12:00:00Z",
+		Actor:        "admin",
+		Change: &Change{
+			Version: 3,
+			Snapshot: testAgent{ID: "a1", Name: "New", Version: 3, Tools: []string{"x", "y"},
+				Headers: map[string]string{"Authorization": "Bearer abc", "X-Team": "core"}},
+			Previous: testAgent{ID: "a1", Name: "Old", Version: 2, Tools: []string{"x"}},
+		},
+	}
+}
+
+func decodePayload(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	return m
+}
+
+func TestPayloadDefaultOmitsChange(t *testing.T) {
+	b := newPayloadBuilder(testChangeEvent(), "e1")
+	body, contentType, err := b.build(PayloadOptions{})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if _, _, err := b.build(PayloadOptions{IncludeVersion: true}); err != nil {
+		t.Fatalf("build with version: %v", err)
+	}
+	if b.prepared {
+		t.Error("snapshot and diff should not be computed when no subscription includes them")
+	}
+	if contentType != "application/json" {
+		t.Errorf("content type = %s", contentType)
+	}
+	want := map[string]any{
+		"event":         "agent.updated",
+		"resource_type": "agent",
+		"resource_id":   "a1",
+		"timestamp":     "2026-02-15T12:00:00Z",
+		"actor":         "admin",
+	}
+	if got := decodePayload(t, body); !reflect.DeepEqual(got, want) {
+		t.Errorf("payload = %v, want %v", got, want)
+	}
+}
+
+func TestPayloadWithVersionSnapshotAndDiff(t *testing.T) {
+	body, _, err := newPayloadBuilder(testChangeEvent(), "e1").build(PayloadOptions{
+		IncludeVersion: true, IncludeSnapshot: true, IncludeDiff: true,
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	got := decodePayload(t, body)
+
+	if got["version"] != float64(3) {
+		t.Errorf("version = %v, want 3", got["version"])
+	}
+	headers := got["snapshot"].(map[string]any)["headers"].(map[string]any)
+	if headers["Authorization"] != "[REDACTED]" || headers["X-Team"] != "core" {
+		t.Errorf("snapshot headers not redacted as expected: %v", headers)
+	}
+
+	wantDiff := []any{
+		map[string]any{"op": "add", "path": "/headers", "value": map[string]any{"Authorization": "[REDACTED]", "X-Team": "core"}},
+		map[string]any{"op": "replace", "path": "/name", "value": "New"},
+		map[string]any{"op": "replace", "path": "/tools", "value": []any{"x", "y"}},
+		map[string]any{"op": "replace", "path": "/version", "value": float64(3)},
+	}
+	if !reflect.DeepEqual(got["diff"], wantDiff) {
+		t.Errorf("diff = %v, want %v", got["diff"], wantDiff)
+	}
+}
+
+func TestPayloadWithoutPreviousHasNoDiff(t *testing.T) {
+	event := testChangeEvent()
+	event.Change.Previous = nil
+	body, _, err := newPayloadBuilder(event, "e1").build(PayloadOptions{IncludeDiff: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if _, ok := decodePayload(t, body)["diff"]; ok {
+		t.Error("a first version should have no diff")
+	}
+}
+
+func TestPayloadCloudEvents(t *testing.T) {
+	body, contentType, err := newPayloadBuilder(testChangeEvent(), "e1").build(PayloadOptions{
+		Format: PayloadCloudEvents, IncludeVersion: true,
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if contentType != "application/cloudevents+json" {
+		t.Errorf("content type = %s", contentType)
+	}
+	got := decodePayload(t, body)
+	for attr, want := range map[string]any{
+		"specversion":     "1.0",
+		"id":              "e1",
+		"source":          "/agentic-registry",
+		"type":            "agent.updated",
+		"subject":         "a1",
+		"time":            "2026-02-15T12:00:00Z",
+		"datacontenttype": "application/json",
+	} {
+		if got[attr] != want {
+			t.Errorf("%s = %v, want %v", attr, got[attr], want)
+		}
+	}
+	data := got["data"].(map[string]any)
+	if data["resource_type"] != "agent" || data["version"] != float64(3) {
+		t.Errorf("unexpected data: %v", data)
+	}
+}
+
+func TestJSONDiff(t *testing.T) {
+	from := map[string]any{"a": 1.0, "b": map[string]any{"c": "x", "d/e": true}, "gone": "y"}
+	to := map[string]any{"a": 1.0, "b": map[string]any{"c": "z", "d/e": true}, "new": nil}
+
+	got, err := json.Marshal(jsonDiff(from, to, ""))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `[{"op":"replace","path":"/b/c","value":"z"},{"op":"remove","path":"/gone"},{"op":"add","path":"/new","value":null}]`
+	if string(got) != want {
+		t.Errorf("diff = %s, want %s", got, want)
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"secret":        true,
+		"client_secret": true,
+		"Authorization": true,
+		"x-api-key":     true,
+		"access_token":  true,
+		"password":      true,
+		"max_tokens":    false,
+		"name":          false,
+		"token_limit":   false,
+	} {
+		if got := isSecretKey(key); got != want {
+			t.Errorf("isSecretKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestDispatchUsesSubscriptionPayloadOptions(t *testing.T) {
+	type received struct {
+		contentType string
+		body        []byte
+	}
+	ch := make(chan received, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ch <- received{r.Header.Get("Content-Type"), body}
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	loader := &mockLoader{subs: []Subscription{
+		{ID: uuid.New(), URL: srv.URL, Events: []string{"agent.updated"}},
+		{ID: uuid.New(), URL: srv.URL, Events: []string{"agent.updated"},
+			Payload: PayloadOptions{Format: PayloadCloudEvents, IncludeSnapshot: true}},
+	}}
+	outbox := newMemOutbox(loader.subs...)
+	d := NewDispatcher(loader, outbox, Config{PollInterval: 20 * time.Millisecond, Workers: 1, Timeout: 5 * time.Second})
+	d.Start()
+	defer d.Stop()
+
+	if err := d.Dispatch(context.Background(), testChangeEvent()); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	byType := map[string][]byte{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-ch:
+			byType[r.contentType] = r.body
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for webhook deliveries")
+		}
+	}
+	if plain := decodePayload(t, byType["application/json"]); plain["snapshot"] != nil {
+		t.Errorf("default subscription should not receive a snapshot: %v", plain)
+	}
+	ce := decodePayload(t, byType["application/cloudevents+json"])
+	if ce["specversion"] != "1.0" || ce["data"].(map[string]any)["snapshot"] == nil {
+		t.Errorf("expected a CloudEvents envelope with a snapshot: %v", ce)
+	}
+}
@@ -57,7 +57,7 @@ func NewAgentStore(pool *pgxpool.Pool) *AgentStore {
 
 // Create inserts a new agent and creates version 1 snapshot in a transaction.
 func (s *AgentStore) Create(ctx context.Context, agent *Agent) error {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -104,7 +104,7 @@ func (s *AgentStore) Create(ctx context.Context, agent *Agent) error {
 func (s *AgentStore) CountAll(ctx context.Context) (int, error) {
 	var count int
 	query := "SELECT COUNT(*) FROM agents"
-	if err := s.pool.QueryRow(ctx, query).Scan(&count); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, query).Scan(&count); err != nil {
 		return 0, fmt.Errorf("counting all agents: %w", err)
 	}
 	return count, nil
@@ -118,7 +118,7 @@ func (s *AgentStore) GetByID(ctx context.Context, id string) (*Agent, error) {
 		FROM agents WHERE id = $1`
 
 	agent := &Agent{}
-	err := s.pool.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, id).Scan(
 		&agent.ID, &agent.Name, &agent.Description, &agent.SystemPrompt,
 		&agent.Tools, &agent.TrustOverrides, &agent.ExamplePrompts,
 		&agent.IsActive, &agent.Version, &agent.CreatedBy,
@@ -145,7 +145,7 @@ func (s *AgentStore) List(ctx context.Context, activeOnly bool, offset, limit in
 
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM agents%s", where)
 	var total int
-	if err := s.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("counting agents: %w", err)
 	}
 
@@ -158,7 +158,7 @@ func (s *AgentStore) List(ctx context.Context, activeOnly bool, offset, limit in
 
 	args = append(args, limit, offset)
 
-	rows, err := s.pool.Query(ctx, dataQuery, args...)
+	rows, err := conn(ctx, s.pool).Query(ctx, dataQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("listing agents: %w", err)
 	}
@@ -186,7 +186,7 @@ func (s *AgentStore) List(ctx context.Context, activeOnly bool, offset, limit in
 
 // Update performs a full update of an agent with optimistic concurrency and creates a new version snapshot.
 func (s *AgentStore) Update(ctx context.Context, agent *Agent, updatedAt time.Time) error {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -299,7 +299,7 @@ func (s *AgentStore) Patch(ctx context.Context, id string, fields map[string]int
 // Delete performs a soft-delete by setting is_active = false.
 func (s *AgentStore) Delete(ctx context.Context, id string) error {
 	query := `UPDATE agents SET is_active = false, updated_at = now() WHERE id = $1`
-	ct, err := s.pool.Exec(ctx, query, id)
+	ct, err := conn(ctx, s.pool).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("soft-deleting agent: %w", err)
 	}
@@ -313,7 +313,7 @@ func (s *AgentStore) Delete(ctx context.Context, id string) error {
 func (s *AgentStore) ListVersions(ctx context.Context, agentID string, offset, limit int) ([]AgentVersion, int, error) {
 	countQuery := `SELECT COUNT(*) FROM agent_versions WHERE agent_id = $1`
 	var total int
-	if err := s.pool.QueryRow(ctx, countQuery, agentID).Scan(&total); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, countQuery, agentID).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("counting agent versions: %w", err)
 	}
 
@@ -324,7 +324,7 @@ func (s *AgentStore) ListVersions(ctx context.Context, agentID string, offset, l
 		ORDER BY version DESC
 		LIMIT $2 OFFSET $3`
 
-	rows, err := s.pool.Query(ctx, query, agentID, limit, offset)
+	rows, err := conn(ctx, s.pool).Query(ctx, query, agentID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("listing agent versions: %w", err)
 	}
@@ -357,7 +357,7 @@ func (s *AgentStore) GetVersion(ctx context.Context, agentID string, version int
 		WHERE agent_id = $1 AND version = $2`
 
 	v := &AgentVersion{}
-	err := s.pool.QueryRow(ctx, query, agentID, version).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, agentID, version).Scan(
 		&v.ID, &v.AgentID, &v.Version, &v.Name, &v.Description,
 		&v.SystemPrompt, &v.Tools, &v.TrustOverrides, &v.ExamplePrompts,
 		&v.IsActive, &v.CreatedBy, &v.CreatedAt,
@@ -379,7 +379,7 @@ func (s *AgentStore) Rollback(ctx context.Context, agentID string, targetVersion
 		return nil, err
 	}
 
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginning transaction: %w", err)
 	}
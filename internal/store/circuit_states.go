@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CircuitState is a gateway circuit breaker's shared state. Key is the
+// server label, or "label|url" for a pooled endpoint.
+type CircuitState struct {
+	Key       string     `json:"key" db:"circuit_key"`
+	State     string     `json:"state" db:"state"`
+	Failures  int        `json:"failures" db:"failures"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty" db:"opened_at"`
+	Forced    bool       `json:"forced" db:"forced"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CircuitStateStore handles database operations for shared circuit state.
+type CircuitStateStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewCircuitStateStore creates a new CircuitStateStore.
+func NewCircuitStateStore(pool *pgxpool.Pool) *CircuitStateStore {
+	return &CircuitStateStore{pool: pool}
+}
+
+// List returns all shared circuit states.
+func (s *CircuitStateStore) List(ctx context.Context) ([]CircuitState, error) {
+	query := `SELECT circuit_key, state, failures, opened_at, forced, updated_at FROM mcp_circuit_states`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing circuit states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []CircuitState
+	for rows.Next() {
+		var c CircuitState
+		if err := rows.Scan(&c.Key, &c.State, &c.Failures, &c.OpenedAt, &c.Forced, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scanning circuit state: %w", err)
+		}
+		states = append(states, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating circuit states: %w", err)
+	}
+	return states, nil
+}
+
+// Upsert saves a circuit state unless a newer one is already stored.
+func (s *CircuitStateStore) Upsert(ctx context.Context, c *CircuitState) error {
+	query := `
+		INSERT INTO mcp_circuit_states (circuit_key, state, failures, opened_at, forced, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (circuit_key) DO UPDATE
+		SET state = EXCLUDED.state, failures = EXCLUDED.failures, opened_at = EXCLUDED.opened_at,
+		    forced = EXCLUDED.forced, updated_at = EXCLUDED.updated_at
+		WHERE mcp_circuit_states.updated_at < EXCLUDED.updated_at`
+
+	if _, err := s.pool.Exec(ctx, query, c.Key, c.State, c.Failures, c.OpenedAt, c.Forced, c.UpdatedAt); err != nil {
+		return fmt.Errorf("saving circuit state: %w", err)
+	}
+	return nil
+}
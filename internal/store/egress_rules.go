@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agent-smit/agentic-registry/internal/errors"
+)
+
+// EgressRule is a global allow or deny entry for gateway egress. Exactly one
+// of CIDR or Hostname is set.
+type EgressRule struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Action      string    `json:"action" db:"action"`
+	CIDR        string    `json:"cidr,omitempty" db:"cidr"`
+	Hostname    string    `json:"hostname,omitempty" db:"hostname"`
+	Description string    `json:"description" db:"description"`
+	CreatedBy   string    `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// EgressRuleStore handles database operations for global egress rules.
+type EgressRuleStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewEgressRuleStore creates a new EgressRuleStore.
+func NewEgressRuleStore(pool *pgxpool.Pool) *EgressRuleStore {
+	return &EgressRuleStore{pool: pool}
+}
+
+// List returns all egress rules, deny rules first.
+func (s *EgressRuleStore) List(ctx context.Context) ([]EgressRule, error) {
+	query := `
+		SELECT id, action, cidr, hostname, description, created_by, created_at
+		FROM egress_rules
+		ORDER BY action DESC, created_at ASC`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing egress rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []EgressRule
+	for rows.Next() {
+		var r EgressRule
+		if err := rows.Scan(&r.ID, &r.Action, &r.CIDR, &r.Hostname, &r.Description, &r.CreatedBy, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning egress rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating egress rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Create inserts a new egress rule.
+func (s *EgressRuleStore) Create(ctx context.Context, rule *EgressRule) error {
+	query := `
+		INSERT INTO egress_rules (action, cidr, hostname, description, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	err := s.pool.QueryRow(ctx, query,
+		rule.Action, rule.CIDR, rule.Hostname, rule.Description, rule.CreatedBy,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("creating egress rule: %w", err)
+	}
+	return nil
+}
+
+// Delete hard-deletes an egress rule by ID.
+func (s *EgressRuleStore) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM egress_rules WHERE id = $1`
+	ct, err := s.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("deleting egress rule: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.NotFound("egress_rule", id.String())
+	}
+	return nil
+}
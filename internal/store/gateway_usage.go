@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GatewayUsageBucket is the aggregated gateway calls for one minute and one
+// combination of server, tool, agent, workspace, caller and outcome.
+type GatewayUsageBucket struct {
+	BucketStart  time.Time `json:"bucket_start" db:"bucket_start"`
+	ServerLabel  string    `json:"server_label" db:"server_label"`
+	ToolName     string    `json:"tool_name" db:"tool_name"`
+	AgentID      string    `json:"agent_id" db:"agent_id"`
+	WorkspaceID  string    `json:"workspace_id" db:"workspace_id"`
+	CallerID     string    `json:"caller_id" db:"caller_id"`
+	Outcome      string    `json:"outcome" db:"outcome"`
+	Calls        int64     `json:"calls" db:"calls"`
+	LatencySumMS int64     `json:"latency_sum_ms" db:"latency_sum_ms"`
+	LatencyHist  []int64   `json:"latency_hist" db:"latency_hist"`
+	BytesIn      int64     `json:"bytes_in" db:"bytes_in"`
+	BytesOut     int64     `json:"bytes_out" db:"bytes_out"`
+	CostMicros   int64     `json:"cost_micros" db:"cost_micros"`
+}
+
+// GatewayUsageQuery selects a window of usage, grouped by one dimension.
+// Empty filters match everything.
+type GatewayUsageQuery struct {
+	From        time.Time
+	To          time.Time
+	GroupBy     string // server, tool, agent, workspace, caller or outcome
+	ServerLabel string
+	ToolName    string
+	AgentID     string
+	WorkspaceID string
+	CallerID    string
+}
+
+// GatewayUsageGroup is the usage of one group within a query window.
+type GatewayUsageGroup struct {
+	Key          string
+	Outcomes     map[string]int64
+	LatencySumMS int64
+	LatencyHist  []int64
+	BytesIn      int64
+	BytesOut     int64
+	CostMicros   int64
+}
+
+// usageGroupColumns maps GroupBy values to the SQL expression they group on.
+var usageGroupColumns = map[string]string{
+	"server":    "server_label",
+	"tool":      "server_label || '/' || tool_name",
+	"agent":     "agent_id",
+	"workspace": "workspace_id",
+	"caller":    "caller_id",
+	"outcome":   "outcome",
+}
+
+// ValidUsageGroupBy reports whether groupBy is a supported usage dimension.
+func ValidUsageGroupBy(groupBy string) bool {
+	_, ok := usageGroupColumns[groupBy]
+	return ok
+}
+
+// GatewayUsageStore handles database operations for gateway usage metrics.
+type GatewayUsageStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewGatewayUsageStore creates a new GatewayUsageStore.
+func NewGatewayUsageStore(pool *pgxpool.Pool) *GatewayUsageStore {
+	return &GatewayUsageStore{pool: pool}
+}
+
+// Add adds buckets to the stored counts, creating rows as needed.
+func (s *GatewayUsageStore) Add(ctx context.Context, buckets []GatewayUsageBucket) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, b := range buckets {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO gateway_usage (bucket_start, server_label, tool_name, agent_id, workspace_id, caller_id,
+			                           outcome, calls, latency_sum_ms, latency_hist, bytes_in, bytes_out, cost_micros)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (bucket_start, server_label, tool_name, agent_id, workspace_id, caller_id, outcome) DO UPDATE
+			SET calls = gateway_usage.calls + EXCLUDED.calls,
+			    latency_sum_ms = gateway_usage.latency_sum_ms + EXCLUDED.latency_sum_ms,
+			    latency_hist = ARRAY(
+			        SELECT COALESCE(a, 0) + COALESCE(b, 0)
+			        FROM unnest(gateway_usage.latency_hist, EXCLUDED.latency_hist) AS h(a, b)),
+			    bytes_in = gateway_usage.bytes_in + EXCLUDED.bytes_in,
+			    bytes_out = gateway_usage.bytes_out + EXCLUDED.bytes_out,
+			    cost_micros = gateway_usage.cost_micros + EXCLUDED.cost_micros`,
+			b.BucketStart, b.ServerLabel, b.ToolName, b.AgentID, b.WorkspaceID, b.CallerID,
+			b.Outcome, b.Calls, b.LatencySumMS, b.LatencyHist, b.BytesIn, b.BytesOut, b.CostMicros)
+		if err != nil {
+			return fmt.Errorf("adding gateway usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing gateway usage: %w", err)
+	}
+	return nil
+}
+
+// Summarize aggregates the usage in a window by the query's dimension.
+func (s *GatewayUsageStore) Summarize(ctx context.Context, q GatewayUsageQuery) ([]GatewayUsageGroup, error) {
+	groupExpr, ok := usageGroupColumns[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported usage grouping %q", q.GroupBy)
+	}
+
+	where := "WHERE bucket_start >= $1 AND bucket_start < $2"
+	args := []interface{}{q.From, q.To}
+	for _, f := range []struct{ column, value string }{
+		{"server_label", q.ServerLabel},
+		{"tool_name", q.ToolName},
+		{"agent_id", q.AgentID},
+		{"workspace_id", q.WorkspaceID},
+		{"caller_id", q.CallerID},
+	} {
+		if f.value != "" {
+			args = append(args, f.value)
+			where += fmt.Sprintf(" AND %s = $%d", f.column, len(args))
+		}
+	}
+
+	groups := make(map[string]*GatewayUsageGroup)
+	var order []string
+	group := func(key string) *GatewayUsageGroup {
+		g, ok := groups[key]
+		if !ok {
+			g = &GatewayUsageGroup{Key: key, Outcomes: make(map[string]int64)}
+			groups[key] = g
+			order = append(order, key)
+		}
+		return g
+	}
+
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`
+		SELECT %s AS key, outcome, SUM(calls)::bigint, SUM(latency_sum_ms)::bigint,
+		       SUM(bytes_in)::bigint, SUM(bytes_out)::bigint, SUM(cost_micros)::bigint
+		FROM gateway_usage %s
+		GROUP BY key, outcome
+		ORDER BY key`, groupExpr, where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("summarizing gateway usage: %w", err)
+	}
+	for rows.Next() {
+		var key, outcome string
+		var calls, latencySum, bytesIn, bytesOut, cost int64
+		if err := rows.Scan(&key, &outcome, &calls, &latencySum, &bytesIn, &bytesOut, &cost); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning gateway usage: %w", err)
+		}
+		g := group(key)
+		g.Outcomes[outcome] += calls
+		g.LatencySumMS += latencySum
+		g.BytesIn += bytesIn
+		g.BytesOut += bytesOut
+		g.CostMicros += cost
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating gateway usage: %w", err)
+	}
+
+	rows, err = s.pool.Query(ctx, fmt.Sprintf(`
+		SELECT %s AS key, h.i, SUM(h.c)::bigint
+		FROM gateway_usage CROSS JOIN LATERAL unnest(latency_hist) WITH ORDINALITY AS h(c, i) %s
+		GROUP BY key, h.i`, groupExpr, where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("summarizing gateway latency: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var idx, count int64
+		if err := rows.Scan(&key, &idx, &count); err != nil {
+			return nil, fmt.Errorf("scanning gateway latency: %w", err)
+		}
+		g := group(key)
+		for int64(len(g.LatencyHist)) < idx {
+			g.LatencyHist = append(g.LatencyHist, 0)
+		}
+		g.LatencyHist[idx-1] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating gateway latency: %w", err)
+	}
+
+	result := make([]GatewayUsageGroup, 0, len(order))
+	for _, key := range order {
+		result = append(result, *groups[key])
+	}
+	return result, nil
+}
+
+// DeleteBefore removes usage buckets that start before t.
+func (s *GatewayUsageStore) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	ct, err := s.pool.Exec(ctx, `DELETE FROM gateway_usage WHERE bucket_start < $1`, t)
+	if err != nil {
+		return 0, fmt.Errorf("deleting gateway usage: %w", err)
+	}
+	return ct.RowsAffected(), nil
+}
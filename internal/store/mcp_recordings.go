@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agent-smit/agentic-registry/internal/errors"
+)
+
+// MCPRecording is an upstream tool call response recorded by the gateway in
+// sandbox record mode, keyed by tool and the hash of its canonicalized
+// arguments.
+type MCPRecording struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	ServerID    uuid.UUID       `json:"server_id" db:"server_id"`
+	ToolName    string          `json:"tool_name" db:"tool_name"`
+	ArgsHash    string          `json:"args_hash" db:"args_hash"`
+	Arguments   json.RawMessage `json:"arguments" db:"arguments"`
+	StatusCode  int             `json:"status_code" db:"status_code"`
+	Response    json.RawMessage `json:"response" db:"response"`
+	WorkspaceID *uuid.UUID      `json:"workspace_id,omitempty" db:"workspace_id"`
+	RecordedBy  string          `json:"recorded_by" db:"recorded_by"`
+	RecordedAt  time.Time       `json:"recorded_at" db:"recorded_at"`
+}
+
+// MCPRecordingStore handles database operations for sandbox recordings.
+type MCPRecordingStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewMCPRecordingStore creates a new MCPRecordingStore.
+func NewMCPRecordingStore(pool *pgxpool.Pool) *MCPRecordingStore {
+	return &MCPRecordingStore{pool: pool}
+}
+
+const recordingColumns = `id, server_id, tool_name, args_hash, arguments, status_code, response, workspace_id, recorded_by, recorded_at`
+
+func scanRecording(row pgx.Row) (*MCPRecording, error) {
+	var rec MCPRecording
+	err := row.Scan(&rec.ID, &rec.ServerID, &rec.ToolName, &rec.ArgsHash, &rec.Arguments,
+		&rec.StatusCode, &rec.Response, &rec.WorkspaceID, &rec.RecordedBy, &rec.RecordedAt)
+	return &rec, err
+}
+
+// Upsert stores a recording, replacing any earlier recording of the same
+// tool call.
+func (s *MCPRecordingStore) Upsert(ctx context.Context, rec *MCPRecording) error {
+	query := `
+		INSERT INTO mcp_recordings (server_id, tool_name, args_hash, arguments, status_code, response, workspace_id, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (server_id, tool_name, args_hash) DO UPDATE
+		SET arguments = EXCLUDED.arguments, status_code = EXCLUDED.status_code,
+		    response = EXCLUDED.response, workspace_id = EXCLUDED.workspace_id,
+		    recorded_by = EXCLUDED.recorded_by, recorded_at = now()
+		RETURNING id, recorded_at`
+
+	args := rec.Arguments
+	if len(args) == 0 {
+		args = json.RawMessage(`{}`)
+	}
+	err := s.pool.QueryRow(ctx, query, rec.ServerID, rec.ToolName, rec.ArgsHash, args,
+		rec.StatusCode, rec.Response, rec.WorkspaceID, rec.RecordedBy).Scan(&rec.ID, &rec.RecordedAt)
+	if err != nil {
+		return fmt.Errorf("upserting recording: %w", err)
+	}
+	return nil
+}
+
+// Get returns the recording of a tool call.
+func (s *MCPRecordingStore) Get(ctx context.Context, serverID uuid.UUID, toolName, argsHash string) (*MCPRecording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM mcp_recordings
+		WHERE server_id = $1 AND tool_name = $2 AND args_hash = $3`
+
+	rec, err := scanRecording(s.pool.QueryRow(ctx, query, serverID, toolName, argsHash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("recording", toolName)
+		}
+		return nil, fmt.Errorf("getting recording: %w", err)
+	}
+	return rec, nil
+}
+
+// List returns a paginated list of a server's recordings, newest first, and
+// the total count. An empty toolName lists every tool.
+func (s *MCPRecordingStore) List(ctx context.Context, serverID uuid.UUID, toolName string, offset, limit int) ([]MCPRecording, int, error) {
+	where := ` WHERE server_id = $1 AND ($2 = '' OR tool_name = $2)`
+
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM mcp_recordings`+where, serverID, toolName).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting recordings: %w", err)
+	}
+
+	query := `SELECT ` + recordingColumns + ` FROM mcp_recordings` + where + `
+		ORDER BY recorded_at DESC, id
+		LIMIT $3 OFFSET $4`
+	rows, err := s.pool.Query(ctx, query, serverID, toolName, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing recordings: %w", err)
+	}
+	defer rows.Close()
+
+	var recs []MCPRecording
+	for rows.Next() {
+		rec, err := scanRecording(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scanning recording: %w", err)
+		}
+		recs = append(recs, *rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterating recordings: %w", err)
+	}
+	return recs, total, nil
+}
+
+// Delete removes a server's recordings and returns how many were removed.
+// An empty toolName removes the recordings of every tool.
+func (s *MCPRecordingStore) Delete(ctx context.Context, serverID uuid.UUID, toolName string) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM mcp_recordings WHERE server_id = $1 AND ($2 = '' OR tool_name = $2)`,
+		serverID, toolName)
+	if err != nil {
+		return 0, fmt.Errorf("deleting recordings: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
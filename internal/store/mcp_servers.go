@@ -20,6 +20,22 @@ type MCPServer struct {
 	Endpoint          string          `json:"endpoint" db:"endpoint"`
 	AuthType          string          `json:"auth_type" db:"auth_type"`
 	AuthCredential    string          `json:"-" db:"auth_credential"`
+	TLSClientCert     string          `json:"-" db:"tls_client_cert"`
+	TLSClientKey      string          `json:"-" db:"tls_client_key"`
+	TLSCABundle       string          `json:"-" db:"tls_ca_bundle"`
+	CustomHeaders     string          `json:"-" db:"custom_headers"`
+	EgressPolicy      json.RawMessage `json:"egress_policy" db:"egress_policy"`
+	RetryPolicy       json.RawMessage `json:"retry_policy" db:"retry_policy"`
+	Endpoints         json.RawMessage `json:"endpoints" db:"endpoints"`
+	LoadBalancing     string          `json:"load_balancing" db:"load_balancing"`
+	ResponseCache     json.RawMessage `json:"response_cache" db:"response_cache"`
+	Pricing           json.RawMessage `json:"pricing" db:"pricing"`
+	CallLimits        json.RawMessage `json:"call_limits" db:"call_limits"`
+	WorkspaceRequired bool            `json:"workspace_required" db:"workspace_required"`
+	Transport         string          `json:"transport" db:"transport"`
+	StdioCommand      string          `json:"stdio_command" db:"stdio_command"`
+	StdioArgs         json.RawMessage `json:"stdio_args" db:"stdio_args"`
+	StdioEnv          string          `json:"-" db:"stdio_env"`
 	HealthEndpoint    string          `json:"health_endpoint" db:"health_endpoint"`
 	CircuitBreaker    json.RawMessage `json:"circuit_breaker" db:"circuit_breaker"`
 	DiscoveryInterval string          `json:"discovery_interval" db:"discovery_interval"`
@@ -38,11 +54,16 @@ func NewMCPServerStore(pool *pgxpool.Pool) *MCPServerStore {
 	return &MCPServerStore{pool: pool}
 }
 
-// Create inserts a new MCP server. auth_credential should already be encrypted.
+// Create inserts a new MCP server. auth_credential, the TLS fields,
+// custom_headers and stdio_env should already be encrypted.
 func (s *MCPServerStore) Create(ctx context.Context, server *MCPServer) error {
 	query := `
-		INSERT INTO mcp_servers (id, label, endpoint, auth_type, auth_credential, health_endpoint, circuit_breaker, discovery_interval, is_enabled)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO mcp_servers (id, label, endpoint, auth_type, auth_credential, health_endpoint, circuit_breaker, discovery_interval, is_enabled,
+		                         tls_client_cert, tls_client_key, tls_ca_bundle, custom_headers, egress_policy,
+		                         retry_policy, endpoints, load_balancing, response_cache, pricing, workspace_required,
+		                         transport, stdio_command, stdio_args, stdio_env, call_limits)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+		        $21, $22, $23, $24, $25)
 		RETURNING created_at, updated_at`
 
 	if server.ID == uuid.Nil {
@@ -51,11 +72,42 @@ func (s *MCPServerStore) Create(ctx context.Context, server *MCPServer) error {
 	if server.CircuitBreaker == nil {
 		server.CircuitBreaker = json.RawMessage(`{"fail_threshold": 5, "open_duration_s": 30}`)
 	}
+	if server.EgressPolicy == nil {
+		server.EgressPolicy = json.RawMessage(`{}`)
+	}
+	if server.RetryPolicy == nil {
+		server.RetryPolicy = json.RawMessage(`{}`)
+	}
+	if server.Endpoints == nil {
+		server.Endpoints = json.RawMessage(`[]`)
+	}
+	if server.LoadBalancing == "" {
+		server.LoadBalancing = "round_robin"
+	}
+	if server.ResponseCache == nil {
+		server.ResponseCache = json.RawMessage(`{}`)
+	}
+	if server.Pricing == nil {
+		server.Pricing = json.RawMessage(`{}`)
+	}
+	if server.CallLimits == nil {
+		server.CallLimits = json.RawMessage(`{}`)
+	}
+	if server.Transport == "" {
+		server.Transport = "http"
+	}
+	if server.StdioArgs == nil {
+		server.StdioArgs = json.RawMessage(`[]`)
+	}
 
-	err := s.pool.QueryRow(ctx, query,
+	err := conn(ctx, s.pool).QueryRow(ctx, query,
 		server.ID, server.Label, server.Endpoint, server.AuthType,
 		server.AuthCredential, server.HealthEndpoint, server.CircuitBreaker,
 		server.DiscoveryInterval, server.IsEnabled,
+		server.TLSClientCert, server.TLSClientKey, server.TLSCABundle, server.CustomHeaders,
+		server.EgressPolicy, server.RetryPolicy, server.Endpoints, server.LoadBalancing,
+		server.ResponseCache, server.Pricing, server.WorkspaceRequired,
+		server.Transport, server.StdioCommand, server.StdioArgs, server.StdioEnv, server.CallLimits,
 	).Scan(&server.CreatedAt, &server.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("creating mcp server: %w", err)
@@ -67,14 +119,21 @@ func (s *MCPServerStore) Create(ctx context.Context, server *MCPServer) error {
 func (s *MCPServerStore) GetByID(ctx context.Context, id uuid.UUID) (*MCPServer, error) {
 	query := `
 		SELECT id, label, endpoint, auth_type, auth_credential, health_endpoint,
-		       circuit_breaker, discovery_interval, is_enabled, created_at, updated_at
+		       circuit_breaker, discovery_interval, is_enabled, created_at, updated_at,
+		       tls_client_cert, tls_client_key, tls_ca_bundle, custom_headers, egress_policy,
+		       retry_policy, endpoints, load_balancing, response_cache, pricing, workspace_required,
+		       transport, stdio_command, stdio_args, stdio_env, call_limits
 		FROM mcp_servers WHERE id = $1`
 
 	server := &MCPServer{}
-	err := s.pool.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, id).Scan(
 		&server.ID, &server.Label, &server.Endpoint, &server.AuthType,
 		&server.AuthCredential, &server.HealthEndpoint, &server.CircuitBreaker,
 		&server.DiscoveryInterval, &server.IsEnabled, &server.CreatedAt, &server.UpdatedAt,
+		&server.TLSClientCert, &server.TLSClientKey, &server.TLSCABundle, &server.CustomHeaders,
+		&server.EgressPolicy, &server.RetryPolicy, &server.Endpoints, &server.LoadBalancing,
+		&server.ResponseCache, &server.Pricing, &server.WorkspaceRequired,
+		&server.Transport, &server.StdioCommand, &server.StdioArgs, &server.StdioEnv, &server.CallLimits,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -89,14 +148,21 @@ func (s *MCPServerStore) GetByID(ctx context.Context, id uuid.UUID) (*MCPServer,
 func (s *MCPServerStore) GetByLabel(ctx context.Context, label string) (*MCPServer, error) {
 	query := `
 		SELECT id, label, endpoint, auth_type, auth_credential, health_endpoint,
-		       circuit_breaker, discovery_interval, is_enabled, created_at, updated_at
+		       circuit_breaker, discovery_interval, is_enabled, created_at, updated_at,
+		       tls_client_cert, tls_client_key, tls_ca_bundle, custom_headers, egress_policy,
+		       retry_policy, endpoints, load_balancing, response_cache, pricing, workspace_required,
+		       transport, stdio_command, stdio_args, stdio_env, call_limits
 		FROM mcp_servers WHERE label = $1`
 
 	server := &MCPServer{}
-	err := s.pool.QueryRow(ctx, query, label).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, label).Scan(
 		&server.ID, &server.Label, &server.Endpoint, &server.AuthType,
 		&server.AuthCredential, &server.HealthEndpoint, &server.CircuitBreaker,
 		&server.DiscoveryInterval, &server.IsEnabled, &server.CreatedAt, &server.UpdatedAt,
+		&server.TLSClientCert, &server.TLSClientKey, &server.TLSCABundle, &server.CustomHeaders,
+		&server.EgressPolicy, &server.RetryPolicy, &server.Endpoints, &server.LoadBalancing,
+		&server.ResponseCache, &server.Pricing, &server.WorkspaceRequired,
+		&server.Transport, &server.StdioCommand, &server.StdioArgs, &server.StdioEnv, &server.CallLimits,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -111,11 +177,14 @@ func (s *MCPServerStore) GetByLabel(ctx context.Context, label string) (*MCPServ
 func (s *MCPServerStore) List(ctx context.Context) ([]MCPServer, error) {
 	query := `
 		SELECT id, label, endpoint, auth_type, auth_credential, health_endpoint,
-		       circuit_breaker, discovery_interval, is_enabled, created_at, updated_at
+		       circuit_breaker, discovery_interval, is_enabled, created_at, updated_at,
+		       tls_client_cert, tls_client_key, tls_ca_bundle, custom_headers, egress_policy,
+		       retry_policy, endpoints, load_balancing, response_cache, pricing, workspace_required,
+		       transport, stdio_command, stdio_args, stdio_env, call_limits
 		FROM mcp_servers
 		ORDER BY label ASC`
 
-	rows, err := s.pool.Query(ctx, query)
+	rows, err := conn(ctx, s.pool).Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("listing mcp servers: %w", err)
 	}
@@ -128,6 +197,10 @@ func (s *MCPServerStore) List(ctx context.Context) ([]MCPServer, error) {
 			&srv.ID, &srv.Label, &srv.Endpoint, &srv.AuthType,
 			&srv.AuthCredential, &srv.HealthEndpoint, &srv.CircuitBreaker,
 			&srv.DiscoveryInterval, &srv.IsEnabled, &srv.CreatedAt, &srv.UpdatedAt,
+			&srv.TLSClientCert, &srv.TLSClientKey, &srv.TLSCABundle, &srv.CustomHeaders,
+			&srv.EgressPolicy, &srv.RetryPolicy, &srv.Endpoints, &srv.LoadBalancing,
+			&srv.ResponseCache, &srv.Pricing, &srv.WorkspaceRequired,
+			&srv.Transport, &srv.StdioCommand, &srv.StdioArgs, &srv.StdioEnv, &srv.CallLimits,
 		); err != nil {
 			return nil, fmt.Errorf("scanning mcp server: %w", err)
 		}
@@ -146,14 +219,51 @@ func (s *MCPServerStore) Update(ctx context.Context, server *MCPServer) error {
 		UPDATE mcp_servers SET
 			label = $2, endpoint = $3, auth_type = $4, auth_credential = $5,
 			health_endpoint = $6, circuit_breaker = $7, discovery_interval = $8,
-			is_enabled = $9, updated_at = now()
+			is_enabled = $9, tls_client_cert = $11, tls_client_key = $12,
+			tls_ca_bundle = $13, custom_headers = $14, egress_policy = $15,
+			retry_policy = $16, endpoints = $17, load_balancing = $18,
+			response_cache = $19, pricing = $20, workspace_required = $21,
+			stdio_command = $22, stdio_args = $23, stdio_env = $24, call_limits = $25,
+			updated_at = now()
 		WHERE id = $1 AND updated_at = $10
 		RETURNING updated_at`
 
-	err := s.pool.QueryRow(ctx, query,
+	if server.EgressPolicy == nil {
+		server.EgressPolicy = json.RawMessage(`{}`)
+	}
+	if server.RetryPolicy == nil {
+		server.RetryPolicy = json.RawMessage(`{}`)
+	}
+	if server.Endpoints == nil {
+		server.Endpoints = json.RawMessage(`[]`)
+	}
+	if server.LoadBalancing == "" {
+		server.LoadBalancing = "round_robin"
+	}
+	if server.ResponseCache == nil {
+		server.ResponseCache = json.RawMessage(`{}`)
+	}
+	if server.Pricing == nil {
+		server.Pricing = json.RawMessage(`{}`)
+	}
+	if server.CallLimits == nil {
+		server.CallLimits = json.RawMessage(`{}`)
+	}
+	if server.Transport == "" {
+		server.Transport = "http"
+	}
+	if server.StdioArgs == nil {
+		server.StdioArgs = json.RawMessage(`[]`)
+	}
+
+	err := conn(ctx, s.pool).QueryRow(ctx, query,
 		server.ID, server.Label, server.Endpoint, server.AuthType,
 		server.AuthCredential, server.HealthEndpoint, server.CircuitBreaker,
 		server.DiscoveryInterval, server.IsEnabled, server.UpdatedAt,
+		server.TLSClientCert, server.TLSClientKey, server.TLSCABundle, server.CustomHeaders,
+		server.EgressPolicy, server.RetryPolicy, server.Endpoints, server.LoadBalancing,
+		server.ResponseCache, server.Pricing, server.WorkspaceRequired,
+		server.StdioCommand, server.StdioArgs, server.StdioEnv, server.CallLimits,
 	).Scan(&server.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -167,7 +277,7 @@ func (s *MCPServerStore) Update(ctx context.Context, server *MCPServer) error {
 // Delete hard-deletes an MCP server by ID.
 func (s *MCPServerStore) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM mcp_servers WHERE id = $1`
-	ct, err := s.pool.Exec(ctx, query, id)
+	ct, err := conn(ctx, s.pool).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("deleting mcp server: %w", err)
 	}
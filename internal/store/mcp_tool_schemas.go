@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agent-smit/agentic-registry/internal/errors"
+)
+
+// MCPToolSchema is a tool definition discovered from an upstream MCP server,
+// together with an optional admin-authored schema overlay that tightens it.
+type MCPToolSchema struct {
+	ServerID      uuid.UUID       `json:"server_id" db:"server_id"`
+	ToolName      string          `json:"tool_name" db:"tool_name"`
+	Description   string          `json:"description" db:"description"`
+	InputSchema   json.RawMessage `json:"input_schema" db:"input_schema"`
+	SchemaOverlay json.RawMessage `json:"schema_overlay" db:"schema_overlay"`
+	DiscoveredAt  *time.Time      `json:"discovered_at,omitempty" db:"discovered_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// MCPToolSchemaStore handles database operations for discovered tool schemas.
+type MCPToolSchemaStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewMCPToolSchemaStore creates a new MCPToolSchemaStore.
+func NewMCPToolSchemaStore(pool *pgxpool.Pool) *MCPToolSchemaStore {
+	return &MCPToolSchemaStore{pool: pool}
+}
+
+const toolSchemaColumns = `server_id, tool_name, description, input_schema, schema_overlay, discovered_at, updated_at`
+
+func scanToolSchema(row pgx.Row) (*MCPToolSchema, error) {
+	var t MCPToolSchema
+	err := row.Scan(&t.ServerID, &t.ToolName, &t.Description, &t.InputSchema, &t.SchemaOverlay, &t.DiscoveredAt, &t.UpdatedAt)
+	return &t, err
+}
+
+// ListByServer returns all tool schemas for a server, ordered by tool name.
+func (s *MCPToolSchemaStore) ListByServer(ctx context.Context, serverID uuid.UUID) ([]MCPToolSchema, error) {
+	query := `SELECT ` + toolSchemaColumns + ` FROM mcp_tool_schemas WHERE server_id = $1 ORDER BY tool_name`
+
+	rows, err := s.pool.Query(ctx, query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("listing tool schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var tools []MCPToolSchema
+	for rows.Next() {
+		t, err := scanToolSchema(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning tool schema: %w", err)
+		}
+		tools = append(tools, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating tool schemas: %w", err)
+	}
+	return tools, nil
+}
+
+// Get returns the schema for a single tool.
+func (s *MCPToolSchemaStore) Get(ctx context.Context, serverID uuid.UUID, toolName string) (*MCPToolSchema, error) {
+	query := `SELECT ` + toolSchemaColumns + ` FROM mcp_tool_schemas WHERE server_id = $1 AND tool_name = $2`
+
+	t, err := scanToolSchema(s.pool.QueryRow(ctx, query, serverID, toolName))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("tool schema", toolName)
+		}
+		return nil, fmt.Errorf("getting tool schema: %w", err)
+	}
+	return t, nil
+}
+
+// ReplaceDiscovered stores the result of a tools/list discovery run. Tools
+// no longer advertised upstream are removed unless an admin overlay exists,
+// in which case only their discovered schema is cleared so the overlay
+// survives until the tool comes back or the overlay is removed.
+func (s *MCPToolSchemaStore) ReplaceDiscovered(ctx context.Context, serverID uuid.UUID, tools []MCPToolSchema) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		schema := t.InputSchema
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{}`)
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO mcp_tool_schemas (server_id, tool_name, description, input_schema, discovered_at, updated_at)
+			VALUES ($1, $2, $3, $4, now(), now())
+			ON CONFLICT (server_id, tool_name) DO UPDATE
+			SET description = EXCLUDED.description, input_schema = EXCLUDED.input_schema,
+			    discovered_at = now(), updated_at = now()`,
+			serverID, t.ToolName, t.Description, schema)
+		if err != nil {
+			return fmt.Errorf("upserting tool schema %s: %w", t.ToolName, err)
+		}
+		names = append(names, t.ToolName)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM mcp_tool_schemas
+		WHERE server_id = $1 AND NOT (tool_name = ANY($2)) AND schema_overlay = '{}'::jsonb`,
+		serverID, names); err != nil {
+		return fmt.Errorf("removing stale tool schemas: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE mcp_tool_schemas
+		SET input_schema = '{}', discovered_at = NULL, updated_at = now()
+		WHERE server_id = $1 AND NOT (tool_name = ANY($2)) AND discovered_at IS NOT NULL`,
+		serverID, names); err != nil {
+		return fmt.Errorf("clearing stale tool schemas: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+// SetOverlay sets the admin schema overlay for a tool, creating the row if
+// the tool has not been discovered yet. An empty object clears the overlay.
+func (s *MCPToolSchemaStore) SetOverlay(ctx context.Context, serverID uuid.UUID, toolName string, overlay json.RawMessage) (*MCPToolSchema, error) {
+	query := `
+		INSERT INTO mcp_tool_schemas (server_id, tool_name, schema_overlay)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (server_id, tool_name) DO UPDATE
+		SET schema_overlay = EXCLUDED.schema_overlay, updated_at = now()
+		RETURNING ` + toolSchemaColumns
+
+	t, err := scanToolSchema(s.pool.QueryRow(ctx, query, serverID, toolName, overlay))
+	if err != nil {
+		return nil, fmt.Errorf("setting tool schema overlay: %w", err)
+	}
+	return t, nil
+}
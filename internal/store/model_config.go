@@ -58,7 +58,7 @@ func scanModelConfig(row pgx.Row) (*ModelConfig, error) {
 func (s *ModelConfigStore) GetByScope(ctx context.Context, scope, scopeID string) (*ModelConfig, error) {
 	query := fmt.Sprintf(`SELECT %s FROM model_config WHERE scope = $1 AND scope_id = $2`, modelConfigColumns)
 
-	c, err := scanModelConfig(s.pool.QueryRow(ctx, query, scope, scopeID))
+	c, err := scanModelConfig(conn(ctx, s.pool).QueryRow(ctx, query, scope, scopeID))
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, errors.NotFound("model_config", scope+"/"+scopeID)
@@ -148,7 +148,7 @@ func (s *ModelConfigStore) Update(ctx context.Context, config *ModelConfig, etag
 		WHERE scope = $1 AND scope_id = $2 AND updated_at = $12
 		RETURNING updated_at`
 
-	err := s.pool.QueryRow(ctx, query,
+	err := conn(ctx, s.pool).QueryRow(ctx, query,
 		config.Scope, config.ScopeID,
 		config.DefaultModel, config.Temperature, config.MaxTokens,
 		config.MaxToolRounds, config.DefaultContextWindow, config.DefaultMaxOutputTokens,
@@ -184,7 +184,7 @@ func (s *ModelConfigStore) Upsert(ctx context.Context, config *ModelConfig) erro
 			updated_at = now()
 		RETURNING %s`, modelConfigColumns)
 
-	_, err := scanModelConfig(s.pool.QueryRow(ctx, query,
+	_, err := scanModelConfig(conn(ctx, s.pool).QueryRow(ctx, query,
 		config.Scope, config.ScopeID,
 		config.DefaultModel, config.Temperature, config.MaxTokens,
 		config.MaxToolRounds, config.DefaultContextWindow, config.DefaultMaxOutputTokens,
@@ -57,7 +57,7 @@ func NewModelEndpointStore(pool *pgxpool.Pool, encKey []byte) *ModelEndpointStor
 
 // Create inserts a new model endpoint and creates version 1 in a transaction.
 func (s *ModelEndpointStore) Create(ctx context.Context, endpoint *ModelEndpoint, initialConfig json.RawMessage, changeNote string) error {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -114,7 +114,7 @@ func (s *ModelEndpointStore) GetBySlug(ctx context.Context, slug string) (*Model
 		FROM model_endpoints WHERE slug = $1`
 
 	ep := &ModelEndpoint{}
-	err := s.pool.QueryRow(ctx, query, slug).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, slug).Scan(
 		&ep.ID, &ep.Slug, &ep.Name, &ep.Provider, &ep.EndpointURL,
 		&ep.IsFixedModel, &ep.ModelName, &ep.AllowedModels,
 		&ep.IsActive, &ep.WorkspaceID, &ep.CreatedBy,
@@ -157,7 +157,7 @@ func (s *ModelEndpointStore) List(ctx context.Context, workspaceID *string, acti
 
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM model_endpoints%s", where)
 	var total int
-	if err := s.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("counting model endpoints: %w", err)
 	}
 
@@ -170,7 +170,7 @@ func (s *ModelEndpointStore) List(ctx context.Context, workspaceID *string, acti
 
 	args = append(args, limit, offset)
 
-	rows, err := s.pool.Query(ctx, dataQuery, args...)
+	rows, err := conn(ctx, s.pool).Query(ctx, dataQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("listing model endpoints: %w", err)
 	}
@@ -210,7 +210,7 @@ func (s *ModelEndpointStore) Update(ctx context.Context, endpoint *ModelEndpoint
 		WHERE slug = $1 AND updated_at = $10
 		RETURNING id, updated_at`
 
-	err := s.pool.QueryRow(ctx, query,
+	err := conn(ctx, s.pool).QueryRow(ctx, query,
 		endpoint.Slug, endpoint.Name, endpoint.Provider, endpoint.EndpointURL,
 		endpoint.IsFixedModel, endpoint.ModelName, endpoint.AllowedModels,
 		endpoint.IsActive, endpoint.WorkspaceID, updatedAt,
@@ -227,7 +227,7 @@ func (s *ModelEndpointStore) Update(ctx context.Context, endpoint *ModelEndpoint
 // Delete performs a soft-delete by setting is_active = false.
 func (s *ModelEndpointStore) Delete(ctx context.Context, slug string) error {
 	query := `UPDATE model_endpoints SET is_active = false, updated_at = now() WHERE slug = $1`
-	ct, err := s.pool.Exec(ctx, query, slug)
+	ct, err := conn(ctx, s.pool).Exec(ctx, query, slug)
 	if err != nil {
 		return fmt.Errorf("soft-deleting model endpoint: %w", err)
 	}
@@ -240,7 +240,7 @@ func (s *ModelEndpointStore) Delete(ctx context.Context, slug string) error {
 // CreateVersion inserts a new version for a model endpoint, auto-incrementing the version number.
 // The new version is NOT automatically activated.
 func (s *ModelEndpointStore) CreateVersion(ctx context.Context, endpointID uuid.UUID, config json.RawMessage, changeNote, createdBy string) (*ModelEndpointVersion, error) {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -295,7 +295,7 @@ func (s *ModelEndpointStore) CreateVersion(ctx context.Context, endpointID uuid.
 func (s *ModelEndpointStore) ListVersions(ctx context.Context, endpointID uuid.UUID, offset, limit int) ([]ModelEndpointVersion, int, error) {
 	countQuery := `SELECT COUNT(*) FROM model_endpoint_versions WHERE endpoint_id = $1`
 	var total int
-	if err := s.pool.QueryRow(ctx, countQuery, endpointID).Scan(&total); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, countQuery, endpointID).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("counting model endpoint versions: %w", err)
 	}
 
@@ -306,7 +306,7 @@ func (s *ModelEndpointStore) ListVersions(ctx context.Context, endpointID uuid.U
 		ORDER BY version DESC
 		LIMIT $2 OFFSET $3`
 
-	rows, err := s.pool.Query(ctx, query, endpointID, limit, offset)
+	rows, err := conn(ctx, s.pool).Query(ctx, query, endpointID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("listing model endpoint versions: %w", err)
 	}
@@ -339,7 +339,7 @@ func (s *ModelEndpointStore) GetVersion(ctx context.Context, endpointID uuid.UUI
 		WHERE endpoint_id = $1 AND version = $2`
 
 	v := &ModelEndpointVersion{}
-	err := s.pool.QueryRow(ctx, query, endpointID, version).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, endpointID, version).Scan(
 		&v.ID, &v.EndpointID, &v.Version, &v.Config,
 		&v.IsActive, &v.ChangeNote, &v.CreatedBy, &v.CreatedAt,
 	)
@@ -362,7 +362,7 @@ func (s *ModelEndpointStore) GetActiveVersion(ctx context.Context, endpointID uu
 		LIMIT 1`
 
 	v := &ModelEndpointVersion{}
-	err := s.pool.QueryRow(ctx, query, endpointID).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, endpointID).Scan(
 		&v.ID, &v.EndpointID, &v.Version, &v.Config,
 		&v.IsActive, &v.ChangeNote, &v.CreatedBy, &v.CreatedAt,
 	)
@@ -383,7 +383,7 @@ func (s *ModelEndpointStore) ActivateVersion(ctx context.Context, endpointID uui
 		return nil, err
 	}
 
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -417,7 +417,7 @@ func (s *ModelEndpointStore) ActivateVersion(ctx context.Context, endpointID uui
 func (s *ModelEndpointStore) CountAll(ctx context.Context) (int, error) {
 	var count int
 	query := "SELECT COUNT(*) FROM model_endpoints"
-	if err := s.pool.QueryRow(ctx, query).Scan(&count); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, query).Scan(&count); err != nil {
 		return 0, fmt.Errorf("counting all model endpoints: %w", err)
 	}
 	return count, nil
@@ -48,7 +48,7 @@ func (s *PromptStore) List(ctx context.Context, agentID string, activeOnly bool,
 
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM prompts %s", where)
 	var total int
-	if err := s.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("counting prompts: %w", err)
 	}
 
@@ -60,7 +60,7 @@ func (s *PromptStore) List(ctx context.Context, agentID string, activeOnly bool,
 
 	args = append(args, limit, offset)
 
-	rows, err := s.pool.Query(ctx, dataQuery, args...)
+	rows, err := conn(ctx, s.pool).Query(ctx, dataQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("listing prompts: %w", err)
 	}
@@ -93,7 +93,7 @@ func (s *PromptStore) GetActive(ctx context.Context, agentID string) (*Prompt, e
 		LIMIT 1`
 
 	p := &Prompt{}
-	err := s.pool.QueryRow(ctx, query, agentID).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, agentID).Scan(
 		&p.ID, &p.AgentID, &p.Version, &p.SystemPrompt,
 		&p.TemplateVars, &p.Mode, &p.IsActive, &p.CreatedBy, &p.CreatedAt,
 	)
@@ -114,7 +114,7 @@ func (s *PromptStore) GetByID(ctx context.Context, id uuid.UUID) (*Prompt, error
 		WHERE id = $1`
 
 	p := &Prompt{}
-	err := s.pool.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, id).Scan(
 		&p.ID, &p.AgentID, &p.Version, &p.SystemPrompt,
 		&p.TemplateVars, &p.Mode, &p.IsActive, &p.CreatedBy, &p.CreatedAt,
 	)
@@ -130,7 +130,7 @@ func (s *PromptStore) GetByID(ctx context.Context, id uuid.UUID) (*Prompt, error
 // Create inserts a new prompt, deactivating any previously active prompt for the same agent.
 // The version is auto-assigned as max(version)+1. This is transactional.
 func (s *PromptStore) Create(ctx context.Context, prompt *Prompt) error {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -186,7 +186,7 @@ func (s *PromptStore) Activate(ctx context.Context, id uuid.UUID) (*Prompt, erro
 		return nil, err
 	}
 
-	tx, err := s.pool.Begin(ctx)
+	tx, err := conn(ctx, s.pool).Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -230,7 +230,7 @@ func (s *PromptStore) Rollback(ctx context.Context, agentID string, targetVersio
 	var templateVars json.RawMessage
 	var mode string
 
-	err := s.pool.QueryRow(ctx, targetQuery, agentID, targetVersion).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, targetQuery, agentID, targetVersion).Scan(
 		&systemPrompt, &templateVars, &mode,
 	)
 	if err != nil {
@@ -264,7 +264,7 @@ func (s *PromptStore) GetByVersion(ctx context.Context, agentID string, version
 		WHERE agent_id = $1 AND version = $2`
 
 	p := &Prompt{}
-	err := s.pool.QueryRow(ctx, query, agentID, version).Scan(
+	err := conn(ctx, s.pool).QueryRow(ctx, query, agentID, version).Scan(
 		&p.ID, &p.AgentID, &p.Version, &p.SystemPrompt,
 		&p.TemplateVars, &p.Mode, &p.IsActive, &p.CreatedBy, &p.CreatedAt,
 	)
@@ -39,7 +39,7 @@ func (s *TrustDefaultStore) List(ctx context.Context) ([]TrustDefault, error) {
 		FROM trust_defaults
 		ORDER BY priority ASC`
 
-	rows, err := s.pool.Query(ctx, query)
+	rows, err := conn(ctx, s.pool).Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("listing trust defaults: %w", err)
 	}
@@ -67,7 +67,7 @@ func (s *TrustDefaultStore) GetByID(ctx context.Context, id uuid.UUID) (*TrustDe
 		FROM trust_defaults WHERE id = $1`
 
 	d := &TrustDefault{}
-	err := s.pool.QueryRow(ctx, query, id).Scan(&d.ID, &d.Tier, &d.Patterns, &d.Priority, &d.UpdatedAt)
+	err := conn(ctx, s.pool).QueryRow(ctx, query, id).Scan(&d.ID, &d.Tier, &d.Patterns, &d.Priority, &d.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, errors.NotFound("trust_default", id.String())
@@ -85,7 +85,7 @@ func (s *TrustDefaultStore) Update(ctx context.Context, d *TrustDefault) error {
 		WHERE id = $1 AND updated_at = $3
 		RETURNING updated_at`
 
-	err := s.pool.QueryRow(ctx, query, d.ID, d.Patterns, d.UpdatedAt).Scan(&d.UpdatedAt)
+	err := conn(ctx, s.pool).QueryRow(ctx, query, d.ID, d.Patterns, d.UpdatedAt).Scan(&d.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return errors.Conflict("trust default was modified by another request")
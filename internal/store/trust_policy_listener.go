@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TrustPolicyChannel is the notification channel trust policy triggers
+// publish to.
+const TrustPolicyChannel = "trust_policy_changed"
+
+// TrustPolicyChange is the payload of a trust policy notification. Scope is
+// trust_rule, trust_default or agent; Key is the workspace or agent ID.
+type TrustPolicyChange struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+}
+
+// TrustPolicyListener subscribes to trust policy changes from every replica.
+type TrustPolicyListener struct {
+	pool *pgxpool.Pool
+}
+
+// NewTrustPolicyListener creates a new TrustPolicyListener.
+func NewTrustPolicyListener(pool *pgxpool.Pool) *TrustPolicyListener {
+	return &TrustPolicyListener{pool: pool}
+}
+
+// Listen holds a dedicated connection listening on TrustPolicyChannel and
+// calls onChange for each notification until ctx is canceled or the
+// connection fails. ready is called once the subscription is active.
+func (l *TrustPolicyListener) Listen(ctx context.Context, ready func(), onChange func(TrustPolicyChange)) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring listener connection: %w", err)
+	}
+	// The connection stays subscribed, so it is taken out of the pool.
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+
+	if _, err := pgConn.Exec(ctx, "LISTEN "+TrustPolicyChannel); err != nil {
+		return fmt.Errorf("listening for trust policy changes: %w", err)
+	}
+	ready()
+
+	for {
+		n, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("waiting for trust policy changes: %w", err)
+		}
+		var change TrustPolicyChange
+		if err := json.Unmarshal([]byte(n.Payload), &change); err != nil {
+			// An unreadable change may be for any policy.
+			log.Printf("invalid trust policy notification %q: %v", n.Payload, err)
+			change = TrustPolicyChange{}
+		}
+		onChange(change)
+	}
+}
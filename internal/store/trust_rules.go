@@ -40,7 +40,7 @@ func (s *TrustRuleStore) List(ctx context.Context, workspaceID uuid.UUID) ([]Tru
 		WHERE workspace_id = $1
 		ORDER BY tool_pattern ASC`
 
-	rows, err := s.pool.Query(ctx, query, workspaceID)
+	rows, err := conn(ctx, s.pool).Query(ctx, query, workspaceID)
 	if err != nil {
 		return nil, fmt.Errorf("listing trust rules: %w", err)
 	}
@@ -77,7 +77,7 @@ func (s *TrustRuleStore) Upsert(ctx context.Context, rule *TrustRule) error {
 		rule.ID = uuid.New()
 	}
 
-	err := s.pool.QueryRow(ctx, query,
+	err := conn(ctx, s.pool).QueryRow(ctx, query,
 		rule.ID, rule.WorkspaceID, rule.ToolPattern, rule.Tier, rule.CreatedBy,
 	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
 	if err != nil {
@@ -89,7 +89,7 @@ func (s *TrustRuleStore) Upsert(ctx context.Context, rule *TrustRule) error {
 // Delete hard-deletes a trust rule by ID.
 func (s *TrustRuleStore) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM trust_rules WHERE id = $1`
-	ct, err := s.pool.Exec(ctx, query, id)
+	ct, err := conn(ctx, s.pool).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("deleting trust rule: %w", err)
 	}
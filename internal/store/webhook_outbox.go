@@ -0,0 +1,356 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agent-smit/agentic-registry/internal/errors"
+)
+
+// Webhook outbox row statuses.
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxFailed    = "failed"
+)
+
+// WebhookOutboxEntry is a webhook event queued for delivery to one
+// subscription.
+type WebhookOutboxEntry struct {
+	ID             uuid.UUID       `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	ContentType    string          `json:"content_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error"`
+	LastStatusCode *int            `json:"last_status_code"`
+	RedeliveryOf   *uuid.UUID      `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	FinishedAt     *time.Time      `json:"finished_at,omitempty"`
+}
+
+// WebhookDeliveryAttempt is one attempt to deliver an outbox entry.
+// StatusCode is nil when no response was received.
+type WebhookDeliveryAttempt struct {
+	ID           uuid.UUID `json:"id"`
+	DeliveryID   uuid.UUID `json:"delivery_id"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   *int      `json:"status_code"`
+	LatencyMS    int       `json:"latency_ms"`
+	ResponseBody string    `json:"response_body"`
+	Error        string    `json:"error"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+}
+
+// WebhookDeliveryFilter narrows a subscription's delivery list. Zero fields
+// match everything; From and To bound the creation time.
+type WebhookDeliveryFilter struct {
+	Status    string
+	EventType string
+	From      time.Time
+	To        time.Time
+}
+
+// ClaimedWebhookDelivery is an outbox entry claimed for delivery, with the
+// subscription's endpoint and signing secret. MessageID is the ID of the
+// original delivery for a redelivery, and the entry's own ID otherwise.
+type ClaimedWebhookDelivery struct {
+	ID              uuid.UUID
+	MessageID       uuid.UUID
+	SubscriptionID  uuid.UUID
+	URL             string
+	Secret          string
+	PreviousSecret  string
+	SignatureScheme string
+	EventType       string
+	Payload         json.RawMessage
+	ContentType     string
+	Attempts        int
+}
+
+// WebhookOutboxStore handles database operations for the webhook outbox.
+type WebhookOutboxStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebhookOutboxStore creates a new WebhookOutboxStore.
+func NewWebhookOutboxStore(pool *pgxpool.Pool) *WebhookOutboxStore {
+	return &WebhookOutboxStore{pool: pool}
+}
+
+// InTx runs fn in a transaction; see InTx.
+func (s *WebhookOutboxStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return InTx(ctx, s.pool, fn)
+}
+
+// Enqueue inserts pending entries, in the transaction ctx carries if any.
+func (s *WebhookOutboxStore) Enqueue(ctx context.Context, entries []WebhookOutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(`
+			INSERT INTO webhook_outbox (subscription_id, event_type, payload, content_type)
+			VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'application/json'))`,
+			e.SubscriptionID, e.EventType, e.Payload, e.ContentType)
+	}
+	if err := conn(ctx, s.pool).SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("enqueueing webhook events: %w", err)
+	}
+	return nil
+}
+
+// Claim locks up to limit due pending entries of active subscriptions,
+// skipping entries other replicas have locked. Each claimed entry's attempt
+// count is incremented and its next attempt is pushed back by lease, so an
+// entry whose claimant dies before marking it is claimed again once the
+// lease expires. Entries of paused subscriptions wait until they resume.
+func (s *WebhookOutboxStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]ClaimedWebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT o.id FROM webhook_outbox o
+			JOIN webhook_subscriptions s ON s.id = o.subscription_id
+			WHERE o.status = 'pending' AND o.next_attempt_at <= now() AND s.is_active
+			ORDER BY o.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF o SKIP LOCKED
+		)
+		UPDATE webhook_outbox o
+		SET attempts = o.attempts + 1, next_attempt_at = now() + $2 * interval '1 millisecond'
+		FROM due, webhook_subscriptions s
+		WHERE o.id = due.id AND s.id = o.subscription_id
+		RETURNING o.id, COALESCE(o.redelivery_of, o.id), o.subscription_id, s.url, s.secret,
+			CASE WHEN s.previous_secret_expires_at > now() THEN s.previous_secret ELSE '' END,
+			s.signature_scheme, o.event_type, o.payload, o.content_type, o.attempts`
+
+	rows, err := conn(ctx, s.pool).Query(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []ClaimedWebhookDelivery
+	for rows.Next() {
+		var d ClaimedWebhookDelivery
+		if err := rows.Scan(&d.ID, &d.MessageID, &d.SubscriptionID, &d.URL, &d.Secret, &d.PreviousSecret,
+			&d.SignatureScheme, &d.EventType, &d.Payload, &d.ContentType, &d.Attempts); err != nil {
+			return nil, fmt.Errorf("scanning webhook delivery: %w", err)
+		}
+		claimed = append(claimed, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating webhook deliveries: %w", err)
+	}
+	return claimed, nil
+}
+
+// MarkDelivered records a successful delivery.
+func (s *WebhookOutboxStore) MarkDelivered(ctx context.Context, id uuid.UUID) error {
+	_, err := conn(ctx, s.pool).Exec(ctx, `
+		UPDATE webhook_outbox SET status = 'delivered', last_error = '', finished_at = now()
+		WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("marking webhook delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and schedules the next one.
+func (s *WebhookOutboxStore) MarkRetry(ctx context.Context, id uuid.UUID, next time.Time, lastErr string) error {
+	_, err := conn(ctx, s.pool).Exec(ctx, `
+		UPDATE webhook_outbox SET next_attempt_at = $2, last_error = $3
+		WHERE id = $1`, id, next, lastErr)
+	if err != nil {
+		return fmt.Errorf("scheduling webhook retry: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a delivery that exhausted its attempts.
+func (s *WebhookOutboxStore) MarkFailed(ctx context.Context, id uuid.UUID, lastErr string) error {
+	_, err := conn(ctx, s.pool).Exec(ctx, `
+		UPDATE webhook_outbox SET status = 'failed', last_error = $2, finished_at = now()
+		WHERE id = $1`, id, lastErr)
+	if err != nil {
+		return fmt.Errorf("marking webhook failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteFinishedBefore removes delivered and failed entries finished before
+// cutoff and returns how many were removed.
+func (s *WebhookOutboxStore) DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := conn(ctx, s.pool).Exec(ctx, `
+		DELETE FROM webhook_outbox WHERE status <> 'pending' AND finished_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("deleting finished webhook deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RecordAttempt stores the outcome of a delivery attempt.
+func (s *WebhookOutboxStore) RecordAttempt(ctx context.Context, a *WebhookDeliveryAttempt) error {
+	query := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, latency_ms, response_body, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, attempted_at`
+
+	err := conn(ctx, s.pool).QueryRow(ctx, query, a.DeliveryID, a.Attempt, a.StatusCode,
+		a.LatencyMS, a.ResponseBody, a.Error).Scan(&a.ID, &a.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("recording webhook attempt: %w", err)
+	}
+	return nil
+}
+
+const outboxColumns = `o.id, o.subscription_id, o.event_type, o.payload, o.content_type, o.status, o.attempts,
+	o.next_attempt_at, o.last_error,
+	(SELECT a.status_code FROM webhook_delivery_attempts a WHERE a.delivery_id = o.id ORDER BY a.attempt DESC LIMIT 1),
+	o.redelivery_of, o.created_at, o.finished_at`
+
+func scanOutboxEntry(row pgx.Row) (*WebhookOutboxEntry, error) {
+	var e WebhookOutboxEntry
+	err := row.Scan(&e.ID, &e.SubscriptionID, &e.EventType, &e.Payload, &e.ContentType, &e.Status, &e.Attempts,
+		&e.NextAttemptAt, &e.LastError, &e.LastStatusCode, &e.RedeliveryOf, &e.CreatedAt, &e.FinishedAt)
+	return &e, err
+}
+
+// ListDeliveries returns a paginated list of a subscription's deliveries,
+// newest first, and the total count.
+func (s *WebhookOutboxStore) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, f WebhookDeliveryFilter, offset, limit int) ([]WebhookOutboxEntry, int, error) {
+	where := ` WHERE o.subscription_id = $1
+		AND ($2 = '' OR o.status = $2)
+		AND ($3 = '' OR o.event_type = $3)
+		AND ($4::timestamptz IS NULL OR o.created_at >= $4)
+		AND ($5::timestamptz IS NULL OR o.created_at < $5)`
+	args := []any{subscriptionID, f.Status, f.EventType, nullTime(f.From), nullTime(f.To)}
+
+	var total int
+	if err := conn(ctx, s.pool).QueryRow(ctx, `SELECT COUNT(*) FROM webhook_outbox o`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting webhook deliveries: %w", err)
+	}
+
+	query := `SELECT ` + outboxColumns + ` FROM webhook_outbox o` + where + `
+		ORDER BY o.created_at DESC, o.id
+		LIMIT $6 OFFSET $7`
+	rows, err := conn(ctx, s.pool).Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []WebhookOutboxEntry
+	for rows.Next() {
+		e, err := scanOutboxEntry(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scanning webhook delivery: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterating webhook deliveries: %w", err)
+	}
+	return entries, total, nil
+}
+
+// GetDelivery returns one of a subscription's deliveries.
+func (s *WebhookOutboxStore) GetDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*WebhookOutboxEntry, error) {
+	query := `SELECT ` + outboxColumns + ` FROM webhook_outbox o WHERE o.subscription_id = $1 AND o.id = $2`
+
+	e, err := scanOutboxEntry(conn(ctx, s.pool).QueryRow(ctx, query, subscriptionID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("webhook_delivery", id.String())
+		}
+		return nil, fmt.Errorf("getting webhook delivery: %w", err)
+	}
+	return e, nil
+}
+
+// ListAttempts returns the attempts of a delivery, oldest first.
+func (s *WebhookOutboxStore) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]WebhookDeliveryAttempt, error) {
+	rows, err := conn(ctx, s.pool).Query(ctx, `
+		SELECT id, delivery_id, attempt, status_code, latency_ms, response_body, error, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt`, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []WebhookDeliveryAttempt
+	for rows.Next() {
+		var a WebhookDeliveryAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &a.LatencyMS,
+			&a.ResponseBody, &a.Error, &a.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("scanning webhook attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating webhook attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// Redeliver queues a new delivery of the payload of one of a subscription's
+// deliveries and returns it. RedeliveryOf is always the original delivery,
+// so every redelivery of an event shares its message ID.
+func (s *WebhookOutboxStore) Redeliver(ctx context.Context, subscriptionID, id uuid.UUID) (*WebhookOutboxEntry, error) {
+	query := `
+		INSERT INTO webhook_outbox (subscription_id, event_type, payload, content_type, redelivery_of)
+		SELECT subscription_id, event_type, payload, content_type, COALESCE(redelivery_of, id) FROM webhook_outbox
+		WHERE subscription_id = $1 AND id = $2
+		RETURNING id, subscription_id, event_type, payload, content_type, status, attempts, next_attempt_at,
+			last_error, redelivery_of, created_at`
+
+	var e WebhookOutboxEntry
+	err := conn(ctx, s.pool).QueryRow(ctx, query, subscriptionID, id).Scan(&e.ID, &e.SubscriptionID,
+		&e.EventType, &e.Payload, &e.ContentType, &e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError,
+		&e.RedeliveryOf, &e.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("webhook_delivery", id.String())
+		}
+		return nil, fmt.Errorf("redelivering webhook: %w", err)
+	}
+	return &e, nil
+}
+
+// RedeliverFailed queues a new delivery for each of a subscription's failed
+// deliveries created in [from, to) that has not been redelivered since, and
+// returns how many were queued.
+func (s *WebhookOutboxStore) RedeliverFailed(ctx context.Context, subscriptionID uuid.UUID, from, to time.Time) (int64, error) {
+	tag, err := conn(ctx, s.pool).Exec(ctx, `
+		INSERT INTO webhook_outbox (subscription_id, event_type, payload, content_type, redelivery_of)
+		SELECT o.subscription_id, o.event_type, o.payload, o.content_type, COALESCE(o.redelivery_of, o.id)
+		FROM webhook_outbox o
+		WHERE o.subscription_id = $1 AND o.status = 'failed'
+		  AND o.created_at >= $2 AND o.created_at < $3
+		  AND NOT EXISTS (
+			SELECT 1 FROM webhook_outbox r
+			WHERE r.redelivery_of = COALESCE(o.redelivery_of, o.id) AND r.created_at > o.created_at)`,
+		subscriptionID, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("redelivering failed webhooks: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
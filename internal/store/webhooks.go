@@ -7,20 +7,48 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/agent-smit/agentic-registry/internal/errors"
 )
 
-// WebhookSubscription represents a webhook subscription.
+// Webhook signature schemes.
+const (
+	SignatureHMACSHA256       = "hmac_sha256"
+	SignatureStandardWebhooks = "standard_webhooks"
+)
+
+// Webhook payload formats.
+const (
+	PayloadFormatRegistry    = "registry"
+	PayloadFormatCloudEvents = "cloudevents"
+)
+
+// WebhookSubscription represents a webhook subscription. PreviousSecret is
+// the secret replaced by the last rotation; deliveries are signed with it
+// too until PreviousSecretExpiresAt. The Include flags add the resource
+// version, a snapshot and a diff to the payloads of versioned resources.
+// Events holds event type patterns; ResourceTypes, ResourceIDs and
+// WorkspaceID further narrow the events delivered when set.
 type WebhookSubscription struct {
-	ID        uuid.UUID       `json:"id"`
-	URL       string          `json:"url"`
-	Secret    string          `json:"-"`
-	Events    json.RawMessage `json:"events"`
-	IsActive  bool            `json:"is_active"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID                      uuid.UUID       `json:"id"`
+	URL                     string          `json:"url"`
+	Secret                  string          `json:"-"`
+	PreviousSecret          string          `json:"-"`
+	PreviousSecretExpiresAt *time.Time      `json:"previous_secret_expires_at,omitempty"`
+	SignatureScheme         string          `json:"signature_scheme"`
+	PayloadFormat           string          `json:"payload_format"`
+	IncludeVersion          bool            `json:"include_version"`
+	IncludeSnapshot         bool            `json:"include_snapshot"`
+	IncludeDiff             bool            `json:"include_diff"`
+	Events                  json.RawMessage `json:"events"`
+	ResourceTypes           json.RawMessage `json:"resource_types"`
+	ResourceIDs             json.RawMessage `json:"resource_ids"`
+	WorkspaceID             *string         `json:"workspace_id"`
+	IsActive                bool            `json:"is_active"`
+	CreatedAt               time.Time       `json:"created_at"`
+	UpdatedAt               time.Time       `json:"updated_at"`
 }
 
 // WebhookStore handles database operations for webhook subscriptions.
@@ -33,14 +61,50 @@ func NewWebhookStore(pool *pgxpool.Pool) *WebhookStore {
 	return &WebhookStore{pool: pool}
 }
 
+// webhookColumns selects a subscription without its secrets. An expired
+// previous secret reads as none.
+const webhookColumns = `id, url,
+	CASE WHEN previous_secret_expires_at > now() THEN previous_secret_expires_at END,
+	signature_scheme, payload_format, include_version, include_snapshot, include_diff,
+	events, resource_types, resource_ids, workspace_id, is_active, created_at, updated_at`
+
+// webhookSecretColumns selects a subscription's current secret and its
+// previous secret while that is still valid.
+const webhookSecretColumns = `secret,
+	CASE WHEN previous_secret_expires_at > now() THEN previous_secret ELSE '' END`
+
+func scanWebhook(row pgx.Row, extra ...any) (*WebhookSubscription, error) {
+	var sub WebhookSubscription
+	dest := append([]any{&sub.ID, &sub.URL, &sub.PreviousSecretExpiresAt, &sub.SignatureScheme,
+		&sub.PayloadFormat, &sub.IncludeVersion, &sub.IncludeSnapshot, &sub.IncludeDiff, &sub.Events,
+		&sub.ResourceTypes, &sub.ResourceIDs, &sub.WorkspaceID, &sub.IsActive, &sub.CreatedAt, &sub.UpdatedAt}, extra...)
+	return &sub, row.Scan(dest...)
+}
+
 // Create inserts a new webhook subscription.
 func (s *WebhookStore) Create(ctx context.Context, sub *WebhookSubscription) error {
 	query := `
-		INSERT INTO webhook_subscriptions (url, secret, events, is_active)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO webhook_subscriptions (url, secret, signature_scheme, payload_format,
+			include_version, include_snapshot, include_diff, events, resource_types, resource_ids,
+			workspace_id, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at, updated_at`
 
-	err := s.pool.QueryRow(ctx, query, sub.URL, sub.Secret, sub.Events, sub.IsActive).
+	if sub.SignatureScheme == "" {
+		sub.SignatureScheme = SignatureHMACSHA256
+	}
+	if sub.PayloadFormat == "" {
+		sub.PayloadFormat = PayloadFormatRegistry
+	}
+	if sub.ResourceTypes == nil {
+		sub.ResourceTypes = json.RawMessage(`[]`)
+	}
+	if sub.ResourceIDs == nil {
+		sub.ResourceIDs = json.RawMessage(`[]`)
+	}
+	err := conn(ctx, s.pool).QueryRow(ctx, query, sub.URL, sub.Secret, sub.SignatureScheme, sub.PayloadFormat,
+		sub.IncludeVersion, sub.IncludeSnapshot, sub.IncludeDiff, sub.Events, sub.ResourceTypes, sub.ResourceIDs,
+		sub.WorkspaceID, sub.IsActive).
 		Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("creating webhook subscription: %w", err)
@@ -51,11 +115,11 @@ func (s *WebhookStore) Create(ctx context.Context, sub *WebhookSubscription) err
 // List returns all webhook subscriptions (excluding secret).
 func (s *WebhookStore) List(ctx context.Context) ([]WebhookSubscription, error) {
 	query := `
-		SELECT id, url, events, is_active, created_at, updated_at
+		SELECT ` + webhookColumns + `
 		FROM webhook_subscriptions
 		ORDER BY created_at ASC`
 
-	rows, err := s.pool.Query(ctx, query)
+	rows, err := conn(ctx, s.pool).Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("listing webhook subscriptions: %w", err)
 	}
@@ -63,11 +127,11 @@ func (s *WebhookStore) List(ctx context.Context) ([]WebhookSubscription, error)
 
 	var subs []WebhookSubscription
 	for rows.Next() {
-		var sub WebhookSubscription
-		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Events, &sub.IsActive, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		sub, err := scanWebhook(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scanning webhook subscription: %w", err)
 		}
-		subs = append(subs, sub)
+		subs = append(subs, *sub)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating webhook subscriptions: %w", err)
@@ -76,11 +140,119 @@ func (s *WebhookStore) List(ctx context.Context) ([]WebhookSubscription, error)
 	return subs, nil
 }
 
+// GetByID returns a webhook subscription (excluding secret).
+func (s *WebhookStore) GetByID(ctx context.Context, id uuid.UUID) (*WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhook_subscriptions
+		WHERE id = $1`
+
+	sub, err := scanWebhook(conn(ctx, s.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("webhook_subscription", id.String())
+		}
+		return nil, fmt.Errorf("getting webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// GetWithSecrets returns a webhook subscription including its secrets (for
+// dispatcher use).
+func (s *WebhookStore) GetWithSecrets(ctx context.Context, id uuid.UUID) (*WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookColumns + `, ` + webhookSecretColumns + `
+		FROM webhook_subscriptions
+		WHERE id = $1`
+
+	var secret, previous string
+	sub, err := scanWebhook(conn(ctx, s.pool).QueryRow(ctx, query, id), &secret, &previous)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("webhook_subscription", id.String())
+		}
+		return nil, fmt.Errorf("getting webhook subscription: %w", err)
+	}
+	sub.Secret, sub.PreviousSecret = secret, previous
+	return sub, nil
+}
+
+// Update replaces a subscription's URL, signature scheme, payload options,
+// event filters and active flag. A non-empty Secret replaces the secret and
+// discards any previous one. The subscription's UpdatedAt must match the
+// stored value.
+func (s *WebhookStore) Update(ctx context.Context, sub *WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions SET
+			url = $2, signature_scheme = $3, payload_format = $4,
+			include_version = $5, include_snapshot = $6, include_diff = $7,
+			events = $8, resource_types = $9, resource_ids = $10, workspace_id = $11,
+			is_active = $12,
+			secret = CASE WHEN $14 <> '' THEN $14 ELSE secret END,
+			previous_secret = CASE WHEN $14 <> '' THEN '' ELSE previous_secret END,
+			previous_secret_expires_at = CASE WHEN $14 <> '' THEN NULL ELSE previous_secret_expires_at END,
+			updated_at = now()
+		WHERE id = $1 AND updated_at = $13
+		RETURNING updated_at`
+
+	err := conn(ctx, s.pool).QueryRow(ctx, query, sub.ID, sub.URL, sub.SignatureScheme, sub.PayloadFormat,
+		sub.IncludeVersion, sub.IncludeSnapshot, sub.IncludeDiff, sub.Events, sub.ResourceTypes, sub.ResourceIDs,
+		sub.WorkspaceID, sub.IsActive, sub.UpdatedAt, sub.Secret).
+		Scan(&sub.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.Conflict("webhook subscription was modified by another request")
+		}
+		return fmt.Errorf("updating webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// SetActive pauses or resumes a subscription and returns it.
+func (s *WebhookStore) SetActive(ctx context.Context, id uuid.UUID, active bool) (*WebhookSubscription, error) {
+	query := `
+		UPDATE webhook_subscriptions SET is_active = $2, updated_at = now()
+		WHERE id = $1
+		RETURNING ` + webhookColumns
+
+	sub, err := scanWebhook(conn(ctx, s.pool).QueryRow(ctx, query, id, active))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("webhook_subscription", id.String())
+		}
+		return nil, fmt.Errorf("updating webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// RotateSecret replaces a subscription's secret and returns it. With a
+// positive grace period the old secret stays valid for that long;
+// otherwise it is discarded at once.
+func (s *WebhookStore) RotateSecret(ctx context.Context, id uuid.UUID, secret string, grace time.Duration) (*WebhookSubscription, error) {
+	query := `
+		UPDATE webhook_subscriptions SET
+			previous_secret = CASE WHEN $3 > 0 THEN secret ELSE '' END,
+			previous_secret_expires_at = CASE WHEN $3 > 0 THEN now() + $3 * interval '1 millisecond' END,
+			secret = $2, updated_at = now()
+		WHERE id = $1
+		RETURNING ` + webhookColumns
+
+	sub, err := scanWebhook(conn(ctx, s.pool).QueryRow(ctx, query, id, secret, grace.Milliseconds()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("webhook_subscription", id.String())
+		}
+		return nil, fmt.Errorf("rotating webhook secret: %w", err)
+	}
+	sub.Secret = secret
+	return sub, nil
+}
+
 // Delete removes a webhook subscription by ID.
 func (s *WebhookStore) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
 
-	tag, err := s.pool.Exec(ctx, query, id)
+	tag, err := conn(ctx, s.pool).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("deleting webhook subscription: %w", err)
 	}
@@ -93,12 +265,12 @@ func (s *WebhookStore) Delete(ctx context.Context, id uuid.UUID) error {
 // ListActive returns all active webhook subscriptions including their secrets (for dispatcher use).
 func (s *WebhookStore) ListActive(ctx context.Context) ([]WebhookSubscription, error) {
 	query := `
-		SELECT id, url, secret, events, is_active, created_at, updated_at
+		SELECT ` + webhookColumns + `, ` + webhookSecretColumns + `
 		FROM webhook_subscriptions
 		WHERE is_active = true
 		ORDER BY created_at ASC`
 
-	rows, err := s.pool.Query(ctx, query)
+	rows, err := conn(ctx, s.pool).Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("listing active webhook subscriptions: %w", err)
 	}
@@ -106,11 +278,13 @@ func (s *WebhookStore) ListActive(ctx context.Context) ([]WebhookSubscription, e
 
 	var subs []WebhookSubscription
 	for rows.Next() {
-		var sub WebhookSubscription
-		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, &sub.Events, &sub.IsActive, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		var secret, previous string
+		sub, err := scanWebhook(rows, &secret, &previous)
+		if err != nil {
 			return nil, fmt.Errorf("scanning active webhook subscription: %w", err)
 		}
-		subs = append(subs, sub)
+		sub.Secret, sub.PreviousSecret = secret, previous
+		subs = append(subs, *sub)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating active webhook subscriptions: %w", err)
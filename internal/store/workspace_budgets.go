@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agent-smit/agentic-registry/internal/errors"
+)
+
+// WorkspaceBudget is a workspace's monthly gateway spending limit, in
+// micro-units of the billing currency.
+type WorkspaceBudget struct {
+	WorkspaceID        uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	MonthlyLimitMicros int64     `json:"monthly_limit_micros" db:"monthly_limit_micros"`
+	SoftThresholds     []int     `json:"soft_thresholds" db:"soft_thresholds"`
+	OnExhausted        string    `json:"on_exhausted" db:"on_exhausted"`
+	CreatedBy          string    `json:"created_by" db:"created_by"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WorkspaceBudgetStore handles database operations for workspace budgets
+// and metered spend.
+type WorkspaceBudgetStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewWorkspaceBudgetStore creates a new WorkspaceBudgetStore.
+func NewWorkspaceBudgetStore(pool *pgxpool.Pool) *WorkspaceBudgetStore {
+	return &WorkspaceBudgetStore{pool: pool}
+}
+
+// Get returns a workspace's budget.
+func (s *WorkspaceBudgetStore) Get(ctx context.Context, workspaceID uuid.UUID) (*WorkspaceBudget, error) {
+	query := `
+		SELECT workspace_id, monthly_limit_micros, soft_thresholds, on_exhausted, created_by, created_at, updated_at
+		FROM workspace_budgets WHERE workspace_id = $1`
+
+	b := &WorkspaceBudget{}
+	err := conn(ctx, s.pool).QueryRow(ctx, query, workspaceID).Scan(
+		&b.WorkspaceID, &b.MonthlyLimitMicros, &b.SoftThresholds, &b.OnExhausted,
+		&b.CreatedBy, &b.CreatedAt, &b.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("workspace_budget", workspaceID.String())
+		}
+		return nil, fmt.Errorf("getting workspace budget: %w", err)
+	}
+	return b, nil
+}
+
+// Upsert creates or replaces a workspace's budget.
+func (s *WorkspaceBudgetStore) Upsert(ctx context.Context, b *WorkspaceBudget) error {
+	query := `
+		INSERT INTO workspace_budgets (workspace_id, monthly_limit_micros, soft_thresholds, on_exhausted, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (workspace_id) DO UPDATE
+		SET monthly_limit_micros = EXCLUDED.monthly_limit_micros, soft_thresholds = EXCLUDED.soft_thresholds,
+		    on_exhausted = EXCLUDED.on_exhausted, updated_at = now()
+		RETURNING created_by, created_at, updated_at`
+
+	if b.SoftThresholds == nil {
+		b.SoftThresholds = []int{}
+	}
+	err := conn(ctx, s.pool).QueryRow(ctx, query,
+		b.WorkspaceID, b.MonthlyLimitMicros, b.SoftThresholds, b.OnExhausted, b.CreatedBy,
+	).Scan(&b.CreatedBy, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("upserting workspace budget: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a workspace's budget. Metered spend is kept.
+func (s *WorkspaceBudgetStore) Delete(ctx context.Context, workspaceID uuid.UUID) error {
+	ct, err := conn(ctx, s.pool).Exec(ctx, `DELETE FROM workspace_budgets WHERE workspace_id = $1`, workspaceID)
+	if err != nil {
+		return fmt.Errorf("deleting workspace budget: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.NotFound("workspace_budget", workspaceID.String())
+	}
+	return nil
+}
+
+// GetSpend returns a workspace's metered spend and call count for the
+// period starting at period.
+func (s *WorkspaceBudgetStore) GetSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time) (int64, int64, error) {
+	query := `SELECT spent_micros, calls FROM workspace_spend WHERE workspace_id = $1 AND period = $2`
+
+	var spent, calls int64
+	err := conn(ctx, s.pool).QueryRow(ctx, query, workspaceID, period).Scan(&spent, &calls)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("getting workspace spend: %w", err)
+	}
+	return spent, calls, nil
+}
+
+// AddSpend adds one metered call to a workspace's spend and returns the
+// period's spend after the increment.
+func (s *WorkspaceBudgetStore) AddSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time, micros int64) (int64, error) {
+	query := `
+		INSERT INTO workspace_spend (workspace_id, period, spent_micros, calls)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (workspace_id, period) DO UPDATE
+		SET spent_micros = workspace_spend.spent_micros + EXCLUDED.spent_micros,
+		    calls = workspace_spend.calls + 1, updated_at = now()
+		RETURNING spent_micros`
+
+	var spent int64
+	if err := conn(ctx, s.pool).QueryRow(ctx, query, workspaceID, period, micros).Scan(&spent); err != nil {
+		return 0, fmt.Errorf("adding workspace spend: %w", err)
+	}
+	return spent, nil
+}
+
+// ReserveSpend adds one metered call to a workspace's spend unless that
+// would take the period's spend over limit. It returns the spend after the
+// increment and whether it was made; the check and the increment are one
+// statement, so concurrent calls cannot overspend.
+func (s *WorkspaceBudgetStore) ReserveSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time, micros, limit int64) (int64, bool, error) {
+	query := `
+		INSERT INTO workspace_spend (workspace_id, period, spent_micros, calls)
+		SELECT $1::UUID, $2::DATE, $3::BIGINT, 1 WHERE $3::BIGINT <= $4::BIGINT
+		ON CONFLICT (workspace_id, period) DO UPDATE
+		SET spent_micros = workspace_spend.spent_micros + EXCLUDED.spent_micros,
+		    calls = workspace_spend.calls + 1, updated_at = now()
+		WHERE workspace_spend.spent_micros + EXCLUDED.spent_micros <= $4::BIGINT
+		RETURNING spent_micros`
+
+	var spent int64
+	err := conn(ctx, s.pool).QueryRow(ctx, query, workspaceID, period, micros, limit).Scan(&spent)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("reserving workspace spend: %w", err)
+	}
+	return spent, true, nil
+}
+
+// ReleaseSpend takes back a call reserved by ReserveSpend that was not
+// served.
+func (s *WorkspaceBudgetStore) ReleaseSpend(ctx context.Context, workspaceID uuid.UUID, period time.Time, micros int64) error {
+	query := `
+		UPDATE workspace_spend
+		SET spent_micros = spent_micros - $3, calls = calls - 1, updated_at = now()
+		WHERE workspace_id = $1 AND period = $2`
+
+	if _, err := conn(ctx, s.pool).Exec(ctx, query, workspaceID, period, micros); err != nil {
+		return fmt.Errorf("releasing workspace spend: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agent-smit/agentic-registry/internal/errors"
+)
+
+// Workspace member principal types.
+const (
+	PrincipalUser   = "user"
+	PrincipalAPIKey = "api_key"
+)
+
+// WorkspaceMember grants a user or API key access to a workspace.
+type WorkspaceMember struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	WorkspaceID   uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	PrincipalType string    `json:"principal_type" db:"principal_type"`
+	PrincipalID   uuid.UUID `json:"principal_id" db:"principal_id"`
+	CreatedBy     string    `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// WorkspaceMemberStore handles database operations for workspace members.
+type WorkspaceMemberStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewWorkspaceMemberStore creates a new WorkspaceMemberStore.
+func NewWorkspaceMemberStore(pool *pgxpool.Pool) *WorkspaceMemberStore {
+	return &WorkspaceMemberStore{pool: pool}
+}
+
+// List returns all members of a workspace.
+func (s *WorkspaceMemberStore) List(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceMember, error) {
+	query := `
+		SELECT id, workspace_id, principal_type, principal_id, created_by, created_at
+		FROM workspace_members
+		WHERE workspace_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := conn(ctx, s.pool).Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []WorkspaceMember
+	for rows.Next() {
+		var m WorkspaceMember
+		if err := rows.Scan(
+			&m.ID, &m.WorkspaceID, &m.PrincipalType, &m.PrincipalID, &m.CreatedBy, &m.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning workspace member: %w", err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating workspace members: %w", err)
+	}
+
+	return members, nil
+}
+
+// Add inserts a workspace member. Adding an existing member returns the
+// existing membership.
+func (s *WorkspaceMemberStore) Add(ctx context.Context, m *WorkspaceMember) error {
+	query := `
+		INSERT INTO workspace_members (id, workspace_id, principal_type, principal_id, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (workspace_id, principal_type, principal_id)
+		DO UPDATE SET principal_id = EXCLUDED.principal_id
+		RETURNING id, created_by, created_at`
+
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+
+	err := conn(ctx, s.pool).QueryRow(ctx, query,
+		m.ID, m.WorkspaceID, m.PrincipalType, m.PrincipalID, m.CreatedBy,
+	).Scan(&m.ID, &m.CreatedBy, &m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("adding workspace member: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a workspace member by ID.
+func (s *WorkspaceMemberStore) Remove(ctx context.Context, workspaceID, id uuid.UUID) error {
+	query := `DELETE FROM workspace_members WHERE workspace_id = $1 AND id = $2`
+	ct, err := conn(ctx, s.pool).Exec(ctx, query, workspaceID, id)
+	if err != nil {
+		return fmt.Errorf("removing workspace member: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.NotFound("workspace_member", id.String())
+	}
+	return nil
+}
+
+// IsMember reports whether a principal belongs to a workspace.
+func (s *WorkspaceMemberStore) IsMember(ctx context.Context, workspaceID uuid.UUID, principalType string, principalID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM workspace_members
+			WHERE workspace_id = $1 AND principal_type = $2 AND principal_id = $3
+		)`
+
+	var ok bool
+	if err := conn(ctx, s.pool).QueryRow(ctx, query, workspaceID, principalType, principalID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("checking workspace membership: %w", err)
+	}
+	return ok, nil
+}
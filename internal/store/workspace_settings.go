@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agent-smit/agentic-registry/internal/errors"
+)
+
+// WorkspaceSettings holds a workspace's gateway policy settings.
+type WorkspaceSettings struct {
+	WorkspaceID          uuid.UUID       `json:"workspace_id" db:"workspace_id"`
+	AgentToolEnforcement string          `json:"agent_tool_enforcement" db:"agent_tool_enforcement"`
+	Sandbox              json.RawMessage `json:"sandbox" db:"sandbox"`
+	UpdatedBy            string          `json:"updated_by" db:"updated_by"`
+	UpdatedAt            time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// WorkspaceSettingsStore handles database operations for workspace settings.
+type WorkspaceSettingsStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewWorkspaceSettingsStore creates a new WorkspaceSettingsStore.
+func NewWorkspaceSettingsStore(pool *pgxpool.Pool) *WorkspaceSettingsStore {
+	return &WorkspaceSettingsStore{pool: pool}
+}
+
+// Get returns a workspace's settings.
+func (s *WorkspaceSettingsStore) Get(ctx context.Context, workspaceID uuid.UUID) (*WorkspaceSettings, error) {
+	query := `
+		SELECT workspace_id, agent_tool_enforcement, sandbox, updated_by, updated_at
+		FROM workspace_settings WHERE workspace_id = $1`
+
+	ws := &WorkspaceSettings{}
+	err := conn(ctx, s.pool).QueryRow(ctx, query, workspaceID).Scan(
+		&ws.WorkspaceID, &ws.AgentToolEnforcement, &ws.Sandbox, &ws.UpdatedBy, &ws.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("workspace_settings", workspaceID.String())
+		}
+		return nil, fmt.Errorf("getting workspace settings: %w", err)
+	}
+	return ws, nil
+}
+
+// Upsert creates or replaces a workspace's settings.
+func (s *WorkspaceSettingsStore) Upsert(ctx context.Context, ws *WorkspaceSettings) error {
+	query := `
+		INSERT INTO workspace_settings (workspace_id, agent_tool_enforcement, sandbox, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id) DO UPDATE
+		SET agent_tool_enforcement = EXCLUDED.agent_tool_enforcement, sandbox = EXCLUDED.sandbox,
+		    updated_by = EXCLUDED.updated_by, updated_at = now()
+		RETURNING updated_at`
+
+	sandbox := ws.Sandbox
+	if len(sandbox) == 0 {
+		sandbox = json.RawMessage(`{}`)
+	}
+	err := conn(ctx, s.pool).QueryRow(ctx, query, ws.WorkspaceID, ws.AgentToolEnforcement, sandbox, ws.UpdatedBy).Scan(&ws.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("upserting workspace settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+UPDATE mcp_servers SET auth_type = 'none', auth_credential = ''
+    WHERE auth_type = 'oauth2_client_credentials';
+ALTER TABLE mcp_servers DROP CONSTRAINT IF EXISTS mcp_servers_auth_type_check;
+ALTER TABLE mcp_servers ADD CONSTRAINT mcp_servers_auth_type_check
+    CHECK (auth_type IN ('none', 'bearer', 'basic'));
@@ -0,0 +1,3 @@
+ALTER TABLE mcp_servers DROP CONSTRAINT IF EXISTS mcp_servers_auth_type_check;
+ALTER TABLE mcp_servers ADD CONSTRAINT mcp_servers_auth_type_check
+    CHECK (auth_type IN ('none', 'bearer', 'basic', 'oauth2_client_credentials'));
@@ -0,0 +1,5 @@
+ALTER TABLE mcp_servers
+    DROP COLUMN IF EXISTS tls_client_cert,
+    DROP COLUMN IF EXISTS tls_client_key,
+    DROP COLUMN IF EXISTS tls_ca_bundle,
+    DROP COLUMN IF EXISTS custom_headers;
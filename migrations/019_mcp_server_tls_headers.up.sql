@@ -0,0 +1,6 @@
+-- Encrypted (AES-256-GCM, base64) upstream client TLS material and custom headers.
+ALTER TABLE mcp_servers
+    ADD COLUMN tls_client_cert TEXT NOT NULL DEFAULT '',
+    ADD COLUMN tls_client_key  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN tls_ca_bundle   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN custom_headers  TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS egress_rules;
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS egress_policy;
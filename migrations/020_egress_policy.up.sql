@@ -0,0 +1,12 @@
+ALTER TABLE mcp_servers ADD COLUMN egress_policy JSONB NOT NULL DEFAULT '{}';
+
+CREATE TABLE egress_rules (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action      VARCHAR(10) NOT NULL CHECK (action IN ('allow', 'deny')),
+    cidr        VARCHAR(50) NOT NULL DEFAULT '',
+    hostname    VARCHAR(253) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    created_by  VARCHAR(200) NOT NULL DEFAULT 'system',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((cidr = '') <> (hostname = ''))
+);
@@ -0,0 +1 @@
+ALTER TABLE mcp_servers ADD COLUMN retry_policy JSONB NOT NULL DEFAULT '{}';
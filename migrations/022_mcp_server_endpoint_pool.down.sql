@@ -0,0 +1,3 @@
+ALTER TABLE mcp_servers
+    DROP COLUMN IF EXISTS load_balancing,
+    DROP COLUMN IF EXISTS endpoints;
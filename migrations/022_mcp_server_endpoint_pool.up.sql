@@ -0,0 +1,4 @@
+ALTER TABLE mcp_servers
+    ADD COLUMN endpoints JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN load_balancing TEXT NOT NULL DEFAULT 'round_robin'
+        CHECK (load_balancing IN ('round_robin', 'least_latency'));
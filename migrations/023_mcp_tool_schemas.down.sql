@@ -0,0 +1 @@
+DROP TABLE IF EXISTS mcp_tool_schemas;
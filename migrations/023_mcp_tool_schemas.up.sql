@@ -0,0 +1,10 @@
+CREATE TABLE mcp_tool_schemas (
+    server_id      UUID NOT NULL REFERENCES mcp_servers(id) ON DELETE CASCADE,
+    tool_name      VARCHAR(200) NOT NULL,
+    description    TEXT NOT NULL DEFAULT '',
+    input_schema   JSONB NOT NULL DEFAULT '{}',
+    schema_overlay JSONB NOT NULL DEFAULT '{}',
+    discovered_at  TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (server_id, tool_name)
+);
@@ -0,0 +1 @@
+ALTER TABLE mcp_servers ADD COLUMN response_cache JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+CREATE TABLE mcp_circuit_states (
+    circuit_key VARCHAR(2300) PRIMARY KEY,
+    state       VARCHAR(20) NOT NULL CHECK (state IN ('closed', 'open')),
+    failures    INT NOT NULL DEFAULT 0,
+    opened_at   TIMESTAMPTZ,
+    forced      BOOLEAN NOT NULL DEFAULT false,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
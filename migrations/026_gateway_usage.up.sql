@@ -0,0 +1,17 @@
+CREATE TABLE gateway_usage (
+    bucket_start   TIMESTAMPTZ NOT NULL,
+    server_label   VARCHAR(100) NOT NULL,
+    tool_name      VARCHAR(200) NOT NULL,
+    agent_id       VARCHAR(100) NOT NULL DEFAULT '',
+    workspace_id   VARCHAR(36) NOT NULL DEFAULT '',
+    caller_id      VARCHAR(36) NOT NULL DEFAULT '',
+    outcome        VARCHAR(30) NOT NULL,
+    calls          BIGINT NOT NULL DEFAULT 0,
+    latency_sum_ms BIGINT NOT NULL DEFAULT 0,
+    latency_hist   BIGINT[] NOT NULL DEFAULT '{}',
+    bytes_in       BIGINT NOT NULL DEFAULT 0,
+    bytes_out      BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket_start, server_label, tool_name, agent_id, workspace_id, caller_id, outcome)
+);
+
+CREATE INDEX idx_gateway_usage_server ON gateway_usage (server_label, bucket_start);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS workspace_spend;
+DROP TABLE IF EXISTS workspace_budgets;
+ALTER TABLE gateway_usage DROP COLUMN IF EXISTS cost_micros;
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS pricing;
@@ -0,0 +1,22 @@
+ALTER TABLE mcp_servers ADD COLUMN pricing JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE gateway_usage ADD COLUMN cost_micros BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE workspace_budgets (
+    workspace_id         UUID PRIMARY KEY,
+    monthly_limit_micros BIGINT NOT NULL CHECK (monthly_limit_micros > 0),
+    soft_thresholds      INT[] NOT NULL DEFAULT '{80}',
+    on_exhausted         VARCHAR(10) NOT NULL DEFAULT 'reject' CHECK (on_exhausted IN ('reject', 'flag')),
+    created_by           VARCHAR(200) NOT NULL DEFAULT 'system',
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE workspace_spend (
+    workspace_id UUID NOT NULL,
+    period       DATE NOT NULL,
+    spent_micros BIGINT NOT NULL DEFAULT 0,
+    calls        BIGINT NOT NULL DEFAULT 0,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (workspace_id, period)
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS agents_policy_changed ON agents;
+DROP TRIGGER IF EXISTS agents_policy_created_or_deleted ON agents;
+DROP TRIGGER IF EXISTS trust_defaults_policy_changed ON trust_defaults;
+DROP TRIGGER IF EXISTS trust_rules_policy_changed ON trust_rules;
+DROP FUNCTION IF EXISTS notify_trust_policy_change();
@@ -0,0 +1,43 @@
+-- Notify gateway replicas when trust policy changes so they can invalidate
+-- their cached policy. TG_ARGV[0] is the change scope and TG_ARGV[1] the
+-- column identifying the changed policy, if any.
+CREATE FUNCTION notify_trust_policy_change() RETURNS trigger AS $$
+DECLARE
+    rec RECORD;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+    ELSE
+        rec := NEW;
+    END IF;
+    PERFORM pg_notify('trust_policy_changed', json_build_object(
+        'scope', TG_ARGV[0],
+        'key', COALESCE(to_jsonb(rec) ->> TG_ARGV[1], '')
+    )::text);
+    IF TG_OP = 'UPDATE' AND (to_jsonb(OLD) ->> TG_ARGV[1]) IS DISTINCT FROM (to_jsonb(NEW) ->> TG_ARGV[1]) THEN
+        PERFORM pg_notify('trust_policy_changed', json_build_object(
+            'scope', TG_ARGV[0],
+            'key', COALESCE(to_jsonb(OLD) ->> TG_ARGV[1], '')
+        )::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trust_rules_policy_changed
+    AFTER INSERT OR UPDATE OR DELETE ON trust_rules
+    FOR EACH ROW EXECUTE FUNCTION notify_trust_policy_change('trust_rule', 'workspace_id');
+
+CREATE TRIGGER trust_defaults_policy_changed
+    AFTER INSERT OR UPDATE OR DELETE ON trust_defaults
+    FOR EACH ROW EXECUTE FUNCTION notify_trust_policy_change('trust_default', '');
+
+-- Agents are cached even when they do not exist yet, so creation notifies too.
+CREATE TRIGGER agents_policy_created_or_deleted
+    AFTER INSERT OR DELETE ON agents
+    FOR EACH ROW EXECUTE FUNCTION notify_trust_policy_change('agent', 'id');
+
+CREATE TRIGGER agents_policy_changed
+    AFTER UPDATE ON agents
+    FOR EACH ROW WHEN (OLD.trust_overrides IS DISTINCT FROM NEW.trust_overrides)
+    EXECUTE FUNCTION notify_trust_policy_change('agent', 'id');
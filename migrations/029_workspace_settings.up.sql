@@ -0,0 +1,6 @@
+CREATE TABLE workspace_settings (
+    workspace_id           UUID PRIMARY KEY,
+    agent_tool_enforcement VARCHAR(10) NOT NULL DEFAULT 'off' CHECK (agent_tool_enforcement IN ('off', 'audit', 'enforce')),
+    updated_by             VARCHAR(200) NOT NULL DEFAULT 'system',
+    updated_at             TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workspace_members;
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS workspace_required;
@@ -0,0 +1,12 @@
+ALTER TABLE mcp_servers ADD COLUMN workspace_required BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE workspace_members (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id   UUID NOT NULL,
+    principal_type VARCHAR(10) NOT NULL CHECK (principal_type IN ('user', 'api_key')),
+    principal_id   UUID NOT NULL,
+    created_by     VARCHAR(200) NOT NULL DEFAULT 'system',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE(workspace_id, principal_type, principal_id)
+);
+CREATE INDEX idx_workspace_members_principal ON workspace_members(principal_type, principal_id);
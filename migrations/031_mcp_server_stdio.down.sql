@@ -0,0 +1,4 @@
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS stdio_env;
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS stdio_args;
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS stdio_command;
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS transport;
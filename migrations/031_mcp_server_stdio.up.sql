@@ -0,0 +1,4 @@
+ALTER TABLE mcp_servers ADD COLUMN transport VARCHAR(10) NOT NULL DEFAULT 'http' CHECK (transport IN ('http', 'stdio'));
+ALTER TABLE mcp_servers ADD COLUMN stdio_command TEXT NOT NULL DEFAULT '';
+ALTER TABLE mcp_servers ADD COLUMN stdio_args JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE mcp_servers ADD COLUMN stdio_env TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE mcp_servers ADD COLUMN call_limits JSONB NOT NULL DEFAULT '{}';
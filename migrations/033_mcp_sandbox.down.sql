@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS mcp_recordings;
+ALTER TABLE workspace_settings DROP COLUMN IF EXISTS sandbox;
@@ -0,0 +1,15 @@
+ALTER TABLE workspace_settings ADD COLUMN sandbox JSONB NOT NULL DEFAULT '{}';
+
+CREATE TABLE mcp_recordings (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id    UUID NOT NULL REFERENCES mcp_servers(id) ON DELETE CASCADE,
+    tool_name    VARCHAR(200) NOT NULL,
+    args_hash    VARCHAR(64) NOT NULL,
+    arguments    JSONB NOT NULL DEFAULT '{}',
+    status_code  INT NOT NULL,
+    response     JSONB NOT NULL,
+    workspace_id UUID,
+    recorded_by  VARCHAR(200) NOT NULL DEFAULT 'system',
+    recorded_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (server_id, tool_name, args_hash)
+);
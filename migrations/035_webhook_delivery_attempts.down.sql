@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP INDEX IF EXISTS idx_webhook_outbox_subscription;
+ALTER TABLE webhook_outbox DROP COLUMN IF EXISTS redelivery_of;
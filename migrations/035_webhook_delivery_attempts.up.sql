@@ -0,0 +1,16 @@
+ALTER TABLE webhook_outbox ADD COLUMN redelivery_of UUID REFERENCES webhook_outbox(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_webhook_outbox_subscription ON webhook_outbox (subscription_id, created_at DESC);
+
+CREATE TABLE webhook_delivery_attempts (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id   UUID NOT NULL REFERENCES webhook_outbox(id) ON DELETE CASCADE,
+    attempt       INT NOT NULL,
+    status_code   INT,
+    latency_ms    INT NOT NULL,
+    response_body TEXT NOT NULL DEFAULT '',
+    error         TEXT NOT NULL DEFAULT '',
+    attempted_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id, attempt);
@@ -0,0 +1,2 @@
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS previous_secret_expires_at;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS previous_secret;
@@ -0,0 +1,2 @@
+ALTER TABLE webhook_subscriptions ADD COLUMN previous_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_subscriptions ADD COLUMN previous_secret_expires_at TIMESTAMPTZ;
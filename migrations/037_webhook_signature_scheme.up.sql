@@ -0,0 +1,2 @@
+ALTER TABLE webhook_subscriptions ADD COLUMN signature_scheme VARCHAR(20) NOT NULL DEFAULT 'hmac_sha256'
+    CHECK (signature_scheme IN ('hmac_sha256', 'standard_webhooks'));
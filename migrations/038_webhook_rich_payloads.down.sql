@@ -0,0 +1,6 @@
+ALTER TABLE webhook_outbox DROP COLUMN IF EXISTS content_type;
+
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS include_diff;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS include_snapshot;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS include_version;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS payload_format;
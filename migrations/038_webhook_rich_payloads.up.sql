@@ -0,0 +1,7 @@
+ALTER TABLE webhook_subscriptions ADD COLUMN payload_format VARCHAR(20) NOT NULL DEFAULT 'registry'
+    CHECK (payload_format IN ('registry', 'cloudevents'));
+ALTER TABLE webhook_subscriptions ADD COLUMN include_version BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE webhook_subscriptions ADD COLUMN include_snapshot BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE webhook_subscriptions ADD COLUMN include_diff BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE webhook_outbox ADD COLUMN content_type VARCHAR(100) NOT NULL DEFAULT 'application/json';
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS resource_ids;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS resource_types;
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_subscriptions ADD COLUMN resource_types JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE webhook_subscriptions ADD COLUMN resource_ids JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE webhook_subscriptions ADD COLUMN workspace_id VARCHAR(100);
@@ -0,0 +1,136 @@
+// Package webhook signs and verifies registry webhook deliveries that use the
+// Standard Webhooks signature scheme (https://www.standardwebhooks.com).
+//
+// The signed content is "<webhook-id>.<webhook-timestamp>.<body>", so a
+// captured delivery cannot be replayed once its timestamp falls outside the
+// verifier's tolerance. Receivers verify a delivery with:
+//
+//	v, err := webhook.NewVerifier(secret)
+//	...
+//	body, _ := io.ReadAll(r.Body)
+//	if err := v.Verify(body, r.Header); err != nil {
+//		http.Error(w, "invalid signature", http.StatusUnauthorized)
+//		return
+//	}
+//
+// During a secret rotation grace period deliveries carry a signature with the
+// old and the new secret; a verifier given either secret accepts them.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Standard Webhooks headers.
+const (
+	HeaderID        = "webhook-id"
+	HeaderTimestamp = "webhook-timestamp"
+	HeaderSignature = "webhook-signature"
+)
+
+// SecretPrefix marks a base64-encoded secret.
+const SecretPrefix = "whsec_"
+
+// DefaultTolerance is how far a delivery's timestamp may be from the
+// receiver's clock.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrMissingHeaders      = errors.New("webhook: missing signature headers")
+	ErrInvalidTimestamp    = errors.New("webhook: invalid timestamp")
+	ErrTimestampOutOfRange = errors.New("webhook: timestamp outside tolerance")
+	ErrNoMatchingSignature = errors.New("webhook: no matching signature")
+)
+
+// Key returns the HMAC key of a secret. A secret with the whsec_ prefix is
+// base64-decoded, as the Standard Webhooks libraries do; any other secret is
+// used as is.
+func Key(secret string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(secret, SecretPrefix)
+	if !ok {
+		return []byte(secret), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: decoding secret: %w", err)
+	}
+	return key, nil
+}
+
+// Sign returns the "v1,<base64 signature>" of a delivery made with key.
+func Sign(key []byte, id string, ts time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + strconv.FormatInt(ts.Unix(), 10) + "."))
+	mac.Write(body)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier checks the signatures and timestamps of deliveries.
+type Verifier struct {
+	keys [][]byte
+
+	// Tolerance bounds the age, and the clock skew into the future, of an
+	// accepted delivery. Zero means DefaultTolerance.
+	Tolerance time.Duration
+
+	now func() time.Time
+}
+
+// NewVerifier creates a Verifier accepting deliveries signed with any of the
+// given secrets.
+func NewVerifier(secrets ...string) (*Verifier, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("webhook: no secret given")
+	}
+	v := &Verifier{now: time.Now}
+	for _, s := range secrets {
+		key, err := Key(s)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, key)
+	}
+	return v, nil
+}
+
+// Verify checks that body and the Standard Webhooks headers in h form a
+// delivery signed with one of the verifier's secrets, timestamped within
+// the tolerance.
+func (v *Verifier) Verify(body []byte, h http.Header) error {
+	id, tsHeader, sigHeader := h.Get(HeaderID), h.Get(HeaderTimestamp), h.Get(HeaderSignature)
+	if id == "" || tsHeader == "" || sigHeader == "" {
+		return ErrMissingHeaders
+	}
+	unix, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	ts := time.Unix(unix, 0)
+
+	tolerance := v.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	now := v.now()
+	if ts.Before(now.Add(-tolerance)) || ts.After(now.Add(tolerance)) {
+		return ErrTimestampOutOfRange
+	}
+
+	for _, key := range v.keys {
+		expected := Sign(key, id, ts, body)
+		for _, sig := range strings.Fields(sigHeader) {
+			if hmac.Equal([]byte(sig), []byte(expected)) {
+				return nil
+			}
+		}
+	}
+	return ErrNoMatchingSignature
+}
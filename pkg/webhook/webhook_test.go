@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signedHeader(t *testing.T, secret, id string, ts time.Time, body []byte) http.Header {
+	t.Helper()
+	key, err := Key(secret)
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	h := http.Header{}
+	h.Set(HeaderID, id)
+	h.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	h.Set(HeaderSignature, Sign(key, id, ts, body))
+	return h
+}
+
+// TestSignKnownVector checks Sign against the example in the Standard
+// Webhooks specification.
+func TestSignKnownVector(t *testing.T) {
+	key, err := Key("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw")
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	body := []byte(`{"test": 2432232314}`)
+	got := Sign(key, "msg_p5jXN8AQM9LWM0D4loKWxJek", time.Unix(1614265330, 0), body)
+	want := "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE="
+	if got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"agent.created"}`)
+
+	tests := []struct {
+		name    string
+		secrets []string
+		header  func() http.Header
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			secrets: []string{"whsec_c2VjcmV0LWtleQ=="},
+			header:  func() http.Header { return signedHeader(t, "whsec_c2VjcmV0LWtleQ==", "d1", now, body) },
+		},
+		{
+			name:    "raw secret",
+			secrets: []string{"plain-secret"},
+			header:  func() http.Header { return signedHeader(t, "plain-secret", "d1", now, body) },
+		},
+		{
+			name:    "old secret during rotation",
+			secrets: []string{"old"},
+			header: func() http.Header {
+				h := signedHeader(t, "new", "d1", now, body)
+				old := signedHeader(t, "old", "d1", now, body)
+				h.Set(HeaderSignature, h.Get(HeaderSignature)+" "+old.Get(HeaderSignature))
+				return h
+			},
+		},
+		{
+			name:    "wrong secret",
+			secrets: []string{"other"},
+			header:  func() http.Header { return signedHeader(t, "secret", "d1", now, body) },
+			wantErr: ErrNoMatchingSignature,
+		},
+		{
+			name:    "replayed with another id",
+			secrets: []string{"secret"},
+			header: func() http.Header {
+				h := signedHeader(t, "secret", "d1", now, body)
+				h.Set(HeaderID, "d2")
+				return h
+			},
+			wantErr: ErrNoMatchingSignature,
+		},
+		{
+			name:    "too old",
+			secrets: []string{"secret"},
+			header:  func() http.Header { return signedHeader(t, "secret", "d1", now.Add(-6*time.Minute), body) },
+			wantErr: ErrTimestampOutOfRange,
+		},
+		{
+			name:    "too new",
+			secrets: []string{"secret"},
+			header:  func() http.Header { return signedHeader(t, "secret", "d1", now.Add(6*time.Minute), body) },
+			wantErr: ErrTimestampOutOfRange,
+		},
+		{
+			name:    "invalid timestamp",
+			secrets: []string{"secret"},
+			header: func() http.Header {
+				h := signedHeader(t, "secret", "d1", now, body)
+				h.Set(HeaderTimestamp, "yesterday")
+				return h
+			},
+			wantErr: ErrInvalidTimestamp,
+		},
+		{
+			name:    "missing headers",
+			secrets: []string{"secret"},
+			header:  func() http.Header { return http.Header{} },
+			wantErr: ErrMissingHeaders,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(tt.secrets...)
+			if err != nil {
+				t.Fatalf("NewVerifier: %v", err)
+			}
+			v.now = func() time.Time { return now }
+
+			err = v.Verify(body, tt.header())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewVerifierRejectsBadSecret(t *testing.T) {
+	if _, err := NewVerifier(); err == nil {
+		t.Fatal("expected error without secrets")
+	}
+	if _, err := NewVerifier("whsec_not base64!"); err == nil {
+		t.Fatal("expected error for an invalid whsec_ secret")
+	}
+}